@@ -5,34 +5,74 @@ import (
 	"log"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
 	cnt "github.com/R3DPanda1/LWN-Sim-Plus/controllers"
+	"github.com/R3DPanda1/LWN-Sim-Plus/logging"
 	"github.com/R3DPanda1/LWN-Sim-Plus/models"
 	repo "github.com/R3DPanda1/LWN-Sim-Plus/repositories"
 	"github.com/R3DPanda1/LWN-Sim-Plus/shared"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	ws "github.com/R3DPanda1/LWN-Sim-Plus/webserver"
 )
 
+// Build metadata, injected with -ldflags "-X main.commit=... -X main.buildDate=..."
+var (
+	commit    string
+	buildDate string
+)
+
 // Entry point of the program.
 func main() {
+	// Expose the build metadata, if it was injected at build time.
+	if commit != "" {
+		shared.Commit = commit
+	}
+	if buildDate != "" {
+		shared.BuildDate = buildDate
+	}
 	// Load the configuration file, and if there is an error, log it and terminate the program.
 	cfg, err := models.GetConfigFile("config.json")
 	if err != nil {
 		log.Fatal(err)
 	}
+	// Write the logs to a rotating file too, if one is configured.
+	logWriter, err := logging.Setup(cfg.Logging)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if logWriter != nil {
+		defer logWriter.Close()
+		log.Printf("Logging to %s\n", cfg.Logging.File)
+	}
 	// Check if the verbose flag is set to true, and if so, enable verbose logging.
 	if cfg.Verbose {
 		shared.Verbose = true
 		shared.DebugPrint("Verbose mode enabled")
 	}
+	// If a seed is set, use it for the shared random source so that runs are reproducible.
+	if cfg.Seed != 0 {
+		util.SetSeed(cfg.Seed)
+		log.Printf("Random seed set to %d\n", cfg.Seed)
+	}
+	// If the in-memory mode is set, nothing is loaded from or saved to the config directory.
+	if cfg.InMemory {
+		util.SetInMemory(true)
+		log.Println("In-memory mode enabled, the configuration files are not used")
+	}
 	// Create a new simulator controller and repository.
 	simulatorRepository := repo.NewSimulatorRepository()
 	simulatorController := cnt.NewSimulatorController(simulatorRepository)
-	simulatorController.GetInstance()
-	log.Printf("LWN Simulator (%s) is ready to start...\n", shared.Version)
+	simulatorController.GetInstance(cfg.Performance)
+	simulatorController.SetupHistory(cfg.History)
+	log.Printf("LWN Simulator (%s, commit %s) is ready to start...\n", shared.Version, shared.Commit)
 	// Start the metrics server.
 	go startMetrics(cfg)
+	// Save the pending changes, stopping the simulation first, when the process is asked to exit.
+	go shutdownOnSignal(simulatorController)
 	// If the autoStart flag is set to true, start the simulator automatically.
 	if cfg.AutoStart {
 		log.Println("Auto-starting the simulation")
@@ -46,10 +86,26 @@ func main() {
 	log.Println("webUI online")
 }
 
+// Waits for SIGINT or SIGTERM, then stops the simulator and saves before exiting
+func shutdownOnSignal(simulatorController cnt.SimulatorController) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	sig := <-signals
+	log.Printf("Received %v, shutting down\n", sig)
+	simulatorController.Shutdown()
+	os.Exit(0)
+}
+
 // Prometheus metrics server
 func startMetrics(cfg *models.ServerConfig) {
 	http.Handle("/metrics", promhttp.Handler())
-	err := http.ListenAndServe(cfg.Address+":"+strconv.Itoa(cfg.MetricsPort), nil)
+	address := cfg.Address + ":" + strconv.Itoa(cfg.MetricsPort)
+	var err error
+	if cfg.TLSEnabled() {
+		err = http.ListenAndServeTLS(address, cfg.TLSCertFile, cfg.TLSKeyFile, nil)
+	} else {
+		err = http.ListenAndServe(address, nil)
+	}
 	if err != nil {
 		log.Println("[Metrics] [ERROR]:", err.Error())
 	}
@@ -16,4 +16,34 @@ const (
     CodeErrorGatewayActive
     // CodeSaving indicates that the operation is saving data.
     CodeSaving
+    // CodeErrorMaxDevices indicates that the configured maximum number of devices has been reached.
+    CodeErrorMaxDevices
+    // CodeErrorBridge indicates that the bridge address of a gateway is invalid.
+    CodeErrorBridge
+    // CodeErrorReference indicates that a codec or integration enabled on a device does not exist.
+    CodeErrorReference
+    // CodeErrorLatency indicates that the latency of a gateway is negative.
+    CodeErrorLatency
+    // CodeErrorStatInterval indicates that the stat interval of a gateway is negative.
+    CodeErrorStatInterval
+    // CodeErrorInvalidRequest indicates that the request body, a path or a query parameter is malformed.
+    CodeErrorInvalidRequest
+    // CodeErrorNotFound indicates that the requested resource does not exist.
+    CodeErrorNotFound
+    // CodeErrorDevice indicates that an operation on a device failed.
+    CodeErrorDevice
+    // CodeErrorGateway indicates that an operation on a gateway failed.
+    CodeErrorGateway
+    // CodeErrorCodec indicates that a codec is invalid or an operation on a codec failed.
+    CodeErrorCodec
+    // CodeErrorIntegration indicates that an integration is invalid or an operation on an integration failed.
+    CodeErrorIntegration
+    // CodeErrorTemplate indicates that a template is invalid or an operation on a template failed.
+    CodeErrorTemplate
+    // CodeErrorSimulator indicates that a simulator-wide setting could not be applied.
+    CodeErrorSimulator
+    // CodeErrorInternal indicates an unexpected failure of the simulator.
+    CodeErrorInternal
+    // CodeErrorUnauthorized indicates that the request lacks a valid API token.
+    CodeErrorUnauthorized
 )
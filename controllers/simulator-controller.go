@@ -1,15 +1,22 @@
 package controllers
 
 import (
+	"io"
+
 	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/output/mqtt"
 	repo "github.com/R3DPanda1/LWN-Sim-Plus/repositories"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/thingsboard"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/webhook"
 
 	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	devFeatures "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features"
+	devModels "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
 	gw "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/gateway"
+	loc "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/location"
 	e "github.com/R3DPanda1/LWN-Sim-Plus/socket"
 	"github.com/brocaar/lorawan"
 	socketio "github.com/googollee/go-socket.io"
@@ -19,41 +26,97 @@ import (
 type SimulatorController interface {
 	Run() bool                                 // Run the simulator
 	Stop() bool                                // Stop the simulator
+	Shutdown()                                 // Stop the simulator if it runs and write the pending saves, before exiting
 	Status() bool                              // Get the status of the simulator
-	GetInstance()                              // Get the instance of the simulator repository
+	Health() (models.HealthStatus, bool)       // Get the health snapshot, false if the simulator is not initialized
+	GetInstance(models.PerformanceConfig)      // Get the instance of the simulator repository
+	SetupHistory(models.HistoryConfig)         // Restore the saved device history and save it periodically, if enabled
 	AddWebSocket(*socketio.Conn)               // Add a websocket connection
 	SaveBridgeAddress(models.AddressIP) error  // Save the bridge address
 	GetBridgeAddress() models.AddressIP        // Get the bridge address
-	GetGateways() []gw.Gateway                 // Get the gateways
+	GetForwarderTopology() map[lorawan.EUI64][]lorawan.EUI64 // Get the in-range gateways of every device
+	GetCoverage(loc.Location, float64) []models.CoverageGateway // Get the gateways covering a point
+	GetGateways() []*gw.Gateway                // Get the gateways
 	AddGateway(*gw.Gateway) (int, int, error)  // Add a gateway
 	UpdateGateway(*gw.Gateway) (int, error)    // Update a gateway
 	DeleteGateway(int) bool                    // Delete a gateway
-	AddDevice(*dev.Device) (int, int, error)   // Add a device
-	GetDevices() []dev.Device                  // Get the devices
+	ReconnectGateway(int) error                // Drop and re-establish the UDP connection of a running gateway
+	AddDevice(*dev.Device, bool) (int, int, error) // Add a device, provisioning it unless skipped
+	GetDevices() []*dev.Device                 // Get the devices
+	SearchDevices(models.DeviceFilter) []*dev.Device // Search the devices matching a filter
+	WriteDevicesCSV(io.Writer) error           // Stream the devices as CSV
+	ImportDevicesCSV(io.Reader) (models.CSVImportSummary, error) // Create devices from a CSV
 	UpdateDevice(*dev.Device) (int, error)     // Update a device
+	ValidateDeviceUpdate(int, *dev.Device) (models.DeviceUpdateCheck, error) // Check an update of a device and list its changes, without applying it
+	ProvisionDeviceIntegrations(int) error     // Provision a device added with skipProvisioning to its integrations
+	CheckDevEUI(lorawan.EUI64, int) (int, error) // Check that a DevEUI is valid and not used by another component
+	CheckDeviceName(string, int) (int, error) // Check that a device name is valid and not used by another component
 	DeleteDevice(int) bool                     // Delete a device
 	DeleteAllDevices() (int, error)            // Delete all devices in bulk
+	SetDevicesActive([]int, bool) []models.SetActiveResult // Activate or deactivate devices in bulk, turning them on or off if running
+	Reset() (models.ResetSummary, error)       // Wipe all components and restore defaults
+	SetTimeScale(float64) error // Change the factor dividing every send interval and ACK timeout
+	SetNetworkServerDelay(int) error // Change the milliseconds added to every downlink to model the network server latency
+	GetWebhooks() []webhook.Config // Get the URLs notified of joins, device errors and gateway disconnections
+	SetWebhooks([]webhook.Config) error // Replace the webhooks
+	GetMQTT() mqtt.Config // Get the MQTT output configuration, without the password
+	SetMQTT(mqtt.Config) error // Replace the MQTT output configuration, reconnecting to the broker
 	ToggleStateDevice(int)                     // Toggle the state of a device
+	StartDevice(int) (bool, error) // Turn a device on unless already running, returning whether it runs
+	StopDevice(int) (bool, error) // Turn a device off unless already stopped, returning whether it runs
+	GetDownlinkAcks(int) ([]devModels.DownlinkAck, error) // Get the confirmed downlink ACK ledger of a device
+	GetDeviceCounters(int) (devModels.Counters, error) // Get the uplink/downlink counters of a device
+	GetDeviceCodecErrors(int) ([]codec.ExecutionError, error) // Get the recent codec execution errors of a device
+	GetDeviceCodecState(int) (*codec.State, error)            // Get the variables the codec of a device keeps between executions
+	ResetDeviceCodecState(int) error                          // Drop the codec state of a device
+	GetOrphanCodecStates() []string                           // List the DevEUIs having a codec state but no device
+	PruneCodecStates() []string                               // Drop the codec states having no device and return their DevEUIs
+	RekeyDevice(int) error                     // Regenerate the session keys of a stopped device
+	SetRXWindows(int, devModels.RXWindowsUpdate) ([]devFeatures.Window, error) // Override the RX window timing of a device
+	GetRetransmission(int) (devModels.Retransmission, error) // Get the confirmed-uplink retries and ACK timeout of a device
+	SetRetransmission(int, devModels.RetransmissionUpdate) (devModels.Retransmission, error) // Change the confirmed-uplink retries and ACK timeout of a device
+	SetFrameCounters(int, devModels.FrameCountersUpdate) (devModels.FrameCounters, error) // Set the current frame counters of a stopped device
+	GetJoinStatus(int) (devModels.JoinStatus, error) // Get the OTAA join state and attempts of a device
+	GetChannels(int) ([]devModels.ChannelInfo, error) // Get the channel plan of a device
+	GetPayloadLimit(int) (devModels.PayloadLimit, error) // Get the maximum payload size of a device at its data rate
+	SetChannels(int, devModels.ChannelsUpdate) ([]devModels.ChannelInfo, error) // Enable or disable uplink channels of a running device
+	ReplayDevice(int, devModels.Replay) error // Play recorded uplinks through a running device, in place of its periodic ones
+	StopReplayDevice(int) error // Stop the replay of a device, which resumes its periodic uplinks
+	InjectMACCommand(int, devModels.MACInjection) ([]lorawan.Payload, error) // Execute a downlink MAC command on a running device and return its queued answers
+	QueueMACCommands(int, []devModels.UplinkMACCommand) ([]lorawan.Payload, error) // Queue a batch of uplink MAC commands at once and return the waiting MAC commands
+	TestDownlinkCodec(int, devModels.DownlinkCodecTest) (devModels.DownlinkCodecTestResult, error) // Run a downlink through the codec of a device against a copy of its state
 	SendMACCommand(lorawan.CID, e.MacCommand)  // Send a MAC command
 	ChangePayload(e.NewPayload) (string, bool) // Change the payload
-	SendUplink(e.NewPayload)                   // Send an uplink
+	SendUplink(e.NewPayload) error             // Send an uplink
 	ChangeLocation(e.NewLocation) bool         // Change the location
+	StartMovement(e.Movement) error            // Move a device along a path of waypoints
+	StopMovement(int) bool                     // Stop a moving device
 	ToggleStateGateway(int)                    // Toggle the state of a gateway
+	StartGateway(int) (bool, error) // Turn a gateway on unless already running, returning whether it runs
+	StopGateway(int) (bool, error) // Turn a gateway off unless already stopped, returning whether it runs
 	GetCodecs() []codec.CodecMetadata        // Get all available codecs
+	GetCodecsFull(int, int) ([]*codec.Codec, int) // Get a page of codecs including their scripts, and the total count
 	GetCodec(int) (*codec.Codec, error)      // Get a specific codec by ID
 	AddCodec(*codec.Codec) error             // Add a custom codec
+	ImportCodecs([]codec.CodecExport) ([]codec.CodecImportResult, error) // Add a batch of exported codecs, skipping duplicate names
 	UpdateCodec(int, string, string) error   // Update an existing codec by ID
 	DeleteCodec(int) error                   // Delete a codec by ID
 	GetDevicesUsingCodec(int) []string       // Get devices using a specific codec
+	GetCodecUsage() map[int]int // Get the number of devices and templates using each codec
+	ResetCodecUsage() map[int]int // Count the codec usage again from scratch
+	GetCodecMetrics() codec.ExecutorMetrics // Get the execution statistics of the codec executor
 	EmitCodecEvent(string, interface{})      // Emit a WebSocket event for codec operations
 
 	// Integration management
 	GetIntegrations() []*integration.Integration                                                    // Get all integrations
 	GetIntegration(int) (*integration.Integration, error)                                           // Get a specific integration
+	RevealIntegrationKey(int) (string, error)                                                       // Get the stored API key of an integration
+	GetIntegrationUsage(int) []string // Get devices and templates using a specific integration
 	AddIntegration(string, integration.IntegrationType, string, string, string, string) (int, error) // Add a new integration (name, type, url, apiKey, tenantId, appId)
 	UpdateIntegration(int, string, string, string, string, string, bool) error                      // Update an integration (id, name, url, apiKey, tenantId, appId, enabled)
 	DeleteIntegration(int) error                                                                    // Delete an integration
 	TestIntegrationConnection(int) error                                                            // Test connection to an integration
+	ProvisionIntegrationDevices(int) (models.ProvisionSummary, error)                               // Provision the devices of a ChirpStack integration that are missing there
 	GetDeviceProfiles(int) ([]integration.DeviceProfile, error)                                     // Get device profiles from an integration (CS or TB)
 	GetThingsBoardCustomers(int) ([]thingsboard.Customer, error)                                    // Get customers for a ThingsBoard integration
 	EmitIntegrationEvent(string, interface{})                                                       // Emit a WebSocket event for integration operations
@@ -61,14 +124,17 @@ type SimulatorController interface {
 	// Template management
 	GetTemplates() []*template.DeviceTemplate                                                      // Get all templates
 	GetTemplate(int) (*template.DeviceTemplate, error)                                             // Get a specific template
+	GetTemplateDependencies(int) (template.Dependencies, error) // Get the codec and integrations a template relies on
 	AddTemplate(*template.DeviceTemplate) (int, error)                                             // Add a new template
 	UpdateTemplate(*template.DeviceTemplate) error                                                 // Update a template
 	DeleteTemplate(int) error                                                                      // Delete a template
-	CreateDevicesFromTemplate(int, int, string, float64, float64, int32, float64) ([]int, error) // Bulk create devices from template
+	CreateDevicesFromTemplate(int, int, string, float64, float64, int32, float64, string) ([]int, int, error) // Bulk create devices from template, also returns how many were skipped
+	PreviewDevicesFromTemplate(int, int, string, float64, float64, int32, float64, string) ([]*dev.Device, int, error) // Generate the devices of a bulk creation without creating them
 
 	// Device watch
 	WatchDevice(int) []e.ConsoleLog
 	UnwatchDevice()
+	SetStreamFilter(e.StreamRequest) // Restrict socket events to the given types
 }
 
 // simulatorController controller struct
@@ -86,8 +152,12 @@ func NewSimulatorController(repo repo.SimulatorRepository) SimulatorController {
 // --- Controller calls to Repository, no need to comment them, they are self-explanatory ---
 // Check the repository methods to see what they do
 
-func (c *simulatorController) GetInstance() {
-	c.repo.GetInstance()
+func (c *simulatorController) GetInstance(perf models.PerformanceConfig) {
+	c.repo.GetInstance(perf)
+}
+
+func (c *simulatorController) SetupHistory(config models.HistoryConfig) {
+	c.repo.SetupHistory(config)
 }
 func (c *simulatorController) AddWebSocket(socket *socketio.Conn) {
 	c.repo.AddWebSocket(socket)
@@ -101,10 +171,18 @@ func (c *simulatorController) Stop() bool {
 	return c.repo.Stop()
 }
 
+func (c *simulatorController) Shutdown() {
+	c.repo.Shutdown()
+}
+
 func (c *simulatorController) Status() bool {
 	return c.repo.Status()
 }
 
+func (c *simulatorController) Health() (models.HealthStatus, bool) {
+	return c.repo.Health()
+}
+
 func (c *simulatorController) SaveBridgeAddress(addr models.AddressIP) error {
 	return c.repo.SaveBridgeAddress(addr)
 }
@@ -113,7 +191,15 @@ func (c *simulatorController) GetBridgeAddress() models.AddressIP {
 	return c.repo.GetBridgeAddress()
 }
 
-func (c *simulatorController) GetGateways() []gw.Gateway {
+func (c *simulatorController) GetForwarderTopology() map[lorawan.EUI64][]lorawan.EUI64 {
+	return c.repo.GetForwarderTopology()
+}
+
+func (c *simulatorController) GetCoverage(point loc.Location, rangeMeters float64) []models.CoverageGateway {
+	return c.repo.GetCoverage(point, rangeMeters)
+}
+
+func (c *simulatorController) GetGateways() []*gw.Gateway {
 	return c.repo.GetGateways()
 }
 
@@ -129,18 +215,50 @@ func (c *simulatorController) DeleteGateway(Id int) bool {
 	return c.repo.DeleteGateway(Id)
 }
 
-func (c *simulatorController) AddDevice(device *dev.Device) (int, int, error) {
-	return c.repo.AddDevice(device)
+func (c *simulatorController) ReconnectGateway(id int) error {
+	return c.repo.ReconnectGateway(id)
 }
 
-func (c *simulatorController) GetDevices() []dev.Device {
+func (c *simulatorController) AddDevice(device *dev.Device, skipProvisioning bool) (int, int, error) {
+	return c.repo.AddDevice(device, skipProvisioning)
+}
+
+func (c *simulatorController) GetDevices() []*dev.Device {
 	return c.repo.GetDevices()
 }
 
+func (c *simulatorController) SearchDevices(filter models.DeviceFilter) []*dev.Device {
+	return c.repo.SearchDevices(filter)
+}
+
+func (c *simulatorController) WriteDevicesCSV(w io.Writer) error {
+	return c.repo.WriteDevicesCSV(w)
+}
+
+func (c *simulatorController) ImportDevicesCSV(r io.Reader) (models.CSVImportSummary, error) {
+	return c.repo.ImportDevicesCSV(r)
+}
+
 func (c *simulatorController) UpdateDevice(device *dev.Device) (int, error) {
 	return c.repo.UpdateDevice(device)
 }
 
+func (c *simulatorController) ValidateDeviceUpdate(id int, device *dev.Device) (models.DeviceUpdateCheck, error) {
+	return c.repo.ValidateDeviceUpdate(id, device)
+}
+
+func (c *simulatorController) ProvisionDeviceIntegrations(id int) error {
+	return c.repo.ProvisionDeviceIntegrations(id)
+}
+
+func (c *simulatorController) CheckDevEUI(devEUI lorawan.EUI64, id int) (int, error) {
+	return c.repo.CheckDevEUI(devEUI, id)
+}
+
+func (c *simulatorController) CheckDeviceName(name string, id int) (int, error) {
+	return c.repo.CheckDeviceName(name, id)
+}
+
 func (c *simulatorController) DeleteDevice(Id int) bool {
 	return c.repo.DeleteDevice(Id)
 }
@@ -149,10 +267,134 @@ func (c *simulatorController) DeleteAllDevices() (int, error) {
 	return c.repo.DeleteAllDevices()
 }
 
+func (c *simulatorController) SetDevicesActive(ids []int, active bool) []models.SetActiveResult {
+	return c.repo.SetDevicesActive(ids, active)
+}
+
+func (c *simulatorController) Reset() (models.ResetSummary, error) {
+	return c.repo.Reset()
+}
+
+func (c *simulatorController) SetTimeScale(scale float64) error {
+	return c.repo.SetTimeScale(scale)
+}
+
+func (c *simulatorController) SetNetworkServerDelay(ms int) error {
+	return c.repo.SetNetworkServerDelay(ms)
+}
+
+func (c *simulatorController) GetWebhooks() []webhook.Config {
+	return c.repo.GetWebhooks()
+}
+
+func (c *simulatorController) SetWebhooks(configs []webhook.Config) error {
+	return c.repo.SetWebhooks(configs)
+}
+
+func (c *simulatorController) GetMQTT() mqtt.Config {
+	return c.repo.GetMQTT()
+}
+
+func (c *simulatorController) SetMQTT(config mqtt.Config) error {
+	return c.repo.SetMQTT(config)
+}
+
 func (c *simulatorController) ToggleStateDevice(Id int) {
 	c.repo.ToggleStateDevice(Id)
 }
 
+func (c *simulatorController) StartDevice(id int) (bool, error) {
+	return c.repo.StartDevice(id)
+}
+
+func (c *simulatorController) StopDevice(id int) (bool, error) {
+	return c.repo.StopDevice(id)
+}
+
+func (c *simulatorController) GetDownlinkAcks(id int) ([]devModels.DownlinkAck, error) {
+	return c.repo.GetDownlinkAcks(id)
+}
+
+func (c *simulatorController) GetDeviceCounters(id int) (devModels.Counters, error) {
+	return c.repo.GetDeviceCounters(id)
+}
+
+func (c *simulatorController) GetDeviceCodecErrors(id int) ([]codec.ExecutionError, error) {
+	return c.repo.GetDeviceCodecErrors(id)
+}
+
+func (c *simulatorController) GetDeviceCodecState(id int) (*codec.State, error) {
+	return c.repo.GetDeviceCodecState(id)
+}
+
+func (c *simulatorController) ResetDeviceCodecState(id int) error {
+	return c.repo.ResetDeviceCodecState(id)
+}
+
+func (c *simulatorController) GetOrphanCodecStates() []string {
+	return c.repo.GetOrphanCodecStates()
+}
+
+func (c *simulatorController) PruneCodecStates() []string {
+	return c.repo.PruneCodecStates()
+}
+
+func (c *simulatorController) RekeyDevice(id int) error {
+	return c.repo.RekeyDevice(id)
+}
+
+func (c *simulatorController) SetRXWindows(id int, update devModels.RXWindowsUpdate) ([]devFeatures.Window, error) {
+	return c.repo.SetRXWindows(id, update)
+}
+
+func (c *simulatorController) GetRetransmission(id int) (devModels.Retransmission, error) {
+	return c.repo.GetRetransmission(id)
+}
+
+func (c *simulatorController) SetRetransmission(id int, update devModels.RetransmissionUpdate) (devModels.Retransmission, error) {
+	return c.repo.SetRetransmission(id, update)
+}
+
+func (c *simulatorController) SetFrameCounters(id int, update devModels.FrameCountersUpdate) (devModels.FrameCounters, error) {
+	return c.repo.SetFrameCounters(id, update)
+}
+
+func (c *simulatorController) GetJoinStatus(id int) (devModels.JoinStatus, error) {
+	return c.repo.GetJoinStatus(id)
+}
+
+func (c *simulatorController) GetChannels(id int) ([]devModels.ChannelInfo, error) {
+	return c.repo.GetChannels(id)
+}
+
+func (c *simulatorController) GetPayloadLimit(id int) (devModels.PayloadLimit, error) {
+	return c.repo.GetPayloadLimit(id)
+}
+
+func (c *simulatorController) SetChannels(id int, update devModels.ChannelsUpdate) ([]devModels.ChannelInfo, error) {
+	return c.repo.SetChannels(id, update)
+}
+
+func (c *simulatorController) ReplayDevice(id int, replay devModels.Replay) error {
+	return c.repo.ReplayDevice(id, replay)
+}
+
+func (c *simulatorController) StopReplayDevice(id int) error {
+	return c.repo.StopReplayDevice(id)
+}
+
+func (c *simulatorController) InjectMACCommand(id int, injection devModels.MACInjection) ([]lorawan.Payload, error) {
+	return c.repo.InjectMACCommand(id, injection)
+}
+
+func (c *simulatorController) QueueMACCommands(id int, batch []devModels.UplinkMACCommand) ([]lorawan.Payload, error) {
+	return c.repo.QueueMACCommands(id, batch)
+}
+
+func (c *simulatorController) TestDownlinkCodec(id int, test devModels.DownlinkCodecTest) (devModels.DownlinkCodecTestResult, error) {
+	return c.repo.TestDownlinkCodec(id, test)
+}
+
 func (c *simulatorController) SendMACCommand(cid lorawan.CID, data e.MacCommand) {
 	c.repo.SendMACCommand(cid, data)
 }
@@ -161,22 +403,42 @@ func (c *simulatorController) ChangePayload(pl e.NewPayload) (string, bool) {
 	return c.repo.ChangePayload(pl)
 }
 
-func (c *simulatorController) SendUplink(pl e.NewPayload) {
-	c.repo.SendUplink(pl)
+func (c *simulatorController) SendUplink(pl e.NewPayload) error {
+	return c.repo.SendUplink(pl)
 }
 
 func (c *simulatorController) ChangeLocation(loc e.NewLocation) bool {
 	return c.repo.ChangeLocation(loc)
 }
 
+func (c *simulatorController) StartMovement(m e.Movement) error {
+	return c.repo.StartMovement(m)
+}
+
+func (c *simulatorController) StopMovement(id int) bool {
+	return c.repo.StopMovement(id)
+}
+
 func (c *simulatorController) ToggleStateGateway(Id int) {
 	c.repo.ToggleStateGateway(Id)
 }
 
+func (c *simulatorController) StartGateway(id int) (bool, error) {
+	return c.repo.StartGateway(id)
+}
+
+func (c *simulatorController) StopGateway(id int) (bool, error) {
+	return c.repo.StopGateway(id)
+}
+
 func (c *simulatorController) GetCodecs() []codec.CodecMetadata {
 	return c.repo.GetCodecs()
 }
 
+func (c *simulatorController) GetCodecsFull(offset, limit int) ([]*codec.Codec, int) {
+	return c.repo.GetCodecsFull(offset, limit)
+}
+
 func (c *simulatorController) GetCodec(id int) (*codec.Codec, error) {
 	return c.repo.GetCodec(id)
 }
@@ -185,6 +447,10 @@ func (c *simulatorController) AddCodec(codec *codec.Codec) error {
 	return c.repo.AddCodec(codec)
 }
 
+func (c *simulatorController) ImportCodecs(items []codec.CodecExport) ([]codec.CodecImportResult, error) {
+	return c.repo.ImportCodecs(items)
+}
+
 func (c *simulatorController) UpdateCodec(id int, name string, script string) error {
 	return c.repo.UpdateCodec(id, name, script)
 }
@@ -197,6 +463,18 @@ func (c *simulatorController) GetDevicesUsingCodec(codecID int) []string {
 	return c.repo.GetDevicesUsingCodec(codecID)
 }
 
+func (c *simulatorController) GetCodecUsage() map[int]int {
+	return c.repo.GetCodecUsage()
+}
+
+func (c *simulatorController) ResetCodecUsage() map[int]int {
+	return c.repo.ResetCodecUsage()
+}
+
+func (c *simulatorController) GetCodecMetrics() codec.ExecutorMetrics {
+	return c.repo.GetCodecMetrics()
+}
+
 func (c *simulatorController) EmitCodecEvent(eventName string, data interface{}) {
 	c.repo.EmitCodecEvent(eventName, data)
 }
@@ -211,6 +489,14 @@ func (c *simulatorController) GetIntegration(id int) (*integration.Integration,
 	return c.repo.GetIntegration(id)
 }
 
+func (c *simulatorController) RevealIntegrationKey(id int) (string, error) {
+	return c.repo.RevealIntegrationKey(id)
+}
+
+func (c *simulatorController) GetIntegrationUsage(integrationID int) []string {
+	return c.repo.GetIntegrationUsage(integrationID)
+}
+
 func (c *simulatorController) AddIntegration(name string, intType integration.IntegrationType, url, apiKey, tenantID, appID string) (int, error) {
 	return c.repo.AddIntegration(name, intType, url, apiKey, tenantID, appID)
 }
@@ -227,6 +513,10 @@ func (c *simulatorController) TestIntegrationConnection(id int) error {
 	return c.repo.TestIntegrationConnection(id)
 }
 
+func (c *simulatorController) ProvisionIntegrationDevices(integrationID int) (models.ProvisionSummary, error) {
+	return c.repo.ProvisionIntegrationDevices(integrationID)
+}
+
 func (c *simulatorController) GetDeviceProfiles(id int) ([]integration.DeviceProfile, error) {
 	return c.repo.GetDeviceProfiles(id)
 }
@@ -249,6 +539,10 @@ func (c *simulatorController) GetTemplate(id int) (*template.DeviceTemplate, err
 	return c.repo.GetTemplate(id)
 }
 
+func (c *simulatorController) GetTemplateDependencies(id int) (template.Dependencies, error) {
+	return c.repo.GetTemplateDependencies(id)
+}
+
 func (c *simulatorController) AddTemplate(tmpl *template.DeviceTemplate) (int, error) {
 	return c.repo.AddTemplate(tmpl)
 }
@@ -261,8 +555,12 @@ func (c *simulatorController) DeleteTemplate(id int) error {
 	return c.repo.DeleteTemplate(id)
 }
 
-func (c *simulatorController) CreateDevicesFromTemplate(templateID int, count int, namePrefix string, baseLat, baseLng float64, baseAlt int32, spreadMeters float64) ([]int, error) {
-	return c.repo.CreateDevicesFromTemplate(templateID, count, namePrefix, baseLat, baseLng, baseAlt, spreadMeters)
+func (c *simulatorController) CreateDevicesFromTemplate(templateID int, count int, namePrefix string, baseLat, baseLng float64, baseAlt int32, spreadMeters float64, spreadShape string) ([]int, int, error) {
+	return c.repo.CreateDevicesFromTemplate(templateID, count, namePrefix, baseLat, baseLng, baseAlt, spreadMeters, spreadShape)
+}
+
+func (c *simulatorController) PreviewDevicesFromTemplate(templateID int, count int, namePrefix string, baseLat, baseLng float64, baseAlt int32, spreadMeters float64, spreadShape string) ([]*dev.Device, int, error) {
+	return c.repo.PreviewDevicesFromTemplate(templateID, count, namePrefix, baseLat, baseLng, baseAlt, spreadMeters, spreadShape)
 }
 
 func (c *simulatorController) WatchDevice(id int) []e.ConsoleLog {
@@ -273,3 +571,7 @@ func (c *simulatorController) UnwatchDevice() {
 	c.repo.UnwatchDevice()
 }
 
+func (c *simulatorController) SetStreamFilter(req e.StreamRequest) {
+	c.repo.SetStreamFilter(req)
+}
+
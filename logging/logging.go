@@ -0,0 +1,161 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+)
+
+// DefaultMaxSizeMB is the rotation size used when the configuration doesn't set one
+const DefaultMaxSizeMB = 100
+
+// backupTimeFormat is appended to the rotated file names; it sorts lexicographically by time
+const backupTimeFormat = "20060102-150405.000"
+
+// Setup redirects the standard logger to the console and, if a file is configured, to a rotating log file.
+// The returned writer must be closed on shutdown; it is nil when file logging is disabled.
+func Setup(cfg models.LoggingConfig) (*RotatingWriter, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+	w, err := NewRotatingWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	log.SetOutput(io.MultiWriter(os.Stderr, w))
+	return w, nil
+}
+
+// RotatingWriter is an io.Writer that writes to a file and rotates it once it exceeds the
+// maximum size, pruning old backups by count and age
+type RotatingWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingWriter opens (or creates) the configured log file in append mode
+func NewRotatingWriter(cfg models.LoggingConfig) (*RotatingWriter, error) {
+	maxSizeMB := cfg.MaxSizeMB
+	if maxSizeMB <= 0 {
+		maxSizeMB = DefaultMaxSizeMB
+	}
+	w := &RotatingWriter{
+		path:       cfg.File,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: cfg.MaxBackups,
+		maxAge:     time.Duration(cfg.MaxAgeDays) * 24 * time.Hour,
+	}
+	if dir := filepath.Dir(w.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write writes p to the current file, rotating first if p would exceed the maximum size.
+// A failed rotation doesn't drop p: it is written to the current file instead.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the current log file
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// open opens the log file in append mode and records its current size
+func (w *RotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate renames the current file with a timestamp suffix, opens a fresh one and prunes old backups.
+// On failure the current file stays open so writing can continue.
+func (w *RotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	backup := w.path + "." + time.Now().Format(backupTimeFormat)
+	renameErr := os.Rename(w.path, backup)
+	if err := w.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	w.prune()
+	return nil
+}
+
+// prune removes the backups beyond MaxBackups (oldest first) and those older than MaxAgeDays
+func (w *RotatingWriter) prune() {
+	backups, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return
+	}
+	// Newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	cutoff := time.Now().Add(-w.maxAge)
+	for i, backup := range backups {
+		remove := w.maxBackups > 0 && i >= w.maxBackups
+		if !remove && w.maxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && info.ModTime().Before(cutoff) {
+				remove = true
+			}
+		}
+		if remove {
+			os.Remove(backup)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+)
+
+func TestRotatingWriterRotatesWithoutDroppingEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sim.log")
+	w, err := NewRotatingWriter(models.LoggingConfig{File: path, MaxSizeMB: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("NewRotatingWriter: %v", err)
+	}
+	defer w.Close()
+
+	chunk := bytes.Repeat([]byte("x"), 700*1024)
+	for i := 0; i < 4; i++ {
+		if _, err := w.Write(chunk); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+		time.Sleep(2 * time.Millisecond) // distinct backup timestamps
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat current file: %v", err)
+	}
+	if info.Size() != int64(len(chunk)) {
+		t.Errorf("current file size = %d, want %d", info.Size(), len(chunk))
+	}
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Errorf("got %d backups, want 2 (MaxBackups)", len(backups))
+	}
+	for _, b := range backups {
+		if info, err := os.Stat(b); err != nil || info.Size() != int64(len(chunk)) {
+			t.Errorf("backup %s has unexpected size", b)
+		}
+	}
+}
+
+func TestSetupWithoutFileIsNoop(t *testing.T) {
+	w, err := Setup(models.LoggingConfig{})
+	if err != nil || w != nil {
+		t.Fatalf("Setup with no file = (%v, %v), want (nil, nil)", w, err)
+	}
+}
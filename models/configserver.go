@@ -1,8 +1,10 @@
 package models
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 )
 
@@ -12,8 +14,118 @@ type ServerConfig struct {
 	Port          int    `json:"port"`          // Port to bind to (default is 8000)
 	MetricsPort   int    `json:"metricsPort"`   // Port to bind to for metrics (default is 8081)
 	ConfigDirname string `json:"configDirname"` // Directory name for configuration files
+	CompressData  bool   `json:"compressData"`  // Save the configuration files gzipped (.json.gz) instead of plain JSON
+	InMemory      bool   `json:"inMemory"`      // Keep everything in memory, never reading or writing the configuration files
 	AutoStart     bool   `json:"autoStart"`     // Flag to automatically start the simulation when the server starts
 	Verbose       bool   `json:"verbose"`       // Flag to enable verbose logging
+	Seed          int64  `json:"seed"`          // Seed for the shared random source, for reproducible runs (0 = time based)
+	TLSCertFile   string `json:"tlsCertFile"`   // PEM certificate used to serve HTTPS (empty = plain HTTP)
+	TLSKeyFile    string `json:"tlsKeyFile"`    // PEM private key matching TLSCertFile
+	APIToken      string `json:"apiToken"`      // Bearer token required by the API and the socket (empty = no authentication)
+
+	AllowedOrigins []string `json:"allowedOrigins"` // Origins allowed by CORS, e.g. "https://ui.example.com" (empty = every origin)
+
+	Logging     LoggingConfig     `json:"logging"`     // File logging and rotation settings
+	Performance PerformanceConfig `json:"performance"` // Tuning of the codec executor
+	History     HistoryConfig     `json:"history"`     // Saving of the recent device console history
+}
+
+// LoggingConfig holds the settings for writing the logs to a file with size and age based rotation.
+type LoggingConfig struct {
+	File       string `json:"file"`       // Path of the log file (empty = console only)
+	MaxSizeMB  int    `json:"maxSizeMB"`  // Size in megabytes after which the file is rotated (default is 100)
+	MaxBackups int    `json:"maxBackups"` // Number of rotated files to keep (0 = keep all)
+	MaxAgeDays int    `json:"maxAgeDays"` // Days after which rotated files are removed (0 = no age limit)
+}
+
+// PerformanceConfig holds the tuning settings of the codec executor.
+type PerformanceConfig struct {
+	CodecMaxVMs       int `json:"codecMaxVMs"`       // Size of the goja VM pool (0 = default 100)
+	CodecTimeoutMs    int `json:"codecTimeoutMs"`    // Max duration of one codec execution in milliseconds (0 = default 1000)
+	CodecMaxMemoryMB  int `json:"codecMaxMemoryMB"`  // Heap growth in megabytes after which a codec execution is aborted (0 = no limit)
+	CodecMaxCallStack int `json:"codecMaxCallStack"` // Max depth of JavaScript calls in a codec (0 = default 1000)
+}
+
+// HistoryConfig holds the settings for saving the recent console history of the devices to
+// disk, so that it survives a restart.
+type HistoryConfig struct {
+	Persist     bool `json:"persist"`     // Save the history periodically and reload it at startup
+	IntervalSec int  `json:"intervalSec"` // Seconds between two snapshots (0 = default 60)
+	MaxSizeKB   int  `json:"maxSizeKB"`   // Size of the snapshot in kilobytes above which the oldest entries are dropped (0 = default 1024)
+}
+
+// TLSEnabled reports whether both the certificate and the key are configured.
+func (c *ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// ValidateTLS checks that the certificate and key are set together and can be loaded.
+func (c *ServerConfig) ValidateTLS() error {
+	if c.TLSCertFile == "" && c.TLSKeyFile == "" {
+		return nil
+	}
+	if !c.TLSEnabled() {
+		return fmt.Errorf("tlsCertFile and tlsKeyFile must be set together")
+	}
+	if _, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile); err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	return nil
+}
+
+// ValidateOrigins checks that every allowed origin is a scheme and a host, without path.
+func (c *ServerConfig) ValidateOrigins() error {
+	for _, origin := range c.AllowedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			(u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+			return fmt.Errorf("allowed origin %q must look like https://host[:port]", origin)
+		}
+	}
+	return nil
+}
+
+// Bounds accepted for the codec executor settings
+const (
+	MaxCodecVMs       = 10000
+	MinCodecTimeoutMs = 10
+	MaxCodecTimeoutMs = 60000
+	MaxCodecMemoryMB  = 16384
+	MaxCodecCallStack = 100000
+)
+
+// Validate checks that the performance settings are within sane bounds.
+func (p PerformanceConfig) Validate() error {
+	if p.CodecMaxVMs < 0 || p.CodecMaxVMs > MaxCodecVMs {
+		return fmt.Errorf("codecMaxVMs must be between 1 and %d (0 = default)", MaxCodecVMs)
+	}
+	if p.CodecTimeoutMs != 0 && (p.CodecTimeoutMs < MinCodecTimeoutMs || p.CodecTimeoutMs > MaxCodecTimeoutMs) {
+		return fmt.Errorf("codecTimeoutMs must be between %d and %d (0 = default)", MinCodecTimeoutMs, MaxCodecTimeoutMs)
+	}
+	if p.CodecMaxMemoryMB < 0 || p.CodecMaxMemoryMB > MaxCodecMemoryMB {
+		return fmt.Errorf("codecMaxMemoryMB must be between 1 and %d (0 = no limit)", MaxCodecMemoryMB)
+	}
+	if p.CodecMaxCallStack < 0 || p.CodecMaxCallStack > MaxCodecCallStack {
+		return fmt.Errorf("codecMaxCallStack must be between 1 and %d (0 = default)", MaxCodecCallStack)
+	}
+	return nil
+}
+
+// Bounds accepted for the history settings
+const (
+	MaxHistoryIntervalSec = 86400
+	MaxHistorySizeKB      = 1 << 20
+)
+
+// Validate checks that the history settings are within sane bounds.
+func (h HistoryConfig) Validate() error {
+	if h.IntervalSec < 0 || h.IntervalSec > MaxHistoryIntervalSec {
+		return fmt.Errorf("intervalSec must be between 1 and %d (0 = default)", MaxHistoryIntervalSec)
+	}
+	if h.MaxSizeKB < 0 || h.MaxSizeKB > MaxHistorySizeKB {
+		return fmt.Errorf("maxSizeKB must be between 1 and %d (0 = default)", MaxHistorySizeKB)
+	}
+	return nil
 }
 
 // GetConfigFile loads the configuration from the specified file path, parses it as JSON,
@@ -27,5 +139,17 @@ func GetConfigFile(path string) (*ServerConfig, error) {
 	if err := json.Unmarshal(data, config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config file: %w", err)
 	}
+	if err := config.Performance.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid performance config: %w", err)
+	}
+	if err := config.History.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid history config: %w", err)
+	}
+	if err := config.ValidateTLS(); err != nil {
+		return nil, fmt.Errorf("invalid TLS config: %w", err)
+	}
+	if err := config.ValidateOrigins(); err != nil {
+		return nil, fmt.Errorf("invalid CORS config: %w", err)
+	}
 	return config, nil
 }
@@ -0,0 +1,12 @@
+package models
+
+import "github.com/brocaar/lorawan"
+
+// CoverageGateway is a gateway that would receive the uplinks of a device placed at the queried point.
+type CoverageGateway struct {
+	Id         int           `json:"id"`         // Gateway ID
+	Name       string        `json:"name"`       // Gateway name
+	MACAddress lorawan.EUI64 `json:"macAddress"` // Gateway EUI
+	Active     bool          `json:"active"`     // Whether the gateway is marked active
+	Distance   float64       `json:"distance"`   // Distance from the point in meters
+}
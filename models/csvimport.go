@@ -0,0 +1,15 @@
+package models
+
+// CSVRowError describes why a CSV row could not be imported.
+type CSVRowError struct {
+	Line  int    `json:"line"`  // Line number in the uploaded file (the header is line 1)
+	Error string `json:"error"` // Reason the row was rejected
+}
+
+// CSVImportSummary reports the outcome of a device CSV import.
+type CSVImportSummary struct {
+	Created   int           `json:"created"`   // Number of devices created
+	Failed    int           `json:"failed"`    // Number of rows rejected
+	DeviceIDs []int         `json:"deviceIds"` // IDs of the created devices
+	Errors    []CSVRowError `json:"errors"`    // Per-row errors for the rejected rows
+}
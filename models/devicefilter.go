@@ -0,0 +1,11 @@
+package models
+
+// DeviceFilter holds the optional criteria used to search devices.
+// Unset fields are ignored; set fields are combined with AND semantics.
+type DeviceFilter struct {
+	Region  *int   // Region code the device is configured for
+	Class   string // Class the device supports ("A", "B" or "C")
+	CodecID *int   // Codec assigned to the device
+	Active  *bool  // Whether the device is enabled
+	Name    string // Case-insensitive substring of the device name
+}
@@ -0,0 +1,21 @@
+package models
+
+// UpdateProblem is a reason why a device update would be rejected.
+type UpdateProblem struct {
+	Code  int    `json:"code"`  // Code returned by the update (see codes)
+	Error string `json:"error"` // Reason the update would be rejected
+}
+
+// FieldChange is a device field whose value an update would change.
+type FieldChange struct {
+	Field string      `json:"field"` // Path of the field in the device JSON, e.g. "configuration.sendInterval"
+	Old   interface{} `json:"old"`   // Current value, nil if the field is new
+	New   interface{} `json:"new"`   // Proposed value, nil if the field would be removed
+}
+
+// DeviceUpdateCheck tells whether a device update would be applied and what it would change.
+type DeviceUpdateCheck struct {
+	Allowed  bool            `json:"allowed"`  // True if the update would be applied
+	Problems []UpdateProblem `json:"problems"` // Every reason the update would be rejected
+	Changes  []FieldChange   `json:"changes"`  // Fields that would change, ordered by path
+}
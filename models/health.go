@@ -0,0 +1,11 @@
+package models
+
+// HealthStatus is a lightweight snapshot of the simulator used by readiness probes.
+type HealthStatus struct {
+	State          string `json:"state"`          // Simulator state: "running" or "stopped"
+	DeviceCount    int    `json:"deviceCount"`    // Number of configured devices
+	GatewayCount   int    `json:"gatewayCount"`   // Number of configured gateways
+	ActiveDevices  int    `json:"activeDevices"`  // Number of devices marked active
+	ActiveGateways int    `json:"activeGateways"` // Number of gateways marked active
+	Version        string `json:"version"`        // Simulator version
+}
@@ -0,0 +1,25 @@
+package models
+
+// Outcomes reported by ProvisionResult.Status
+const (
+	ProvisionStatusProvisioned = "provisioned" // The device was created in the integration
+	ProvisionStatusExists      = "exists"      // The device was already present and left untouched
+	ProvisionStatusFailed      = "failed"      // The device could not be checked or created
+)
+
+// ProvisionResult reports the outcome of provisioning one device to an integration.
+type ProvisionResult struct {
+	ID     int    `json:"id"`              // ID of the device
+	Name   string `json:"name"`            // Name of the device
+	DevEUI string `json:"devEUI"`          // DevEUI of the device, in hex
+	Status string `json:"status"`          // One of the ProvisionStatus values
+	Error  string `json:"error,omitempty"` // Reason the provisioning failed
+}
+
+// ProvisionSummary reports the outcome of provisioning all the devices of an integration.
+type ProvisionSummary struct {
+	Provisioned int               `json:"provisioned"` // Number of devices created
+	Existing    int               `json:"existing"`    // Number of devices already present
+	Failed      int               `json:"failed"`      // Number of devices that failed
+	Results     []ProvisionResult `json:"results"`     // Per-device results, by device ID
+}
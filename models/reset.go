@@ -0,0 +1,9 @@
+package models
+
+// ResetSummary reports how many components were removed by a simulator reset.
+type ResetSummary struct {
+	Devices      int `json:"devices"`      // Number of devices removed
+	Gateways     int `json:"gateways"`     // Number of gateways removed
+	Integrations int `json:"integrations"` // Number of integrations removed
+	Templates    int `json:"templates"`    // Number of user-created templates removed
+}
@@ -0,0 +1,9 @@
+package models
+
+// SetActiveResult reports the outcome of activating or deactivating one device.
+type SetActiveResult struct {
+	ID      int    `json:"id"`              // ID of the device
+	Active  bool   `json:"active"`          // Whether the device is active after the request
+	Running bool   `json:"running"`         // Whether the device is running after the request
+	Error   string `json:"error,omitempty"` // Reason the device was left unchanged
+}
@@ -0,0 +1,12 @@
+package models
+
+import mrp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters/models_rp"
+
+// VersionInfo describes the running build of the simulator.
+type VersionInfo struct {
+	Version   string              `json:"version"`   // Simulator version
+	GoVersion string              `json:"goVersion"` // Go runtime the binary was built with
+	Commit    string              `json:"commit"`    // Git commit of the build ("unknown" if not set at build time)
+	BuildDate string              `json:"buildDate"` // Build date ("unknown" if not set at build time)
+	Regions   []mrp.RegionSummary `json:"regions"`   // Supported LoRaWAN regions
+}
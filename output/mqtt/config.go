@@ -0,0 +1,75 @@
+package mqtt
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultTopicPrefix is used when the configuration leaves the prefix empty
+const DefaultTopicPrefix = "lwnsim"
+
+var ErrInvalidConfig = errors.New("invalid MQTT configuration")
+
+// Config describes the broker events are published to
+type Config struct {
+	Enabled     bool   `json:"enabled"`
+	BrokerURL   string `json:"brokerUrl"`   // tcp://host:1883, mqtt://, ssl:// or mqtts://
+	TopicPrefix string `json:"topicPrefix"` // Events go to prefix/device/{devEUI}/{eventType} (empty = lwnsim)
+	ClientID    string `json:"clientId"`    // Empty = lwnsim-<random>
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"` // Saved in plain text in simulator.json, never returned by the API
+}
+
+// RedactedPassword is returned instead of a set password; sending it back keeps the password
+const RedactedPassword = "********"
+
+// Redacted returns a copy of the configuration safe to return by the API
+func (c Config) Redacted() Config {
+	if c.Password != "" {
+		c.Password = RedactedPassword
+	}
+	return c
+}
+
+// Validate checks the broker URL
+func (c *Config) Validate() error {
+	if _, _, err := c.address(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return nil
+}
+
+// address returns the host:port of the broker and whether TLS is used
+func (c *Config) address() (string, bool, error) {
+	u, err := url.Parse(c.BrokerURL)
+	if err != nil || u.Host == "" {
+		return "", false, errors.New("broker URL must look like tcp://host:1883")
+	}
+
+	var useTLS bool
+	port := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		useTLS = true
+		port = "8883"
+	default:
+		return "", false, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	return u.Hostname() + ":" + port, useTLS, nil
+}
+
+// prefix returns the topic prefix without trailing slashes
+func (c *Config) prefix() string {
+	prefix := strings.TrimRight(c.TopicPrefix, "/")
+	if prefix == "" {
+		return DefaultTopicPrefix
+	}
+	return prefix
+}
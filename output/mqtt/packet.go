@@ -0,0 +1,126 @@
+package mqtt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MQTT 3.1.1 control packet types used by the publisher
+const (
+	packetConnect    = 0x10
+	packetConnAck    = 0x20
+	packetPublish    = 0x30 // QoS 0, no retain
+	packetPingReq    = 0xC0
+	packetPingResp   = 0xD0
+	packetDisconnect = 0xE0
+
+	protocolLevel = 4 // MQTT 3.1.1
+
+	flagCleanSession = 0x02
+	flagPassword     = 0x40
+	flagUsername     = 0x80
+
+	maxRemainingLength = 268435455
+)
+
+// connectPacket encodes a CONNECT with a clean session
+func connectPacket(clientID, username, password string, keepAlive uint16) []byte {
+	flags := byte(flagCleanSession)
+	payload := appendString(nil, clientID)
+	if username != "" {
+		flags |= flagUsername
+		payload = appendString(payload, username)
+		if password != "" {
+			flags |= flagPassword
+			payload = appendString(payload, password)
+		}
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, protocolLevel, flags, byte(keepAlive>>8), byte(keepAlive))
+	body = append(body, payload...)
+	return packet(packetConnect, body)
+}
+
+// publishPacket encodes a QoS 0 PUBLISH
+func publishPacket(topic string, payload []byte) []byte {
+	body := appendString(nil, topic)
+	body = append(body, payload...)
+	return packet(packetPublish, body)
+}
+
+// readConnAck reads the broker answer to CONNECT
+func readConnAck(r *bufio.Reader) error {
+	header, body, err := readPacket(r)
+	if err != nil {
+		return err
+	}
+	if header&0xF0 != packetConnAck || len(body) != 2 {
+		return errors.New("unexpected answer to CONNECT")
+	}
+	if code := body[1]; code != 0 {
+		return fmt.Errorf("connection refused by the broker (code %d)", code)
+	}
+	return nil
+}
+
+// readPacket reads a whole control packet and returns its first byte and its body
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7F) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+		if multiplier > 128*128*128 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// packet prepends the fixed header to the body
+func packet(header byte, body []byte) []byte {
+	length := len(body)
+	if length > maxRemainingLength {
+		length = maxRemainingLength
+		body = body[:length]
+	}
+
+	out := []byte{header}
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if length == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
+
+// appendString appends a length-prefixed UTF-8 string
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
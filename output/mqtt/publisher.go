@@ -0,0 +1,299 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/webhook"
+)
+
+// Publisher settings
+const (
+	QueueSize         = 256
+	KeepAlive         = 60 * time.Second
+	PingTimeout       = 10 * time.Second // Time the broker has to answer a PINGREQ
+	DialTimeout       = 10 * time.Second
+	MinReconnectDelay = time.Second
+	MaxReconnectDelay = 30 * time.Second
+)
+
+// Publisher forwards simulator events to an MQTT broker with QoS 0. It connects
+// once from a background worker and reconnects with backoff when the connection
+// is lost or the broker stops answering pings. Publish never blocks: events are
+// dropped when the queue is full, and ignored while the output is disabled.
+type Publisher struct {
+	mu      sync.RWMutex
+	config  Config
+	queue   chan webhook.Event
+	dropped atomic.Int64 // Events dropped since the queue was last found full
+
+	workerMu sync.Mutex         // Serializes SetConfig and Close
+	cancel   context.CancelFunc // Stops the worker; nil when none runs
+	stopped  chan struct{}      // Closed by the worker once disconnected
+
+	keepAlive   time.Duration
+	pingTimeout time.Duration
+}
+
+// NewPublisher creates a publisher and starts its worker if the output is enabled
+func NewPublisher(config Config) *Publisher {
+	p := newPublisher()
+	p.SetConfig(config)
+	return p
+}
+
+func newPublisher() *Publisher {
+	return &Publisher{
+		queue:       make(chan webhook.Event, QueueSize),
+		keepAlive:   KeepAlive,
+		pingTimeout: PingTimeout,
+	}
+}
+
+// SetConfig disconnects from the current broker, if any, and connects to the new
+// one when the output is enabled. Queued events are kept.
+func (p *Publisher) SetConfig(config Config) {
+	p.workerMu.Lock()
+	defer p.workerMu.Unlock()
+	p.stop()
+
+	clientID := config.ClientID
+	if clientID == "" {
+		clientID = "lwnsim-" + randomSuffix()
+	}
+	p.mu.Lock()
+	p.config = config
+	p.mu.Unlock()
+	if !config.Enabled {
+		return
+	}
+
+	var ctx context.Context
+	ctx, p.cancel = context.WithCancel(context.Background())
+	p.stopped = make(chan struct{})
+	go p.run(ctx, config, clientID, p.stopped)
+}
+
+// Close disconnects from the broker and disables the output
+func (p *Publisher) Close() {
+	p.workerMu.Lock()
+	defer p.workerMu.Unlock()
+	p.stop()
+}
+
+// stop disables the output, then ends the worker and waits for it to disconnect,
+// which interrupts a connection attempt. Called with p.workerMu held.
+func (p *Publisher) stop() {
+	p.mu.Lock()
+	p.config.Enabled = false
+	p.mu.Unlock()
+
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.stopped
+	p.cancel, p.stopped = nil, nil
+}
+
+// Publish queues the event
+func (p *Publisher) Publish(event webhook.Event) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if !p.config.Enabled {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	select {
+	case p.queue <- event:
+		if n := p.dropped.Swap(0); n > 0 {
+			log.Printf("MQTT: %d events dropped while the queue was full", n)
+		}
+	default:
+		// Logged once per burst, device events can fill the queue many times a second
+		if p.dropped.Add(1) == 1 {
+			log.Printf("MQTT queue full, %s event of %s dropped, dropping the next ones until there is room", event.Type, event.Name)
+		}
+	}
+}
+
+// Topic returns prefix/{source}/{eui}/{eventType}, the ID replacing a missing EUI
+func (p *Publisher) Topic(event webhook.Event) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return topic(p.config, event)
+}
+
+func topic(config Config, event webhook.Event) string {
+	id := event.EUI
+	if id == "" {
+		id = "id-" + strconv.Itoa(event.ID)
+	}
+	return config.prefix() + "/" + event.Source + "/" + id + "/" + event.Type
+}
+
+func (p *Publisher) run(ctx context.Context, config Config, clientID string, stopped chan struct{}) {
+	defer close(stopped)
+
+	delay := MinReconnectDelay
+	var pending *webhook.Event // event whose publication failed with the connection
+
+	for {
+		conn, r, err := p.connect(ctx, config, clientID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("MQTT: unable to connect to %s: %v", config.BrokerURL, err)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			if delay *= 2; delay > MaxReconnectDelay {
+				delay = MaxReconnectDelay
+			}
+			continue
+		}
+		delay = MinReconnectDelay
+		log.Printf("MQTT: connected to %s", config.BrokerURL)
+
+		pending = p.serve(ctx, conn, r, config, pending)
+		conn.Close()
+
+		if ctx.Err() != nil {
+			log.Printf("MQTT: disconnected from %s", config.BrokerURL)
+			return
+		}
+	}
+}
+
+// serve publishes queued events until the connection fails or the worker is stopped,
+// returning the event that could not be sent
+func (p *Publisher) serve(ctx context.Context, conn net.Conn, r *bufio.Reader, config Config, pending *webhook.Event) *webhook.Event {
+	closed := make(chan struct{})
+	pong := make(chan struct{}, 1)
+	go func() { // consume PINGRESP and detect the broker closing the connection
+		for {
+			header, _, err := readPacket(r)
+			if err != nil {
+				close(closed)
+				return
+			}
+			if header&0xF0 == packetPingResp {
+				select {
+				case pong <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	ping := time.NewTicker(p.keepAlive / 2)
+	defer ping.Stop()
+	var pingDeadline <-chan time.Time // Set while a PINGREQ waits for its PINGRESP
+
+	for {
+		if pending != nil {
+			if err := p.write(conn, p.encode(config, *pending)); err != nil {
+				log.Printf("MQTT: connection lost: %v", err)
+				return pending
+			}
+			pending = nil
+		}
+
+		select {
+		case event := <-p.queue:
+			pending = &event
+		case <-ping.C:
+			if err := p.write(conn, []byte{packetPingReq, 0}); err != nil {
+				log.Printf("MQTT: connection lost: %v", err)
+				return nil
+			}
+			if pingDeadline == nil {
+				pingDeadline = time.After(p.pingTimeout)
+			}
+		case <-pong:
+			pingDeadline = nil
+		case <-pingDeadline:
+			log.Printf("MQTT: no answer to ping from %s within %v, reconnecting", config.BrokerURL, p.pingTimeout)
+			return nil
+		case <-closed:
+			log.Printf("MQTT: connection closed by the broker")
+			return nil
+		case <-ctx.Done():
+			p.write(conn, []byte{packetDisconnect, 0})
+			return nil
+		}
+	}
+}
+
+// connect dials the broker and performs the MQTT handshake, until ctx is canceled
+func (p *Publisher) connect(ctx context.Context, config Config, clientID string) (net.Conn, *bufio.Reader, error) {
+	address, useTLS, err := config.address()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: DialTimeout}
+	if useTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// Unblock the handshake if the worker is stopped meanwhile
+	stopHandshake := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stopHandshake()
+
+	packet := connectPacket(clientID, config.Username, config.Password, uint16(p.keepAlive/time.Second))
+	if err := p.write(conn, packet); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(DialTimeout))
+	if err := readConnAck(r); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	return conn, r, nil
+}
+
+func (p *Publisher) encode(config Config, event webhook.Event) []byte {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		payload = []byte("{}")
+	}
+	return publishPacket(topic(config, event), payload)
+}
+
+func (p *Publisher) write(conn net.Conn, packet []byte) error {
+	conn.SetWriteDeadline(time.Now().Add(DialTimeout))
+	_, err := conn.Write(packet)
+	return err
+}
+
+func randomSuffix() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,301 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/webhook"
+)
+
+// fakeBroker accepts one client, acknowledges its CONNECT and returns the first PUBLISH
+func fakeBroker(t *testing.T, ln net.Listener, published chan<- [2]string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	header, body, err := readPacket(r)
+	if err != nil || header != packetConnect {
+		t.Errorf("expected CONNECT, got %x (%v)", header, err)
+		return
+	}
+	if string(body[2:6]) != "MQTT" || body[6] != protocolLevel {
+		t.Errorf("unexpected protocol header %v", body[:7])
+	}
+	conn.Write([]byte{packetConnAck, 2, 0, 0})
+
+	for {
+		header, body, err = readPacket(r)
+		if err != nil {
+			return
+		}
+		if header&0xF0 == packetPublish {
+			n := int(body[0])<<8 | int(body[1])
+			published <- [2]string{string(body[2 : 2+n]), string(body[2+n:])}
+			return
+		}
+	}
+}
+
+func TestPublisherPublishesEvents(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	published := make(chan [2]string, 1)
+	go fakeBroker(t, ln, published)
+
+	p := NewPublisher(Config{Enabled: true, BrokerURL: "tcp://" + ln.Addr().String(), TopicPrefix: "sim/"})
+	defer p.Close()
+	p.Publish(webhook.Event{Type: webhook.EventJoinAccepted, Source: webhook.SourceDevice, ID: 3, EUI: "0102030405060708", Name: "dev"})
+
+	select {
+	case msg := <-published:
+		if want := "sim/device/0102030405060708/join-accepted"; msg[0] != want {
+			t.Errorf("topic = %q, want %q", msg[0], want)
+		}
+		var event webhook.Event
+		if err := json.Unmarshal([]byte(msg[1]), &event); err != nil {
+			t.Fatalf("payload: %v", err)
+		}
+		if event.Name != "dev" || event.Time.IsZero() {
+			t.Errorf("unexpected event %+v", event)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("event not published")
+	}
+}
+
+func TestPacketRemainingLength(t *testing.T) {
+	tests := []struct {
+		length int
+		header []byte
+	}{
+		{0, []byte{0x30, 0x00}},
+		{127, []byte{0x30, 0x7F}},
+		{128, []byte{0x30, 0x80, 0x01}},
+		{16383, []byte{0x30, 0xFF, 0x7F}},
+		{16384, []byte{0x30, 0x80, 0x80, 0x01}},
+	}
+	for _, tt := range tests {
+		got := packet(packetPublish, make([]byte, tt.length))
+		if string(got[:len(tt.header)]) != string(tt.header) || len(got) != len(tt.header)+tt.length {
+			t.Errorf("length %d: header %x, want %x", tt.length, got[:len(tt.header)], tt.header)
+		}
+		_, body, err := readPacket(bufio.NewReader(bytes.NewReader(got)))
+		if err != nil || len(body) != tt.length {
+			t.Errorf("length %d: read back %d bytes (%v)", tt.length, len(body), err)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for url, valid := range map[string]bool{
+		"tcp://localhost:1883": true,
+		"mqtt://broker":        true,
+		"mqtts://broker:8883":  true,
+		"http://broker":        false,
+		"localhost:1883":       false,
+		"":                     false,
+	} {
+		c := Config{BrokerURL: url}
+		if err := c.Validate(); (err == nil) != valid {
+			t.Errorf("Validate(%q) error = %v, want valid %v", url, err, valid)
+		}
+	}
+}
+
+// acceptConnect accepts a client and acknowledges its CONNECT
+func acceptConnect(t *testing.T, ln net.Listener) (net.Conn, *bufio.Reader) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return nil, nil
+	}
+	r := bufio.NewReader(conn)
+	if header, _, err := readPacket(r); err != nil || header != packetConnect {
+		t.Errorf("expected CONNECT, got %x (%v)", header, err)
+	}
+	conn.Write([]byte{packetConnAck, 2, 0, 0})
+	return conn, r
+}
+
+func TestPublisherPingTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		answerPings   bool
+		wantReconnect bool
+	}{
+		{"broker answers", true, false},
+		{"broker silent", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("listen: %v", err)
+			}
+			defer ln.Close()
+
+			connections := make(chan struct{}, 4)
+			go func() {
+				for {
+					conn, r := acceptConnect(t, ln)
+					if conn == nil {
+						return
+					}
+					connections <- struct{}{}
+					go func() {
+						defer conn.Close()
+						for {
+							header, _, err := readPacket(r)
+							if err != nil {
+								return
+							}
+							if header == packetPingReq && tt.answerPings {
+								conn.Write([]byte{packetPingResp, 0})
+							}
+						}
+					}()
+				}
+			}()
+
+			p := newPublisher()
+			p.keepAlive = 100 * time.Millisecond
+			p.pingTimeout = 100 * time.Millisecond
+			p.SetConfig(Config{Enabled: true, BrokerURL: "tcp://" + ln.Addr().String()})
+			defer p.Close()
+
+			<-connections
+			select {
+			case <-connections:
+				if !tt.wantReconnect {
+					t.Error("reconnected although the broker answers pings")
+				}
+			case <-time.After(time.Second):
+				if tt.wantReconnect {
+					t.Error("not reconnected after the broker stopped answering pings")
+				}
+			}
+		})
+	}
+}
+
+func TestPublisherSetConfigAndClose(t *testing.T) {
+	first, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer first.Close()
+	second, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer second.Close()
+
+	connected, disconnected := make(chan struct{}), make(chan struct{})
+	go func() {
+		conn, r := acceptConnect(t, first)
+		if conn == nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			header, _, err := readPacket(r)
+			if err != nil {
+				return
+			}
+			switch header & 0xF0 {
+			case packetPublish:
+				close(connected)
+			case packetDisconnect:
+				close(disconnected)
+				return
+			}
+		}
+	}()
+	published := make(chan [2]string, 1)
+	go fakeBroker(t, second, published)
+
+	p := NewPublisher(Config{Enabled: true, BrokerURL: "tcp://" + first.Addr().String()})
+	p.Publish(webhook.Event{Type: webhook.EventJoinFailed, Source: webhook.SourceDevice, ID: 1, Name: "dev"})
+	select {
+	case <-connected:
+	case <-time.After(3 * time.Second):
+		t.Fatal("event not published to the first broker")
+	}
+	p.Close()
+	select {
+	case <-disconnected:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Close() did not disconnect from the broker")
+	}
+	p.Publish(webhook.Event{Type: webhook.EventJoinFailed, Source: webhook.SourceDevice, ID: 1, Name: "ignored"})
+
+	p.SetConfig(Config{Enabled: true, BrokerURL: "tcp://" + second.Addr().String()})
+	defer p.Close()
+	p.Publish(webhook.Event{Type: webhook.EventJoinAccepted, Source: webhook.SourceDevice, ID: 1, Name: "dev"})
+
+	select {
+	case msg := <-published:
+		if want := DefaultTopicPrefix + "/device/id-1/join-accepted"; msg[0] != want {
+			t.Errorf("topic = %q, want %q (the event published while closed must be ignored)", msg[0], want)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("event not published to the new broker")
+	}
+}
+
+func TestConfigRedacted(t *testing.T) {
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"", ""},
+		{"secret", RedactedPassword},
+	}
+	for _, tt := range tests {
+		c := Config{BrokerURL: "tcp://broker", Password: tt.password}
+		if got := c.Redacted(); got.Password != tt.want || got.BrokerURL != c.BrokerURL {
+			t.Errorf("Redacted() of password %q = %+v, want password %q", tt.password, got, tt.want)
+		}
+		if c.Password != tt.password {
+			t.Error("Redacted() changed the configuration")
+		}
+	}
+}
+
+func TestPublisherDropsWhenQueueFull(t *testing.T) {
+	p := newPublisher() // no worker: the queue is never drained
+	p.config.Enabled = true
+
+	steps := []struct {
+		name        string
+		drain       int // events taken from the queue first
+		publish     int
+		wantQueued  int
+		wantDropped int64
+	}{
+		{"fill the queue", 0, QueueSize, QueueSize, 0},
+		{"full queue", 0, 3, QueueSize, 3},
+		{"room again", 1, 1, QueueSize, 0},
+	}
+	for _, step := range steps {
+		for i := 0; i < step.drain; i++ {
+			<-p.queue
+		}
+		for i := 0; i < step.publish; i++ {
+			p.Publish(webhook.Event{Type: "uplink", Source: webhook.SourceDevice, ID: i})
+		}
+		if len(p.queue) != step.wantQueued || p.dropped.Load() != step.wantDropped {
+			t.Errorf("%s: %d queued, %d dropped, want %d and %d", step.name, len(p.queue), p.dropped.Load(), step.wantQueued, step.wantDropped)
+		}
+	}
+}
@@ -2,19 +2,25 @@ package repositories
 
 import (
 	"errors"
+	"io"
 
 	"github.com/brocaar/lorawan"
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/output/mqtt"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/thingsboard"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/webhook"
 	e "github.com/R3DPanda1/LWN-Sim-Plus/socket"
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator"
 	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	devFeatures "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features"
+	devModels "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
 	gw "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/gateway"
+	loc "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/location"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	socketio "github.com/googollee/go-socket.io"
 )
@@ -23,41 +29,97 @@ import (
 type SimulatorRepository interface {
 	Run() bool                                 // Run the simulator
 	Stop() bool                                // Stop the simulator
+	Shutdown()                                 // Stop the simulator if it runs and write the pending saves, before exiting
 	Status() bool                              // Get the status of the simulator
-	GetInstance()                              // Get the instance of the simulator
+	Health() (models.HealthStatus, bool)       // Get the health snapshot, false if the simulator is not initialized
+	GetInstance(models.PerformanceConfig)      // Get the instance of the simulator
+	SetupHistory(models.HistoryConfig)         // Restore the saved device history and save it periodically, if enabled
 	AddWebSocket(*socketio.Conn)               // Add a websocket connection
 	SaveBridgeAddress(models.AddressIP) error  // Save the bridge address
 	GetBridgeAddress() models.AddressIP        // Get the bridge address
-	GetGateways() []gw.Gateway                 // Get the gateways
+	GetForwarderTopology() map[lorawan.EUI64][]lorawan.EUI64 // Get the in-range gateways of every device
+	GetCoverage(loc.Location, float64) []models.CoverageGateway // Get the gateways covering a point
+	GetGateways() []*gw.Gateway                // Get the gateways
 	AddGateway(*gw.Gateway) (int, int, error)  // Add a gateway
 	UpdateGateway(*gw.Gateway) (int, error)    // Update a gateway
 	DeleteGateway(int) bool                    // Delete a gateway
-	AddDevice(*dev.Device) (int, int, error)   // Add a device
-	GetDevices() []dev.Device                  // Get the devices
+	ReconnectGateway(int) error                // Drop and re-establish the UDP connection of a running gateway
+	AddDevice(*dev.Device, bool) (int, int, error) // Add a device, provisioning it unless skipped
+	GetDevices() []*dev.Device                 // Get the devices
+	SearchDevices(models.DeviceFilter) []*dev.Device // Search the devices matching a filter
+	WriteDevicesCSV(io.Writer) error           // Stream the devices as CSV
+	ImportDevicesCSV(io.Reader) (models.CSVImportSummary, error) // Create devices from a CSV
 	UpdateDevice(*dev.Device) (int, error)     // Update a device
+	ValidateDeviceUpdate(int, *dev.Device) (models.DeviceUpdateCheck, error) // Check an update of a device and list its changes, without applying it
+	ProvisionDeviceIntegrations(int) error     // Provision a device added with skipProvisioning to its integrations
+	CheckDevEUI(lorawan.EUI64, int) (int, error) // Check that a DevEUI is valid and not used by another component
+	CheckDeviceName(string, int) (int, error) // Check that a device name is valid and not used by another component
 	DeleteDevice(int) bool                     // Delete a device
 	DeleteAllDevices() (int, error)            // Delete all devices in bulk
+	SetDevicesActive([]int, bool) []models.SetActiveResult // Activate or deactivate devices in bulk, turning them on or off if running
+	Reset() (models.ResetSummary, error)       // Wipe all components and restore defaults
+	SetTimeScale(float64) error // Change the factor dividing every send interval and ACK timeout
+	SetNetworkServerDelay(int) error // Change the milliseconds added to every downlink to model the network server latency
+	GetWebhooks() []webhook.Config // Get the URLs notified of joins, device errors and gateway disconnections
+	SetWebhooks([]webhook.Config) error // Replace the webhooks
+	GetMQTT() mqtt.Config // Get the MQTT output configuration, without the password
+	SetMQTT(mqtt.Config) error // Replace the MQTT output configuration, reconnecting to the broker
 	ToggleStateDevice(int)                     // Toggle the state of a device
+	StartDevice(int) (bool, error) // Turn a device on unless already running, returning whether it runs
+	StopDevice(int) (bool, error) // Turn a device off unless already stopped, returning whether it runs
+	GetDownlinkAcks(int) ([]devModels.DownlinkAck, error) // Get the confirmed downlink ACK ledger of a device
+	GetDeviceCounters(int) (devModels.Counters, error) // Get the uplink/downlink counters of a device
+	GetDeviceCodecErrors(int) ([]codec.ExecutionError, error) // Get the recent codec execution errors of a device
+	GetDeviceCodecState(int) (*codec.State, error)            // Get the variables the codec of a device keeps between executions
+	ResetDeviceCodecState(int) error                          // Drop the codec state of a device
+	GetOrphanCodecStates() []string                           // List the DevEUIs having a codec state but no device
+	PruneCodecStates() []string                               // Drop the codec states having no device and return their DevEUIs
+	RekeyDevice(int) error                     // Regenerate the session keys of a stopped device
+	SetRXWindows(int, devModels.RXWindowsUpdate) ([]devFeatures.Window, error) // Override the RX window timing of a device
+	GetRetransmission(int) (devModels.Retransmission, error) // Get the confirmed-uplink retries and ACK timeout of a device
+	SetRetransmission(int, devModels.RetransmissionUpdate) (devModels.Retransmission, error) // Change the confirmed-uplink retries and ACK timeout of a device
+	SetFrameCounters(int, devModels.FrameCountersUpdate) (devModels.FrameCounters, error) // Set the current frame counters of a stopped device
+	GetJoinStatus(int) (devModels.JoinStatus, error) // Get the OTAA join state and attempts of a device
+	GetChannels(int) ([]devModels.ChannelInfo, error) // Get the channel plan of a device
+	GetPayloadLimit(int) (devModels.PayloadLimit, error) // Get the maximum payload size of a device at its data rate
+	SetChannels(int, devModels.ChannelsUpdate) ([]devModels.ChannelInfo, error) // Enable or disable uplink channels of a running device
+	ReplayDevice(int, devModels.Replay) error // Play recorded uplinks through a running device, in place of its periodic ones
+	StopReplayDevice(int) error // Stop the replay of a device, which resumes its periodic uplinks
+	InjectMACCommand(int, devModels.MACInjection) ([]lorawan.Payload, error) // Execute a downlink MAC command on a running device and return its queued answers
+	QueueMACCommands(int, []devModels.UplinkMACCommand) ([]lorawan.Payload, error) // Queue a batch of uplink MAC commands at once and return the waiting MAC commands
+	TestDownlinkCodec(int, devModels.DownlinkCodecTest) (devModels.DownlinkCodecTestResult, error) // Run a downlink through the codec of a device against a copy of its state
 	SendMACCommand(lorawan.CID, e.MacCommand)  // Send a MAC command
 	ChangePayload(e.NewPayload) (string, bool) // Change the payload
-	SendUplink(e.NewPayload)                   // Send an uplink
+	SendUplink(e.NewPayload) error             // Send an uplink
 	ChangeLocation(e.NewLocation) bool         // Change the location
+	StartMovement(e.Movement) error            // Move a device along a path of waypoints
+	StopMovement(int) bool                     // Stop a moving device
 	ToggleStateGateway(int)                    // Toggle the state of a gateway
+	StartGateway(int) (bool, error) // Turn a gateway on unless already running, returning whether it runs
+	StopGateway(int) (bool, error) // Turn a gateway off unless already stopped, returning whether it runs
 	GetCodecs() []codec.CodecMetadata        // Get all available codecs
+	GetCodecsFull(int, int) ([]*codec.Codec, int) // Get a page of codecs including their scripts, and the total count
 	GetCodec(int) (*codec.Codec, error)      // Get a specific codec by ID
 	AddCodec(*codec.Codec) error             // Add a custom codec
+	ImportCodecs([]codec.CodecExport) ([]codec.CodecImportResult, error) // Add a batch of exported codecs, skipping duplicate names
 	UpdateCodec(int, string, string) error   // Update an existing codec by ID
 	DeleteCodec(int) error                   // Delete a codec by ID
 	GetDevicesUsingCodec(int) []string       // Get devices using a specific codec
+	GetCodecUsage() map[int]int // Get the number of devices and templates using each codec
+	ResetCodecUsage() map[int]int // Count the codec usage again from scratch
+	GetCodecMetrics() codec.ExecutorMetrics // Get the execution statistics of the codec executor
 	EmitCodecEvent(string, interface{})      // Emit a WebSocket event for codec operations
 
 	// Integration management
 	GetIntegrations() []*integration.Integration                                                    // Get all integrations
 	GetIntegration(int) (*integration.Integration, error)                                           // Get a specific integration
+	RevealIntegrationKey(int) (string, error)                                                       // Get the stored API key of an integration
+	GetIntegrationUsage(int) []string // Get devices and templates using a specific integration
 	AddIntegration(string, integration.IntegrationType, string, string, string, string) (int, error) // Add a new integration (name, type, url, apiKey, tenantId, appId)
 	UpdateIntegration(int, string, string, string, string, string, bool) error                      // Update an integration (id, name, url, apiKey, tenantId, appId, enabled)
 	DeleteIntegration(int) error                                                                    // Delete an integration
 	TestIntegrationConnection(int) error                                                            // Test connection to an integration
+	ProvisionIntegrationDevices(int) (models.ProvisionSummary, error)                               // Provision the devices of a ChirpStack integration that are missing there
 	GetDeviceProfiles(int) ([]integration.DeviceProfile, error)                                     // Get device profiles from an integration (CS or TB)
 	GetThingsBoardCustomers(int) ([]thingsboard.Customer, error)                                    // Get customers for a ThingsBoard integration
 	EmitIntegrationEvent(string, interface{})                                                       // Emit a WebSocket event for integration operations
@@ -65,14 +127,17 @@ type SimulatorRepository interface {
 	// Template management
 	GetTemplates() []*template.DeviceTemplate                                                      // Get all templates
 	GetTemplate(int) (*template.DeviceTemplate, error)                                             // Get a specific template
+	GetTemplateDependencies(int) (template.Dependencies, error) // Get the codec and integrations a template relies on
 	AddTemplate(*template.DeviceTemplate) (int, error)                                             // Add a new template
 	UpdateTemplate(*template.DeviceTemplate) error                                                 // Update a template
 	DeleteTemplate(int) error                                                                      // Delete a template
-	CreateDevicesFromTemplate(int, int, string, float64, float64, int32, float64) ([]int, error) // Bulk create devices from template
+	CreateDevicesFromTemplate(int, int, string, float64, float64, int32, float64, string) ([]int, int, error) // Bulk create devices from template, also returns how many were skipped
+	PreviewDevicesFromTemplate(int, int, string, float64, float64, int32, float64, string) ([]*dev.Device, int, error) // Generate the devices of a bulk creation without creating them
 
 	// Device watch
 	WatchDevice(int) []e.ConsoleLog
 	UnwatchDevice()
+	SetStreamFilter(e.StreamRequest) // Restrict socket events to the given types
 }
 
 // simulatorRepository repository struct
@@ -88,8 +153,12 @@ func NewSimulatorRepository() SimulatorRepository {
 // --- Repository calls to Simulator, no need to comment them, they are self-explanatory ---
 // Check the simulator methods to see what they do
 
-func (s *simulatorRepository) GetInstance() {
-	s.sim = simulator.GetInstance()
+func (s *simulatorRepository) GetInstance(perf models.PerformanceConfig) {
+	s.sim = simulator.GetInstance(perf)
+}
+
+func (s *simulatorRepository) SetupHistory(config models.HistoryConfig) {
+	s.sim.SetupHistory(config)
 }
 
 func (s *simulatorRepository) AddWebSocket(socket *socketio.Conn) {
@@ -120,6 +189,11 @@ func (s *simulatorRepository) Stop() bool {
 	}
 }
 
+// Shutdown stops the simulator if it is running and writes the changes still waiting to be saved.
+func (s *simulatorRepository) Shutdown() {
+	s.sim.Shutdown()
+}
+
 // Status returns True if the simulator is running, otherwise it returns False.
 func (s *simulatorRepository) Status() bool {
 	if s.sim.State == util.Running {
@@ -128,6 +202,14 @@ func (s *simulatorRepository) Status() bool {
 	return false
 }
 
+// Health returns the health snapshot of the simulator, or false if the instance is not initialized yet.
+func (s *simulatorRepository) Health() (models.HealthStatus, bool) {
+	if s.sim == nil {
+		return models.HealthStatus{}, false
+	}
+	return s.sim.Health(), true
+}
+
 func (s *simulatorRepository) SaveBridgeAddress(addr models.AddressIP) error {
 	return s.sim.SaveBridgeAddress(addr)
 }
@@ -136,7 +218,15 @@ func (s *simulatorRepository) GetBridgeAddress() models.AddressIP {
 	return s.sim.GetBridgeAddress()
 }
 
-func (s *simulatorRepository) GetGateways() []gw.Gateway {
+func (s *simulatorRepository) GetForwarderTopology() map[lorawan.EUI64][]lorawan.EUI64 {
+	return s.sim.GetForwarderTopology()
+}
+
+func (s *simulatorRepository) GetCoverage(point loc.Location, rangeMeters float64) []models.CoverageGateway {
+	return s.sim.GetCoverage(point, rangeMeters)
+}
+
+func (s *simulatorRepository) GetGateways() []*gw.Gateway {
 	return s.sim.GetGateways()
 }
 
@@ -153,19 +243,51 @@ func (s *simulatorRepository) DeleteGateway(Id int) bool {
 	return s.sim.DeleteGateway(Id)
 }
 
-func (s *simulatorRepository) AddDevice(device *dev.Device) (int, int, error) {
-	return s.sim.SetDevice(device, false)
+func (s *simulatorRepository) ReconnectGateway(id int) error {
+	return s.sim.ReconnectGateway(id)
 }
 
-func (s *simulatorRepository) GetDevices() []dev.Device {
+func (s *simulatorRepository) AddDevice(device *dev.Device, skipProvisioning bool) (int, int, error) {
+	return s.sim.AddDevice(device, skipProvisioning)
+}
+
+func (s *simulatorRepository) GetDevices() []*dev.Device {
 	return s.sim.GetDevices()
 }
 
+func (s *simulatorRepository) SearchDevices(filter models.DeviceFilter) []*dev.Device {
+	return s.sim.SearchDevices(filter)
+}
+
+func (s *simulatorRepository) WriteDevicesCSV(w io.Writer) error {
+	return s.sim.WriteDevicesCSV(w)
+}
+
+func (s *simulatorRepository) ImportDevicesCSV(r io.Reader) (models.CSVImportSummary, error) {
+	return s.sim.ImportDevicesCSV(r)
+}
+
 func (s *simulatorRepository) UpdateDevice(device *dev.Device) (int, error) {
 	code, _, err := s.sim.SetDevice(device, true)
 	return code, err
 }
 
+func (s *simulatorRepository) ValidateDeviceUpdate(id int, device *dev.Device) (models.DeviceUpdateCheck, error) {
+	return s.sim.ValidateDeviceUpdate(id, device)
+}
+
+func (s *simulatorRepository) ProvisionDeviceIntegrations(id int) error {
+	return s.sim.ProvisionDeviceIntegrations(id)
+}
+
+func (s *simulatorRepository) CheckDevEUI(devEUI lorawan.EUI64, id int) (int, error) {
+	return s.sim.CheckDevEUI(devEUI, id)
+}
+
+func (s *simulatorRepository) CheckDeviceName(name string, id int) (int, error) {
+	return s.sim.CheckDeviceName(name, id)
+}
+
 func (s *simulatorRepository) DeleteDevice(Id int) bool {
 	return s.sim.DeleteDevice(Id)
 }
@@ -174,10 +296,134 @@ func (s *simulatorRepository) DeleteAllDevices() (int, error) {
 	return s.sim.DeleteAllDevices()
 }
 
+func (s *simulatorRepository) SetDevicesActive(ids []int, active bool) []models.SetActiveResult {
+	return s.sim.SetDevicesActive(ids, active)
+}
+
+func (s *simulatorRepository) Reset() (models.ResetSummary, error) {
+	return s.sim.Reset()
+}
+
+func (s *simulatorRepository) SetTimeScale(scale float64) error {
+	return s.sim.SetTimeScale(scale)
+}
+
+func (s *simulatorRepository) SetNetworkServerDelay(ms int) error {
+	return s.sim.SetNetworkServerDelay(ms)
+}
+
+func (s *simulatorRepository) GetWebhooks() []webhook.Config {
+	return s.sim.GetWebhooks()
+}
+
+func (s *simulatorRepository) SetWebhooks(configs []webhook.Config) error {
+	return s.sim.SetWebhooks(configs)
+}
+
+func (s *simulatorRepository) GetMQTT() mqtt.Config {
+	return s.sim.GetMQTT()
+}
+
+func (s *simulatorRepository) SetMQTT(config mqtt.Config) error {
+	return s.sim.SetMQTT(config)
+}
+
 func (s *simulatorRepository) ToggleStateDevice(Id int) {
 	s.sim.ToggleStateDevice(Id)
 }
 
+func (s *simulatorRepository) StartDevice(id int) (bool, error) {
+	return s.sim.StartDevice(id)
+}
+
+func (s *simulatorRepository) StopDevice(id int) (bool, error) {
+	return s.sim.StopDevice(id)
+}
+
+func (s *simulatorRepository) GetDownlinkAcks(id int) ([]devModels.DownlinkAck, error) {
+	return s.sim.GetDownlinkAcks(id)
+}
+
+func (s *simulatorRepository) GetDeviceCounters(id int) (devModels.Counters, error) {
+	return s.sim.GetDeviceCounters(id)
+}
+
+func (s *simulatorRepository) GetDeviceCodecErrors(id int) ([]codec.ExecutionError, error) {
+	return s.sim.GetDeviceCodecErrors(id)
+}
+
+func (s *simulatorRepository) GetDeviceCodecState(id int) (*codec.State, error) {
+	return s.sim.GetDeviceCodecState(id)
+}
+
+func (s *simulatorRepository) ResetDeviceCodecState(id int) error {
+	return s.sim.ResetDeviceCodecState(id)
+}
+
+func (s *simulatorRepository) GetOrphanCodecStates() []string {
+	return s.sim.GetOrphanCodecStates()
+}
+
+func (s *simulatorRepository) PruneCodecStates() []string {
+	return s.sim.PruneCodecStates()
+}
+
+func (s *simulatorRepository) RekeyDevice(id int) error {
+	return s.sim.RekeyDevice(id)
+}
+
+func (s *simulatorRepository) SetRXWindows(id int, update devModels.RXWindowsUpdate) ([]devFeatures.Window, error) {
+	return s.sim.SetRXWindows(id, update)
+}
+
+func (s *simulatorRepository) GetRetransmission(id int) (devModels.Retransmission, error) {
+	return s.sim.GetRetransmission(id)
+}
+
+func (s *simulatorRepository) SetRetransmission(id int, update devModels.RetransmissionUpdate) (devModels.Retransmission, error) {
+	return s.sim.SetRetransmission(id, update)
+}
+
+func (s *simulatorRepository) SetFrameCounters(id int, update devModels.FrameCountersUpdate) (devModels.FrameCounters, error) {
+	return s.sim.SetFrameCounters(id, update)
+}
+
+func (s *simulatorRepository) GetJoinStatus(id int) (devModels.JoinStatus, error) {
+	return s.sim.GetJoinStatus(id)
+}
+
+func (s *simulatorRepository) GetChannels(id int) ([]devModels.ChannelInfo, error) {
+	return s.sim.GetChannels(id)
+}
+
+func (s *simulatorRepository) GetPayloadLimit(id int) (devModels.PayloadLimit, error) {
+	return s.sim.GetPayloadLimit(id)
+}
+
+func (s *simulatorRepository) SetChannels(id int, update devModels.ChannelsUpdate) ([]devModels.ChannelInfo, error) {
+	return s.sim.SetChannels(id, update)
+}
+
+func (s *simulatorRepository) ReplayDevice(id int, replay devModels.Replay) error {
+	return s.sim.ReplayDevice(id, replay)
+}
+
+func (s *simulatorRepository) StopReplayDevice(id int) error {
+	return s.sim.StopReplayDevice(id)
+}
+
+func (s *simulatorRepository) InjectMACCommand(id int, injection devModels.MACInjection) ([]lorawan.Payload, error) {
+	return s.sim.InjectMACCommand(id, injection)
+}
+
+func (s *simulatorRepository) QueueMACCommands(id int, batch []devModels.UplinkMACCommand) ([]lorawan.Payload, error) {
+	return s.sim.QueueMACCommands(id, batch)
+}
+
+func (s *simulatorRepository) TestDownlinkCodec(id int, test devModels.DownlinkCodecTest) (devModels.DownlinkCodecTestResult, error) {
+	return s.sim.TestDownlinkCodec(id, test)
+}
+
 func (s *simulatorRepository) SendMACCommand(cid lorawan.CID, data e.MacCommand) {
 	s.sim.SendMACCommand(cid, data)
 }
@@ -186,22 +432,42 @@ func (s *simulatorRepository) ChangePayload(pl e.NewPayload) (string, bool) {
 	return s.sim.ChangePayload(pl)
 }
 
-func (s *simulatorRepository) SendUplink(pl e.NewPayload) {
-	s.sim.SendUplink(pl)
+func (s *simulatorRepository) SendUplink(pl e.NewPayload) error {
+	return s.sim.SendUplink(pl)
 }
 
 func (s *simulatorRepository) ChangeLocation(loc e.NewLocation) bool {
 	return s.sim.ChangeLocation(loc)
 }
 
+func (s *simulatorRepository) StartMovement(m e.Movement) error {
+	return s.sim.StartMovement(m)
+}
+
+func (s *simulatorRepository) StopMovement(id int) bool {
+	return s.sim.StopMovement(id)
+}
+
 func (s *simulatorRepository) ToggleStateGateway(Id int) {
 	s.sim.ToggleStateGateway(Id)
 }
 
+func (s *simulatorRepository) StartGateway(id int) (bool, error) {
+	return s.sim.StartGateway(id)
+}
+
+func (s *simulatorRepository) StopGateway(id int) (bool, error) {
+	return s.sim.StopGateway(id)
+}
+
 func (s *simulatorRepository) GetCodecs() []codec.CodecMetadata {
 	return s.sim.GetCodecs()
 }
 
+func (s *simulatorRepository) GetCodecsFull(offset, limit int) ([]*codec.Codec, int) {
+	return s.sim.GetCodecsFull(offset, limit)
+}
+
 func (s *simulatorRepository) GetCodec(id int) (*codec.Codec, error) {
 	return s.sim.GetCodec(id)
 }
@@ -210,6 +476,10 @@ func (s *simulatorRepository) AddCodec(codec *codec.Codec) error {
 	return s.sim.AddCodec(codec)
 }
 
+func (s *simulatorRepository) ImportCodecs(items []codec.CodecExport) ([]codec.CodecImportResult, error) {
+	return s.sim.ImportCodecs(items)
+}
+
 func (s *simulatorRepository) UpdateCodec(id int, name string, script string) error {
 	return s.sim.UpdateCodec(id, name, script)
 }
@@ -222,6 +492,18 @@ func (s *simulatorRepository) GetDevicesUsingCodec(codecID int) []string {
 	return s.sim.GetDevicesUsingCodec(codecID)
 }
 
+func (s *simulatorRepository) GetCodecUsage() map[int]int {
+	return s.sim.GetCodecUsage()
+}
+
+func (s *simulatorRepository) ResetCodecUsage() map[int]int {
+	return s.sim.ResetCodecUsage()
+}
+
+func (s *simulatorRepository) GetCodecMetrics() codec.ExecutorMetrics {
+	return s.sim.GetCodecMetrics()
+}
+
 func (s *simulatorRepository) EmitCodecEvent(eventName string, data interface{}) {
 	s.sim.Console.PrintSocket(eventName, data)
 }
@@ -236,6 +518,14 @@ func (s *simulatorRepository) GetIntegration(id int) (*integration.Integration,
 	return s.sim.GetIntegration(id)
 }
 
+func (s *simulatorRepository) RevealIntegrationKey(id int) (string, error) {
+	return s.sim.RevealIntegrationKey(id)
+}
+
+func (s *simulatorRepository) GetIntegrationUsage(integrationID int) []string {
+	return s.sim.GetIntegrationUsage(integrationID)
+}
+
 func (s *simulatorRepository) AddIntegration(name string, intType integration.IntegrationType, url, apiKey, tenantID, appID string) (int, error) {
 	return s.sim.AddIntegration(name, intType, url, apiKey, tenantID, appID)
 }
@@ -252,6 +542,10 @@ func (s *simulatorRepository) TestIntegrationConnection(id int) error {
 	return s.sim.TestIntegrationConnection(id)
 }
 
+func (s *simulatorRepository) ProvisionIntegrationDevices(integrationID int) (models.ProvisionSummary, error) {
+	return s.sim.ProvisionIntegrationDevices(integrationID)
+}
+
 func (s *simulatorRepository) GetDeviceProfiles(id int) ([]integration.DeviceProfile, error) {
 	return s.sim.GetDeviceProfiles(id)
 }
@@ -274,6 +568,10 @@ func (s *simulatorRepository) GetTemplate(id int) (*template.DeviceTemplate, err
 	return s.sim.GetTemplate(id)
 }
 
+func (s *simulatorRepository) GetTemplateDependencies(id int) (template.Dependencies, error) {
+	return s.sim.GetTemplateDependencies(id)
+}
+
 func (s *simulatorRepository) AddTemplate(tmpl *template.DeviceTemplate) (int, error) {
 	return s.sim.AddTemplate(tmpl)
 }
@@ -286,8 +584,12 @@ func (s *simulatorRepository) DeleteTemplate(id int) error {
 	return s.sim.DeleteTemplate(id)
 }
 
-func (s *simulatorRepository) CreateDevicesFromTemplate(templateID int, count int, namePrefix string, baseLat, baseLng float64, baseAlt int32, spreadMeters float64) ([]int, error) {
-	return s.sim.CreateDevicesFromTemplate(templateID, count, namePrefix, baseLat, baseLng, baseAlt, spreadMeters)
+func (s *simulatorRepository) CreateDevicesFromTemplate(templateID int, count int, namePrefix string, baseLat, baseLng float64, baseAlt int32, spreadMeters float64, spreadShape string) ([]int, int, error) {
+	return s.sim.CreateDevicesFromTemplate(templateID, count, namePrefix, baseLat, baseLng, baseAlt, spreadMeters, spreadShape)
+}
+
+func (s *simulatorRepository) PreviewDevicesFromTemplate(templateID int, count int, namePrefix string, baseLat, baseLng float64, baseAlt int32, spreadMeters float64, spreadShape string) ([]*dev.Device, int, error) {
+	return s.sim.PreviewDevicesFromTemplate(templateID, count, namePrefix, baseLat, baseLng, baseAlt, spreadMeters, spreadShape)
 }
 
 func (s *simulatorRepository) WatchDevice(id int) []e.ConsoleLog {
@@ -298,4 +600,8 @@ func (s *simulatorRepository) UnwatchDevice() {
 	s.sim.UnwatchDevice()
 }
 
+func (s *simulatorRepository) SetStreamFilter(req e.StreamRequest) {
+	s.sim.SetStreamFilter(req)
+}
+
 
@@ -8,6 +8,12 @@ var Verbose bool = false
 // Version of the simulator
 const Version = "1.0.3"
 
+// Build metadata, set by main from the values injected at build time with -ldflags
+var (
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
 func DebugPrint(msg string) {
 	if Verbose {
 		log.Printf("[DEBUG]: %s", msg)
@@ -7,9 +7,10 @@ import (
 	"fmt"
 	"log"
 	"math"
-	mrand "math/rand"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/brocaar/lorawan"
@@ -20,7 +21,9 @@ import (
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/chirpstack"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/thingsboard"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/webhook"
 	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/output/mqtt"
 	"github.com/R3DPanda1/LWN-Sim-Plus/shared"
 
 	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
@@ -38,31 +41,47 @@ import (
 	socketio "github.com/googollee/go-socket.io"
 )
 
-func GetInstance() *Simulator {
+func GetInstance(perf models.PerformanceConfig) *Simulator {
 	var s Simulator
 	shared.DebugPrint("Init new Simulator instance")
 	// Initial state of the simulator is stopped
 	s.State = util.Stopped
 	// Load saved data
 	s.loadData()
+	if s.TimeScale <= 0 {
+		s.TimeScale = 1
+	}
 	// Initialized the active devices and gateways maps
 	s.ActiveDevices = make(map[int]int)
 	s.ActiveGateways = make(map[int]int)
 	// Init Forwarder
 	s.Forwarder = *f.Setup()
+	s.Forwarder.SetDownlinkDelay(time.Duration(s.NetworkServerDelay) * time.Millisecond)
 	// Attach console with watched device pointer
 	noWatch := -1
 	var ws socketio.Conn
-	s.Console = c.Console{WebSocket: &ws, WatchedID: &noWatch}
+	var eventTypes atomic.Pointer[[]string]
+	// simulator.json may have been edited by hand: skip the webhooks SetWebhooks would refuse
+	webhooks, errs := webhook.ValidConfigs(s.Webhooks)
+	for _, err := range errs {
+		log.Printf("Warning: %v, ignored", err)
+	}
+	s.Webhooks = webhooks
+	if err := s.MQTT.Validate(); s.MQTT.Enabled && err != nil {
+		log.Printf("Warning: %v, MQTT output disabled", err)
+		s.MQTT.Enabled = false
+	}
+	s.Console = c.Console{WebSocket: &ws, WatchedID: &noWatch, EventTypes: &eventTypes,
+		Webhooks: webhook.NewDispatcher(s.Webhooks), MQTT: mqtt.NewPublisher(s.MQTT)}
 
 	// Initialize codec manager (Phase 1-3 enhancement)
 	if dev.Codecs == nil {
-		dev.Codecs = codec.NewRegistry(codec.DefaultExecutorConfig())
+		dev.Codecs = codec.NewRegistry(executorConfig(perf))
 
 		// Load codec library from disk
 		pathDir, err := util.GetPath()
 		codecLibLoaded := false
-		if err == nil {
+		if err == nil && !util.IsInMemory() {
 			codecLibPath := pathDir + "/codecs.json"
 			if err := dev.Codecs.Load(codecLibPath); err != nil {
 				shared.DebugPrint(fmt.Sprintf("Warning: %v", err))
@@ -90,6 +109,173 @@ func GetInstance() *Simulator {
 	return &s
 }
 
+// Bounds accepted for the time scale
+const (
+	MinTimeScale = 0.01
+	MaxTimeScale = 1000
+)
+
+// SetTimeScale changes the factor dividing the send interval and ACK timeout of every
+// device, rescheduling the running ones, and saves it
+func (s *Simulator) SetTimeScale(scale float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if math.IsNaN(scale) || scale < MinTimeScale || scale > MaxTimeScale {
+		return fmt.Errorf("time scale must be between %v and %v", MinTimeScale, MaxTimeScale)
+	}
+
+	s.TimeScale = scale
+	for _, d := range s.Devices {
+		d.SetTimeScale(scale)
+	}
+
+	pathDir, err := util.GetPath()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.saveComponent(pathDir+"/simulator.json", &s)
+
+	s.Print(fmt.Sprintf("Time scale set to %v", scale), nil, util.PrintBoth)
+	return nil
+}
+
+// MaxNetworkServerDelay is the highest network server latency accepted, in milliseconds
+const MaxNetworkServerDelay = 10000
+
+// SetNetworkServerDelay changes the milliseconds added to every downlink before it reaches
+// the devices, and saves it. Downlinks delayed past the receive window are dropped.
+func (s *Simulator) SetNetworkServerDelay(ms int) error {
+	if ms < 0 || ms > MaxNetworkServerDelay {
+		return fmt.Errorf("network server delay must be between 0 and %d ms", MaxNetworkServerDelay)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.NetworkServerDelay = ms
+	s.Forwarder.SetDownlinkDelay(time.Duration(ms) * time.Millisecond)
+
+	pathDir, err := util.GetPath()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.saveComponent(pathDir+"/simulator.json", &s)
+
+	s.Print(fmt.Sprintf("Network server delay set to %d ms", ms), nil, util.PrintBoth)
+	return nil
+}
+
+// GetWebhooks returns the URLs notified of significant events
+func (s *Simulator) GetWebhooks() []webhook.Config {
+	return s.Console.Webhooks.Configs()
+}
+
+// SetWebhooks validates and replaces the webhooks, then saves them. Nothing is
+// changed if any webhook is invalid or has the URL of a previous one.
+func (s *Simulator) SetWebhooks(configs []webhook.Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := webhook.ValidateConfigs(configs); err != nil {
+		return err
+	}
+
+	s.Webhooks = configs
+	s.Console.Webhooks.SetConfigs(configs)
+
+	pathDir, err := util.GetPath()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.saveComponent(pathDir+"/simulator.json", &s)
+
+	s.Print(fmt.Sprintf("%d webhook(s) configured", len(configs)), nil, util.PrintBoth)
+	return nil
+}
+
+// GetMQTT returns the MQTT output configuration, without the password
+func (s *Simulator) GetMQTT() mqtt.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.MQTT.Redacted()
+}
+
+// SetMQTT validates and applies the MQTT output configuration, reconnecting to the
+// broker, then saves it. The stored password is kept when the redacted one is sent back.
+func (s *Simulator) SetMQTT(config mqtt.Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if config.Enabled {
+		if err := config.Validate(); err != nil {
+			return err
+		}
+	}
+	if config.Password == mqtt.RedactedPassword {
+		config.Password = s.MQTT.Password
+	}
+
+	s.MQTT = config
+	s.Console.MQTT.SetConfig(config)
+
+	pathDir, err := util.GetPath()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.saveComponent(pathDir+"/simulator.json", &s)
+
+	if config.Enabled {
+		s.Print("Publishing events to MQTT broker "+config.BrokerURL, nil, util.PrintBoth)
+	} else {
+		s.Print("MQTT output disabled", nil, util.PrintBoth)
+	}
+	return nil
+}
+
+// applyDeviceDefaults gives the configured default region and class to a new device
+// that left them unset. Invalid defaults fall back to EU868 and class A.
+func (s *Simulator) applyDeviceDefaults(device *dev.Device) {
+	region := s.DefaultRegion
+	if !rp.IsSupported(region) {
+		region = rp.Code_Eu868
+	}
+	class := s.DefaultClass
+	if !devModels.IsValidClass(class) {
+		class = devModels.ClassA
+	}
+	device.Info.Configuration.ApplyDefaults(region, class)
+}
+
+// applyRetransmissionDefaults gives the configured default ACK timeout and number of
+// retransmissions to a device leaving them at 0. A device built from a template carries
+// the values of the template, so the precedence is device > template > simulator default.
+// Defaults out of the bounds accepted for a device are ignored.
+func (s *Simulator) applyRetransmissionDefaults(device *dev.Device) {
+	conf := &device.Info.Configuration
+	ackTimeout := time.Duration(s.DefaultAckTimeout) * time.Second
+	if conf.AckTimeout == 0 && ackTimeout > 0 && ackTimeout <= dev.MaxAckTimeout {
+		conf.AckTimeout = ackTimeout
+	}
+	if conf.NbRepConfirmedDataUp == 0 && s.DefaultNbRetransmission > 0 && s.DefaultNbRetransmission <= dev.MaxNbRetransmission {
+		conf.NbRepConfirmedDataUp = s.DefaultNbRetransmission
+	}
+}
+
+// executorConfig returns the default codec executor configuration with the configured overrides applied
+func executorConfig(perf models.PerformanceConfig) *codec.ExecutorConfig {
+	config := codec.DefaultExecutorConfig()
+	if perf.CodecMaxVMs > 0 {
+		config.MaxVMs = perf.CodecMaxVMs
+	}
+	if perf.CodecTimeoutMs > 0 {
+		config.Timeout = time.Duration(perf.CodecTimeoutMs) * time.Millisecond
+	}
+	if perf.CodecMaxMemoryMB > 0 {
+		config.MaxMemory = uint64(perf.CodecMaxMemoryMB) << 20
+	}
+	if perf.CodecMaxCallStack > 0 {
+		config.MaxCallStackSize = perf.CodecMaxCallStack
+	}
+	return config
+}
+
 func (s *Simulator) AddWebSocket(WebSocket *socketio.Conn) {
 	s.Console.SetupWebSocket(WebSocket)
 	s.Resources.AddWebSocket(WebSocket)
@@ -97,6 +283,16 @@ func (s *Simulator) AddWebSocket(WebSocket *socketio.Conn) {
 
 // Run starts the simulation environment
 func (s *Simulator) Run() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping {
+		s.Print("", errors.New("the simulator is still stopping"), util.PrintBoth)
+		return
+	}
+	if s.resetting {
+		s.Print("", errors.New("the simulator is being reset"), util.PrintBoth)
+		return
+	}
 	shared.DebugPrint("Executing Run")
 	s.State = util.Running
 	s.setup()
@@ -114,55 +310,148 @@ func (s *Simulator) Run() {
 	}
 
 	s.Print("START", nil, util.PrintBoth)
+	s.notifyState(webhook.SourceSimulator, 0, "", "", webhook.StateRunning)
 	shared.DebugPrint("Turning ON active components")
 	for _, id := range s.ActiveGateways {
 		s.turnONGateway(id)
 	}
+	stagger := time.Duration(s.StartupStagger) * time.Millisecond
+	offset := time.Duration(0)
 	for _, id := range s.ActiveDevices {
+		s.Devices[id].SetStartDelay(offset)
 		s.turnONDevice(id)
+		offset += stagger
 	}
+	s.startAutoSave()
 }
 
-// Stop terminates the simulation environment
+// Stop terminates the simulation environment.
+// s.mu is released while waiting for the components to exit, so the API stays responsive.
 func (s *Simulator) Stop() {
+	s.mu.Lock()
+	if s.stopping {
+		s.mu.Unlock()
+		return
+	}
 	shared.DebugPrint("Executing Stop")
 	s.State = util.Stopped
-	s.Resources.ExitGroup.Add(len(s.ActiveGateways) + len(s.ActiveDevices) - s.ComponentsInactiveTmp)
-	shared.DebugPrint("Turning OFF active components")
+	s.stopping = true
+	s.stopAutoSave()
+	gateways := make(map[int]*gw.Gateway, len(s.ActiveGateways))
 	for _, id := range s.ActiveGateways {
-		s.Gateways[id].TurnOFF()
+		gateways[id] = s.Gateways[id]
 	}
+	devices := make(map[int]*dev.Device, len(s.ActiveDevices))
 	for _, id := range s.ActiveDevices {
-		s.Devices[id].TurnOFF()
+		devices[id] = s.Devices[id]
+	}
+	s.Resources.ExitGroup.Add(len(gateways) + len(devices) - s.ComponentsInactiveTmp)
+	shared.DebugPrint("Turning OFF active components")
+	for _, g := range gateways {
+		g.TurnOFF()
+	}
+	for _, d := range devices {
+		d.TurnOFF()
 	}
+	s.mu.Unlock()
+
 	s.Resources.ExitGroup.Wait()
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopping = false
+	for id, g := range gateways {
+		s.notifyState(webhook.SourceGateway, id, g.Info.MACAddress.String(), g.Info.Name, webhook.StateStopped)
+	}
+	for id, d := range devices {
+		s.notifyState(webhook.SourceDevice, id, d.Info.DevEUI.String(), d.Info.Name, webhook.StateStopped)
+	}
+
 	// Save all state (includes integrations and templates now)
 	s.saveStatus()
+	s.saveHistory()
 
 	// Save codec library (codec uses its own registry)
-	if dev.Codecs != nil {
-		pathDir, err := util.GetPath()
-		if err == nil {
-			codecLibPath := pathDir + "/codecs.json"
-			if err := dev.Codecs.Save(codecLibPath); err != nil {
-				shared.DebugPrint(fmt.Sprintf("Warning: failed to save codec library: %v", err))
-			} else {
-				shared.DebugPrint("Codec library saved to disk")
-			}
-		}
-	}
+	s.saveCodecLibrary()
 
 	// Reset watched device
 	*s.Console.WatchedID = -1
 
 	s.Forwarder.Reset()
 	s.Print("STOPPED", nil, util.PrintBoth)
+	s.notifyState(webhook.SourceSimulator, 0, "", "", webhook.StateStopped)
 	s.reset()
 }
 
+// Shutdown stops the simulation if it runs and writes the changes still waiting for
+// their delayed save, so that nothing is lost when the process exits. It then stops
+// the periodic history saves and disconnects from the MQTT broker.
+func (s *Simulator) Shutdown() {
+	s.mu.RLock()
+	running := s.State == util.Running
+	s.mu.RUnlock()
+	if running {
+		s.Stop()
+	}
+	s.mu.Lock()
+	s.stopHistory()
+	s.mu.Unlock()
+	s.flushSaves()
+	if s.Console.MQTT != nil {
+		s.Console.MQTT.Close()
+	}
+}
+
+// Health returns the simulator state and component counts without serializing the components
+func (s *Simulator) Health() models.HealthStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state := "stopped"
+	if s.State == util.Running {
+		state = "running"
+	}
+	return models.HealthStatus{
+		State:          state,
+		DeviceCount:    len(s.Devices),
+		GatewayCount:   len(s.Gateways),
+		ActiveDevices:  len(s.ActiveDevices),
+		ActiveGateways: len(s.ActiveGateways),
+		Version:        shared.Version,
+	}
+}
+
+// GetForwarderTopology returns, per DevEUI, the gateways the forwarder currently routes the device's uplinks to
+func (s *Simulator) GetForwarderTopology() map[lorawan.EUI64][]lorawan.EUI64 {
+	return s.Forwarder.Topology()
+}
+
+// GetCoverage returns the gateways that would receive a device with the given antenna range (meters)
+// placed at point, closest first
+func (s *Simulator) GetCoverage(point location.Location, rangeMeters float64) []models.CoverageGateway {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	covering := []models.CoverageGateway{}
+	for _, g := range s.Gateways {
+		if !location.InRange(point, g.Info.Location, rangeMeters) {
+			continue
+		}
+		covering = append(covering, models.CoverageGateway{
+			Id:         g.Id,
+			Name:       g.Info.Name,
+			MACAddress: g.Info.MACAddress,
+			Active:     g.Info.Active,
+			Distance: location.GetDistance(point.Latitude, point.Longitude,
+				g.Info.Location.Latitude, g.Info.Location.Longitude) * 1000,
+		})
+	}
+	sort.Slice(covering, func(i, j int) bool { return covering[i].Distance < covering[j].Distance })
+	return covering
+}
+
 // SaveBridgeAddress stores the bridge address in the simulator struct and saves it to the simulator.json file
 func (s *Simulator) SaveBridgeAddress(remoteAddr models.AddressIP) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	// Store the bridge address in the simulator struct
 	s.BridgeAddress = fmt.Sprintf("%v:%v", remoteAddr.Address, remoteAddr.Port)
 	pathDir, err := util.GetPath()
@@ -177,6 +466,8 @@ func (s *Simulator) SaveBridgeAddress(remoteAddr models.AddressIP) error {
 
 // GetBridgeAddress returns the bridge address stored in the simulator struct
 func (s *Simulator) GetBridgeAddress() models.AddressIP {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	// Create an empty AddressIP struct with default values
 	var rServer models.AddressIP
 	if s.BridgeAddress == "" {
@@ -190,25 +481,67 @@ func (s *Simulator) GetBridgeAddress() models.AddressIP {
 }
 
 // GetGateways returns an array of all gateways in the simulator
-func (s *Simulator) GetGateways() []gw.Gateway {
-	var gateways []gw.Gateway
+func (s *Simulator) GetGateways() []*gw.Gateway {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var gateways []*gw.Gateway
 	for _, g := range s.Gateways {
-		gateways = append(gateways, *g)
+		gateways = append(gateways, g)
 	}
 	return gateways
 }
 
 // GetDevices returns an array of all devices in the simulator
-func (s *Simulator) GetDevices() []dev.Device {
-	var devices []dev.Device
+func (s *Simulator) GetDevices() []*dev.Device {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var devices []*dev.Device
+	for _, d := range s.Devices {
+		devices = append(devices, d)
+	}
+	return devices
+}
+
+// SearchDevices returns the devices matching every criteria set in the filter, by ID
+func (s *Simulator) SearchDevices(filter models.DeviceFilter) []*dev.Device {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	devices := []*dev.Device{}
+	name := strings.ToLower(strings.TrimSpace(filter.Name))
 	for _, d := range s.Devices {
-		devices = append(devices, *d)
+		conf := d.Info.Configuration
+		if filter.Region != nil && (conf.Region == nil || conf.Region.GetCode() != *filter.Region) {
+			continue
+		}
+		if filter.CodecID != nil && (!conf.UseCodec || conf.CodecID != *filter.CodecID) {
+			continue
+		}
+		if filter.Active != nil && d.Info.Status.Active != *filter.Active {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(d.Info.Name), name) {
+			continue
+		}
+		switch strings.ToUpper(filter.Class) {
+		case "B":
+			if !conf.SupportedClassB {
+				continue
+			}
+		case "C":
+			if !conf.SupportedClassC {
+				continue
+			}
+		}
+		devices = append(devices, d)
 	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Id < devices[j].Id })
 	return devices
 }
 
 // SetGateway adds or updates a gateway
 func (s *Simulator) SetGateway(gateway *gw.Gateway, update bool) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	shared.DebugPrint(fmt.Sprintf("Adding/Updating Gateway [%s]", gateway.Info.MACAddress.String()))
 	emptyAddr := lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 0}
 	// Check if the MAC address is valid
@@ -224,6 +557,12 @@ func (s *Simulator) SetGateway(gateway *gw.Gateway, update bool) (int, int, erro
 			return codes.CodeErrorDeviceActive, -1, errors.New("Gateway is running, unable update")
 		}
 	}
+	// Check if the name is valid
+	if err := util.ValidateName(gateway.Info.Name); err != nil {
+		s.Print("Name invalid", nil, util.PrintOnlyConsole)
+		return codes.CodeErrorName, -1, err
+	}
+	gateway.Info.Name = strings.TrimSpace(gateway.Info.Name)
 	// Check if the name is already used
 	code, err := s.searchName(gateway.Info.Name, gateway.Id, true)
 	if err != nil {
@@ -236,9 +575,20 @@ func (s *Simulator) SetGateway(gateway *gw.Gateway, update bool) (int, int, erro
 		s.Print("DevEUI already used", nil, util.PrintOnlyConsole)
 		return code, -1, err
 	}
+	if gateway.Info.Latency < 0 || gateway.Info.LatencyJitter < 0 {
+		return codes.CodeErrorLatency, -1, errors.New("Gateway latency can't be negative")
+	}
+	if gateway.Info.StatInterval < 0 {
+		return codes.CodeErrorStatInterval, -1, errors.New("Gateway stat interval can't be negative")
+	}
 	if !gateway.Info.TypeGateway {
 
-		if s.BridgeAddress == "" {
+		gateway.Info.Bridge = strings.TrimSpace(gateway.Info.Bridge)
+		if gateway.Info.Bridge != "" {
+			if err := util.ValidateUDPAddress(gateway.Info.Bridge); err != nil {
+				return codes.CodeErrorBridge, -1, fmt.Errorf("Invalid bridge address: %w", err)
+			}
+		} else if s.BridgeAddress == "" {
 			return codes.CodeNoBridge, -1, errors.New("No gateway bridge configured")
 		}
 
@@ -280,7 +630,6 @@ func (s *Simulator) SetGateway(gateway *gw.Gateway, update bool) (int, int, erro
 		s.ActiveGateways[gateway.Id] = gateway.Id
 
 		if s.State == util.Running {
-			s.Gateways[gateway.Id].Setup(&s.BridgeAddress, &s.Resources, &s.Forwarder)
 			s.turnONGateway(gateway.Id)
 		}
 
@@ -294,7 +643,20 @@ func (s *Simulator) SetGateway(gateway *gw.Gateway, update bool) (int, int, erro
 	return codes.CodeOK, gateway.Id, nil
 }
 
+// ReconnectGateway closes the UDP connection of a running gateway so that it is re-established immediately
+func (s *Simulator) ReconnectGateway(id int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	g, ok := s.Gateways[id]
+	if !ok {
+		return errors.New("gateway not found")
+	}
+	return g.Reconnect()
+}
+
 func (s *Simulator) DeleteGateway(Id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	if s.Gateways[Id].IsOn() {
 		return false
@@ -326,7 +688,21 @@ func (s *Simulator) DeleteGateway(Id int) bool {
 	return true
 }
 
+// SetDevice adds a new device, provisioned to its integrations, or updates one
 func (s *Simulator) SetDevice(device *dev.Device, update bool) (int, int, error) {
+	return s.setDevice(device, update, true)
+}
+
+// AddDevice adds a new device, provisioning it to its integrations unless skipProvisioning
+// is set, in which case ProvisionDeviceIntegrations can provision it later
+func (s *Simulator) AddDevice(device *dev.Device, skipProvisioning bool) (int, int, error) {
+	return s.setDevice(device, false, !skipProvisioning)
+}
+
+// setDevice adds or updates a device; provision applies to new devices only
+func (s *Simulator) setDevice(device *dev.Device, update bool, provision bool) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	emptyAddr := lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 0}
 
@@ -337,11 +713,25 @@ func (s *Simulator) SetDevice(device *dev.Device, update bool) (int, int, error)
 
 	}
 
+	if err := s.checkDeviceReferences(&device.Info.Configuration); err != nil {
+
+		s.Print("Codec or integration not found", nil, util.PrintOnlyConsole)
+		return codes.CodeErrorReference, -1, err
+
+	}
+
 	if !update { //new
 
+		if s.MaxDevices > 0 && len(s.Devices) >= s.MaxDevices {
+			s.Print("Maximum number of devices reached", nil, util.PrintOnlyConsole)
+			return codes.CodeErrorMaxDevices, -1, fmt.Errorf("Error: maximum number of devices (%d) reached", s.MaxDevices)
+		}
+
 		device.Id = s.NextIDDev
 		s.NextIDDev++
 
+		s.applyDeviceDefaults(device)
+
 	} else {
 
 		if s.Devices[device.Id].IsOn() {
@@ -350,6 +740,23 @@ func (s *Simulator) SetDevice(device *dev.Device, update bool) (int, int, error)
 
 	}
 
+	s.applyRetransmissionDefaults(device)
+
+	if err := device.Info.Configuration.Validate(); err != nil {
+
+		s.Print("Configuration invalid", nil, util.PrintOnlyConsole)
+		return codes.CodeErrorInvalidRequest, -1, err
+
+	}
+
+	if err := util.ValidateName(device.Info.Name); err != nil {
+
+		s.Print("Name invalid", nil, util.PrintOnlyConsole)
+		return codes.CodeErrorName, -1, err
+
+	}
+	device.Info.Name = strings.TrimSpace(device.Info.Name)
+
 	code, err := s.searchName(device.Info.Name, device.Id, false)
 	if err != nil {
 
@@ -366,6 +773,8 @@ func (s *Simulator) SetDevice(device *dev.Device, update bool) (int, int, error)
 
 	}
 
+	s.trackDeviceCodec(s.Devices[device.Id], -1)
+	s.trackDeviceCodec(device, 1)
 	s.Devices[device.Id] = device
 
 	pathDir, err := util.GetPath()
@@ -380,12 +789,114 @@ func (s *Simulator) SetDevice(device *dev.Device, update bool) (int, int, error)
 
 	s.Print("Device Saved", nil, util.PrintOnlyConsole)
 
+	// Provision new devices, unless the caller provisions them later. The requests can take
+	// a while, so they are made without holding s.mu
+	if !update && provision {
+		s.mu.Unlock()
+		s.provisionDevice(device, pathDir)
+		s.mu.Lock()
+		if s.Devices[device.Id] != device {
+			// Deleted or replaced in the meantime
+			return codes.CodeOK, device.Id, nil
+		}
+	}
+
+	if device.Info.Status.Active {
+
+		s.ActiveDevices[device.Id] = device.Id
+
+		if s.State == util.Running && !device.IsOn() {
+			s.turnONDevice(device.Id)
+		}
+
+	} else {
+		_, ok := s.ActiveDevices[device.Id]
+		if ok {
+			delete(s.ActiveDevices, device.Id)
+		}
+	}
+
+	return codes.CodeOK, device.Id, nil
+}
+
+// ProvisionDeviceIntegrations provisions an existing device to the integrations it enables,
+// for devices added with skipProvisioning. It can be called again after a failure: a device
+// already in ChirpStack is left there, and only the missing ThingsBoard device is created.
+func (s *Simulator) ProvisionDeviceIntegrations(id int) error {
+	s.mu.RLock()
+	device, ok := s.Devices[id]
+	var conf devModels.Configuration
+	if ok {
+		conf = device.Info.Configuration
+	}
+	s.mu.RUnlock()
+	if !ok {
+		return errors.New("device not found")
+	}
+	provisionTB := conf.TBIntegrationEnabled && conf.TBDeviceID == ""
+	if !conf.IntegrationEnabled && !provisionTB {
+		return errors.New("no integration enabled left to provision the device to")
+	}
+
+	pathDir, err := util.GetPath()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// ThingsBoard first, so that provisionMissingDevice passes its access token to ChirpStack
+	tbDevID := ""
+	if provisionTB {
+		devEUI := hex.EncodeToString(device.Info.DevEUI[:])
+		tbDevID, err = s.ProvisionDeviceToThingsBoard(conf.TBIntegrationID, devEUI, device.Info.Name, conf.TBDeviceProfileID, conf.TBCustomerID)
+		if err != nil {
+			s.Print("ThingsBoard provisioning failed: "+err.Error(), nil, util.PrintOnlyConsole)
+			return fmt.Errorf("ThingsBoard provisioning failed: %w", err)
+		}
+		s.setTBDeviceID(device, tbDevID, pathDir)
+		s.Print("Device provisioned to ThingsBoard", nil, util.PrintOnlyConsole)
+	}
+
+	if conf.IntegrationEnabled {
+		client, err := s.chirpStackClient(conf.IntegrationID)
+		if err == nil {
+			result := s.provisionMissingDevice(client, device)
+			switch result.Status {
+			case models.ProvisionStatusProvisioned:
+				s.Print("Device provisioned to ChirpStack", nil, util.PrintOnlyConsole)
+			case models.ProvisionStatusExists:
+				s.Print("Device already in ChirpStack", nil, util.PrintOnlyConsole)
+			default:
+				err = errors.New(result.Error)
+			}
+		}
+		if err != nil {
+			s.Print("ChirpStack provisioning failed: "+err.Error(), nil, util.PrintOnlyConsole)
+			if tbDevID != "" {
+				if rerr := s.DeleteDeviceFromThingsBoard(conf.TBIntegrationID, tbDevID); rerr != nil {
+					s.Print("ThingsBoard rollback failed: "+rerr.Error(), nil, util.PrintOnlyConsole)
+				} else {
+					s.setTBDeviceID(device, "", pathDir)
+					s.Print("Rolled back ThingsBoard device after ChirpStack failure", nil, util.PrintOnlyConsole)
+				}
+			}
+			return fmt.Errorf("ChirpStack provisioning failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// provisionDevice creates the device in the ThingsBoard and ChirpStack integrations it
+// enables, ThingsBoard first. Failures are printed and returned; the ThingsBoard device
+// is rolled back if ChirpStack fails. Called without s.mu held.
+func (s *Simulator) provisionDevice(device *dev.Device, pathDir string) error {
+	var errs []error
+
 	// Dual-provisioning order: TB first so its access token can be baked
 	// into the CS device's Variables map in a single POST.
 	tbProvisioned := false
 	tbToken := ""
 
-	if !update && device.Info.Configuration.TBIntegrationEnabled && device.Info.Configuration.TBDeviceID == "" {
+	if device.Info.Configuration.TBIntegrationEnabled && device.Info.Configuration.TBDeviceID == "" {
 		devEUI := hex.EncodeToString(device.Info.DevEUI[:])
 		tbDevID, err := s.ProvisionDeviceToThingsBoard(
 			device.Info.Configuration.TBIntegrationID,
@@ -396,28 +907,28 @@ func (s *Simulator) SetDevice(device *dev.Device, update bool) (int, int, error)
 		)
 		if err != nil {
 			s.Print("ThingsBoard provisioning failed: "+err.Error(), nil, util.PrintOnlyConsole)
+			errs = append(errs, fmt.Errorf("ThingsBoard provisioning failed: %w", err))
 		} else {
-			device.Info.Configuration.TBDeviceID = tbDevID
-			s.saveComponent(pathDir+"/devices.json", &s.Devices)
+			s.setTBDeviceID(device, tbDevID, pathDir)
 			s.Print("Device provisioned to ThingsBoard", nil, util.PrintOnlyConsole)
 			tbProvisioned = true
 
 			if device.Info.Configuration.IntegrationEnabled {
-				tbClient, ok := s.ThingsBoardClients[device.Info.Configuration.TBIntegrationID]
+				tbClient, ok := s.thingsBoardClient(device.Info.Configuration.TBIntegrationID)
 				if !ok {
 					_ = s.DeleteDeviceFromThingsBoard(device.Info.Configuration.TBIntegrationID, tbDevID)
-					device.Info.Configuration.TBDeviceID = ""
-					s.saveComponent(pathDir+"/devices.json", &s.Devices)
+					s.setTBDeviceID(device, "", pathDir)
 					tbProvisioned = false
 					s.Print("ThingsBoard client lookup failed after create; rolled back TB device", nil, util.PrintOnlyConsole)
+					errs = append(errs, errors.New("ThingsBoard client lookup failed after create"))
 				} else {
 					token, terr := tbClient.GetDeviceCredentials(tbDevID)
 					if terr != nil {
 						_ = s.DeleteDeviceFromThingsBoard(device.Info.Configuration.TBIntegrationID, tbDevID)
-						device.Info.Configuration.TBDeviceID = ""
-						s.saveComponent(pathDir+"/devices.json", &s.Devices)
+						s.setTBDeviceID(device, "", pathDir)
 						tbProvisioned = false
 						s.Print("ThingsBoard access-token fetch failed; rolled back TB device: "+terr.Error(), nil, util.PrintOnlyConsole)
+						errs = append(errs, fmt.Errorf("ThingsBoard access-token fetch failed: %w", terr))
 					} else {
 						tbToken = token
 					}
@@ -426,50 +937,22 @@ func (s *Simulator) SetDevice(device *dev.Device, update bool) (int, int, error)
 		}
 	}
 
-	// Provision device to ChirpStack if integration is enabled (only for new devices)
-	if !update && device.Info.Configuration.IntegrationEnabled {
-		devEUI := hex.EncodeToString(device.Info.DevEUI[:])
-
+	// Provision device to ChirpStack if integration is enabled
+	if device.Info.Configuration.IntegrationEnabled {
 		var variables map[string]string
 		if tbToken != "" {
 			variables = map[string]string{"ThingsBoardAccessToken": tbToken}
 		}
 
-		var err error
-		if device.Info.Configuration.SupportedOtaa {
-			appKey := hex.EncodeToString(device.Info.AppKey[:])
-			err = s.ProvisionDevice(
-				device.Info.Configuration.IntegrationID,
-				devEUI,
-				device.Info.Name,
-				device.Info.Configuration.DeviceProfileID,
-				appKey,
-				variables,
-			)
-		} else {
-			devAddr := hex.EncodeToString(device.Info.DevAddr[:])
-			nwkSKey := hex.EncodeToString(device.Info.NwkSKey[:])
-			appSKey := hex.EncodeToString(device.Info.AppSKey[:])
-			err = s.ProvisionDeviceABP(
-				device.Info.Configuration.IntegrationID,
-				devEUI,
-				device.Info.Name,
-				device.Info.Configuration.DeviceProfileID,
-				devAddr,
-				nwkSKey,
-				appSKey,
-				variables,
-			)
-		}
-
+		err := s.provisionChirpStack(device, variables)
 		if err != nil {
 			s.Print("ChirpStack provisioning failed: "+err.Error(), nil, util.PrintOnlyConsole)
+			errs = append(errs, fmt.Errorf("ChirpStack provisioning failed: %w", err))
 			if tbProvisioned {
 				if rerr := s.DeleteDeviceFromThingsBoard(device.Info.Configuration.TBIntegrationID, device.Info.Configuration.TBDeviceID); rerr != nil {
 					s.Print("ThingsBoard rollback failed: "+rerr.Error(), nil, util.PrintOnlyConsole)
 				} else {
-					device.Info.Configuration.TBDeviceID = ""
-					s.saveComponent(pathDir+"/devices.json", &s.Devices)
+					s.setTBDeviceID(device, "", pathDir)
 					s.Print("Rolled back ThingsBoard device after ChirpStack failure", nil, util.PrintOnlyConsole)
 				}
 			}
@@ -482,25 +965,50 @@ func (s *Simulator) SetDevice(device *dev.Device, update bool) (int, int, error)
 		}
 	}
 
-	if device.Info.Status.Active {
-
-		s.ActiveDevices[device.Id] = device.Id
+	return errors.Join(errs...)
+}
 
-		if s.State == util.Running {
-			s.turnONDevice(device.Id)
-		}
+// setTBDeviceID records the ThingsBoard device of a device and saves the devices
+func (s *Simulator) setTBDeviceID(device *dev.Device, tbDeviceID string, pathDir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	device.Info.Configuration.TBDeviceID = tbDeviceID
+	s.saveComponent(pathDir+"/devices.json", &s.Devices)
+}
 
-	} else {
-		_, ok := s.ActiveDevices[device.Id]
-		if ok {
-			delete(s.ActiveDevices, device.Id)
-		}
+// provisionChirpStack creates the device in its ChirpStack integration, with OTAA keys or
+// an ABP activation depending on SupportedOtaa
+func (s *Simulator) provisionChirpStack(device *dev.Device, variables map[string]string) error {
+	devEUI := hex.EncodeToString(device.Info.DevEUI[:])
+	if device.Info.Configuration.SupportedOtaa {
+		appKey := hex.EncodeToString(device.Info.AppKey[:])
+		return s.ProvisionDevice(
+			device.Info.Configuration.IntegrationID,
+			devEUI,
+			device.Info.Name,
+			device.Info.Configuration.DeviceProfileID,
+			appKey,
+			variables,
+		)
 	}
-
-	return codes.CodeOK, device.Id, nil
+	devAddr := hex.EncodeToString(device.Info.DevAddr[:])
+	nwkSKey := hex.EncodeToString(device.Info.NwkSKey[:])
+	appSKey := hex.EncodeToString(device.Info.AppSKey[:])
+	return s.ProvisionDeviceABP(
+		device.Info.Configuration.IntegrationID,
+		devEUI,
+		device.Info.Name,
+		device.Info.Configuration.DeviceProfileID,
+		devAddr,
+		nwkSKey,
+		appSKey,
+		variables,
+	)
 }
 
 func (s *Simulator) DeleteDevice(Id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	if s.Devices[Id].IsOn() {
 		return false
@@ -526,6 +1034,10 @@ func (s *Simulator) DeleteDevice(Id int) bool {
 		}
 	}
 
+	s.trackDeviceCodec(device, -1)
+	if dev.Codecs != nil {
+		dev.Codecs.RemoveState(device.Info.DevEUI.String())
+	}
 	delete(s.Devices, Id)
 	delete(s.ActiveDevices, Id)
 
@@ -544,136 +1056,243 @@ func (s *Simulator) DeleteDevice(Id int) bool {
 
 // DeleteAllDevices deletes all devices in bulk.
 // Parallelizes ChirpStack deprovisioning and saves JSON once at the end.
+// s.mu is released during the deprovisioning requests, as in setDevice.
 func (s *Simulator) DeleteAllDevices() (int, error) {
-	// Collect devices to delete (must not be running)
-	var toDelete []*dev.Device
-	for _, d := range s.Devices {
-		if d.IsOn() {
-			return 0, fmt.Errorf("device '%s' (ID %d) is still running, stop simulation first", d.Info.Name, d.Id)
-		}
-		toDelete = append(toDelete, d)
+	s.mu.Lock()
+	devices, err := s.beginDeleteAllDevices()
+	s.mu.Unlock()
+	if err != nil {
+		return 0, err
 	}
 
-	if len(toDelete) == 0 {
-		return 0, nil
-	}
+	s.deprovisionDevices(devices)
 
-	total := len(toDelete)
-	s.Print(fmt.Sprintf("Bulk deleting %d devices...", total), nil, util.PrintOnlyConsole)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resetting = false
+	return s.removeDevices(devices.devices), nil
+}
 
-	// Phase 1: Parallel ChirpStack deprovisioning
-	var csDevices []*dev.Device
-	for _, d := range toDelete {
-		if d.Info.Configuration.IntegrationEnabled {
-			csDevices = append(csDevices, d)
-		}
-	}
+// integrationRef is a component to remove from an integration, collected under s.mu so
+// that the requests can be made without it
+type integrationRef struct {
+	integrationID int
+	id            string // DevEUI or gateway ID in ChirpStack, device ID in ThingsBoard
+}
 
-	if len(csDevices) > 0 {
-		s.Print(fmt.Sprintf("Deprovisioning %d devices from ChirpStack (parallel)...", len(csDevices)), nil, util.PrintOnlyConsole)
+// devicesToDelete are the devices of a bulk deletion, with their integrations
+type devicesToDelete struct {
+	devices     []*dev.Device
+	chirpStack  []integrationRef
+	thingsBoard []integrationRef
+}
 
-		workers := 10
-		if len(csDevices) < workers {
-			workers = len(csDevices)
+// beginDeleteAllDevices collects the devices to delete, none of which may be running, and
+// sets s.resetting so that they can't be turned on until they are removed. Called with s.mu held.
+func (s *Simulator) beginDeleteAllDevices() (devicesToDelete, error) {
+	var toDelete devicesToDelete
+	if s.resetting {
+		return toDelete, errors.New("a reset or a bulk deletion is already in progress")
+	}
+	for _, d := range s.Devices {
+		if d.IsOn() {
+			return devicesToDelete{}, fmt.Errorf("device '%s' (ID %d) is still running, stop simulation first", d.Info.Name, d.Id)
 		}
-
-		jobs := make(chan *dev.Device, workers*2)
-		var wg sync.WaitGroup
-		var csErrors int64
-		var csMu sync.Mutex
-
-		for w := 0; w < workers; w++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for d := range jobs {
-					devEUI := hex.EncodeToString(d.Info.DevEUI[:])
-					if err := s.DeleteDeviceFromChirpStack(d.Info.Configuration.IntegrationID, devEUI); err != nil {
-						csMu.Lock()
-						csErrors++
-						csMu.Unlock()
-					}
-				}
-			}()
+		toDelete.devices = append(toDelete.devices, d)
+		if d.Info.Configuration.IntegrationEnabled {
+			toDelete.chirpStack = append(toDelete.chirpStack,
+				integrationRef{d.Info.Configuration.IntegrationID, hex.EncodeToString(d.Info.DevEUI[:])})
 		}
-
-		for _, d := range csDevices {
-			jobs <- d
+		if d.Info.Configuration.TBIntegrationEnabled && d.Info.Configuration.TBDeviceID != "" {
+			toDelete.thingsBoard = append(toDelete.thingsBoard,
+				integrationRef{d.Info.Configuration.TBIntegrationID, d.Info.Configuration.TBDeviceID})
 		}
-		close(jobs)
-		wg.Wait()
+	}
+	s.resetting = true
 
-		if csErrors > 0 {
-			s.Print(fmt.Sprintf("ChirpStack deprovisioning: %d/%d failed", csErrors, len(csDevices)), nil, util.PrintOnlyConsole)
+	if len(toDelete.devices) > 0 {
+		s.Print(fmt.Sprintf("Bulk deleting %d devices...", len(toDelete.devices)), nil, util.PrintOnlyConsole)
+	}
+	return toDelete, nil
+}
+
+// deprovisionDevices removes the devices from ChirpStack, then from ThingsBoard, in
+// parallel. Called without s.mu held.
+func (s *Simulator) deprovisionDevices(toDelete devicesToDelete) {
+	if n := len(toDelete.chirpStack); n > 0 {
+		s.Print(fmt.Sprintf("Deprovisioning %d devices from ChirpStack (parallel)...", n), nil, util.PrintOnlyConsole)
+		if failed := forEachParallel(toDelete.chirpStack, func(ref integrationRef) error {
+			return s.DeleteDeviceFromChirpStack(ref.integrationID, ref.id)
+		}); failed > 0 {
+			s.Print(fmt.Sprintf("ChirpStack deprovisioning: %d/%d failed", failed, n), nil, util.PrintOnlyConsole)
 		} else {
-			s.Print(fmt.Sprintf("ChirpStack deprovisioning: %d/%d succeeded", len(csDevices), len(csDevices)), nil, util.PrintOnlyConsole)
+			s.Print(fmt.Sprintf("ChirpStack deprovisioning: %d/%d succeeded", n, n), nil, util.PrintOnlyConsole)
 		}
 	}
 
-	// Phase 1b: Parallel ThingsBoard deprovisioning
-	var tbDevices []*dev.Device
-	for _, d := range toDelete {
-		if d.Info.Configuration.TBIntegrationEnabled && d.Info.Configuration.TBDeviceID != "" {
-			tbDevices = append(tbDevices, d)
+	if n := len(toDelete.thingsBoard); n > 0 {
+		s.Print(fmt.Sprintf("Deprovisioning %d devices from ThingsBoard (parallel)...", n), nil, util.PrintOnlyConsole)
+		if failed := forEachParallel(toDelete.thingsBoard, func(ref integrationRef) error {
+			return s.DeleteDeviceFromThingsBoard(ref.integrationID, ref.id)
+		}); failed > 0 {
+			s.Print(fmt.Sprintf("ThingsBoard deprovisioning: %d/%d failed", failed, n), nil, util.PrintOnlyConsole)
+		} else {
+			s.Print(fmt.Sprintf("ThingsBoard deprovisioning: %d/%d succeeded", n, n), nil, util.PrintOnlyConsole)
 		}
 	}
+}
 
-	if len(tbDevices) > 0 {
-		s.Print(fmt.Sprintf("Deprovisioning %d devices from ThingsBoard (parallel)...", len(tbDevices)), nil, util.PrintOnlyConsole)
+// forEachParallel calls fn for every reference from up to 10 workers and returns the number of failures
+func forEachParallel(refs []integrationRef, fn func(integrationRef) error) int {
+	workers := 10
+	if len(refs) < workers {
+		workers = len(refs)
+	}
+
+	jobs := make(chan integrationRef, workers*2)
+	var wg sync.WaitGroup
+	var failed int
+	var failedMu sync.Mutex
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ref := range jobs {
+				if err := fn(ref); err != nil {
+					failedMu.Lock()
+					failed++
+					failedMu.Unlock()
+				}
+			}
+		}()
+	}
 
-		workers := 10
-		if len(tbDevices) < workers {
-			workers = len(tbDevices)
+	for _, ref := range refs {
+		jobs <- ref
+	}
+	close(jobs)
+	wg.Wait()
+
+	return failed
+}
+
+// removeDevices removes the devices and their codec states from memory, except the ones
+// deleted or replaced meanwhile, and saves the devices once. Called with s.mu held.
+func (s *Simulator) removeDevices(devices []*dev.Device) int {
+	if len(devices) == 0 {
+		return 0
+	}
+
+	removed := 0
+	for _, d := range devices {
+		if s.Devices[d.Id] != d {
+			continue
+		}
+		if dev.Codecs != nil {
+			dev.Codecs.RemoveState(d.Info.DevEUI.String())
 		}
+		s.trackDeviceCodec(d, -1)
+		delete(s.Devices, d.Id)
+		delete(s.ActiveDevices, d.Id)
+		removed++
+	}
 
-		jobs := make(chan *dev.Device, workers*2)
-		var wg sync.WaitGroup
-		var tbErrors int64
-		var tbMu sync.Mutex
+	pathDir, err := util.GetPath()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.saveComponent(pathDir+"/devices.json", &s.Devices)
 
-		for w := 0; w < workers; w++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for d := range jobs {
-					if err := s.DeleteDeviceFromThingsBoard(d.Info.Configuration.TBIntegrationID, d.Info.Configuration.TBDeviceID); err != nil {
-						tbMu.Lock()
-						tbErrors++
-						tbMu.Unlock()
-					}
-				}
-			}()
+	s.Print(fmt.Sprintf("Bulk deletion complete: %d devices removed", removed), nil, util.PrintOnlyConsole)
+	return removed
+}
+
+// Reset wipes devices, gateways, integrations and user-created templates, restoring
+// the default templates and codecs. Integration-enabled components are de-provisioned first,
+// with s.mu released during the requests as in setDevice.
+func (s *Simulator) Reset() (models.ResetSummary, error) {
+	var summary models.ResetSummary
+
+	s.mu.Lock()
+	if s.State == util.Running {
+		s.mu.Unlock()
+		return summary, errors.New("simulator is running, stop it before resetting")
+	}
+	devices, err := s.beginDeleteAllDevices()
+	if err != nil {
+		s.mu.Unlock()
+		return summary, err
+	}
+	var gateways []integrationRef
+	for _, g := range s.Gateways {
+		if !g.Info.TypeGateway && g.Info.IntegrationEnabled {
+			gateways = append(gateways, integrationRef{g.Info.IntegrationID, hex.EncodeToString(g.Info.MACAddress[:])})
 		}
+	}
+	s.mu.Unlock()
 
-		for _, d := range tbDevices {
-			jobs <- d
+	s.deprovisionDevices(devices)
+	for _, ref := range gateways {
+		if err := s.DeleteGatewayFromChirpStack(ref.integrationID, ref.id); err != nil {
+			s.Print("ChirpStack gateway deletion failed: "+err.Error(), nil, util.PrintOnlyConsole)
 		}
-		close(jobs)
-		wg.Wait()
+	}
 
-		if tbErrors > 0 {
-			s.Print(fmt.Sprintf("ThingsBoard deprovisioning: %d/%d failed", tbErrors, len(tbDevices)), nil, util.PrintOnlyConsole)
-		} else {
-			s.Print(fmt.Sprintf("ThingsBoard deprovisioning: %d/%d succeeded", len(tbDevices), len(tbDevices)), nil, util.PrintOnlyConsole)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resetting = false
+	summary.Devices = s.removeDevices(devices.devices)
+	summary.Gateways = len(s.Gateways)
+	s.integrationsMu.RLock()
+	summary.Integrations = len(s.Integrations)
+	s.integrationsMu.RUnlock()
+	defaults := template.GetDefaultTemplates(nil)
+	for id := range s.Templates {
+		isDefault := false
+		for _, t := range defaults {
+			if t.ID == id {
+				isDefault = true
+				break
+			}
+		}
+		if !isDefault {
+			summary.Templates++
 		}
 	}
 
-	// Phase 2: Remove all devices from memory
 	s.Devices = make(map[int]*dev.Device)
 	s.ActiveDevices = make(map[int]int)
+	s.Gateways = make(map[int]*gw.Gateway)
+	s.ActiveGateways = make(map[int]int)
+	s.integrationsMu.Lock()
+	s.Integrations = make(map[int]*integration.Integration)
+	s.IntegrationClients = make(map[int]*chirpstack.Client)
+	s.ThingsBoardClients = make(map[int]*thingsboard.Client)
+	s.integrationsMu.Unlock()
+	s.Templates = make(map[int]*template.DeviceTemplate)
+	s.codecUsage = nil
+	s.NextIDDev = 0
+	s.NextIDGw = 0
+	s.NextIDIntegration = 0
+	s.NextIDTemplate = 0
+	s.NextIDCodec = 0
 
-	// Phase 3: Single JSON persistence
-	pathDir, err := util.GetPath()
-	if err != nil {
-		log.Fatal(err)
+	if dev.Codecs != nil {
+		dev.Codecs.Reset()
+		s.saveCodecLibrary()
 	}
-	s.saveComponent(pathDir+"/devices.json", &s.Devices)
+	s.loadDefaultTemplates()
 
-	s.Print(fmt.Sprintf("Bulk deletion complete: %d devices removed", total), nil, util.PrintOnlyConsole)
-	return total, nil
+	s.saveStatus()
+	s.saveHistory() // else the history of the deleted devices would be restored on the new ones with the same IDs
+	s.Print("Simulator reset", nil, util.PrintBoth)
+	return summary, nil
 }
 
 func (s *Simulator) ToggleStateDevice(Id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	if s.Devices[Id].State == util.Stopped {
 		s.turnONDevice(Id)
@@ -683,14 +1302,99 @@ func (s *Simulator) ToggleStateDevice(Id int) {
 
 }
 
+// StartDevice turns a device on, doing nothing if it is already running.
+// Returns whether the device is running.
+func (s *Simulator) StartDevice(id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return false, errors.New("device not found")
+	}
+	if s.State != util.Running {
+		return d.IsOn(), errors.New("simulator is not running")
+	}
+	if !d.IsOn() {
+		s.turnONDevice(id)
+		s.ActiveDevices[id] = id
+	}
+	return d.IsOn(), nil
+}
+
+// SetDevicesActive activates or deactivates the devices, turning them on or off if the
+// simulation is running, and saves them once. Returns the outcome for each device.
+func (s *Simulator) SetDevicesActive(ids []int, active bool) []models.SetActiveResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]models.SetActiveResult, 0, len(ids))
+	changed := false
+	for _, id := range ids {
+		d, ok := s.Devices[id]
+		if !ok {
+			results = append(results, models.SetActiveResult{ID: id, Error: "device not found"})
+			continue
+		}
+
+		if d.Info.Status.Active != active {
+			d.Info.Status.Active = active
+			changed = true
+		}
+		if active {
+			s.ActiveDevices[id] = id
+			if s.State == util.Running && !d.IsOn() {
+				s.turnONDevice(id)
+			}
+		} else {
+			if d.IsOn() {
+				s.turnOFFDevice(id)
+			}
+			delete(s.ActiveDevices, id)
+		}
+
+		results = append(results, models.SetActiveResult{ID: id, Active: d.Info.Status.Active, Running: d.IsOn()})
+	}
+
+	if changed {
+		pathDir, err := util.GetPath()
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.saveComponent(pathDir+"/devices.json", &s.Devices)
+	}
+
+	return results
+}
+
+// StopDevice turns a device off, doing nothing if it is already stopped.
+// Returns whether the device is running.
+func (s *Simulator) StopDevice(id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return false, errors.New("device not found")
+	}
+	if d.IsOn() {
+		s.turnOFFDevice(id)
+	}
+	return d.IsOn(), nil
+}
+
 func (s *Simulator) SendMACCommand(cid lorawan.CID, data socket.MacCommand) {
+	// The device loop queues the command, the lock isn't held while it waits for it
+	d, ok := s.device(data.Id)
+	if !ok {
+		s.Console.PrintSocket(socket.EventResponseCommand, "Unable to send command: device not found")
+		return
+	}
 
-	if !s.Devices[data.Id].IsOn() {
-		s.Console.PrintSocket(socket.EventResponseCommand, s.Devices[data.Id].Info.Name+" is turned off")
+	if !d.IsOn() {
+		s.Console.PrintSocket(socket.EventResponseCommand, d.Info.Name+" is turned off")
 		return
 	}
 
-	err := s.Devices[data.Id].SendMACCommand(cid, data.Periodicity)
+	err := d.SendMACCommand(cid, data.Periodicity)
 	if err != nil {
 		s.Console.PrintSocket(socket.EventResponseCommand, "Unable to send command: "+err.Error())
 	} else {
@@ -700,6 +1404,8 @@ func (s *Simulator) SendMACCommand(cid lorawan.CID, data socket.MacCommand) {
 }
 
 func (s *Simulator) ChangePayload(pl socket.NewPayload) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
 	devEUIstring := hex.EncodeToString(s.Devices[pl.Id].Info.DevEUI[:])
 
@@ -713,69 +1419,445 @@ func (s *Simulator) ChangePayload(pl socket.NewPayload) (string, bool) {
 		MType = lorawan.ConfirmedDataUp
 	}
 
-	Payload := &lorawan.DataPayload{
-		Bytes: []byte(pl.Payload),
+	Payload := &lorawan.DataPayload{
+		Bytes: []byte(pl.Payload),
+	}
+
+	s.Devices[pl.Id].ChangePayload(MType, Payload)
+	s.Devices[pl.Id].SetPayloadTemplate(pl.Payload)
+
+	s.Console.PrintSocket(socket.EventResponseCommand, s.Devices[pl.Id].Info.Name+": Payload changed")
+
+	return devEUIstring, true
+}
+
+// SendUplink queues an uplink on a running device, within the uplink limits
+func (s *Simulator) SendUplink(pl socket.NewPayload) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.Devices[pl.Id].IsOn() {
+		s.Console.PrintSocket(socket.EventResponseCommand, s.Devices[pl.Id].Info.Name+" is turned off")
+		return errors.New("device is turned off")
+	}
+
+	MType := lorawan.UnconfirmedDataUp
+	if pl.MType == "ConfirmedDataUp" {
+		MType = lorawan.ConfirmedDataUp
+	}
+
+	if err := s.Devices[pl.Id].NewUplink(MType, pl.Payload); err != nil {
+		s.Console.PrintSocket(socket.EventResponseCommand, "Uplink not queued: "+err.Error())
+		return err
+	}
+
+	s.Console.PrintSocket(socket.EventResponseCommand, "Uplink queued")
+	return nil
+}
+
+func (s *Simulator) ChangeLocation(l socket.NewLocation) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.Devices[l.Id].IsOn() {
+		return false
+	}
+
+	s.Devices[l.Id].ChangeLocation(l.Latitude, l.Longitude, l.Altitude)
+
+	info := mfw.InfoDevice{
+		DevEUI:   s.Devices[l.Id].Info.DevEUI,
+		DevAddr:  s.Devices[l.Id].Info.DevAddr,
+		Location: s.Devices[l.Id].Info.Location,
+		Range:    s.Devices[l.Id].Info.Configuration.Range,
+	}
+
+	s.Forwarder.UpdateDevice(info)
+
+	return true
+}
+
+func (s *Simulator) WatchDevice(id int) []socket.ConsoleLog {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	*s.Console.WatchedID = id
+	if d, ok := s.Devices[id]; ok {
+		return d.GetLogBuffer()
+	}
+	return nil
+}
+
+func (s *Simulator) UnwatchDevice() {
+	*s.Console.WatchedID = -1
+}
+
+// SetStreamFilter restricts the socket stream to the requested event types.
+// An empty list restores the default behavior of forwarding every event.
+func (s *Simulator) SetStreamFilter(req socket.StreamRequest) {
+	s.Console.SetEventTypes(req.Types)
+}
+
+// RekeyDevice renews the session of a stopped device. ABP devices get new random
+// session keys, kept only if ChirpStack accepts them when the integration is enabled,
+// while OTAA devices drop their session so they rejoin on next start.
+// s.mu is released during the ChirpStack request, as in setDevice.
+func (s *Simulator) RekeyDevice(id int) error {
+	s.mu.Lock()
+	d, ok := s.Devices[id]
+	if !ok {
+		s.mu.Unlock()
+		return errors.New("device not found")
+	}
+	if d.IsOn() {
+		s.mu.Unlock()
+		return errors.New("device is running, stop it before rekeying")
+	}
+
+	pathDir, err := util.GetPath()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if d.Info.Configuration.SupportedOtaa {
+		d.Info.Status.Joined = false
+		d.Info.Status.Mode = util.Activation
+		d.Info.DevAddr = lorawan.DevAddr{}
+		d.Info.NwkSKey = [16]byte{}
+		d.Info.AppSKey = [16]byte{}
+		s.Print(fmt.Sprintf("Device %s session cleared, it will rejoin on next start", d.Info.Name), nil, util.PrintOnlyConsole)
+		s.saveComponent(pathDir+"/devices.json", &s.Devices)
+		s.mu.Unlock()
+		return nil
+	}
+
+	nwkSKey, err := generateRandomKey()
+	if err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("failed to generate NwkSKey: %w", err)
+	}
+	appSKey, err := generateRandomKey()
+	if err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("failed to generate AppSKey: %w", err)
+	}
+	devAddr := d.Info.DevAddr
+	integrationEnabled := d.Info.Configuration.IntegrationEnabled
+	integrationID := d.Info.Configuration.IntegrationID
+	devEUI := hex.EncodeToString(d.Info.DevEUI[:])
+	s.mu.Unlock()
+
+	// The new keys are kept only once the network server has them, otherwise it would
+	// reject every uplink of the device
+	if integrationEnabled {
+		err := s.ActivateDeviceABPInChirpStack(integrationID, devEUI, hex.EncodeToString(devAddr[:]),
+			hex.EncodeToString(nwkSKey[:]), hex.EncodeToString(appSKey[:]))
+		if err != nil {
+			s.Print("ChirpStack re-activation failed: "+err.Error(), nil, util.PrintOnlyConsole)
+			return fmt.Errorf("ChirpStack re-activation failed, session keys unchanged: %w", err)
+		}
+		s.Print("Device re-activated in ChirpStack (ABP)", nil, util.PrintOnlyConsole)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Devices[id] != d || d.IsOn() || d.Info.DevAddr != devAddr {
+		// Deleted, started or readdressed in the meantime
+		return errors.New("device changed while rekeying, rekey it again")
+	}
+	d.Info.NwkSKey = nwkSKey
+	d.Info.AppSKey = appSKey
+	d.Info.Status.FCntDown = 0
+	d.Info.Status.DataUplink.FCnt = 0
+	s.Print(fmt.Sprintf("Device %s session keys regenerated", d.Info.Name), nil, util.PrintOnlyConsole)
+	s.saveComponent(pathDir+"/devices.json", &s.Devices)
+
+	return nil
+}
+
+// GetDownlinkAcks returns the confirmed downlink ACK ledger of a device
+func (s *Simulator) GetDownlinkAcks(id int) ([]devModels.DownlinkAck, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return nil, errors.New("device not found")
+	}
+	return d.GetDownlinkAcks(), nil
+}
+
+// GetDeviceCounters returns the uplinks sent and downlinks received by a device, with its frame counters
+func (s *Simulator) GetDeviceCounters(id int) (devModels.Counters, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return devModels.Counters{}, errors.New("device not found")
+	}
+	return d.GetCounters(), nil
+}
+
+// GetDeviceCodecErrors returns the recent codec execution errors of a device
+func (s *Simulator) GetDeviceCodecErrors(id int) ([]codec.ExecutionError, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return nil, errors.New("device not found")
+	}
+	if dev.Codecs == nil {
+		return []codec.ExecutionError{}, nil
+	}
+	return dev.Codecs.GetErrors(d.Info.DevEUI.String()), nil
+}
+
+// GetDeviceCodecState returns a copy of the codec state of a device, empty if its codec never ran
+func (s *Simulator) GetDeviceCodecState(id int) (*codec.State, error) {
+	d, ok := s.device(id)
+	if !ok {
+		return nil, errors.New("device not found")
+	}
+	devEUI := d.Info.DevEUI.String()
+	if dev.Codecs != nil {
+		if state := dev.Codecs.GetState(devEUI); state != nil {
+			return state, nil
+		}
+	}
+	return codec.NewState(devEUI), nil
+}
+
+// ResetDeviceCodecState drops the variables the codec of a device keeps between executions
+func (s *Simulator) ResetDeviceCodecState(id int) error {
+	d, ok := s.device(id)
+	if !ok {
+		return errors.New("device not found")
+	}
+	if dev.Codecs != nil {
+		dev.Codecs.ResetState(d.Info.DevEUI.String())
+	}
+	s.Print(fmt.Sprintf("Codec state of device %d reset", id), nil, util.PrintOnlyConsole)
+	return nil
+}
+
+// GetOrphanCodecStates returns the DevEUIs having a codec state but no device, such as
+// the devices deleted before their state was dropped along with them
+func (s *Simulator) GetOrphanCodecStates() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.orphanCodecStates()
+}
+
+// PruneCodecStates drops the codec states having no device and returns their DevEUIs
+func (s *Simulator) PruneCodecStates() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	orphans := s.orphanCodecStates()
+	for _, devEUI := range orphans {
+		dev.Codecs.RemoveState(devEUI)
+	}
+	if len(orphans) > 0 {
+		s.Print(fmt.Sprintf("%d orphaned codec states pruned", len(orphans)), nil, util.PrintOnlyConsole)
+	}
+	return orphans
+}
+
+// orphanCodecStates lists the DevEUIs having a codec state but no device, with s.mu held
+func (s *Simulator) orphanCodecStates() []string {
+	orphans := []string{}
+	if dev.Codecs == nil {
+		return orphans
+	}
+	devices := make(map[string]struct{}, len(s.Devices))
+	for _, d := range s.Devices {
+		devices[d.Info.DevEUI.String()] = struct{}{}
+	}
+	for _, devEUI := range dev.Codecs.StateDevEUIs() {
+		if _, ok := devices[devEUI]; !ok {
+			orphans = append(orphans, devEUI)
+		}
+	}
+	return orphans
+}
+
+// SetRXWindows overrides the RX1/RX2 timing and the RX2 data rate/frequency of a device
+func (s *Simulator) SetRXWindows(id int, update devModels.RXWindowsUpdate) ([]devFeatures.Window, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return nil, errors.New("device not found")
+	}
+
+	windows, err := d.SetRXWindows(update)
+	if err != nil {
+		return nil, err
+	}
+
+	pathDir, err := util.GetPath()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.saveComponent(pathDir+"/devices.json", &s.Devices)
+
+	return windows, nil
+}
+
+// GetRetransmission returns the confirmed-uplink retry settings of a device
+func (s *Simulator) GetRetransmission(id int) (devModels.Retransmission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return devModels.Retransmission{}, errors.New("device not found")
+	}
+	return d.GetRetransmission(), nil
+}
+
+// SetRetransmission changes the confirmed-uplink retry settings of a device, running or not
+func (s *Simulator) SetRetransmission(id int, update devModels.RetransmissionUpdate) (devModels.Retransmission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return devModels.Retransmission{}, errors.New("device not found")
 	}
 
-	s.Devices[pl.Id].ChangePayload(MType, Payload)
+	settings, err := d.SetRetransmission(update)
+	if err != nil {
+		return devModels.Retransmission{}, err
+	}
 
-	s.Console.PrintSocket(socket.EventResponseCommand, s.Devices[pl.Id].Info.Name+": Payload changed")
+	pathDir, err := util.GetPath()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.saveComponent(pathDir+"/devices.json", &s.Devices)
 
-	return devEUIstring, true
+	return settings, nil
 }
 
-func (s *Simulator) SendUplink(pl socket.NewPayload) {
+// SetFrameCounters sets the current frame counters of a stopped device
+func (s *Simulator) SetFrameCounters(id int, update devModels.FrameCountersUpdate) (devModels.FrameCounters, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return devModels.FrameCounters{}, errors.New("device not found")
+	}
 
-	if !s.Devices[pl.Id].IsOn() {
-		s.Console.PrintSocket(socket.EventResponseCommand, s.Devices[pl.Id].Info.Name+" is turned off")
-		return
+	counters, err := d.SetFrameCounters(update)
+	if err != nil {
+		return devModels.FrameCounters{}, err
 	}
 
-	MType := lorawan.UnconfirmedDataUp
-	if pl.MType == "ConfirmedDataUp" {
-		MType = lorawan.ConfirmedDataUp
+	pathDir, err := util.GetPath()
+	if err != nil {
+		log.Fatal(err)
 	}
+	s.saveComponent(pathDir+"/devices.json", &s.Devices)
 
-	s.Devices[pl.Id].NewUplink(MType, pl.Payload)
+	return counters, nil
+}
 
-	s.Console.PrintSocket(socket.EventResponseCommand, "Uplink queued")
+// GetJoinStatus returns the OTAA join state and attempts of a device
+func (s *Simulator) GetJoinStatus(id int) (devModels.JoinStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return devModels.JoinStatus{}, errors.New("device not found")
+	}
+	return d.GetJoinStatus(), nil
 }
 
-func (s *Simulator) ChangeLocation(l socket.NewLocation) bool {
+// GetChannels returns the channel plan of a device
+func (s *Simulator) GetChannels(id int) ([]devModels.ChannelInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return nil, errors.New("device not found")
+	}
+	return d.GetChannels(), nil
+}
 
-	if !s.Devices[l.Id].IsOn() {
-		return false
+// GetPayloadLimit returns the maximum payload size of a device at its current data rate
+func (s *Simulator) GetPayloadLimit(id int) (devModels.PayloadLimit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return devModels.PayloadLimit{}, errors.New("device not found")
 	}
+	return d.GetPayloadLimit(), nil
+}
 
-	s.Devices[l.Id].ChangeLocation(l.Latitude, l.Longitude, l.Altitude)
+// SetChannels enables or disables uplink channels of a running device. The change
+// lasts until the device is turned off, so nothing is saved.
+func (s *Simulator) SetChannels(id int, update devModels.ChannelsUpdate) ([]devModels.ChannelInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	if !ok {
+		return nil, errors.New("device not found")
+	}
+	return d.SetChannels(update)
+}
 
-	info := mfw.InfoDevice{
-		DevEUI:   s.Devices[l.Id].Info.DevEUI,
-		DevAddr:  s.Devices[l.Id].Info.DevAddr,
-		Location: s.Devices[l.Id].Info.Location,
-		Range:    s.Devices[l.Id].Info.Configuration.Range,
+// ReplayDevice plays recorded uplinks through a running device. The replay lasts
+// until it ends, is stopped or the device is turned off, so nothing is saved.
+func (s *Simulator) ReplayDevice(id int, replay devModels.Replay) error {
+	d, ok := s.device(id)
+	if !ok {
+		return errors.New("device not found")
 	}
+	return d.Replay(replay)
+}
 
-	s.Forwarder.UpdateDevice(info)
+// StopReplayDevice stops the replay of a device, if any
+func (s *Simulator) StopReplayDevice(id int) error {
+	d, ok := s.device(id)
+	if !ok {
+		return errors.New("device not found")
+	}
+	d.StopReplay()
+	return nil
+}
 
-	return true
+// InjectMACCommand executes a downlink MAC command on a running device as if the
+// network server had sent it, and returns the answers queued for the next uplink
+func (s *Simulator) InjectMACCommand(id int, injection devModels.MACInjection) ([]lorawan.Payload, error) {
+	d, ok := s.device(id)
+	if !ok {
+		return nil, errors.New("device not found")
+	}
+	return d.InjectMACCommand(injection)
 }
 
-func (s *Simulator) WatchDevice(id int) []socket.ConsoleLog {
-	*s.Console.WatchedID = id
-	if d, ok := s.Devices[id]; ok {
-		return d.GetLogBuffer()
+// QueueMACCommands queues a batch of MAC commands for the next uplink of a running device,
+// all of them or none, and returns the MAC commands waiting for the next uplink
+func (s *Simulator) QueueMACCommands(id int, batch []devModels.UplinkMACCommand) ([]lorawan.Payload, error) {
+	d, ok := s.device(id)
+	if !ok {
+		return nil, errors.New("device not found")
 	}
-	return nil
+	return d.QueueMACCommands(batch)
 }
 
-func (s *Simulator) UnwatchDevice() {
-	*s.Console.WatchedID = -1
+// TestDownlinkCodec runs a downlink payload through the codec of a device, against a copy
+// of its codec state, and returns the changes it would make without applying them
+func (s *Simulator) TestDownlinkCodec(id int, test devModels.DownlinkCodecTest) (devModels.DownlinkCodecTestResult, error) {
+	d, ok := s.device(id)
+	if !ok {
+		return devModels.DownlinkCodecTestResult{}, errors.New("device not found")
+	}
+	return d.TestDownlinkCodec(test)
 }
 
 func (s *Simulator) ToggleStateGateway(Id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if s.Gateways[Id].State == util.Stopped {
+	if !s.Gateways[Id].IsOn() {
 		s.turnONGateway(Id)
 	} else {
 		s.turnOFFGateway(Id)
@@ -783,6 +1865,40 @@ func (s *Simulator) ToggleStateGateway(Id int) {
 
 }
 
+// StartGateway turns a gateway on, doing nothing if it is already running.
+// Returns whether the gateway is running.
+func (s *Simulator) StartGateway(id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.Gateways[id]
+	if !ok {
+		return false, errors.New("gateway not found")
+	}
+	if s.State != util.Running {
+		return g.IsOn(), errors.New("simulator is not running")
+	}
+	if !g.IsOn() {
+		s.turnONGateway(id)
+		s.ActiveGateways[id] = id
+	}
+	return g.IsOn(), nil
+}
+
+// StopGateway turns a gateway off, doing nothing if it is already stopped.
+// Returns whether the gateway is running.
+func (s *Simulator) StopGateway(id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.Gateways[id]
+	if !ok {
+		return false, errors.New("gateway not found")
+	}
+	if g.IsOn() {
+		s.turnOFFGateway(id)
+	}
+	return g.IsOn(), nil
+}
+
 // GetCodecs returns all available codec metadata
 func (s *Simulator) GetCodecs() []codec.CodecMetadata {
 	if dev.Codecs == nil {
@@ -791,6 +1907,24 @@ func (s *Simulator) GetCodecs() []codec.CodecMetadata {
 	return dev.Codecs.ListCodecs()
 }
 
+// GetCodecsFull returns up to limit codecs with their scripts, starting at offset (by ID order),
+// along with the total number of codecs
+func (s *Simulator) GetCodecsFull(offset, limit int) ([]*codec.Codec, int) {
+	if dev.Codecs == nil {
+		return []*codec.Codec{}, 0
+	}
+	codecs := dev.Codecs.ListFullCodecs()
+	total := len(codecs)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return codecs[offset:end], total
+}
+
 // GetCodec returns a specific codec by ID
 func (s *Simulator) GetCodec(id int) (*codec.Codec, error) {
 	if dev.Codecs == nil {
@@ -802,6 +1936,21 @@ func (s *Simulator) GetCodec(id int) (*codec.Codec, error) {
 // GetDevicesUsingCodec returns a list of device EUIs using the specified codec
 // Also counts templates that use this codec
 func (s *Simulator) GetDevicesUsingCodec(codecID int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.devicesUsingCodec(codecID)
+}
+
+// GetCodecMetrics returns the execution statistics of the codec executor
+func (s *Simulator) GetCodecMetrics() codec.ExecutorMetrics {
+	if dev.Codecs == nil {
+		return codec.ExecutorMetrics{}
+	}
+	return dev.Codecs.GetMetrics()
+}
+
+// devicesUsingCodec is GetDevicesUsingCodec with s.mu already held
+func (s *Simulator) devicesUsingCodec(codecID int) []string {
 	devicesUsingCodec := []string{}
 
 	// Check devices
@@ -821,6 +1970,28 @@ func (s *Simulator) GetDevicesUsingCodec(codecID int) []string {
 	return devicesUsingCodec
 }
 
+// GetIntegrationUsage returns the devices (by DevEUI) referencing the integration
+// and the templates (as "template:<id>") that have it enabled
+func (s *Simulator) GetIntegrationUsage(integrationID int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.integrationUsage(integrationID)
+}
+
+// integrationUsage is GetIntegrationUsage with s.mu already held
+func (s *Simulator) integrationUsage(integrationID int) []string {
+	usage := s.devicesUsingIntegration(integrationID)
+
+	// Check templates
+	for _, tmpl := range s.Templates {
+		if tmpl.UsesIntegration(integrationID) {
+			usage = append(usage, fmt.Sprintf("template:%d", tmpl.ID))
+		}
+	}
+
+	return usage
+}
+
 // AddCodec adds a custom codec
 func (s *Simulator) AddCodec(c *codec.Codec) error {
 	if dev.Codecs == nil {
@@ -836,6 +2007,51 @@ func (s *Simulator) AddCodec(c *codec.Codec) error {
 	return nil
 }
 
+// ImportCodecs adds a batch of exported codecs, skipping those whose name is already used
+func (s *Simulator) ImportCodecs(items []codec.CodecExport) ([]codec.CodecImportResult, error) {
+	if dev.Codecs == nil {
+		return nil, errors.New("codec registry not initialized")
+	}
+
+	results := make([]codec.CodecImportResult, 0, len(items))
+	added := 0
+	for _, item := range items {
+		result := codec.CodecImportResult{Name: item.Name}
+
+		if item.Name != "" && dev.Codecs.GetCodecIDByName(item.Name) != 0 {
+			result.Status = "skipped"
+			result.Error = "a codec with this name already exists"
+			results = append(results, result)
+			continue
+		}
+
+		newCodec := codec.NewCodec(item.Name, item.Script)
+		if err := newCodec.Validate(); err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		if err := dev.Codecs.AddCodec(newCodec); err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		result.ID = newCodec.ID
+		result.Status = "added"
+		results = append(results, result)
+		added++
+	}
+
+	if added > 0 {
+		s.saveCodecLibrary()
+	}
+	s.Print(fmt.Sprintf("Imported %d of %d codecs", added, len(items)), nil, util.PrintOnlyConsole)
+	return results, nil
+}
+
 // UpdateCodec updates an existing codec
 func (s *Simulator) UpdateCodec(id int, name string, script string) error {
 	if dev.Codecs == nil {
@@ -853,33 +2069,16 @@ func (s *Simulator) UpdateCodec(id int, name string, script string) error {
 
 // DeleteCodec removes a codec by ID
 func (s *Simulator) DeleteCodec(id int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	if dev.Codecs == nil {
 		return errors.New("codec registry not initialized")
 	}
 
 	// Check if any devices or templates are using this codec
-	usersOfCodec := s.GetDevicesUsingCodec(id)
+	usersOfCodec := s.devicesUsingCodec(id)
 	if len(usersOfCodec) > 0 {
-		// Count devices and templates separately for better error message
-		deviceCount := 0
-		templateCount := 0
-		for _, user := range usersOfCodec {
-			if len(user) > 9 && user[:9] == "template:" {
-				templateCount++
-			} else {
-				deviceCount++
-			}
-		}
-
-		var parts []string
-		if deviceCount > 0 {
-			parts = append(parts, fmt.Sprintf("%d device(s)", deviceCount))
-		}
-		if templateCount > 0 {
-			parts = append(parts, fmt.Sprintf("%d template(s)", templateCount))
-		}
-
-		return fmt.Errorf("cannot delete codec: used by %s", strings.Join(parts, " and "))
+		return fmt.Errorf("cannot delete codec: used by %s", describeUsers(usersOfCodec))
 	}
 
 	if err := dev.Codecs.RemoveCodec(id); err != nil {
@@ -891,10 +2090,33 @@ func (s *Simulator) DeleteCodec(id int) error {
 	return nil
 }
 
+// describeUsers counts devices and templates separately in a list of users
+// (DevEUIs and "template:<id>"), e.g. "2 device(s) and 1 template(s)"
+func describeUsers(users []string) string {
+	deviceCount := 0
+	templateCount := 0
+	for _, user := range users {
+		if strings.HasPrefix(user, "template:") {
+			templateCount++
+		} else {
+			deviceCount++
+		}
+	}
+
+	var parts []string
+	if deviceCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d device(s)", deviceCount))
+	}
+	if templateCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d template(s)", templateCount))
+	}
+	return strings.Join(parts, " and ")
+}
+
 // saveCodecLibrary saves the codec library to disk
 func (s *Simulator) saveCodecLibrary() {
 	pathDir, err := util.GetPath()
-	if err == nil && dev.Codecs != nil {
+	if err == nil && dev.Codecs != nil && !util.IsInMemory() {
 		codecLibPath := pathDir + "/codecs.json"
 		if err := dev.Codecs.Save(codecLibPath); err != nil {
 			shared.DebugPrint(fmt.Sprintf("Warning: failed to save codec library: %v", err))
@@ -906,6 +2128,8 @@ func (s *Simulator) saveCodecLibrary() {
 
 // GetIntegrations returns all integrations (without API keys for security)
 func (s *Simulator) GetIntegrations() []*integration.Integration {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return []*integration.Integration{}
 	}
@@ -918,6 +2142,8 @@ func (s *Simulator) GetIntegrations() []*integration.Integration {
 
 // GetIntegration returns a specific integration by ID
 func (s *Simulator) GetIntegration(id int) (*integration.Integration, error) {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return nil, integration.ErrIntegrationNotFound
 	}
@@ -925,11 +2151,26 @@ func (s *Simulator) GetIntegration(id int) (*integration.Integration, error) {
 	if !exists {
 		return nil, integration.ErrIntegrationNotFound
 	}
-	return integ.Clone(), nil
+	return integ.PublicCopy(), nil
+}
+
+// RevealIntegrationKey returns the stored API key of an integration
+func (s *Simulator) RevealIntegrationKey(id int) (string, error) {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
+	integ, exists := s.Integrations[id]
+	if !exists {
+		return "", integration.ErrIntegrationNotFound
+	}
+	return integ.APIKey, nil
 }
 
 // AddIntegration adds a new integration
 func (s *Simulator) AddIntegration(name string, intType integration.IntegrationType, url, apiKey, tenantID, appID string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.integrationsMu.Lock()
+	defer s.integrationsMu.Unlock()
 	if s.Integrations == nil {
 		s.Integrations = make(map[int]*integration.Integration)
 	}
@@ -961,8 +2202,12 @@ func (s *Simulator) AddIntegration(name string, intType integration.IntegrationT
 	return integ.ID, nil
 }
 
-// UpdateIntegration updates an existing integration
+// UpdateIntegration updates an existing integration. An empty or redacted API key keeps the stored one.
 func (s *Simulator) UpdateIntegration(id int, name, url, apiKey, tenantID, appID string, enabled bool) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.integrationsMu.Lock()
+	defer s.integrationsMu.Unlock()
 	if s.Integrations == nil {
 		return integration.ErrIntegrationNotFound
 	}
@@ -974,7 +2219,9 @@ func (s *Simulator) UpdateIntegration(id int, name, url, apiKey, tenantID, appID
 
 	existing.Name = name
 	existing.URL = url
-	existing.APIKey = apiKey
+	if !integration.KeepsAPIKey(apiKey) {
+		existing.APIKey = apiKey
+	}
 	existing.TenantID = tenantID
 	existing.ApplicationID = appID
 	existing.Enabled = enabled
@@ -999,6 +2246,10 @@ func (s *Simulator) UpdateIntegration(id int, name, url, apiKey, tenantID, appID
 
 // DeleteIntegration removes an integration by ID
 func (s *Simulator) DeleteIntegration(id int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.integrationsMu.Lock()
+	defer s.integrationsMu.Unlock()
 	if s.Integrations == nil {
 		return integration.ErrIntegrationNotFound
 	}
@@ -1007,9 +2258,10 @@ func (s *Simulator) DeleteIntegration(id int) error {
 		return integration.ErrIntegrationNotFound
 	}
 
-	devicesUsingIntegration := s.GetDevicesUsingIntegration(id)
-	if len(devicesUsingIntegration) > 0 {
-		return fmt.Errorf("cannot delete integration: used by %d device(s)", len(devicesUsingIntegration))
+	// Check if any devices or templates are using this integration
+	usersOfIntegration := s.integrationUsage(id)
+	if len(usersOfIntegration) > 0 {
+		return fmt.Errorf("cannot delete integration: used by %s", describeUsers(usersOfIntegration))
 	}
 
 	delete(s.Integrations, id)
@@ -1022,6 +2274,8 @@ func (s *Simulator) DeleteIntegration(id int) error {
 
 // TestIntegrationConnection tests connection to an integration
 func (s *Simulator) TestIntegrationConnection(id int) error {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return integration.ErrIntegrationNotFound
 	}
@@ -1050,6 +2304,8 @@ func (s *Simulator) TestIntegrationConnection(id int) error {
 
 // GetDeviceProfiles returns a type-neutral {ID,Name} list of profiles for the given integration.
 func (s *Simulator) GetDeviceProfiles(id int) ([]integration.DeviceProfile, error) {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return nil, integration.ErrIntegrationNotFound
 	}
@@ -1095,6 +2351,13 @@ func (s *Simulator) GetDeviceProfiles(id int) ([]integration.DeviceProfile, erro
 // GetDevicesUsingIntegration returns a list of device EUIs using the specified integration
 // (either ChirpStack or ThingsBoard side).
 func (s *Simulator) GetDevicesUsingIntegration(integrationID int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.devicesUsingIntegration(integrationID)
+}
+
+// devicesUsingIntegration is GetDevicesUsingIntegration with s.mu already held
+func (s *Simulator) devicesUsingIntegration(integrationID int) []string {
 	devicesUsingIntegration := []string{}
 	for _, device := range s.Devices {
 		cfg := device.Info.Configuration
@@ -1108,6 +2371,8 @@ func (s *Simulator) GetDevicesUsingIntegration(integrationID int) []string {
 // ProvisionDevice provisions a device to ChirpStack using OTAA.
 // `variables` is written to the CS device's Variables map — pass nil for none.
 func (s *Simulator) ProvisionDevice(integrationID int, devEUI, name, deviceProfileID, appKey string, variables map[string]string) error {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return integration.ErrIntegrationNotFound
 	}
@@ -1149,6 +2414,8 @@ func (s *Simulator) ProvisionDevice(integrationID int, devEUI, name, deviceProfi
 // ProvisionDeviceABP provisions a device to ChirpStack using ABP.
 // `variables` is written to the CS device's Variables map — pass nil for none.
 func (s *Simulator) ProvisionDeviceABP(integrationID int, devEUI, name, deviceProfileID, devAddr, nwkSKey, appSKey string, variables map[string]string) error {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return integration.ErrIntegrationNotFound
 	}
@@ -1188,8 +2455,35 @@ func (s *Simulator) ProvisionDeviceABP(integrationID int, devEUI, name, devicePr
 	return nil
 }
 
+// ActivateDeviceABPInChirpStack updates the ABP session keys of an already provisioned device
+func (s *Simulator) ActivateDeviceABPInChirpStack(integrationID int, devEUI, devAddr, nwkSKey, appSKey string) error {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
+	if s.Integrations == nil {
+		return integration.ErrIntegrationNotFound
+	}
+
+	integ, exists := s.Integrations[integrationID]
+	if !exists {
+		return integration.ErrIntegrationNotFound
+	}
+
+	if !integ.Enabled {
+		return errors.New("integration is disabled")
+	}
+
+	client, exists := s.IntegrationClients[integrationID]
+	if !exists {
+		return errors.New("client not initialized for this integration")
+	}
+
+	return client.ActivateDeviceABP(devEUI, devAddr, nwkSKey, appSKey)
+}
+
 // DeleteDeviceFromChirpStack removes a device from ChirpStack
 func (s *Simulator) DeleteDeviceFromChirpStack(integrationID int, devEUI string) error {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return nil // Silently skip
 	}
@@ -1214,6 +2508,8 @@ func (s *Simulator) DeleteDeviceFromChirpStack(integrationID int, devEUI string)
 // ProvisionDeviceToThingsBoard creates a device in ThingsBoard and returns its UUID.
 // Name = devEUI (hex), label = simulator-side friendly name, customerID optional.
 func (s *Simulator) ProvisionDeviceToThingsBoard(integrationID int, devEUI, label, profileID, customerID string) (string, error) {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return "", integration.ErrIntegrationNotFound
 	}
@@ -1234,8 +2530,18 @@ func (s *Simulator) ProvisionDeviceToThingsBoard(integrationID int, devEUI, labe
 	return client.CreateDevice(devEUI, label, profileID, customerID)
 }
 
+// thingsBoardClient returns the client of a ThingsBoard integration
+func (s *Simulator) thingsBoardClient(integrationID int) (*thingsboard.Client, bool) {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
+	client, ok := s.ThingsBoardClients[integrationID]
+	return client, ok
+}
+
 // GetThingsBoardCustomers returns the customer list for a TB integration.
 func (s *Simulator) GetThingsBoardCustomers(integrationID int) ([]thingsboard.Customer, error) {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return nil, integration.ErrIntegrationNotFound
 	}
@@ -1255,6 +2561,8 @@ func (s *Simulator) GetThingsBoardCustomers(integrationID int) ([]thingsboard.Cu
 
 // DeleteDeviceFromThingsBoard removes a device from ThingsBoard by its UUID.
 func (s *Simulator) DeleteDeviceFromThingsBoard(integrationID int, tbDeviceID string) error {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return nil
 	}
@@ -1274,6 +2582,8 @@ func (s *Simulator) DeleteDeviceFromThingsBoard(integrationID int, tbDeviceID st
 
 // ProvisionGateway provisions a virtual gateway to ChirpStack
 func (s *Simulator) ProvisionGateway(integrationID int, gatewayID, name string, lat, lng float64, alt int32) error {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return integration.ErrIntegrationNotFound
 	}
@@ -1314,6 +2624,8 @@ func (s *Simulator) ProvisionGateway(integrationID int, gatewayID, name string,
 
 // DeleteGatewayFromChirpStack removes a gateway from ChirpStack
 func (s *Simulator) DeleteGatewayFromChirpStack(integrationID int, gatewayID string) error {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
 	if s.Integrations == nil {
 		return nil
 	}
@@ -1339,6 +2651,8 @@ func (s *Simulator) DeleteGatewayFromChirpStack(integrationID int, gatewayID str
 
 // GetTemplates returns all templates
 func (s *Simulator) GetTemplates() []*template.DeviceTemplate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	if s.Templates == nil {
 		return []*template.DeviceTemplate{}
 	}
@@ -1351,6 +2665,8 @@ func (s *Simulator) GetTemplates() []*template.DeviceTemplate {
 
 // GetTemplate returns a specific template by ID
 func (s *Simulator) GetTemplate(id int) (*template.DeviceTemplate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	if s.Templates == nil {
 		return nil, template.ErrTemplateNotFound
 	}
@@ -1361,8 +2677,21 @@ func (s *Simulator) GetTemplate(id int) (*template.DeviceTemplate, error) {
 	return tmpl.Clone(), nil
 }
 
+// GetTemplateDependencies returns the codec and integrations a template relies on
+func (s *Simulator) GetTemplateDependencies(id int) (template.Dependencies, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tmpl, exists := s.Templates[id]
+	if !exists {
+		return template.Dependencies{}, template.ErrTemplateNotFound
+	}
+	return tmpl.Dependencies(), nil
+}
+
 // AddTemplate adds a new template
 func (s *Simulator) AddTemplate(tmpl *template.DeviceTemplate) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.Templates == nil {
 		s.Templates = make(map[int]*template.DeviceTemplate)
 	}
@@ -1379,6 +2708,8 @@ func (s *Simulator) AddTemplate(tmpl *template.DeviceTemplate) (int, error) {
 		s.NextIDTemplate = tmpl.ID + 1
 	}
 
+	s.trackTemplateCodec(s.Templates[tmpl.ID], -1)
+	s.trackTemplateCodec(tmpl, 1)
 	s.Templates[tmpl.ID] = tmpl
 
 	// Save to disk
@@ -1388,6 +2719,8 @@ func (s *Simulator) AddTemplate(tmpl *template.DeviceTemplate) (int, error) {
 
 // UpdateTemplate updates an existing template
 func (s *Simulator) UpdateTemplate(tmpl *template.DeviceTemplate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.Templates == nil {
 		return template.ErrTemplateNotFound
 	}
@@ -1400,6 +2733,8 @@ func (s *Simulator) UpdateTemplate(tmpl *template.DeviceTemplate) error {
 		return err
 	}
 
+	s.trackTemplateCodec(s.Templates[tmpl.ID], -1)
+	s.trackTemplateCodec(tmpl, 1)
 	s.Templates[tmpl.ID] = tmpl
 
 	// Save to disk
@@ -1409,6 +2744,8 @@ func (s *Simulator) UpdateTemplate(tmpl *template.DeviceTemplate) error {
 
 // DeleteTemplate removes a template by ID
 func (s *Simulator) DeleteTemplate(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.Templates == nil {
 		return template.ErrTemplateNotFound
 	}
@@ -1417,6 +2754,7 @@ func (s *Simulator) DeleteTemplate(id int) error {
 		return template.ErrTemplateNotFound
 	}
 
+	s.trackTemplateCodec(s.Templates[id], -1)
 	delete(s.Templates, id)
 
 	// Save to disk
@@ -1426,17 +2764,38 @@ func (s *Simulator) DeleteTemplate(id int) error {
 
 // ==================== Bulk Device Creation ====================
 
-// CreateDevicesFromTemplate creates multiple devices from a template.
-// Optimized for bulk: defers JSON persistence, parallelizes ChirpStack provisioning,
-// and uses hash sets for O(1) collision detection.
-func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePrefix string, baseLat, baseLng float64, baseAlt int32, spreadMeters float64) ([]int, error) {
+// generateDevicesFromTemplate checks a bulk creation and builds the devices in memory, with
+// names, coordinates and keys, but without IDs: nothing is stored, persisted or provisioned.
+// It also returns how many devices don't fit within MaxDevices.
+func (s *Simulator) generateDevicesFromTemplate(templateID int, count int, namePrefix string, baseLat, baseLng float64, baseAlt int32, spreadMeters float64, spreadShape string) (*template.DeviceTemplate, []*dev.Device, int, error) {
 	if s.Templates == nil {
-		return nil, template.ErrTemplateNotFound
+		return nil, nil, 0, template.ErrTemplateNotFound
 	}
 
 	tmpl, exists := s.Templates[templateID]
 	if !exists {
-		return nil, template.ErrTemplateNotFound
+		return nil, nil, 0, template.ErrTemplateNotFound
+	}
+
+	if err := s.checkTemplateReferences(tmpl); err != nil {
+		return nil, nil, 0, err
+	}
+
+	if !validSpreadShape(spreadShape) {
+		return nil, nil, 0, fmt.Errorf("unknown spread shape '%s' (use %s, %s or %s)", spreadShape, SpreadSquare, SpreadCircle, SpreadGrid)
+	}
+
+	// Only create up to the remaining capacity when a device limit is configured
+	skipped := 0
+	if s.MaxDevices > 0 {
+		remaining := s.MaxDevices - len(s.Devices)
+		if remaining <= 0 {
+			return nil, nil, 0, fmt.Errorf("maximum number of devices (%d) reached", s.MaxDevices)
+		}
+		if count > remaining {
+			skipped = count - remaining
+			count = remaining
+		}
 	}
 
 	useOTAA := tmpl.ActivationMode != "abp"
@@ -1457,26 +2816,11 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 	for i := 1; i <= count; i++ {
 		name := fmt.Sprintf("%s-%d", namePrefix, i)
 		if _, exists := nameSet[name]; exists {
-			return nil, fmt.Errorf("name '%s' already exists", name)
+			return nil, nil, 0, fmt.Errorf("name '%s' already exists", name)
 		}
 	}
 
-	mrand.Seed(time.Now().UnixNano())
-
-	createdIDs := make([]int, 0, count)
-	mode := "ABP"
-	if useOTAA {
-		mode = "OTAA"
-	}
-
-	s.Print(fmt.Sprintf("Bulk creating %d %s devices from template '%s'...", count, mode, tmpl.Name), nil, util.PrintOnlyConsole)
-
-	// Phase 1: Create all devices in memory (no disk writes, no ChirpStack calls)
-	type pendingDevice struct {
-		device *dev.Device
-		id     int
-	}
-	pending := make([]pendingDevice, 0, count)
+	devices := make([]*dev.Device, 0, count)
 
 	for i := 1; i <= count; i++ {
 		name := fmt.Sprintf("%s-%d", namePrefix, i)
@@ -1502,7 +2846,7 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 			continue
 		}
 
-		lat, lng := randomizeCoordinates(baseLat, baseLng, spreadMeters)
+		lat, lng := spreadCoordinates(spreadShape, baseLat, baseLng, spreadMeters, i-1, count)
 
 		var device *dev.Device
 		if useOTAA {
@@ -1528,19 +2872,72 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 			device = s.createDeviceFromTemplateABP(tmpl, name, devEUI, nwkSKey, appSKey, devAddr, lat, lng, baseAlt)
 		}
 
+		nameSet[name] = struct{}{}
+		euiSet[devEUI] = struct{}{}
+
+		devices = append(devices, device)
+	}
+
+	return tmpl, devices, skipped, nil
+}
+
+// PreviewDevicesFromTemplate runs the bulk creation checks and generation without creating anything,
+// returning the would-be devices (with the IDs they would get) and how many would be skipped
+func (s *Simulator) PreviewDevicesFromTemplate(templateID int, count int, namePrefix string, baseLat, baseLng float64, baseAlt int32, spreadMeters float64, spreadShape string) ([]*dev.Device, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, devices, skipped, err := s.generateDevicesFromTemplate(templateID, count, namePrefix, baseLat, baseLng, baseAlt, spreadMeters, spreadShape)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, device := range devices {
+		device.Id = s.NextIDDev + i
+	}
+	return devices, skipped, nil
+}
+
+// CreateDevicesFromTemplate creates multiple devices from a template.
+// Optimized for bulk: defers JSON persistence, parallelizes ChirpStack provisioning,
+// and uses hash sets for O(1) collision detection.
+func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePrefix string, baseLat, baseLng float64, baseAlt int32, spreadMeters float64, spreadShape string) ([]int, int, error) {
+	// Phases 1, 2 and 5 hold s.mu; the provisioning requests of phases 3 and 4 are made
+	// without it, and their results recorded under it once they are over
+	s.mu.Lock()
+	// Phase 1: Create all devices in memory (no disk writes, no ChirpStack calls)
+	tmpl, devices, skipped, err := s.generateDevicesFromTemplate(templateID, count, namePrefix, baseLat, baseLng, baseAlt, spreadMeters, spreadShape)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, 0, err
+	}
+	if skipped > 0 {
+		s.Print(fmt.Sprintf("Device limit %d: skipping %d devices", s.MaxDevices, skipped), nil, util.PrintOnlyConsole)
+	}
+
+	mode := "OTAA"
+	if tmpl.ActivationMode == "abp" {
+		mode = "ABP"
+	}
+	s.Print(fmt.Sprintf("Bulk creating %d %s devices from template '%s'...", len(devices), mode, tmpl.Name), nil, util.PrintOnlyConsole)
+
+	type pendingDevice struct {
+		device *dev.Device
+		id     int
+	}
+	pending := make([]pendingDevice, 0, len(devices))
+	createdIDs := make([]int, 0, len(devices))
+
+	for i, device := range devices {
 		// Assign ID and store in memory (skipping searchName/searchAddress — already checked)
 		device.Id = s.NextIDDev
 		s.NextIDDev++
+		s.trackDeviceCodec(device, 1)
 		s.Devices[device.Id] = device
 
-		nameSet[name] = struct{}{}
-		euiSet[devEUI] = struct{}{}
-
 		pending = append(pending, pendingDevice{device: device, id: device.Id})
 		createdIDs = append(createdIDs, device.Id)
 
-		if i%1000 == 0 {
-			s.Print(fmt.Sprintf("  ...%d/%d devices created in memory", i, count), nil, util.PrintOnlyConsole)
+		if (i+1)%1000 == 0 {
+			s.Print(fmt.Sprintf("  ...%d/%d devices created in memory", i+1, len(devices)), nil, util.PrintOnlyConsole)
 		}
 	}
 
@@ -1552,10 +2949,12 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 	s.saveComponent(pathDir+"/devices.json", &s.Devices)
 	s.saveComponent(pathDir+"/simulator.json", &s)
 	s.Print(fmt.Sprintf("Saved %d devices to disk", len(pending)), nil, util.PrintOnlyConsole)
+	s.mu.Unlock()
 
 	// Phase 3: Parallel ThingsBoard provisioning (10 workers) — runs first so
 	// its access token can be fed into the CS device's Variables map.
 	tbTokens := make(map[int]string)
+	tbIDs := make(map[int]string)
 	var tbTokensMu sync.Mutex // guards writes in Phase 3; Phase 4 reads after wg.Wait()
 
 	tbDevices := make([]pendingDevice, 0)
@@ -1596,13 +2995,11 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 						tbMu.Unlock()
 						continue
 					}
-					pd.device.Info.Configuration.TBDeviceID = tbID
 
 					if pd.device.Info.Configuration.IntegrationEnabled {
-						tbClient, ok := s.ThingsBoardClients[pd.device.Info.Configuration.TBIntegrationID]
+						tbClient, ok := s.thingsBoardClient(pd.device.Info.Configuration.TBIntegrationID)
 						if !ok {
 							_ = s.DeleteDeviceFromThingsBoard(pd.device.Info.Configuration.TBIntegrationID, tbID)
-							pd.device.Info.Configuration.TBDeviceID = ""
 							tbMu.Lock()
 							tbErrors++
 							tbMu.Unlock()
@@ -1611,7 +3008,6 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 						token, terr := tbClient.GetDeviceCredentials(tbID)
 						if terr != nil {
 							_ = s.DeleteDeviceFromThingsBoard(pd.device.Info.Configuration.TBIntegrationID, tbID)
-							pd.device.Info.Configuration.TBDeviceID = ""
 							tbMu.Lock()
 							tbErrors++
 							tbMu.Unlock()
@@ -1621,6 +3017,9 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 						tbTokens[pd.id] = token
 						tbTokensMu.Unlock()
 					}
+					tbTokensMu.Lock()
+					tbIDs[pd.id] = tbID
+					tbTokensMu.Unlock()
 				}
 			}()
 		}
@@ -1631,7 +3030,14 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 		close(jobs)
 		wg.Wait()
 
+		s.mu.Lock()
+		for _, pd := range tbDevices {
+			if tbID, ok := tbIDs[pd.id]; ok {
+				pd.device.Info.Configuration.TBDeviceID = tbID
+			}
+		}
 		s.saveComponent(pathDir+"/devices.json", &s.Devices)
+		s.mu.Unlock()
 
 		if tbErrors > 0 {
 			s.Print(fmt.Sprintf("ThingsBoard provisioning: %d/%d failed", tbErrors, len(tbDevices)), nil, util.PrintOnlyConsole)
@@ -1659,7 +3065,8 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 		jobs := make(chan pendingDevice, workers*2)
 		var wg sync.WaitGroup
 		var csErrors int64
-		var csMu sync.Mutex
+		var csMu sync.Mutex // also guards rolledBack
+		rolledBack := make([]pendingDevice, 0)
 
 		for w := 0; w < workers; w++ {
 			wg.Add(1)
@@ -1699,9 +3106,11 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 						csMu.Lock()
 						csErrors++
 						csMu.Unlock()
-						if pd.device.Info.Configuration.TBIntegrationEnabled && pd.device.Info.Configuration.TBDeviceID != "" {
-							_ = s.DeleteDeviceFromThingsBoard(pd.device.Info.Configuration.TBIntegrationID, pd.device.Info.Configuration.TBDeviceID)
-							pd.device.Info.Configuration.TBDeviceID = ""
+						if tbID := tbIDs[pd.id]; pd.device.Info.Configuration.TBIntegrationEnabled && tbID != "" {
+							_ = s.DeleteDeviceFromThingsBoard(pd.device.Info.Configuration.TBIntegrationID, tbID)
+							csMu.Lock()
+							rolledBack = append(rolledBack, pd)
+							csMu.Unlock()
 						}
 					}
 				}
@@ -1714,7 +3123,12 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 		close(jobs)
 		wg.Wait()
 
+		s.mu.Lock()
+		for _, pd := range rolledBack {
+			pd.device.Info.Configuration.TBDeviceID = ""
+		}
 		s.saveComponent(pathDir+"/devices.json", &s.Devices)
+		s.mu.Unlock()
 
 		if csErrors > 0 {
 			s.Print(fmt.Sprintf("ChirpStack provisioning: %d/%d failed", csErrors, len(csDevices)), nil, util.PrintOnlyConsole)
@@ -1723,18 +3137,24 @@ func (s *Simulator) CreateDevicesFromTemplate(templateID int, count int, namePre
 		}
 	}
 
-	// Phase 5: Activate devices (add to ActiveDevices, turn on if sim running)
+	// Phase 5: Activate devices (add to ActiveDevices, turn on if sim running),
+	// skipping the ones deleted or replaced during the provisioning
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for _, pd := range pending {
+		if s.Devices[pd.id] != pd.device {
+			continue
+		}
 		if pd.device.Info.Status.Active {
 			s.ActiveDevices[pd.id] = pd.id
-			if s.State == util.Running {
+			if s.State == util.Running && !pd.device.IsOn() {
 				s.turnONDevice(pd.id)
 			}
 		}
 	}
 
 	s.Print(fmt.Sprintf("Bulk creation complete: %d devices created", len(createdIDs)), nil, util.PrintOnlyConsole)
-	return createdIDs, nil
+	return createdIDs, skipped, nil
 }
 
 // createDeviceFromTemplateOTAA creates a Device struct from a template using OTAA activation
@@ -1788,6 +3208,11 @@ func (s *Simulator) buildDeviceFromTemplate(tmpl *template.DeviceTemplate, name
 				SendInterval:         time.Duration(tmpl.SendInterval) * time.Second,
 				AckTimeout:           time.Duration(tmpl.AckTimeout) * time.Second,
 				NbRepConfirmedDataUp: tmpl.NbRetransmission,
+				FCntUpStart:          tmpl.FCntUpStart,
+				FCntDownStart:        tmpl.FCntDownStart,
+				FCntBits:             tmpl.FCntBits,
+				JoinAcceptDelay1:     tmpl.JoinAcceptDelay1,
+				JoinAcceptDelay2:     tmpl.JoinAcceptDelay2,
 				UseCodec:             tmpl.UseCodec,
 				CodecID:              tmpl.CodecID,
 				IntegrationEnabled:   tmpl.IntegrationEnabled,
@@ -1816,9 +3241,18 @@ func (s *Simulator) buildDeviceFromTemplate(tmpl *template.DeviceTemplate, name
 		},
 	}
 
+	s.applyRetransmissionDefaults(device)
+
 	fport := tmpl.FPort
 	device.Info.Status.DataUplink.FPort = &fport
 
+	if tmpl.UseCodec {
+		device.Info.Configuration.PayloadConfig = tmpl.Clone().PayloadConfig
+		device.Info.Configuration.ProfileID = tmpl.ProfileID
+	} else if payload, err := hex.DecodeString(strings.TrimSpace(tmpl.StaticPayloadHex)); err == nil {
+		device.Info.Status.Payload = &lorawan.DataPayload{Bytes: payload}
+	}
+
 	return device
 }
 
@@ -1843,19 +3277,73 @@ func generateRandomDevAddr() (lorawan.DevAddr, error) {
 	return addr, err
 }
 
-// randomizeCoordinates adds random offset to coordinates within a square spread
-func randomizeCoordinates(baseLat, baseLng, spreadMeters float64) (float64, float64) {
+// Spread shapes accepted by the bulk device creation
+const (
+	SpreadSquare = "square" // Uniformly random within a square of half-side spreadMeters (default)
+	SpreadCircle = "circle" // Uniformly random within a circle of radius spreadMeters
+	SpreadGrid   = "grid"   // Evenly spaced rows and columns spanning the square
+)
+
+// validSpreadShape reports whether the shape is supported; empty selects SpreadSquare
+func validSpreadShape(shape string) bool {
+	switch shape {
+	case "", SpreadSquare, SpreadCircle, SpreadGrid:
+		return true
+	}
+	return false
+}
+
+// spreadCoordinates returns the position of the device at index (0-based) out of count,
+// placed around the base point according to the shape
+func spreadCoordinates(shape string, baseLat, baseLng, spreadMeters float64, index, count int) (float64, float64) {
+	switch shape {
+	case SpreadCircle:
+		// sqrt keeps the density uniform over the area
+		radius := spreadMeters * math.Sqrt(util.RandFloat64())
+		angle := 2 * math.Pi * util.RandFloat64()
+		return offsetCoordinates(baseLat, baseLng, radius*math.Sin(angle), radius*math.Cos(angle))
+	case SpreadGrid:
+		north, east := gridOffset(spreadMeters, index, count)
+		return offsetCoordinates(baseLat, baseLng, north, east)
+	default:
+		return randomizeCoordinates(baseLat, baseLng, spreadMeters)
+	}
+}
+
+// gridOffset places count points in the nearest square arrangement (filled row by row)
+// spanning [-spreadMeters, spreadMeters] and centered on the origin; it returns the offset of point index
+func gridOffset(spreadMeters float64, index, count int) (float64, float64) {
+	columns := int(math.Ceil(math.Sqrt(float64(count))))
+	rows := (count + columns - 1) / columns
+	if columns < 2 {
+		return 0, 0
+	}
+	spacing := 2 * spreadMeters / float64(columns-1)
+
+	row, column := index/columns, index%columns
+	north := (float64(rows-1)/2 - float64(row)) * spacing
+	east := (float64(column) - float64(columns-1)/2) * spacing
+	return north, east
+}
+
+// offsetCoordinates moves the base point by the given meters towards north and east
+func offsetCoordinates(baseLat, baseLng, north, east float64) (float64, float64) {
 	// Approximately 111,320 meters per degree of latitude
 	const metersPerDegree = 111320.0
 
-	// Random offset in range [-1, 1]
-	latOffset := (mrand.Float64()*2 - 1) * (spreadMeters / metersPerDegree)
-
 	// Longitude degrees vary with latitude
 	lngMetersPerDegree := metersPerDegree * math.Cos(baseLat*math.Pi/180)
-	lngOffset := (mrand.Float64()*2 - 1) * (spreadMeters / lngMetersPerDegree)
 
-	return baseLat + latOffset, baseLng + lngOffset
+	return baseLat + north/metersPerDegree, baseLng + east/lngMetersPerDegree
+}
+
+// randomizeCoordinates adds random offset to coordinates within a square spread
+func randomizeCoordinates(baseLat, baseLng, spreadMeters float64) (float64, float64) {
+	// Random offset in range [-1, 1]
+	north := (util.RandFloat64()*2 - 1) * spreadMeters
+	east := (util.RandFloat64()*2 - 1) * spreadMeters
+
+	return offsetCoordinates(baseLat, baseLng, north, east)
 }
 
 // getMType converts int to lorawan.MType
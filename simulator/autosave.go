@@ -0,0 +1,124 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/shared"
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+)
+
+// startAutoSave saves the status and the codec library every AutoSaveInterval seconds
+// until stopAutoSave, so that a crash loses less than one interval of runtime changes.
+// Nothing is started when AutoSaveInterval is 0 or negative. Called with s.mu held.
+func (s *Simulator) startAutoSave() {
+	if s.AutoSaveInterval <= 0 || s.autoSaveStop != nil {
+		return
+	}
+	interval := time.Duration(s.AutoSaveInterval) * time.Second
+	stop := make(chan struct{})
+	s.autoSaveStop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.autoSave(stop)
+			}
+		}
+	}()
+	shared.DebugPrint(fmt.Sprintf("Automatic save every %v", interval))
+}
+
+// stopAutoSave stops the automatic saves started by startAutoSave. Called with s.mu held.
+func (s *Simulator) stopAutoSave() {
+	if s.autoSaveStop != nil {
+		close(s.autoSaveStop)
+		s.autoSaveStop = nil
+	}
+}
+
+// autoSave runs one automatic save, skipping it if the previous one is still writing
+// or the automatic saves were stopped while it waited for s.mu
+func (s *Simulator) autoSave(stop <-chan struct{}) {
+	if !s.autoSaveMu.TryLock() {
+		shared.DebugPrint("Automatic save skipped, the previous one is still running")
+		return
+	}
+	defer s.autoSaveMu.Unlock()
+
+	// Waiting for the run loops can take an uplink, so the running devices are marshalled
+	// without holding s.mu
+	s.mu.RLock()
+	devices := make([]*dev.Device, 0, len(s.Devices))
+	for _, d := range s.Devices {
+		devices = append(devices, d)
+	}
+	s.mu.RUnlock()
+	running := marshalRunningDevices(devices)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	select {
+	case <-stop:
+		return
+	default:
+	}
+	saved := make(map[int]json.RawMessage, len(s.Devices))
+	for id, d := range s.Devices {
+		data, ok := running[d]
+		if !ok {
+			// Stopped, or turned on since
+			var err error
+			if data, err = marshalDevice(d); err != nil {
+				shared.DebugPrint(fmt.Sprintf("Automatic save skipped: %v", err))
+				return
+			}
+		}
+		saved[id] = data
+	}
+	s.saveStatusFiles(saved)
+	s.saveHistory()
+	s.saveCodecLibrary()
+}
+
+// marshalRunningDevices marshals the running devices on their run loop, all at once.
+// Devices turned off meanwhile are left out.
+func marshalRunningDevices(devices []*dev.Device) map[*dev.Device]json.RawMessage {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	marshalled := make(map[*dev.Device]json.RawMessage)
+	for _, d := range devices {
+		if !d.IsOn() {
+			continue
+		}
+		wg.Add(1)
+		go func(d *dev.Device) {
+			defer wg.Done()
+			if data, ok, err := d.MarshalOnLoop(); ok && err == nil {
+				mu.Lock()
+				marshalled[d] = data
+				mu.Unlock()
+			}
+		}(d)
+	}
+	wg.Wait()
+	return marshalled
+}
+
+// marshalDevice marshals a device, on its run loop if it is running. Called with s.mu
+// held, so that a stopped device isn't turned on meanwhile.
+func marshalDevice(d *dev.Device) (json.RawMessage, error) {
+	if d.IsOn() {
+		if data, ok, err := d.MarshalOnLoop(); ok {
+			return data, err
+		}
+	}
+	return json.Marshal(d)
+}
@@ -0,0 +1,95 @@
+package simulator
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestAutoSave(t *testing.T) {
+	s := newTestSimulator(t)
+
+	// Off by default
+	s.startAutoSave()
+	if s.autoSaveStop != nil {
+		t.Fatal("automatic saves started with AutoSaveInterval = 0")
+	}
+
+	s.AutoSaveInterval = 60
+	s.startAutoSave()
+	stop := s.autoSaveStop
+	if stop == nil {
+		t.Fatal("automatic saves not started with AutoSaveInterval = 60")
+	}
+	s.startAutoSave()
+	if s.autoSaveStop != stop {
+		t.Error("startAutoSave() started the automatic saves twice")
+	}
+
+	s.stopAutoSave()
+	if s.autoSaveStop != nil {
+		t.Error("stopAutoSave() left the automatic saves running")
+	}
+	select {
+	case <-stop:
+	default:
+		t.Error("stopAutoSave() didn't signal the saving goroutine")
+	}
+
+	// A save still running makes the next one skip instead of waiting
+	s.autoSaveMu.Lock()
+	s.mu.Lock()
+	s.autoSave(make(chan struct{})) // would deadlock on s.mu if it didn't skip
+	s.mu.Unlock()
+	s.autoSaveMu.Unlock()
+
+	// A save scheduled before stopAutoSave does nothing once it gets s.mu
+	s.autoSave(stop)
+}
+
+// Run with -race: the automatic saves marshal the devices while they send uplinks
+func TestAutoSaveRunningDevices(t *testing.T) {
+	s := newTestSimulator(t)
+	n := testutil.NewNetwork()
+
+	running := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, lorawan.DevAddr{1, 2, 3, 4}, [16]byte{1}, [16]byte{2})
+	running.Info.Configuration.SendInterval = time.Millisecond
+	stopped := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 9}, lorawan.DevAddr{1, 2, 3, 5}, [16]byte{1}, [16]byte{2})
+	stopped.State = util.Stopped // as left by TurnOFF, testutil devices are marked running
+	s.Devices[1] = running
+	s.Devices[2] = stopped
+
+	n.Start(running)
+	defer n.Stop(running)
+
+	tests := []struct {
+		name   string
+		device *dev.Device
+		want   bool // marshalled by marshalRunningDevices
+	}{
+		{"running", running, true},
+		{"stopped", stopped, false},
+	}
+	// Each marshalling of the running device waits for the end of its uplink
+	for i := 0; i < 3; i++ {
+		marshalled := marshalRunningDevices([]*dev.Device{running, stopped})
+		for _, tt := range tests {
+			data, ok := marshalled[tt.device]
+			if ok != tt.want {
+				t.Fatalf("%s device marshalled = %v, want %v", tt.name, ok, tt.want)
+			}
+			if !ok {
+				data, _ = marshalDevice(tt.device)
+			}
+			if !json.Valid(data) {
+				t.Fatalf("%s device: invalid JSON %s", tt.name, data)
+			}
+		}
+		s.autoSave(make(chan struct{}))
+	}
+}
@@ -0,0 +1,34 @@
+package simulator
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/codes"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+// CheckDevEUI runs the DevEUI checks of SetDevice without adding anything: it returns
+// an error with its code if the DevEUI is invalid or used by another device or gateway.
+// id is the device being edited, whose own DevEUI is allowed (-1 for a new device).
+func (s *Simulator) CheckDevEUI(devEUI lorawan.EUI64, id int) (int, error) {
+	if devEUI == (lorawan.EUI64{}) {
+		return codes.CodeErrorAddress, errors.New("Error: DevEUI invalid")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.searchAddress(devEUI, id, false)
+}
+
+// CheckDeviceName runs the name checks of SetDevice without adding anything: it returns
+// an error with its code if the name is invalid or used by another device or gateway.
+// id is the device being edited, whose own name is allowed (-1 for a new device).
+func (s *Simulator) CheckDeviceName(name string, id int) (int, error) {
+	if err := util.ValidateName(name); err != nil {
+		return codes.CodeErrorName, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.searchName(strings.TrimSpace(name), id, false)
+}
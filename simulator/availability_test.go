@@ -0,0 +1,57 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/codes"
+	gw "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/gateway"
+	gwModels "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/gateway/models"
+	"github.com/brocaar/lorawan"
+)
+
+func TestCheckDevEUIAndName(t *testing.T) {
+	s := newTestSimulator(t)
+	s.Gateways[7] = &gw.Gateway{Id: 7, Info: gwModels.InfoGateway{Name: "gateway", MACAddress: lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 9}}}
+
+	_, first, err := s.SetDevice(newTestDevice("first", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}), false)
+	if err != nil {
+		t.Fatalf("SetDevice() error = %v", err)
+	}
+
+	addressTests := []struct {
+		devEUI lorawan.EUI64
+		id     int
+		want   int
+	}{
+		{lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 2}, -1, codes.CodeOK},
+		{lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}, -1, codes.CodeErrorAddress}, // used by a device
+		{lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}, first, codes.CodeOK},        // its own DevEUI
+		{lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 9}, -1, codes.CodeErrorAddress}, // used by a gateway
+		{lorawan.EUI64{}, -1, codes.CodeErrorAddress},                       // invalid
+	}
+	for _, tt := range addressTests {
+		code, err := s.CheckDevEUI(tt.devEUI, tt.id)
+		if code != tt.want || (err == nil) != (tt.want == codes.CodeOK) {
+			t.Errorf("CheckDevEUI(%v, %d) = %d, %v, want code %d", tt.devEUI, tt.id, code, err, tt.want)
+		}
+	}
+
+	nameTests := []struct {
+		name string
+		id   int
+		want int
+	}{
+		{"second", -1, codes.CodeOK},
+		{"first", -1, codes.CodeErrorName},
+		{" first ", -1, codes.CodeErrorName}, // trimmed as SetDevice does
+		{"first", first, codes.CodeOK},
+		{"gateway", -1, codes.CodeErrorName},
+		{"", -1, codes.CodeErrorName},
+	}
+	for _, tt := range nameTests {
+		code, err := s.CheckDeviceName(tt.name, tt.id)
+		if code != tt.want || (err == nil) != (tt.want == codes.CodeOK) {
+			t.Errorf("CheckDeviceName(%q, %d) = %d, %v, want code %d", tt.name, tt.id, code, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package simulator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/brocaar/lorawan"
+)
+
+func TestOrphanCodecStates(t *testing.T) {
+	s := newTestSimulator(t)
+
+	registry := codec.NewRegistry(nil)
+	defer registry.Close()
+	previous := dev.Codecs
+	dev.Codecs = registry
+	defer func() { dev.Codecs = previous }()
+
+	var ids []int
+	for i, name := range []string{"kept", "deleted"} {
+		d := newTestDevice(name, lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, byte(i + 1)})
+		_, id, err := s.SetDevice(d, false)
+		if err != nil {
+			t.Fatalf("SetDevice(%s) error = %v", name, err)
+		}
+		registry.GetOrCreateState(d.Info.DevEUI.String())
+		ids = append(ids, id)
+	}
+	orphan := lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 9}.String() // left by a device deleted before the fix
+	registry.GetOrCreateState(orphan)
+
+	if !s.DeleteDevice(ids[1]) {
+		t.Fatal("DeleteDevice() = false")
+	}
+	if state := registry.GetState(lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 2}.String()); state != nil {
+		t.Error("DeleteDevice() kept the codec state of the device")
+	}
+
+	if got := s.GetOrphanCodecStates(); !reflect.DeepEqual(got, []string{orphan}) {
+		t.Fatalf("GetOrphanCodecStates() = %v, want [%s]", got, orphan)
+	}
+	if got := s.PruneCodecStates(); !reflect.DeepEqual(got, []string{orphan}) {
+		t.Fatalf("PruneCodecStates() = %v, want [%s]", got, orphan)
+	}
+	if got := s.GetOrphanCodecStates(); len(got) != 0 {
+		t.Errorf("GetOrphanCodecStates() after pruning = %v, want none", got)
+	}
+	if got := registry.StateDevEUIs(); !reflect.DeepEqual(got, []string{lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}.String()}) {
+		t.Errorf("states after pruning = %v, want only the kept device", got)
+	}
+}
@@ -0,0 +1,77 @@
+package simulator
+
+import (
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+)
+
+// GetCodecUsage returns, for every codec of the library, the number of devices and
+// templates using it. The counts are cached and kept up to date as devices and
+// templates change, instead of scanning them at every request.
+func (s *Simulator) GetCodecUsage() map[int]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.codecUsage == nil {
+		s.buildCodecUsage()
+	}
+	return s.codecUsageCounts()
+}
+
+// ResetCodecUsage drops the cached codec usage and counts it again from the devices and templates
+func (s *Simulator) ResetCodecUsage() map[int]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buildCodecUsage()
+	return s.codecUsageCounts()
+}
+
+// buildCodecUsage counts the codec usage of all devices and templates, with s.mu held
+func (s *Simulator) buildCodecUsage() {
+	s.codecUsage = make(map[int]int)
+	for _, d := range s.Devices {
+		s.trackDeviceCodec(d, 1)
+	}
+	for _, t := range s.Templates {
+		s.trackTemplateCodec(t, 1)
+	}
+}
+
+// codecUsageCounts returns a copy of the cached usage, with the unused codecs of the library at 0
+func (s *Simulator) codecUsageCounts() map[int]int {
+	counts := make(map[int]int, len(s.codecUsage))
+	if dev.Codecs != nil {
+		for _, c := range dev.Codecs.ListCodecs() {
+			counts[c.ID] = 0
+		}
+	}
+	for id, n := range s.codecUsage {
+		counts[id] = n
+	}
+	return counts
+}
+
+// trackDeviceCodec adds delta to the usage of the codec of a device (nil is ignored), with
+// s.mu held. As in devicesUsingCodec, a device counts for its codec even with the codec disabled.
+func (s *Simulator) trackDeviceCodec(d *dev.Device, delta int) {
+	if d != nil {
+		s.addCodecUsage(d.Info.Configuration.CodecID, delta)
+	}
+}
+
+// trackTemplateCodec adds delta to the usage of the codec of a template (nil is ignored), with s.mu held
+func (s *Simulator) trackTemplateCodec(t *template.DeviceTemplate, delta int) {
+	if t != nil && t.UseCodec {
+		s.addCodecUsage(t.CodecID, delta)
+	}
+}
+
+// addCodecUsage updates the cached count of a codec, unless the cache is yet to be built
+func (s *Simulator) addCodecUsage(codecID, delta int) {
+	if s.codecUsage == nil || codecID == 0 {
+		return
+	}
+	s.codecUsage[codecID] += delta
+	if s.codecUsage[codecID] <= 0 {
+		delete(s.codecUsage, codecID)
+	}
+}
@@ -0,0 +1,95 @@
+package simulator
+
+import (
+	"testing"
+
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+	"github.com/brocaar/lorawan"
+)
+
+func TestCodecUsage(t *testing.T) {
+	s := newTestSimulator(t)
+
+	newDevice := func(name string, devEUI lorawan.EUI64, codecID int) *dev.Device {
+		d := newTestDevice(name, devEUI)
+		d.Info.Configuration.CodecID = codecID // counted even with UseCodec off, as in devicesUsingCodec
+		return d
+	}
+	newTemplate := func(name string, codecID int, useCodec bool) *template.DeviceTemplate {
+		return &template.DeviceTemplate{
+			Name:         name,
+			Region:       rp.Code_Eu868,
+			SendInterval: 10,
+			Range:        100,
+			UseCodec:     useCodec,
+			CodecID:      codecID,
+		}
+	}
+
+	// checkUsage compares the cached counts with a full scan of the devices and templates
+	checkUsage := func(step string, want map[int]int) {
+		t.Helper()
+		usage := s.GetCodecUsage()
+		for id, n := range want {
+			if usage[id] != n {
+				t.Errorf("%s: usage[%d] = %d, want %d", step, id, usage[id], n)
+			}
+			if scanned := len(s.devicesUsingCodec(id)); scanned != n {
+				t.Errorf("%s: devicesUsingCodec(%d) = %d, want %d", step, id, scanned, n)
+			}
+		}
+	}
+
+	_, first, err := s.SetDevice(newDevice("first", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}, 1), false)
+	if err != nil {
+		t.Fatalf("SetDevice(first) error = %v", err)
+	}
+	checkUsage("first device", map[int]int{1: 1, 2: 0})
+
+	if _, _, err := s.SetDevice(newDevice("second", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 2}, 1), false); err != nil {
+		t.Fatalf("SetDevice(second) error = %v", err)
+	}
+	checkUsage("second device", map[int]int{1: 2, 2: 0})
+
+	updated := newDevice("first", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}, 2)
+	updated.Id = first
+	if _, _, err := s.SetDevice(updated, true); err != nil {
+		t.Fatalf("SetDevice(update) error = %v", err)
+	}
+	checkUsage("device update", map[int]int{1: 1, 2: 1})
+
+	templateID, err := s.AddTemplate(newTemplate("with-codec", 2, true))
+	if err != nil {
+		t.Fatalf("AddTemplate() error = %v", err)
+	}
+	if _, err := s.AddTemplate(newTemplate("codec-disabled", 1, false)); err != nil {
+		t.Fatalf("AddTemplate() error = %v", err)
+	}
+	checkUsage("templates added", map[int]int{1: 1, 2: 2})
+
+	tmpl := newTemplate("with-codec", 1, true)
+	tmpl.ID = templateID
+	if err := s.UpdateTemplate(tmpl); err != nil {
+		t.Fatalf("UpdateTemplate() error = %v", err)
+	}
+	checkUsage("template update", map[int]int{1: 2, 2: 1})
+
+	if err := s.DeleteTemplate(templateID); err != nil {
+		t.Fatalf("DeleteTemplate() error = %v", err)
+	}
+	if !s.DeleteDevice(first) {
+		t.Fatalf("DeleteDevice(first) = false")
+	}
+	checkUsage("deletions", map[int]int{1: 1, 2: 0})
+
+	// A change made behind the cache is only picked up by a reset
+	s.Devices[first] = newDevice("restored", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}, 2)
+	if usage := s.GetCodecUsage(); usage[2] != 0 {
+		t.Errorf("stale cache: usage[2] = %d, want 0", usage[2])
+	}
+	if usage := s.ResetCodecUsage(); usage[1] != 1 || usage[2] != 1 {
+		t.Errorf("ResetCodecUsage() = %v, want 1 use of codecs 1 and 2", usage)
+	}
+}
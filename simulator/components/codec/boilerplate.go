@@ -0,0 +1,101 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Boilerplate types returned by Boilerplate
+const (
+	BoilerplateGeneric   = "generic"
+	BoilerplateMilesight = "milesight"
+)
+
+// ErrUnknownBoilerplate is returned when the requested boilerplate type does not exist
+var ErrUnknownBoilerplate = errors.New("unknown boilerplate type")
+
+// BoilerplateTypes lists the available boilerplate types
+var BoilerplateTypes = []string{BoilerplateGeneric, BoilerplateMilesight}
+
+// Boilerplate returns a starter codec script of the given type ("generic" when empty),
+// with the helpers available to codecs documented in a header comment
+func Boilerplate(kind string) (string, error) {
+	switch kind {
+	case "", BoilerplateGeneric:
+		return boilerplateHeader + genericBoilerplate, nil
+	case BoilerplateMilesight:
+		return boilerplateHeader + milesightBoilerplate, nil
+	}
+	return "", fmt.Errorf("%w %q, must be one of: %s", ErrUnknownBoilerplate, kind, strings.Join(BoilerplateTypes, ", "))
+}
+
+const boilerplateHeader = `// Helpers available to the codec:
+//   getState(name) / setState(name, value)  - variables kept per device between executions
+//   setUplinkField(name, value)             - field of the config passed to the next OnUplink calls
+//   getSendInterval() / setSendInterval(s)  - uplink interval of the device, in seconds
+//   log(message)                            - print to the device console
+//   hexToBytes(hex) / base64ToBytes(b64)    - convert a string to a byte array
+//   random(min, max), randomInt(min, max), gaussian(mean, stddev) - seeded random values
+`
+
+const genericBoilerplate = `
+// OnUplink is called before each uplink with the payload config of the device, and returns
+// the payload as a byte array, or as { fPort: n, bytes: [...] } to choose the fPort
+function OnUplink(config) {
+    var counter = (getState('counter') || 0) + 1;
+    setState('counter', counter);
+
+    var temperature = Math.round(gaussian(21, 0.5) * 10); // 0.1 C
+    return {
+        fPort: 1,
+        bytes: [(temperature >> 8) & 0xFF, temperature & 0xFF, counter & 0xFF]
+    };
+}
+
+// OnDownlink (optional) is called with each downlink carrying data; what it returns is
+// reported as the decoded downlink
+function OnDownlink(bytes, fPort) {
+    if (fPort === 1 && bytes.length >= 2) {
+        var interval = (bytes[0] << 8) | bytes[1];
+        setSendInterval(interval);
+        log('Send interval set to ' + interval + 's');
+        return { interval: interval };
+    }
+    return {};
+}
+`
+
+const milesightBoilerplate = `
+// Milesight channel format: each value is a channel byte, a type byte and the value,
+// little endian. Downlink commands start with 0xFF, then the command byte.
+
+function int16LE(value) {
+    if (value < 0) value = 0x10000 + value;
+    return [value & 0xFF, (value >> 8) & 0xFF];
+}
+
+function OnUplink(config) {
+    var battery = getState('battery') || 100;
+    var temperature = Math.round(gaussian(21, 0.5) * 10); // 0.1 C
+    var humidity = Math.round(random(30, 60) * 2);        // 0.5 %
+
+    var bytes = [0x01, 0x75, battery];                    // channel 1: battery
+    bytes.push(0x03, 0x67);                               // channel 3: temperature
+    bytes = bytes.concat(int16LE(temperature));
+    bytes.push(0x04, 0x68, humidity & 0xFF);              // channel 4: humidity
+
+    return { fPort: 85, bytes: bytes };
+}
+
+function OnDownlink(bytes, fPort) {
+    // 0xFF 0x03 <interval uint16 LE>: set the report interval in seconds
+    if (bytes[0] === 0xFF && bytes[1] === 0x03 && bytes.length >= 4) {
+        var interval = bytes[2] | (bytes[3] << 8);
+        setSendInterval(interval);
+        log('Report interval set to ' + interval + 's');
+        return { reportInterval: interval };
+    }
+    return {};
+}
+`
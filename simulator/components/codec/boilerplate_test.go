@@ -0,0 +1,39 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBoilerplatesAreUsableCodecs(t *testing.T) {
+	e := NewExecutor(&ExecutorConfig{MaxVMs: 1})
+
+	for _, kind := range BoilerplateTypes {
+		script, err := Boilerplate(kind)
+		if err != nil {
+			t.Fatalf("Boilerplate(%q) error = %v", kind, err)
+		}
+		if errs := CheckScript(script); len(errs) != 0 {
+			t.Errorf("%s: CheckScript() = %v", kind, errs)
+		}
+		if warnings := Lint(script); len(warnings) != 0 {
+			t.Errorf("%s: Lint() = %v", kind, warnings)
+		}
+
+		device := &fakeDevice{interval: time.Minute}
+		bytes, _, err := e.ExecuteEncode(script, NewState("0102030405060708"), device)
+		if err != nil || len(bytes) == 0 {
+			t.Errorf("%s: ExecuteEncode() = (%v, %v), want a payload", kind, bytes, err)
+		}
+	}
+}
+
+func TestBoilerplateUnknownType(t *testing.T) {
+	if _, err := Boilerplate("nope"); !errors.Is(err, ErrUnknownBoilerplate) {
+		t.Errorf("Boilerplate(nope) error = %v, want ErrUnknownBoilerplate", err)
+	}
+	if script, err := Boilerplate(""); err != nil || script == "" {
+		t.Errorf("Boilerplate(\"\") = (%q, %v), want the generic boilerplate", script, err)
+	}
+}
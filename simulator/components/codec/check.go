@@ -0,0 +1,149 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/parser"
+)
+
+// CheckTimeout bounds the top-level execution of a script being checked
+const CheckTimeout = time.Second
+
+// Stages of a script check, reported with each error
+const (
+	StageSyntax     = "syntax"     // The script does not compile
+	StageRuntime    = "runtime"    // The top-level code throws or does not terminate
+	StageDefinition = "definition" // OnUplink/OnDownlink are missing or not functions
+)
+
+// ScriptError describes one problem found while checking a codec script.
+// Line and Column start at 1 and are 0 when the position is unknown.
+type ScriptError struct {
+	Stage   string `json:"stage"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+func (e ScriptError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s error at line %d, column %d: %s", e.Stage, e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%s error: %s", e.Stage, e.Message)
+}
+
+// CheckScript compiles the script and runs its top-level code in a throwaway VM
+// with the codec helpers injected, then checks that OnUplink is a function and
+// that OnDownlink, when defined, is one too. OnUplink/OnDownlink are never called.
+// Returns nil if the script is usable as a codec.
+func CheckScript(script string) []ScriptError {
+	// Parse separately from compiling: goja.Compile drops the positions of parser errors
+	ast, err := parser.ParseFile(nil, "codec.js", script, 0)
+	if err != nil {
+		return parseErrors(err)
+	}
+	program, err := goja.CompileAST(ast, false)
+	if err != nil {
+		return []ScriptError{compileError(err)}
+	}
+
+	vm := newRuntime()
+	if err := injectCheckHelpers(vm); err != nil {
+		return []ScriptError{{Stage: StageRuntime, Message: err.Error()}}
+	}
+
+	timer := time.AfterFunc(CheckTimeout, func() {
+		vm.Interrupt(ErrExecutionTimeout)
+	})
+	_, err = vm.RunProgram(program)
+	timer.Stop()
+	if err != nil {
+		return []ScriptError{runtimeError(err)}
+	}
+
+	var errs []ScriptError
+	if _, ok := goja.AssertFunction(vm.Get("OnUplink")); !ok {
+		errs = append(errs, ScriptError{Stage: StageDefinition, Message: "OnUplink must be defined as a function"})
+	}
+	if v := vm.Get("OnDownlink"); v != nil && !goja.IsUndefined(v) {
+		if _, ok := goja.AssertFunction(v); !ok {
+			errs = append(errs, ScriptError{Stage: StageDefinition, Message: "OnDownlink is defined but is not a function"})
+		}
+	}
+	return errs
+}
+
+// injectCheckHelpers injects the helpers available to codecs, so that
+// top-level code using them does not fail with a ReferenceError
+func injectCheckHelpers(vm *goja.Runtime) error {
+	if err := InjectConversionHelpers(vm); err != nil {
+		return fmt.Errorf("failed to inject conversion helpers: %w", err)
+	}
+	if err := InjectMathHelpers(vm); err != nil {
+		return fmt.Errorf("failed to inject math helpers: %w", err)
+	}
+	if err := InjectStateHelpers(vm, NewState("")); err != nil {
+		return fmt.Errorf("failed to inject state helpers: %w", err)
+	}
+	return nil
+}
+
+// parseErrors converts the errors reported by the parser, keeping their positions
+func parseErrors(err error) []ScriptError {
+	var parseErrs parser.ErrorList
+	if !errors.As(err, &parseErrs) {
+		return []ScriptError{{Stage: StageSyntax, Message: err.Error()}}
+	}
+	errs := make([]ScriptError, 0, len(parseErrs))
+	for i, pe := range parseErrs {
+		// The parser repeats the same error while recovering (e.g. at the end of input)
+		if i > 0 && *pe == *parseErrs[i-1] {
+			continue
+		}
+		errs = append(errs, ScriptError{
+			Stage:   StageSyntax,
+			Message: pe.Message,
+			Line:    pe.Position.Line,
+			Column:  pe.Position.Column,
+		})
+	}
+	return errs
+}
+
+// compileError converts a goja compilation error (e.g. a duplicate declaration), keeping its position
+func compileError(err error) ScriptError {
+	var syntaxErr *goja.CompilerSyntaxError
+	if errors.As(err, &syntaxErr) {
+		se := ScriptError{Stage: StageSyntax, Message: syntaxErr.Message}
+		if syntaxErr.File != nil {
+			pos := syntaxErr.File.Position(syntaxErr.Offset)
+			se.Line, se.Column = pos.Line, pos.Column
+		}
+		return se
+	}
+	return ScriptError{Stage: StageSyntax, Message: err.Error()}
+}
+
+// runtimeError converts an error thrown by the top-level code, keeping the
+// position of the innermost stack frame
+func runtimeError(err error) ScriptError {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		return ScriptError{Stage: StageRuntime, Message: fmt.Sprintf("%v after %v", ErrExecutionTimeout, CheckTimeout)}
+	}
+	var exception *goja.Exception
+	if errors.As(err, &exception) {
+		se := ScriptError{Stage: StageRuntime, Message: exception.Value().String()}
+		for _, frame := range exception.Stack() {
+			if pos := frame.Position(); pos.Line > 0 {
+				se.Line, se.Column = pos.Line, pos.Column
+				break
+			}
+		}
+		return se
+	}
+	return ScriptError{Stage: StageRuntime, Message: err.Error()}
+}
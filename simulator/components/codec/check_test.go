@@ -0,0 +1,43 @@
+package codec
+
+import "testing"
+
+func TestCheckScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		stage  string // expected stage of the first error, "" if valid
+		line   int
+	}{
+		{"valid", "function OnUplink() { return [1]; }", "", 0},
+		{"valid with downlink", "function OnUplink() { return []; }\nfunction OnDownlink(b, p) {}", "", 0},
+		{"arrow function", "var OnUplink = () => [hexToBytes('01')[0]];", "", 0},
+		{"syntax error", "function OnUplink() {\n  return [1;\n}", StageSyntax, 2},
+		{"top-level throw", "\nthrow new Error('boom');\nfunction OnUplink() {}", StageRuntime, 2},
+		{"missing uplink", "function OnDownlink() {}", StageDefinition, 0},
+		{"uplink not a function", "var OnUplink = 1;", StageDefinition, 0},
+		{"commented out", "// function OnUplink() {}", StageDefinition, 0},
+		{"endless loop", "while (true) {}", StageRuntime, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := CheckScript(tt.script)
+			if tt.stage == "" {
+				if len(errs) != 0 {
+					t.Fatalf("CheckScript() = %v, want no errors", errs)
+				}
+				return
+			}
+			if len(errs) == 0 {
+				t.Fatalf("CheckScript() returned no errors, want a %s error", tt.stage)
+			}
+			if errs[0].Stage != tt.stage {
+				t.Errorf("stage = %q, want %q (%v)", errs[0].Stage, tt.stage, errs[0])
+			}
+			if tt.line > 0 && errs[0].Line != tt.line {
+				t.Errorf("line = %d, want %d (%v)", errs[0].Line, tt.line, errs[0])
+			}
+		})
+	}
+}
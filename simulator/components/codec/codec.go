@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -28,6 +29,20 @@ type CodecMetadata struct {
 	Name string `json:"name"`
 }
 
+// CodecExport is the shareable form of a codec, without the instance-specific ID
+type CodecExport struct {
+	Name   string `json:"name"`
+	Script string `json:"script"`
+}
+
+// CodecImportResult reports the outcome of importing one codec
+type CodecImportResult struct {
+	Name   string `json:"name"`
+	ID     int    `json:"id,omitempty"`    // ID assigned to the added codec
+	Status string `json:"status"`          // "added", "skipped" (name already used) or "failed"
+	Error  string `json:"error,omitempty"` // Reason of the skip or failure
+}
+
 // NewCodec creates a new codec (ID must be set by the registry)
 func NewCodec(name, script string) *Codec {
 	return &Codec{
@@ -45,14 +60,17 @@ func (c *Codec) Validate() error {
 		return fmt.Errorf("%w: script is required", ErrInvalidCodecFormat)
 	}
 
-	// Check if script contains OnUplink function (required)
-	// OnDownlink is optional
-	hasOnUplink := strings.Contains(c.Script, "function OnUplink")
-
-	if !hasOnUplink {
+	// Cheap pre-filter: a script that never mentions OnUplink cannot define it
+	if !strings.Contains(c.Script, "OnUplink") {
 		return fmt.Errorf("%w: script must contain OnUplink function (OnDownlink is optional)", ErrInvalidCodecFormat)
 	}
 
+	// The real decision: OnUplink must be a function once the script has run,
+	// whatever the way it is declared (and not only mentioned in a comment)
+	if errs := CheckScript(c.Script); len(errs) > 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidCodecFormat, errs[0])
+	}
+
 	return nil
 }
 
@@ -64,6 +82,14 @@ func (c *Codec) Metadata() CodecMetadata {
 	}
 }
 
+// Export returns the shareable form of the codec
+func (c *Codec) Export() CodecExport {
+	return CodecExport{
+		Name:   c.Name,
+		Script: c.Script,
+	}
+}
+
 // Clone creates a deep copy of the codec
 func (c *Codec) Clone() *Codec {
 	return &Codec{
@@ -153,6 +179,16 @@ func (cl *CodecLibrary) List() []CodecMetadata {
 	return metadata
 }
 
+// All returns a copy of every codec, scripts included, ordered by ID
+func (cl *CodecLibrary) All() []*Codec {
+	codecs := make([]*Codec, 0, len(cl.codecs))
+	for _, codec := range cl.codecs {
+		codecs = append(codecs, codec.Clone())
+	}
+	sort.Slice(codecs, func(i, j int) bool { return codecs[i].ID < codecs[j].ID })
+	return codecs
+}
+
 // Count returns the number of codecs
 func (cl *CodecLibrary) Count() int {
 	return len(cl.codecs)
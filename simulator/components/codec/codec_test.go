@@ -0,0 +1,63 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCodecLibraryAllIsOrderedCopy(t *testing.T) {
+	cl := NewCodecLibrary()
+	for _, name := range []string{"c", "a", "b"} {
+		if err := cl.Add(NewCodec(name, "function OnUplink() { return []; }")); err != nil {
+			t.Fatalf("Add(%s): %v", name, err)
+		}
+	}
+
+	all := cl.All()
+	if len(all) != 3 {
+		t.Fatalf("All() returned %d codecs, want 3", len(all))
+	}
+	for i, c := range all {
+		if c.ID != i+1 {
+			t.Errorf("All()[%d].ID = %d, want %d", i, c.ID, i+1)
+		}
+		if c.Script == "" {
+			t.Errorf("All()[%d] has no script", i)
+		}
+	}
+
+	all[0].Script = "changed"
+	if c, _ := cl.Get(1); c.Script == "changed" {
+		t.Error("All() returned the stored codec instead of a copy")
+	}
+}
+
+func TestCodecValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		valid  bool
+	}{
+		{"function declaration", "function OnUplink() { return [1]; }", true},
+		{"function expression", "var OnUplink = function() { return [1]; };", true},
+		{"arrow function", "const OnUplink = () => [1];\nvar OnDownlink = (bytes, fPort) => {};", true},
+		{"minified", "var a=1;function OnUplink(){return[a,2]}function OnDownlink(b,p){a=b[0]}", true},
+		{"commented out", "// function OnUplink() { return [1]; }\nfunction OnDownlink() {}", false},
+		{"block comment", "/* function OnUplink() {} */", false},
+		{"string only", "var name = 'function OnUplink';", false},
+		{"no mention", "function OnDownlink() {}", false},
+		{"syntax error", "function OnUplink( { return [1]; }", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewCodec("test", tt.script).Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidCodecFormat) {
+				t.Errorf("Validate() = %v, want ErrInvalidCodecFormat", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package codec
+
+import "time"
+
+// ErrorHistorySize is the number of recent codec errors kept per device
+const ErrorHistorySize = 20
+
+// Codec operations reported with the errors
+const (
+	OperationEncode = "encode" // OnUplink, at transmit time
+	OperationDecode = "decode" // OnDownlink, on a received downlink
+)
+
+// ExecutionError is a failed codec execution for a device
+type ExecutionError struct {
+	CodecID   int       `json:"codecId"`
+	Operation string    `json:"operation"` // OperationEncode or OperationDecode
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+// recordError appends an execution error to the device's history, dropping the oldest ones
+func (r *Registry) recordError(devEUI string, codecID int, operation string, err error) {
+	r.errorsMu.Lock()
+	defer r.errorsMu.Unlock()
+
+	history := append(r.errors[devEUI], ExecutionError{
+		CodecID:   codecID,
+		Operation: operation,
+		Message:   err.Error(),
+		Time:      time.Now(),
+	})
+	if len(history) > ErrorHistorySize {
+		history = history[len(history)-ErrorHistorySize:]
+	}
+	r.errors[devEUI] = history
+}
+
+// GetErrors returns the recent codec execution errors of a device, oldest first
+func (r *Registry) GetErrors(devEUI string) []ExecutionError {
+	r.errorsMu.Lock()
+	defer r.errorsMu.Unlock()
+
+	history := make([]ExecutionError, len(r.errors[devEUI]))
+	copy(history, r.errors[devEUI])
+	return history
+}
@@ -0,0 +1,43 @@
+package codec
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRegistryErrorHistory(t *testing.T) {
+	r := NewRegistry(nil)
+	defer r.Close()
+
+	for i := 0; i < ErrorHistorySize+5; i++ {
+		r.recordError("dev1", 1, OperationEncode, fmt.Errorf("error %d", i))
+	}
+
+	history := r.GetErrors("dev1")
+	if len(history) != ErrorHistorySize {
+		t.Fatalf("GetErrors() returned %d errors, want %d", len(history), ErrorHistorySize)
+	}
+	if history[0].Message != "error 5" || history[len(history)-1].Message != fmt.Sprintf("error %d", ErrorHistorySize+4) {
+		t.Errorf("history spans %q..%q, want the most recent errors", history[0].Message, history[len(history)-1].Message)
+	}
+	if got := r.GetErrors("dev2"); len(got) != 0 {
+		t.Errorf("GetErrors() of another device = %v, want none", got)
+	}
+}
+
+func TestRegistryRecordsEncodeErrors(t *testing.T) {
+	r := NewRegistry(nil)
+	defer r.Close()
+
+	if _, _, err := r.EncodePayload(9999, "dev1", nil, 0); err == nil {
+		t.Fatal("EncodePayload() with an unknown codec succeeded")
+	}
+
+	history := r.GetErrors("dev1")
+	if len(history) != 1 || history[0].Operation != OperationEncode || history[0].CodecID != 9999 {
+		t.Fatalf("GetErrors() = %+v, want one encode error of codec 9999", history)
+	}
+	if history[0].Time.IsZero() {
+		t.Error("error recorded without a timestamp")
+	}
+}
@@ -3,7 +3,9 @@ package codec
 import (
 	"errors"
 	"fmt"
+	"runtime/metrics"
 	"sync"
+	"time"
 
 	"github.com/dop251/goja"
 )
@@ -15,33 +17,51 @@ var (
 	ErrOnUplinkNotFound = errors.New("OnUplink function not found")
 	// ErrInvalidReturnType is returned when the codec returns an invalid type
 	ErrInvalidReturnType = errors.New("invalid return type from codec")
+	// ErrExecutionTimeout is returned when a codec runs longer than the configured timeout
+	ErrExecutionTimeout = errors.New("codec execution timed out")
+	// ErrResourceLimit is returned when a codec exceeds the configured memory or call stack limit
+	ErrResourceLimit = errors.New("codec exceeded its resource limits")
 )
 
 // Executor manages JavaScript codec execution with goja
 type Executor struct {
-	vmPool  *VMPool
-	metrics *ExecutorMetrics
+	vmPool           *VMPool
+	metrics          *ExecutorMetrics
+	timeout          time.Duration
+	maxMemory        uint64
+	maxCallStackSize int
 }
 
 // ExecutorMetrics tracks codec execution statistics
 type ExecutorMetrics struct {
-	TotalExecutions uint64
-	TotalErrors     uint64
-	TotalTimeouts   uint64
-	mu              sync.RWMutex
+	TotalExecutions     uint64
+	TotalErrors         uint64
+	TotalTimeouts       uint64
+	TotalResourceLimits uint64 // executions aborted by the memory or call stack limit
+	mu                  sync.RWMutex
 }
 
 // ExecutorConfig holds configuration for the Executor
 type ExecutorConfig struct {
 	MaxVMs        int
+	Timeout       time.Duration // Max wall-clock time of one execution (0 = no limit)
 	EnableMetrics bool
+
+	// MaxMemory is the heap growth, in bytes, after which an execution is aborted (0 = no limit).
+	// The heap is shared by the whole process and sampled every memorySampleInterval, so the
+	// limit catches runaway allocation loops but must leave room for concurrent executions. The
+	// growth also counts the allocations of the rest of the simulator, so it is off by default.
+	MaxMemory        uint64
+	MaxCallStackSize int // Max depth of JavaScript calls (0 = no limit)
 }
 
 // DefaultExecutorConfig returns default configuration
 func DefaultExecutorConfig() *ExecutorConfig {
 	return &ExecutorConfig{
-		MaxVMs:        100,
-		EnableMetrics: true,
+		MaxVMs:           100,
+		Timeout:          time.Second,
+		EnableMetrics:    true,
+		MaxCallStackSize: 1000,
 	}
 }
 
@@ -52,9 +72,131 @@ func NewExecutor(config *ExecutorConfig) *Executor {
 	}
 
 	return &Executor{
-		vmPool:  NewVMPool(config.MaxVMs),
-		metrics: &ExecutorMetrics{},
+		vmPool:           NewVMPool(config.MaxVMs),
+		metrics:          &ExecutorMetrics{},
+		timeout:          config.Timeout,
+		maxMemory:        config.MaxMemory,
+		maxCallStackSize: config.MaxCallStackSize,
+	}
+}
+
+// memorySampleInterval is the period at which the heap is checked against MaxMemory
+const memorySampleInterval = 10 * time.Millisecond
+
+// heapMetric is the runtime metric compared against MaxMemory
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// startLimits interrupts the VM once the timeout (the configured one when 0) elapses or the
+// heap grows by more than MaxMemory, and applies the call stack limit. The returned function stops the
+// watchers, clears a pending interrupt so the VM can be reused, and returns the error
+// describing the limit that interrupted the execution, if any.
+func (e *Executor) startLimits(vm *goja.Runtime, timeout time.Duration) func() error {
+	if e.maxCallStackSize > 0 {
+		vm.SetMaxCallStackSize(e.maxCallStackSize)
+	}
+
+	var mu sync.Mutex
+	var limit error
+	stopped := false
+	interrupt := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !stopped && limit == nil {
+			limit = err
+			vm.Interrupt(err)
+		}
+	}
+
+	if timeout <= 0 {
+		timeout = e.timeout
+	}
+	var timer *time.Timer
+	if timeout > 0 {
+		timer = time.AfterFunc(timeout, func() {
+			interrupt(fmt.Errorf("%w after %v", ErrExecutionTimeout, timeout))
+		})
+	}
+
+	var done chan struct{}
+	if e.maxMemory > 0 {
+		done = make(chan struct{})
+		go e.watchMemory(done, interrupt)
+	}
+
+	return func() error {
+		if timer != nil {
+			timer.Stop()
+		}
+		if done != nil {
+			close(done)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		vm.ClearInterrupt()
+		return limit
+	}
+}
+
+// watchMemory samples the heap until done is closed, and calls interrupt once it has
+// grown by more than MaxMemory since the execution started
+func (e *Executor) watchMemory(done <-chan struct{}, interrupt func(error)) {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	heap := func() uint64 {
+		metrics.Read(sample)
+		if sample[0].Value.Kind() != metrics.KindUint64 {
+			return 0
+		}
+		return sample[0].Value.Uint64()
+	}
+
+	start := heap()
+	ticker := time.NewTicker(memorySampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if used := heap(); used > start && used-start > e.maxMemory {
+				interrupt(fmt.Errorf("%w: more than %d bytes allocated", ErrResourceLimit, e.maxMemory))
+				return
+			}
+		}
+	}
+}
+
+// limitError returns the error of a failed execution: the limit that interrupted it if
+// any, a resource limit for a stack overflow, or err itself
+func (e *Executor) limitError(err, limit error) error {
+	if err == nil {
+		return nil
+	}
+	if limit != nil {
+		return limit
+	}
+	var overflow *goja.StackOverflowError
+	if errors.As(err, &overflow) {
+		return fmt.Errorf("%w: call stack deeper than %d", ErrResourceLimit, e.maxCallStackSize)
 	}
+	return err
+}
+
+// recordError updates the error metrics, counting timeouts and resource limits separately
+func (e *Executor) recordError(err error) {
+	if err == nil || e.metrics == nil {
+		return
+	}
+	e.metrics.mu.Lock()
+	e.metrics.TotalErrors++
+	if errors.Is(err, ErrExecutionTimeout) {
+		e.metrics.TotalTimeouts++
+	}
+	if errors.Is(err, ErrResourceLimit) {
+		e.metrics.TotalResourceLimits++
+	}
+	e.metrics.mu.Unlock()
 }
 
 // ExecuteEncode executes the OnUplink function from a JavaScript codec
@@ -65,6 +207,11 @@ func NewExecutor(config *ExecutorConfig) *Executor {
 //
 // Returns the encoded byte array, the fPort (from device or codec), and any error
 func (e *Executor) ExecuteEncode(script string, state *State, device DeviceInterface) ([]byte, uint8, error) {
+	return e.ExecuteEncodeWithTimeout(script, state, device, 0)
+}
+
+// ExecuteEncodeWithTimeout is ExecuteEncode with a timeout overriding the configured one (0 = configured)
+func (e *Executor) ExecuteEncodeWithTimeout(script string, state *State, device DeviceInterface, timeout time.Duration) ([]byte, uint8, error) {
 	// Record metrics
 	if e.metrics != nil {
 		e.metrics.mu.Lock()
@@ -79,20 +226,18 @@ func (e *Executor) ExecuteEncode(script string, state *State, device DeviceInter
 	var err error
 
 	func() {
+		stopLimits := e.startLimits(vm, timeout)
 		defer func() {
 			if r := recover(); r != nil {
 				err = fmt.Errorf("codec panic: %v", r)
 			}
+			err = e.limitError(err, stopLimits())
 			e.vmPool.Put(vm)
 		}()
 		data, fPort, err = e.executeEncodeInVM(vm, script, state, device)
 	}()
 
-	if err != nil && e.metrics != nil {
-		e.metrics.mu.Lock()
-		e.metrics.TotalErrors++
-		e.metrics.mu.Unlock()
-	}
+	e.recordError(err)
 	return data, fPort, err
 }
 
@@ -103,6 +248,11 @@ func (e *Executor) executeEncodeInVM(vm *goja.Runtime, script string, state *Sta
 		return nil, 1, fmt.Errorf("failed to inject conversion helpers: %w", err)
 	}
 
+	// Inject math helpers (random, randomInt, gaussian)
+	if err := InjectMathHelpers(vm); err != nil {
+		return nil, 1, fmt.Errorf("failed to inject math helpers: %w", err)
+	}
+
 	// Inject state helper functions
 	if err := InjectStateHelpers(vm, state); err != nil {
 		return nil, 1, fmt.Errorf("failed to inject state helpers: %w", err)
@@ -127,8 +277,13 @@ func (e *Executor) executeEncodeInVM(vm *goja.Runtime, script string, state *Sta
 		return nil, 1, ErrOnUplinkNotFound
 	}
 
-	// Call OnUplink() with no arguments
-	result, err := onUplinkFunc(goja.Undefined())
+	// Call OnUplink(config), where config is the device's payload config overlaid
+	// with the fields set by setUplinkField (or undefined if both are empty)
+	config := goja.Undefined()
+	if merged := uplinkConfig(state, device); merged != nil {
+		config = vm.ToValue(merged)
+	}
+	result, err := onUplinkFunc(goja.Undefined(), config)
 	if err != nil {
 		return nil, 1, fmt.Errorf("OnUplink execution error (check JavaScript): %w", err)
 	}
@@ -143,6 +298,28 @@ func (e *Executor) executeEncodeInVM(vm *goja.Runtime, script string, state *Sta
 	return bytes, returnedFPort, nil
 }
 
+// uplinkConfig merges the device's payload config with the uplink fields stored in the state.
+// The device config is copied so the fields never leak into the persisted configuration.
+func uplinkConfig(state *State, device DeviceInterface) map[string]interface{} {
+	var payloadConfig map[string]interface{}
+	if device != nil {
+		payloadConfig = device.GetPayloadConfig()
+	}
+	fields := state.UplinkFields()
+	if fields == nil {
+		return payloadConfig
+	}
+
+	merged := make(map[string]interface{}, len(payloadConfig)+len(fields))
+	for k, v := range payloadConfig {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return merged
+}
+
 // ExecuteDecode executes the OnDownlink function from a JavaScript codec
 // Parameters:
 //   - script: The JavaScript code containing the OnDownlink function
@@ -151,9 +328,14 @@ func (e *Executor) executeEncodeInVM(vm *goja.Runtime, script string, state *Sta
 //   - state: Device state for stateful decoding
 //   - device: Device interface for accessing configuration
 //
-// OnDownlink is executed for its side effects (log, setState, setSendInterval).
-// Any return value from the JavaScript function is ignored.
-func (e *Executor) ExecuteDecode(script string, bytes []byte, fPort uint8, state *State, device DeviceInterface) error {
+// OnDownlink is executed for its side effects (log, setState, setUplinkField, setSendInterval).
+// Its return value, if any, is returned as the decoded object (nil without OnDownlink).
+func (e *Executor) ExecuteDecode(script string, bytes []byte, fPort uint8, state *State, device DeviceInterface) (interface{}, error) {
+	return e.ExecuteDecodeWithTimeout(script, bytes, fPort, state, device, 0)
+}
+
+// ExecuteDecodeWithTimeout is ExecuteDecode with a timeout overriding the configured one (0 = configured)
+func (e *Executor) ExecuteDecodeWithTimeout(script string, bytes []byte, fPort uint8, state *State, device DeviceInterface, timeout time.Duration) (interface{}, error) {
 	// Record metrics
 	if e.metrics != nil {
 		e.metrics.mu.Lock()
@@ -163,56 +345,63 @@ func (e *Executor) ExecuteDecode(script string, bytes []byte, fPort uint8, state
 
 	// Get a VM from the pool (blocks until one is available)
 	vm := e.vmPool.Get()
+	var decoded interface{}
 	var err error
 
 	func() {
+		stopLimits := e.startLimits(vm, timeout)
 		defer func() {
 			if r := recover(); r != nil {
 				err = fmt.Errorf("codec panic: %v", r)
 			}
+			err = e.limitError(err, stopLimits())
 			e.vmPool.Put(vm)
 		}()
-		err = e.executeDecodeInVM(vm, script, bytes, fPort, state, device)
+		decoded, err = e.executeDecodeInVM(vm, script, bytes, fPort, state, device)
 	}()
 
-	if err != nil && e.metrics != nil {
-		e.metrics.mu.Lock()
-		e.metrics.TotalErrors++
-		e.metrics.mu.Unlock()
+	e.recordError(err)
+	if err != nil {
+		return nil, err
 	}
-	return err
+	return decoded, nil
 }
 
 // executeDecodeInVM performs the actual decoding in the VM
-func (e *Executor) executeDecodeInVM(vm *goja.Runtime, script string, bytes []byte, fPort uint8, state *State, device DeviceInterface) error {
+func (e *Executor) executeDecodeInVM(vm *goja.Runtime, script string, bytes []byte, fPort uint8, state *State, device DeviceInterface) (interface{}, error) {
 	// Inject conversion helpers (hexToBytes, base64ToBytes)
 	if err := InjectConversionHelpers(vm); err != nil {
-		return fmt.Errorf("failed to inject conversion helpers: %w", err)
+		return nil, fmt.Errorf("failed to inject conversion helpers: %w", err)
+	}
+
+	// Inject math helpers (random, randomInt, gaussian)
+	if err := InjectMathHelpers(vm); err != nil {
+		return nil, fmt.Errorf("failed to inject math helpers: %w", err)
 	}
 
 	// Inject state helper functions
 	if err := InjectStateHelpers(vm, state); err != nil {
-		return fmt.Errorf("failed to inject state helpers: %w", err)
+		return nil, fmt.Errorf("failed to inject state helpers: %w", err)
 	}
 
 	// Inject device helpers (getSendInterval, setSendInterval, log)
 	if device != nil {
 		if err := InjectDeviceHelpers(vm, device); err != nil {
-			return fmt.Errorf("failed to inject device helpers: %w", err)
+			return nil, fmt.Errorf("failed to inject device helpers: %w", err)
 		}
 	}
 
 	// Execute the script to define the OnDownlink function
 	_, err := vm.RunString(script)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrInvalidScript, err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScript, err)
 	}
 
 	// Get the OnDownlink function (optional)
 	onDownlinkFunc, ok := goja.AssertFunction(vm.Get("OnDownlink"))
 	if !ok {
 		// OnDownlink is optional, nothing to do
-		return nil
+		return nil, nil
 	}
 
 	// Convert bytes to JS array
@@ -221,13 +410,13 @@ func (e *Executor) executeDecodeInVM(vm *goja.Runtime, script string, bytes []by
 		jsBytes[i] = b
 	}
 
-	// Call OnDownlink(bytes, fPort) - executed for side effects only
-	_, err = onDownlinkFunc(goja.Undefined(), vm.ToValue(jsBytes), vm.ToValue(fPort))
+	// Call OnDownlink(bytes, fPort) - executed for its side effects, the result is optional
+	result, err := onDownlinkFunc(goja.Undefined(), vm.ToValue(jsBytes), vm.ToValue(fPort))
 	if err != nil {
-		return fmt.Errorf("OnDownlink execution error: %w", err)
+		return nil, fmt.Errorf("OnDownlink execution error: %w", err)
 	}
 
-	return nil
+	return result.Export(), nil
 }
 
 // convertToBytesWithFPort converts a goja.Value to a byte slice and extracts fPort if present
@@ -334,6 +523,7 @@ func (e *Executor) ResetMetrics() {
 	e.metrics.TotalExecutions = 0
 	e.metrics.TotalErrors = 0
 	e.metrics.TotalTimeouts = 0
+	e.metrics.TotalResourceLimits = 0
 }
 
 // Close closes the executor and releases resources
@@ -0,0 +1,163 @@
+package codec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDevice implements DeviceInterface for executor tests
+type fakeDevice struct {
+	interval      time.Duration
+	payloadConfig map[string]interface{}
+}
+
+func (d *fakeDevice) GetSendInterval() time.Duration                 { return d.interval }
+func (d *fakeDevice) SetSendInterval(interval time.Duration)         { d.interval = interval }
+func (d *fakeDevice) GetPayloadConfig() map[string]interface{}       { return d.payloadConfig }
+func (d *fakeDevice) Print(content string, err error, printType int) {}
+
+func TestSetUplinkFieldRoundTrip(t *testing.T) {
+	script := `
+function OnDownlink(bytes, fPort) {
+    setUplinkField('mode', bytes[0]);
+}
+function OnUplink(config) {
+    return { fPort: 10, bytes: [config.mode, config.base] };
+}`
+	e := NewExecutor(&ExecutorConfig{MaxVMs: 1})
+	state := NewState("0102030405060708")
+	device := &fakeDevice{payloadConfig: map[string]interface{}{"mode": 1, "base": 7}}
+
+	bytes, _, err := e.ExecuteEncode(script, state, device)
+	if err != nil {
+		t.Fatalf("ExecuteEncode: %v", err)
+	}
+	if bytes[0] != 1 || bytes[1] != 7 {
+		t.Fatalf("before downlink got %v, want [1 7]", bytes)
+	}
+
+	if _, err := e.ExecuteDecode(script, []byte{3}, 10, state, device); err != nil {
+		t.Fatalf("ExecuteDecode: %v", err)
+	}
+
+	bytes, fPort, err := e.ExecuteEncode(script, state, device)
+	if err != nil {
+		t.Fatalf("ExecuteEncode: %v", err)
+	}
+	if fPort != 10 || bytes[0] != 3 || bytes[1] != 7 {
+		t.Fatalf("after downlink got fPort %d bytes %v, want fPort 10 bytes [3 7]", fPort, bytes)
+	}
+	if device.payloadConfig["mode"] != 1 {
+		t.Errorf("device payload config was modified: %v", device.payloadConfig)
+	}
+}
+
+func TestExecuteEncodeTimeout(t *testing.T) {
+	e := NewExecutor(&ExecutorConfig{MaxVMs: 1, Timeout: 50 * time.Millisecond})
+	state := NewState("0102030405060708")
+
+	_, _, err := e.ExecuteEncode(`function OnUplink() { while (true) {} }`, state, nil)
+	if !errors.Is(err, ErrExecutionTimeout) {
+		t.Fatalf("got %v, want ErrExecutionTimeout", err)
+	}
+	if m := e.GetMetrics(); m.TotalTimeouts != 1 {
+		t.Errorf("TotalTimeouts = %d, want 1", m.TotalTimeouts)
+	}
+
+	// The interrupted VM goes back to the pool and must be usable again
+	bytes, _, err := e.ExecuteEncode(`function OnUplink() { return [1]; }`, state, nil)
+	if err != nil || len(bytes) != 1 {
+		t.Fatalf("after timeout got (%v, %v), want ([1], nil)", bytes, err)
+	}
+}
+
+func TestExecuteWithTimeoutOverride(t *testing.T) {
+	r := NewRegistry(&ExecutorConfig{MaxVMs: 1, Timeout: 20 * time.Millisecond})
+	defer r.Close()
+	c := NewCodec("slow", `
+function OnUplink() { var end = Date.now() + 100; while (Date.now() < end) {} return [1]; }
+function OnDownlink() { while (true) {} }`)
+	if err := r.AddCodec(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// The device timeout replaces the executor default, longer or shorter
+	if _, _, err := r.EncodePayload(c.ID, "dev1", nil, 0); !errors.Is(err, ErrExecutionTimeout) {
+		t.Errorf("EncodePayload() with the default timeout = %v, want ErrExecutionTimeout", err)
+	}
+	if bytes, _, err := r.EncodePayload(c.ID, "dev1", nil, 5*time.Second); err != nil || len(bytes) != 1 {
+		t.Errorf("EncodePayload() with a device timeout = (%v, %v), want ([1], nil)", bytes, err)
+	}
+
+	_, err := r.DecodePayload(c.ID, "dev1", []byte{1}, 1, nil, 5*time.Millisecond)
+	if !errors.Is(err, ErrExecutionTimeout) || !strings.Contains(err.Error(), "after 5ms") {
+		t.Errorf("DecodePayload() = %v, want ErrExecutionTimeout after 5ms", err)
+	}
+}
+
+func TestExecuteEncodeMemoryLimit(t *testing.T) {
+	e := NewExecutor(&ExecutorConfig{MaxVMs: 1, Timeout: 10 * time.Second, MaxMemory: 16 << 20})
+	state := NewState("0102030405060708")
+
+	script := `function OnUplink() { var a = []; while (true) { a.push("x".repeat(1024) + a.length); } }`
+	_, _, err := e.ExecuteEncode(script, state, nil)
+	if !errors.Is(err, ErrResourceLimit) {
+		t.Fatalf("got %v, want ErrResourceLimit", err)
+	}
+	if m := e.GetMetrics(); m.TotalResourceLimits != 1 || m.TotalTimeouts != 0 {
+		t.Errorf("TotalResourceLimits/TotalTimeouts = %d/%d, want 1/0", m.TotalResourceLimits, m.TotalTimeouts)
+	}
+
+	bytes, _, err := e.ExecuteEncode(`function OnUplink() { return [1]; }`, state, nil)
+	if err != nil || len(bytes) != 1 {
+		t.Fatalf("after memory limit got (%v, %v), want ([1], nil)", bytes, err)
+	}
+}
+
+func TestDefaultExecutorConfigHasNoMemoryLimit(t *testing.T) {
+	// The heap growth counts the whole simulator, so the limit has to be chosen by the user
+	if got := DefaultExecutorConfig().MaxMemory; got != 0 {
+		t.Errorf("default MaxMemory = %d, want 0 (no limit)", got)
+	}
+}
+
+func TestExecuteDecodeCallStackLimit(t *testing.T) {
+	e := NewExecutor(&ExecutorConfig{MaxVMs: 1, MaxCallStackSize: 100})
+	state := NewState("0102030405060708")
+
+	script := `function depth(n) { return n === 0 ? 0 : 1 + depth(n - 1); }
+function OnDownlink(bytes) { return depth(bytes[0]); }`
+
+	if _, err := e.ExecuteDecode(script, []byte{50}, 1, state, nil); err != nil {
+		t.Fatalf("50 calls deep: %v", err)
+	}
+	_, err := e.ExecuteDecode(script, []byte{200}, 1, state, nil)
+	if !errors.Is(err, ErrResourceLimit) {
+		t.Fatalf("200 calls deep: got %v, want ErrResourceLimit", err)
+	}
+	if m := e.GetMetrics(); m.TotalResourceLimits != 1 {
+		t.Errorf("TotalResourceLimits = %d, want 1", m.TotalResourceLimits)
+	}
+}
+
+func TestExecuteDecodeReturnsObject(t *testing.T) {
+	e := NewExecutor(&ExecutorConfig{MaxVMs: 1})
+	state := NewState("0102030405060708")
+
+	decoded, err := e.ExecuteDecode(`function OnDownlink(bytes, fPort) { return { port: fPort, level: bytes[0] }; }`, []byte{7}, 3, state, nil)
+	if err != nil {
+		t.Fatalf("ExecuteDecode: %v", err)
+	}
+	obj, ok := decoded.(map[string]interface{})
+	if !ok || obj["port"] != int64(3) || obj["level"] != int64(7) {
+		t.Fatalf("got %#v, want {port: 3, level: 7}", decoded)
+	}
+
+	// Scripts without a return value decode to nil
+	decoded, err = e.ExecuteDecode(`function OnDownlink(bytes) { setState("x", bytes[0]); }`, []byte{1}, 1, state, nil)
+	if err != nil || decoded != nil {
+		t.Fatalf("got (%#v, %v), want (nil, nil)", decoded, err)
+	}
+}
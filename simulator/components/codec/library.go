@@ -4,14 +4,16 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"strconv"
 	"time"
 
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/dop251/goja"
 )
 
 // InjectStateHelpers injects state management helper functions into the JavaScript VM
-// Simplified to only include getState and setState (all-purpose state management)
+// getState and setState are all-purpose; setUplinkField lets OnDownlink change the config seen by OnUplink
 func InjectStateHelpers(vm *goja.Runtime, state *State) error {
 	if vm == nil {
 		return fmt.Errorf("VM cannot be nil")
@@ -46,6 +48,18 @@ func InjectStateHelpers(vm *goja.Runtime, state *State) error {
 		return goja.Undefined()
 	})
 
+	// setUplinkField(name, value) - Set a field of the config passed to the next OnUplink calls
+	vm.Set("setUplinkField", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 2 {
+			panic(vm.NewTypeError("setUplinkField requires name and value arguments"))
+		}
+
+		name := call.Argument(0).String()
+		value := call.Argument(1).Export()
+		state.SetUplinkField(name, value)
+		return goja.Undefined()
+	})
+
 	return nil
 }
 
@@ -99,10 +113,61 @@ func InjectConversionHelpers(vm *goja.Runtime) error {
 	return nil
 }
 
+// InjectMathHelpers injects random value helper functions into the JavaScript VM
+// All helpers draw from the simulator's shared random source, so a configured seed makes them reproducible
+func InjectMathHelpers(vm *goja.Runtime) error {
+	if vm == nil {
+		return fmt.Errorf("VM cannot be nil")
+	}
+
+	// random(min, max) - Returns a float in [min, max)
+	vm.Set("random", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 2 {
+			panic(vm.NewTypeError("random requires min and max arguments"))
+		}
+
+		min := call.Argument(0).ToFloat()
+		max := call.Argument(1).ToFloat()
+		return vm.ToValue(min + (max-min)*util.RandFloat64())
+	})
+
+	// randomInt(min, max) - Returns an integer in [min, max] (both inclusive)
+	vm.Set("randomInt", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 2 {
+			panic(vm.NewTypeError("randomInt requires min and max arguments"))
+		}
+
+		min := call.Argument(0).ToInteger()
+		max := call.Argument(1).ToInteger()
+		if max < min {
+			min, max = max, min
+		}
+		span := uint64(max) - uint64(min) // exact even when max-min overflows int64
+		if span >= math.MaxInt {
+			panic(vm.NewTypeError("randomInt range too large"))
+		}
+		return vm.ToValue(min + int64(util.RandIntn(int(span+1))))
+	})
+
+	// gaussian(mean, stddev) - Returns a normally distributed float
+	vm.Set("gaussian", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 2 {
+			panic(vm.NewTypeError("gaussian requires mean and stddev arguments"))
+		}
+
+		mean := call.Argument(0).ToFloat()
+		stddev := call.Argument(1).ToFloat()
+		return vm.ToValue(mean + stddev*util.RandNormFloat64())
+	})
+
+	return nil
+}
+
 // DeviceInterface defines the interface for accessing device configuration from JavaScript
 type DeviceInterface interface {
 	GetSendInterval() time.Duration
 	SetSendInterval(time.Duration)
+	GetPayloadConfig() map[string]interface{}
 	Print(content string, err error, printType int)
 }
 
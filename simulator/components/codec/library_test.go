@@ -0,0 +1,130 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/dop251/goja"
+)
+
+func newMathVM(t *testing.T) *goja.Runtime {
+	t.Helper()
+	vm := goja.New()
+	if err := InjectMathHelpers(vm); err != nil {
+		t.Fatalf("InjectMathHelpers: %v", err)
+	}
+	return vm
+}
+
+// sample evaluates expr n times and returns the results
+func sample(t *testing.T, vm *goja.Runtime, expr string, n int) []float64 {
+	t.Helper()
+	values := make([]float64, n)
+	for i := range values {
+		v, err := vm.RunString(expr)
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		values[i] = v.ToFloat()
+	}
+	return values
+}
+
+func TestRandomVariesWithinRange(t *testing.T) {
+	vm := newMathVM(t)
+	values := sample(t, vm, "random(19, 20)", 200)
+
+	distinct := make(map[float64]bool)
+	for _, v := range values {
+		if v < 19 || v >= 20 {
+			t.Fatalf("random(19, 20) = %v, out of range", v)
+		}
+		distinct[v] = true
+	}
+	if len(distinct) < 100 {
+		t.Errorf("random(19, 20) returned %d distinct values out of 200", len(distinct))
+	}
+}
+
+func TestRandomIntCoversBounds(t *testing.T) {
+	vm := newMathVM(t)
+	values := sample(t, vm, "randomInt(1, 3)", 300)
+
+	seen := make(map[float64]int)
+	for _, v := range values {
+		if v != float64(int(v)) || v < 1 || v > 3 {
+			t.Fatalf("randomInt(1, 3) = %v, want integer in [1, 3]", v)
+		}
+		seen[v]++
+	}
+	for _, want := range []float64{1, 2, 3} {
+		if seen[want] == 0 {
+			t.Errorf("randomInt(1, 3) never returned %v", want)
+		}
+	}
+}
+
+func TestRandomIntRanges(t *testing.T) {
+	tests := []struct {
+		expr     string
+		min, max float64
+		wantErr  bool
+	}{
+		{"randomInt(5, 5)", 5, 5, false},
+		{"randomInt(3, 1)", 1, 3, false},
+		{"randomInt(-2, 2)", -2, 2, false},
+		{"randomInt(0, Number.MAX_SAFE_INTEGER)", 0, 1 << 53, false},
+		{"randomInt(-Number.MAX_VALUE, Number.MAX_VALUE)", 0, 0, true},
+		{"randomInt(-1e19, 0)", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			vm := newMathVM(t)
+			v, err := vm.RunString(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("%s = %v, want an error", tt.expr, v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", tt.expr, err)
+			}
+			if got := v.ToFloat(); got < tt.min || got > tt.max {
+				t.Errorf("%s = %v, want in [%v, %v]", tt.expr, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestGaussianDistribution(t *testing.T) {
+	vm := newMathVM(t)
+	values := sample(t, vm, "gaussian(21, 0.5)", 2000)
+
+	var sum, min, max float64
+	min, max = values[0], values[0]
+	for _, v := range values {
+		sum += v
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	mean := sum / float64(len(values))
+	if mean < 20.9 || mean > 21.1 {
+		t.Errorf("gaussian(21, 0.5) mean = %v, want about 21", mean)
+	}
+	if max-min < 1 {
+		t.Errorf("gaussian(21, 0.5) spread = %v, want values around the mean", max-min)
+	}
+}
+
+func TestMathHelpersRequireArguments(t *testing.T) {
+	vm := newMathVM(t)
+	for _, expr := range []string{"random(1)", "randomInt()", "gaussian(0)"} {
+		if _, err := vm.RunString(expr); err == nil {
+			t.Errorf("%s: expected an error for missing arguments", expr)
+		}
+	}
+}
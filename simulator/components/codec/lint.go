@@ -0,0 +1,187 @@
+package codec
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rules reported by Lint
+const (
+	RuleUnboundedLoop = "unbounded-loop" // while(true) or for(;;) with no break, return or throw
+	RuleUncheckedRead = "unchecked-read" // bytes[i + n] read in a loop only bounded by i < bytes.length
+)
+
+// LintWarning is a likely mistake found by Lint. Unlike ScriptError it does not
+// prevent the codec from being used. Line and Column start at 1.
+type LintWarning struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+var (
+	lintInfiniteLoop    = regexp.MustCompile(`\bwhile\s*\(\s*(?:true|1)\s*\)|\bfor\s*\(\s*;\s*;\s*\)`)
+	lintExits           = regexp.MustCompile(`\b(?:break|return|throw)\b`)
+	lintForLoop         = regexp.MustCompile(`\bfor\s*\(`)
+	lintForLengthCond   = regexp.MustCompile(`^[^;]*;\s*(\w+)\s*<\s*(\w+)\.length\s*;`)
+	lintWhileLengthCond = regexp.MustCompile(`\bwhile\s*\(\s*(\w+)\s*<\s*(\w+)\.length\s*\)`)
+)
+
+// Lint looks for common mistakes in a codec script with heuristics on its text:
+// loops that can never end, and loops bounded by i < bytes.length that read
+// bytes[i + n] or several bytes[i++] without checking the remaining length, which
+// yields undefined on truncated payloads. The script does not need to compile.
+func Lint(script string) []LintWarning {
+	code := stripCommentsAndStrings(script)
+
+	var warnings []LintWarning
+	warn := func(offset int, rule, message string) {
+		line, column := position(script, offset)
+		warnings = append(warnings, LintWarning{Rule: rule, Message: message, Line: line, Column: column})
+	}
+
+	for _, loc := range lintInfiniteLoop.FindAllStringIndex(code, -1) {
+		_, body := loopBody(code, loc[1])
+		if !lintExits.MatchString(body) {
+			warn(loc[0], RuleUnboundedLoop, fmt.Sprintf("%s never ends: the loop has no break, return or throw",
+				strings.Join(strings.Fields(code[loc[0]:loc[1]]), "")))
+		}
+	}
+
+	for _, loc := range lintForLoop.FindAllStringIndex(code, -1) {
+		headerEnd := matchingClose(code, loc[1]-1)
+		if headerEnd < 0 {
+			continue
+		}
+		if m := lintForLengthCond.FindStringSubmatch(code[loc[1]:headerEnd]); m != nil {
+			lintLengthBoundLoop(code, headerEnd+1, m[1], m[2], warn)
+		}
+	}
+	for _, m := range lintWhileLengthCond.FindAllStringSubmatchIndex(code, -1) {
+		lintLengthBoundLoop(code, m[1], code[m[2]:m[3]], code[m[4]:m[5]], warn)
+	}
+
+	sort.Slice(warnings, func(i, j int) bool {
+		if warnings[i].Line != warnings[j].Line {
+			return warnings[i].Line < warnings[j].Line
+		}
+		return warnings[i].Column < warnings[j].Column
+	})
+	return warnings
+}
+
+// lintLengthBoundLoop checks the body of a loop bounded by index < array.length
+// that starts after offset
+func lintLengthBoundLoop(code string, offset int, index, array string, warn func(int, string, string)) {
+	bodyStart, body := loopBody(code, offset)
+	if strings.Contains(body, array+".length") {
+		return // the loop checks the remaining length itself
+	}
+
+	idx, arr := regexp.QuoteMeta(index), regexp.QuoteMeta(array)
+	offsetRead := regexp.MustCompile(`\b` + arr + `\s*\[\s*` + idx + `\s*\+\s*(\d+)\s*\]`)
+	for _, m := range offsetRead.FindAllStringSubmatchIndex(body, -1) {
+		if body[m[2]:m[3]] == "0" {
+			continue
+		}
+		warn(bodyStart+m[0], RuleUncheckedRead, fmt.Sprintf(
+			"%s[%s + %s] is read while the loop only checks %s < %s.length; a truncated payload yields undefined",
+			array, index, body[m[2]:m[3]], index, array))
+		return
+	}
+
+	postIncrementRead := regexp.MustCompile(`\b` + arr + `\s*\[\s*` + idx + `\s*\+\+\s*\]`)
+	if reads := postIncrementRead.FindAllStringIndex(body, -1); len(reads) > 1 {
+		warn(bodyStart+reads[1][0], RuleUncheckedRead, fmt.Sprintf(
+			"%s[%s++] is read %d times per iteration while the loop only checks %s < %s.length; a truncated payload yields undefined",
+			array, index, len(reads), index, array))
+	}
+}
+
+// loopBody returns the offset and the text of the block or the single statement following offset
+func loopBody(code string, offset int) (int, string) {
+	start := offset
+	for start < len(code) && strings.ContainsRune(" \t\r\n", rune(code[start])) {
+		start++
+	}
+	end := len(code)
+	if start < len(code) && code[start] == '{' {
+		if i := matchingClose(code, start); i >= 0 {
+			end = i + 1
+		}
+	} else if i := strings.IndexByte(code[start:], ';'); i >= 0 {
+		end = start + i + 1
+	}
+	return start, code[start:end]
+}
+
+// matchingClose returns the offset of the bracket closing the one at open, or -1
+func matchingClose(code string, open int) int {
+	depth := 0
+	for i := open; i < len(code); i++ {
+		switch code[i] {
+		case '(', '{', '[':
+			depth++
+		case ')', '}', ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// stripCommentsAndStrings replaces comments and the content of string literals with
+// spaces, keeping offsets and line breaks, so that patterns only match code
+func stripCommentsAndStrings(script string) string {
+	out := []byte(script)
+	blank := func(from, to int) {
+		for i := from; i < to && i < len(out); i++ {
+			if out[i] != '\n' {
+				out[i] = ' '
+			}
+		}
+	}
+
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == '/' && i+1 < len(script) && script[i+1] == '/':
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			blank(i, i+end)
+			i += end
+		case c == '/' && i+1 < len(script) && script[i+1] == '*':
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 2
+			}
+			blank(i, i+end+4)
+			i += end + 3
+		case c == '"' || c == '\'' || c == '`':
+			j := i + 1
+			for j < len(script) && script[j] != c {
+				if script[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			blank(i+1, j)
+			i = j
+		}
+	}
+	return string(out)
+}
+
+// position converts a byte offset into a 1-based line and column
+func position(script string, offset int) (int, int) {
+	before := script[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := offset - strings.LastIndexByte(before, '\n')
+	return line, column
+}
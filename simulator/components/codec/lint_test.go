@@ -0,0 +1,82 @@
+package codec
+
+import "testing"
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		rules  []string
+		line   int
+	}{
+		{
+			name:   "clean",
+			script: "function OnUplink() { return [1, 2]; }",
+		},
+		{
+			name:   "while true without exit",
+			script: "function OnUplink() {\n  while (true) { log('x'); }\n}",
+			rules:  []string{RuleUnboundedLoop},
+			line:   2,
+		},
+		{
+			name:   "while true with break",
+			script: "function OnUplink() { var n = 0; while (true) { if (n++ > 3) break; } return [n]; }",
+		},
+		{
+			name:   "for ever without exit",
+			script: "function OnUplink() { for (;;) { log('x'); } }",
+			rules:  []string{RuleUnboundedLoop},
+			line:   1,
+		},
+		{
+			name: "unchecked offset read",
+			script: "function OnDownlink(bytes) {\n" +
+				"  for (var i = 0; i < bytes.length;) {\n" +
+				"    var channel = bytes[i];\n" +
+				"    var type = bytes[i + 1];\n" +
+				"    i += 2;\n" +
+				"  }\n" +
+				"}",
+			rules: []string{RuleUncheckedRead},
+			line:  4,
+		},
+		{
+			name: "offset read with length check",
+			script: "function OnDownlink(bytes) {\n" +
+				"  var i = 0;\n" +
+				"  while (i < bytes.length) {\n" +
+				"    if (i + 1 >= bytes.length) break;\n" +
+				"    setState('x', bytes[i + 1]);\n" +
+				"    i += 2;\n" +
+				"  }\n" +
+				"}",
+		},
+		{
+			name:   "several post-increment reads",
+			script: "function OnDownlink(bytes) { var i = 0; while (i < bytes.length) { var a = bytes[i++]; var b = bytes[i++]; } }",
+			rules:  []string{RuleUncheckedRead},
+			line:   1,
+		},
+		{
+			name:   "patterns inside comments and strings are ignored",
+			script: "// while (true) {}\nfunction OnUplink() { log('for (;;) {}'); return []; }",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := Lint(tt.script)
+			if len(warnings) != len(tt.rules) {
+				t.Fatalf("Lint() = %+v, want rules %v", warnings, tt.rules)
+			}
+			for i, w := range warnings {
+				if w.Rule != tt.rules[i] {
+					t.Errorf("warning %d rule = %q, want %q", i, w.Rule, tt.rules[i])
+				}
+				if w.Line != tt.line {
+					t.Errorf("warning %d line = %d, want %d", i, w.Line, tt.line)
+				}
+			}
+		})
+	}
+}
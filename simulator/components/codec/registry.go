@@ -3,7 +3,9 @@ package codec
 import (
 	"fmt"
 	"os"
+	"sort"
 	"sync"
+	"time"
 )
 
 // Registry manages codecs and device states for the entire simulator
@@ -12,6 +14,8 @@ type Registry struct {
 	library  *CodecLibrary
 	states   map[string]*State // DevEUI -> State
 	mu       sync.RWMutex
+	errors   map[string][]ExecutionError // DevEUI -> recent execution errors
+	errorsMu sync.Mutex
 }
 
 // NewRegistry creates a new codec registry
@@ -20,6 +24,7 @@ func NewRegistry(config *ExecutorConfig) *Registry {
 		executor: NewExecutor(config),
 		library:  NewCodecLibrary(),
 		states:   make(map[string]*State),
+		errors:   make(map[string][]ExecutionError),
 	}
 
 	// Load default codecs
@@ -42,17 +47,59 @@ func (r *Registry) GetOrCreateState(devEUI string) *State {
 	return state
 }
 
+// GetState returns a copy of the state of a device, or nil if its codec never ran
+func (r *Registry) GetState(devEUI string) *State {
+	r.mu.RLock()
+	state, exists := r.states[devEUI]
+	r.mu.RUnlock()
+	if !exists {
+		return nil
+	}
+	return state.Clone()
+}
+
+// ResetState drops the variables of the state of a device, if it has one
+func (r *Registry) ResetState(devEUI string) {
+	r.mu.RLock()
+	state, exists := r.states[devEUI]
+	r.mu.RUnlock()
+	if exists {
+		state.Reset()
+	}
+}
+
+// RemoveState drops the state of a device, once it is deleted
+func (r *Registry) RemoveState(devEUI string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.states, devEUI)
+}
+
+// StateDevEUIs returns the DevEUIs of the devices having a state, sorted
+func (r *Registry) StateDevEUIs() []string {
+	r.mu.RLock()
+	devEUIs := make([]string, 0, len(r.states))
+	for devEUI := range r.states {
+		devEUIs = append(devEUIs, devEUI)
+	}
+	r.mu.RUnlock()
+	sort.Strings(devEUIs)
+	return devEUIs
+}
+
 // EncodePayload encodes a payload using a codec
 // Parameters:
 //   - codecID: ID of the codec to use
 //   - devEUI: Device EUI for state management
 //   - device: Device interface for accessing configuration (send interval, etc.)
+//   - timeout: Max duration of the execution for this device (0 = executor default)
 //
 // Returns the encoded bytes, actual fPort (from codec or device), and any error
-func (r *Registry) EncodePayload(codecID int, devEUI string, device DeviceInterface) ([]byte, uint8, error) {
+func (r *Registry) EncodePayload(codecID int, devEUI string, device DeviceInterface, timeout time.Duration) ([]byte, uint8, error) {
 	// Get codec
 	codec, err := r.library.Get(codecID)
 	if err != nil {
+		r.recordError(devEUI, codecID, OperationEncode, err)
 		return nil, 1, fmt.Errorf("codec not found: %w", err)
 	}
 
@@ -60,8 +107,9 @@ func (r *Registry) EncodePayload(codecID int, devEUI string, device DeviceInterf
 	state := r.GetOrCreateState(devEUI)
 
 	// Execute encoding
-	bytes, returnedFPort, err := r.executor.ExecuteEncode(codec.Script, state, device)
+	bytes, returnedFPort, err := r.executor.ExecuteEncodeWithTimeout(codec.Script, state, device, timeout)
 	if err != nil {
+		r.recordError(devEUI, codecID, OperationEncode, err)
 		return nil, 1, fmt.Errorf("encoding failed: %w", err)
 	}
 
@@ -75,24 +123,55 @@ func (r *Registry) EncodePayload(codecID int, devEUI string, device DeviceInterf
 //   - bytes: Bytes to decode
 //   - fPort: LoRaWAN fPort
 //   - device: Device interface for accessing configuration
+//   - timeout: Max duration of the execution for this device (0 = executor default)
 //
-// OnDownlink is executed for its side effects (log, setState, setSendInterval).
-func (r *Registry) DecodePayload(codecID int, devEUI string, bytes []byte, fPort uint8, device DeviceInterface) error {
+// OnDownlink is executed for its side effects (log, setState, setSendInterval); the
+// object it returns, if any, is returned as the decoded payload.
+func (r *Registry) DecodePayload(codecID int, devEUI string, bytes []byte, fPort uint8, device DeviceInterface, timeout time.Duration) (interface{}, error) {
 	// Get codec
 	codec, err := r.library.Get(codecID)
 	if err != nil {
-		return fmt.Errorf("codec not found: %w", err)
+		r.recordError(devEUI, codecID, OperationDecode, err)
+		return nil, fmt.Errorf("codec not found: %w", err)
 	}
 
 	// Get or create state
 	state := r.GetOrCreateState(devEUI)
 
-	// Execute decoding (for side effects only)
-	if err := r.executor.ExecuteDecode(codec.Script, bytes, fPort, state, device); err != nil {
-		return fmt.Errorf("decoding failed: %w", err)
+	// Execute decoding (side effects, plus the object returned by OnDownlink if any)
+	decoded, err := r.executor.ExecuteDecodeWithTimeout(codec.Script, bytes, fPort, state, device, timeout)
+	if err != nil {
+		r.recordError(devEUI, codecID, OperationDecode, err)
+		return nil, fmt.Errorf("decoding failed: %w", err)
 	}
 
-	return nil
+	return decoded, nil
+}
+
+// TestDecode executes the OnDownlink function of a codec against a copy of the state of a
+// device, leaving the live state untouched. It returns the decoded payload with the state
+// before and after the execution. Errors are not recorded for the device. The timeout is the
+// one of DecodePayload.
+func (r *Registry) TestDecode(codecID int, devEUI string, bytes []byte, fPort uint8, device DeviceInterface, timeout time.Duration) (interface{}, *State, *State, error) {
+	codec, err := r.library.Get(codecID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("codec not found: %w", err)
+	}
+
+	r.mu.RLock()
+	live, exists := r.states[devEUI]
+	r.mu.RUnlock()
+	before := NewState(devEUI)
+	if exists {
+		before = live.Clone()
+	}
+	after := before.Clone()
+
+	decoded, err := r.executor.ExecuteDecodeWithTimeout(codec.Script, bytes, fPort, after, device, timeout)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("decoding failed: %w", err)
+	}
+	return decoded, before, after, nil
 }
 
 // AddCodec adds a codec to the library
@@ -120,6 +199,11 @@ func (r *Registry) ListCodecs() []CodecMetadata {
 	return r.library.List()
 }
 
+// ListFullCodecs returns every codec with its script, ordered by ID
+func (r *Registry) ListFullCodecs() []*Codec {
+	return r.library.All()
+}
+
 // GetCodecIDByName returns the ID of a codec by its name, or 0 if not found
 func (r *Registry) GetCodecIDByName(name string) int {
 	r.mu.RLock()
@@ -154,6 +238,24 @@ func (r *Registry) LoadDefaults() {
 	r.library.LoadDefaults()
 }
 
+// Reset restores the default codecs and drops every device state
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.library.Clear()
+	r.library.LoadDefaults()
+	r.states = make(map[string]*State)
+
+	r.errorsMu.Lock()
+	r.errors = make(map[string][]ExecutionError)
+	r.errorsMu.Unlock()
+}
+
+// GetMetrics returns the execution statistics of the codec executor
+func (r *Registry) GetMetrics() ExecutorMetrics {
+	return r.executor.GetMetrics()
+}
+
 // Close closes the registry and releases resources
 func (r *Registry) Close() {
 	if r.executor != nil {
@@ -1,10 +1,15 @@
 package codec
 
 import (
+	"reflect"
+	"sort"
 	"sync"
 	"time"
 )
 
+// UplinkFieldsVariable is the state variable holding the fields set by setUplinkField
+const UplinkFieldsVariable = "uplinkFields"
+
 // State holds the runtime state for a device's codec execution
 type State struct {
 	DevEUI    string                 `json:"devEUI"`
@@ -39,3 +44,103 @@ func (s *State) SetVariable(name string, value interface{}) {
 	s.Variables[name] = value
 	s.UpdatedAt = time.Now()
 }
+
+// SetUplinkField stores a field that is merged into the config passed to the next OnUplink calls
+func (s *State) SetUplinkField(name string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fields, ok := s.Variables[UplinkFieldsVariable].(map[string]interface{})
+	if !ok {
+		fields = make(map[string]interface{})
+		s.Variables[UplinkFieldsVariable] = fields
+	}
+	fields[name] = value
+	s.UpdatedAt = time.Now()
+}
+
+// UplinkFields returns a copy of the fields set by setUplinkField (nil if none)
+func (s *State) UplinkFields() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fields, ok := s.Variables[UplinkFieldsVariable].(map[string]interface{})
+	if !ok || len(fields) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		out[k] = v
+	}
+	return out
+}
+
+// Reset drops every variable, including the uplink fields, as for a new device
+func (s *State) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Variables = make(map[string]interface{})
+	s.UpdatedAt = time.Now()
+}
+
+// Clone returns a deep copy of the state, which can be changed without affecting the original
+func (s *State) Clone() *State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	variables := make(map[string]interface{}, len(s.Variables))
+	for k, v := range s.Variables {
+		variables[k] = cloneValue(v)
+	}
+	return &State{
+		DevEUI:    s.DevEUI,
+		Variables: variables,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+// cloneValue copies the maps and arrays a variable exported from JavaScript may hold
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = cloneValue(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = cloneValue(e)
+		}
+		return out
+	}
+	return v
+}
+
+// VariableChange is a state variable whose value differs between two states
+type VariableChange struct {
+	Name   string      `json:"name"`
+	Before interface{} `json:"before"` // nil when the variable was added
+	After  interface{} `json:"after"`  // nil when the variable was removed
+}
+
+// ChangedVariables lists, by name, the variables whose value differs between two states
+func ChangedVariables(before, after *State) []VariableChange {
+	before.mu.RLock()
+	defer before.mu.RUnlock()
+	after.mu.RLock()
+	defer after.mu.RUnlock()
+
+	changes := []VariableChange{}
+	for name, old := range before.Variables {
+		if value, ok := after.Variables[name]; !ok || !reflect.DeepEqual(old, value) {
+			changes = append(changes, VariableChange{Name: name, Before: old, After: value})
+		}
+	}
+	for name, value := range after.Variables {
+		if _, ok := before.Variables[name]; !ok {
+			changes = append(changes, VariableChange{Name: name, After: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
@@ -0,0 +1,118 @@
+package codec
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChangedVariables(t *testing.T) {
+	before := NewState("dev1")
+	before.SetVariable("kept", 1)
+	before.SetVariable("changed", 2)
+	before.SetVariable("removed", 3)
+	before.SetUplinkField("mode", 1)
+
+	after := before.Clone()
+	after.SetVariable("changed", 20)
+	after.SetVariable("added", 4)
+	after.SetUplinkField("mode", 2)
+	delete(after.Variables, "removed")
+
+	if before.UplinkFields()["mode"] != 1 {
+		t.Fatalf("Clone() shares the uplink fields with the original state")
+	}
+
+	changes := ChangedVariables(before, after)
+	want := []string{"added", "changed", "removed", UplinkFieldsVariable}
+	if len(changes) != len(want) {
+		t.Fatalf("ChangedVariables() = %+v, want changes of %v", changes, want)
+	}
+	for i, name := range want {
+		if changes[i].Name != name {
+			t.Errorf("changes[%d] = %q, want %q", i, changes[i].Name, name)
+		}
+	}
+	if changes[0].Before != nil || changes[0].After != 4 {
+		t.Errorf("added variable = %+v, want nil -> 4", changes[0])
+	}
+	if changes[2].Before != 3 || changes[2].After != nil {
+		t.Errorf("removed variable = %+v, want 3 -> nil", changes[2])
+	}
+}
+
+func TestRegistryTestDecodeLeavesStateUntouched(t *testing.T) {
+	r := NewRegistry(nil)
+	defer r.Close()
+
+	codec := NewCodec("counter", `
+function OnUplink(config) { return [0]; }
+function OnDownlink(bytes, fPort) {
+    setState('counter', (getState('counter') || 0) + bytes[0]);
+    setSendInterval(bytes[1]);
+    log('fPort ' + fPort);
+    return { added: bytes[0] };
+}`)
+	if err := r.AddCodec(codec); err != nil {
+		t.Fatalf("AddCodec: %v", err)
+	}
+	r.GetOrCreateState("dev1").SetVariable("counter", 5)
+	device := &fakeDevice{interval: time.Minute}
+
+	decoded, before, after, err := r.TestDecode(codec.ID, "dev1", []byte{3, 30}, 2, device, 0)
+	if err != nil {
+		t.Fatalf("TestDecode: %v", err)
+	}
+	if obj, ok := decoded.(map[string]interface{}); !ok || obj["added"] != int64(3) {
+		t.Errorf("decoded = %#v, want {added: 3}", decoded)
+	}
+	if before.GetVariable("counter") != 5 || after.GetVariable("counter") != int64(8) {
+		t.Errorf("counter went from %v to %v, want 5 to 8", before.GetVariable("counter"), after.GetVariable("counter"))
+	}
+	if live := r.GetOrCreateState("dev1").GetVariable("counter"); live != 5 {
+		t.Errorf("live counter = %v, want 5", live)
+	}
+	if len(r.GetErrors("dev1")) != 0 {
+		t.Error("TestDecode recorded errors for the device")
+	}
+
+	if _, _, _, err := r.TestDecode(codec.ID, "dev2", []byte{1, 1}, 2, device, 0); err != nil {
+		t.Fatalf("TestDecode of a device without state: %v", err)
+	}
+	r.mu.RLock()
+	_, created := r.states["dev2"]
+	r.mu.RUnlock()
+	if created {
+		t.Error("TestDecode created a state for a device without one")
+	}
+}
+
+func TestRegistryGetAndResetState(t *testing.T) {
+	r := NewRegistry(nil)
+	defer r.Close()
+
+	if state := r.GetState("dev1"); state != nil {
+		t.Fatalf("GetState() of a device whose codec never ran = %+v, want nil", state)
+	}
+
+	live := r.GetOrCreateState("dev1")
+	live.SetVariable("counter", 7)
+	live.SetUplinkField("mode", 2)
+
+	state := r.GetState("dev1")
+	if state == nil || state.GetVariable("counter") != 7 || state.UplinkFields()["mode"] != 2 {
+		t.Fatalf("GetState() = %+v, want the counter and the uplink field", state)
+	}
+	state.SetVariable("counter", 8)
+	if live.GetVariable("counter") != 7 {
+		t.Error("changing the copy returned by GetState() changed the live state")
+	}
+
+	r.ResetState("dev1")
+	if len(live.Variables) != 0 || live.UplinkFields() != nil {
+		t.Errorf("variables after ResetState() = %v, want none", live.Variables)
+	}
+	if r.GetOrCreateState("dev1") != live {
+		t.Error("ResetState() replaced the state instead of emptying it")
+	}
+	r.ResetState("dev2") // no state, nothing to do
+}
@@ -3,6 +3,7 @@ package codec
 import (
 	"sync"
 
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/dop251/goja"
 )
 
@@ -46,11 +47,19 @@ func (p *VMPool) Put(vm *goja.Runtime) {
 
 // createVM creates a new goja Runtime instance
 func (p *VMPool) createVM() *goja.Runtime {
+	return newRuntime()
+}
+
+// newRuntime creates a goja Runtime set up like the pooled ones
+func newRuntime() *goja.Runtime {
 	vm := goja.New()
 
 	// Set up basic JavaScript environment
 	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
 
+	// Math.random() draws from the shared random source so a configured seed applies to it too
+	vm.SetRandSource(util.RandFloat64)
+
 	// Enable console.log for debugging
 	console := vm.NewObject()
 	console.Set("log", func(call goja.FunctionCall) goja.Value {
@@ -72,6 +81,7 @@ func (p *VMPool) clearVM(vm *goja.Runtime) {
 	// Remove state helper functions
 	vm.Set("getState", goja.Undefined())
 	vm.Set("setState", goja.Undefined())
+	vm.Set("setUplinkField", goja.Undefined())
 
 	// Remove device helper functions
 	vm.Set("getSendInterval", goja.Undefined())
@@ -82,6 +92,11 @@ func (p *VMPool) clearVM(vm *goja.Runtime) {
 	vm.Set("hexToBytes", goja.Undefined())
 	vm.Set("base64ToBytes", goja.Undefined())
 
+	// Remove math helpers
+	vm.Set("random", goja.Undefined())
+	vm.Set("randomInt", goja.Undefined())
+	vm.Set("gaussian", goja.Undefined())
+
 	// Remove codec functions
 	vm.Set("OnUplink", goja.Undefined())
 	vm.Set("OnDownlink", goja.Undefined())
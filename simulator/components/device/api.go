@@ -1,11 +1,14 @@
 package device
 
 import (
+	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/classes"
 	mup "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/frames/uplink/models"
+	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
 	f "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder"
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/console"
 	res "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources"
@@ -17,8 +20,6 @@ func (d *Device) Setup(Resources *res.Resources, forwarder *f.Forwarder) {
 
 	d.State = util.Stopped
 
-	d.Exit = make(chan struct{}, 1) // Buffered to avoid blocking TurnOFF
-
 	d.Info.JoinEUI = lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 0}
 	d.Info.NetID = lorawan.NetID{0, 0, 0}
 
@@ -34,11 +35,14 @@ func (d *Device) Setup(Resources *res.Resources, forwarder *f.Forwarder) {
 
 	}
 
+	rp.SetSubBand(d.Info.Configuration.Region, d.Info.Configuration.AS923SubBand)
 	d.Info.Configuration.Region.Setup()
 	d.Info.Status.DataUplink.ADR.Setup(d.Info.Configuration.SupportedADR)
+	d.setupFCnt()
 
 	d.Info.Status.DataUplink.DwellTime = lorawan.DwellTime400ms
 	d.Info.Status.DataRate = d.Info.Configuration.DataRateInitial
+	d.Info.Status.SweepNext = time.Time{}
 	d.Info.Status.IndexchannelActive = 0
 
 	d.Info.Status.Battery = util.ConnectedPowerSource
@@ -49,6 +53,12 @@ func (d *Device) Setup(Resources *res.Resources, forwarder *f.Forwarder) {
 	d.Info.Status.CounterRepUnConfirmedDataUp = 1
 	d.Info.Configuration.NbRepUnconfirmedDataUp = 1
 
+	//class B
+	if d.Info.Configuration.SupportedClassB {
+		d.Info.Status.InfoClassB = d.Info.Configuration.Region.GetParameters().InfoClassB
+		d.Info.Status.InfoClassB.Downlinks = make(chan lorawan.PHYPayload)
+	}
+
 	//class C
 	if d.Info.Configuration.SupportedClassC {
 		d.Info.Status.InfoClassC.Setup()
@@ -58,6 +68,8 @@ func (d *Device) Setup(Resources *res.Resources, forwarder *f.Forwarder) {
 	d.Info.Forwarder = forwarder
 
 	d.Info.ReceivedDownlink.Notify = sync.NewCond(&d.Info.ReceivedDownlink.Mutex)
+	d.replayTrigger = make(chan struct{})
+	d.loopCalls = make(chan func())
 
 	d.Info.Configuration.Channels = d.Info.Configuration.Region.GetChannels()
 
@@ -76,13 +88,12 @@ func (d *Device) TurnOFF() {
 
 	d.Mutex.Lock()
 	d.State = util.Stopped
+	cancel := d.cancel
 	d.Mutex.Unlock()
 
-	// Non-blocking send to Exit channel (buffered size 1)
-	select {
-	case d.Exit <- struct{}{}:
-	default:
-		// Already signaled
+	// Interrupt the run loop and any timer or receive window it is waiting on
+	if cancel != nil {
+		cancel()
 	}
 
 	// Wake up any goroutine blocked in ReceivedDownlink.Pull()
@@ -92,9 +103,17 @@ func (d *Device) TurnOFF() {
 
 func (d *Device) TurnON() {
 
+	d.Mutex.Lock()
 	d.State = util.Running
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	ctx := d.ctx
+	// Created before the loop starts, so that the interval can be changed right away
+	if d.IntervalChanged == nil {
+		d.IntervalChanged = make(chan struct{}, 1)
+	}
+	d.Mutex.Unlock()
 
-	go d.Run()
+	go d.Run(ctx)
 
 	d.Print("Turn ON", nil, util.PrintBoth)
 }
@@ -126,7 +145,6 @@ func (d *Device) SendMACCommand(cid lorawan.CID, periodicity uint8) error {
 				},
 			},
 		}
-		d.Info.Status.InfoClassB.Periodicity = periodicity
 
 	} else {
 
@@ -138,15 +156,27 @@ func (d *Device) SendMACCommand(cid lorawan.CID, periodicity uint8) error {
 
 	}
 
-	d.newMACComands(command)
+	// The run loop sends the MAC commands and opens the ping slots
+	executed := d.runOnLoop(func() {
+		if cid == lorawan.PingSlotInfoReq {
+			d.Info.Status.InfoClassB.Periodicity = periodicity
+			d.updatePingSlots()
+		}
+		d.newMACComands(command)
+	})
+	if !executed {
+		return errors.New("device turned off before the MAC command was queued")
+	}
 
 	return nil
 }
 
-func (d *Device) NewUplink(mtype lorawan.MType, payload string) {
+// NewUplink queues an uplink sent before the periodic payload. It fails, dropping
+// the uplink, when the queue is full or the minimum spacing has not elapsed.
+func (d *Device) NewUplink(mtype lorawan.MType, payload string) error {
 
 	FRMPayload := &lorawan.DataPayload{
-		Bytes: []byte(payload),
+		Bytes: []byte(d.renderPayload(payload)),
 	}
 
 	info := mup.InfoFrame{
@@ -154,7 +184,7 @@ func (d *Device) NewUplink(mtype lorawan.MType, payload string) {
 		Payload: FRMPayload,
 	}
 
-	d.Info.Status.BufferUplinks = append(d.Info.Status.BufferUplinks, info)
+	return d.queueUplink(info)
 
 }
 
@@ -0,0 +1,112 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+)
+
+// GetChannels returns the channel plan of the device. A device that has not been
+// started yet reports the default channels of its region.
+func (d *Device) GetChannels() []models.ChannelInfo {
+	d.Mutex.Lock()
+	defer d.Mutex.Unlock()
+
+	list := d.Info.Configuration.Channels
+	if len(list) == 0 {
+		list = d.Info.Configuration.Region.GetChannels()
+	}
+
+	infos := make([]models.ChannelInfo, len(list))
+	for i, ch := range list {
+		infos[i] = models.ChannelInfo{
+			Index:             i,
+			Active:            ch.Active,
+			EnableUplink:      ch.EnableUplink,
+			FrequencyUplink:   ch.FrequencyUplink,
+			FrequencyDownlink: ch.FrequencyDownlink,
+			MinDR:             ch.MinDR,
+			MaxDR:             ch.MaxDR,
+		}
+	}
+	return infos
+}
+
+// GetPayloadLimit returns the maximum payload size of the next uplink. Larger payloads
+// are truncated, unless the device supports fragmentation.
+func (d *Device) GetPayloadLimit() models.PayloadLimit {
+	d.Mutex.Lock()
+	defer d.Mutex.Unlock()
+
+	return models.PayloadLimit{
+		DataRate:   d.Info.Status.DataRate,
+		MaxPayload: d.maxPayloadSize(),
+	}
+}
+
+// SetChannels enables or disables channels for uplinks, as a LinkADRReq channel
+// mask would. The channel plan is rebuilt from the region at every turn-on, so
+// the device must be running. Nothing is applied if any index is invalid or if
+// no channel would be left for uplinks.
+func (d *Device) SetChannels(update models.ChannelsUpdate) ([]models.ChannelInfo, error) {
+
+	if !d.IsOn() {
+		return nil, errors.New("device is not running, start it before changing its channels")
+	}
+	if len(update.Enable) == 0 && len(update.Disable) == 0 {
+		return nil, errors.New("no channels to enable or disable")
+	}
+
+	d.Mutex.Lock()
+
+	plan := make([]channels.Channel, len(d.Info.Configuration.Channels))
+	copy(plan, d.Info.Configuration.Channels)
+
+	disabled := make(map[int]bool, len(update.Disable))
+	for _, index := range update.Disable {
+		if index < 0 || index >= len(plan) {
+			d.Mutex.Unlock()
+			return nil, fmt.Errorf("channel %d does not exist, the device has %d channels", index, len(plan))
+		}
+		disabled[index] = true
+		plan[index].EnableUplink = false
+	}
+
+	for _, index := range update.Enable {
+		if index < 0 || index >= len(plan) {
+			d.Mutex.Unlock()
+			return nil, fmt.Errorf("channel %d does not exist, the device has %d channels", index, len(plan))
+		}
+		if disabled[index] {
+			d.Mutex.Unlock()
+			return nil, fmt.Errorf("channel %d can't be both enabled and disabled", index)
+		}
+		if !plan[index].Active || plan[index].FrequencyUplink == 0 {
+			d.Mutex.Unlock()
+			return nil, fmt.Errorf("channel %d is not defined and can't be enabled", index)
+		}
+		plan[index].EnableUplink = true
+	}
+
+	usable := false
+	for _, ch := range plan {
+		if ch.Active && ch.EnableUplink {
+			usable = true
+			break
+		}
+	}
+	if !usable {
+		d.Mutex.Unlock()
+		return nil, errors.New("at least one channel must stay enabled for uplinks")
+	}
+
+	d.Info.Configuration.Channels = plan
+	d.Mutex.Unlock()
+
+	d.Print("Configuration of channels is changed", nil, util.PrintBoth)
+
+	return d.GetChannels(), nil
+}
@@ -0,0 +1,72 @@
+package device_test
+
+import (
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestSetChannels(t *testing.T) {
+	util.SetSeed(1)
+
+	tests := []struct {
+		name        string
+		stopped     bool
+		update      models.ChannelsUpdate
+		wantErr     bool
+		wantEnabled []bool // EnableUplink of the three default EU868 channels
+	}{
+		{"disable one", false, models.ChannelsUpdate{Disable: []int{1}}, false, []bool{true, false, true}},
+		{"disable then enable", false, models.ChannelsUpdate{Disable: []int{0, 2}, Enable: []int{1}}, false, []bool{false, true, false}},
+		{"disable all", false, models.ChannelsUpdate{Disable: []int{0, 1, 2}}, true, []bool{true, true, true}},
+		{"undefined channel", false, models.ChannelsUpdate{Enable: []int{5}}, true, []bool{true, true, true}},
+		{"out of range", false, models.ChannelsUpdate{Disable: []int{99}}, true, []bool{true, true, true}},
+		{"enable and disable", false, models.ChannelsUpdate{Enable: []int{1}, Disable: []int{1}}, true, []bool{true, true, true}},
+		{"nothing", false, models.ChannelsUpdate{}, true, []bool{true, true, true}},
+		{"stopped device", true, models.ChannelsUpdate{Disable: []int{1}}, true, []bool{true, true, true}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := testutil.NewNetwork()
+			d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 13, byte(i)}, lorawan.DevAddr{1, 2, 9, byte(i)},
+				[16]byte{1}, [16]byte{2})
+			if tt.stopped {
+				d.State = util.Stopped
+			}
+
+			_, err := d.SetChannels(tt.update)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetChannels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			channels := d.GetChannels()
+			for index, want := range tt.wantEnabled {
+				if channels[index].EnableUplink != want {
+					t.Errorf("channel %d EnableUplink = %v, want %v", index, channels[index].EnableUplink, want)
+				}
+			}
+			if tt.wantErr || tt.stopped {
+				return
+			}
+
+			// The uplinks only use the channels left enabled
+			allowed := make(map[uint32]bool)
+			for index, enabled := range tt.wantEnabled {
+				if enabled {
+					allowed[channels[index].FrequencyUplink] = true
+				}
+			}
+			for cycle := 0; cycle < 4; cycle++ {
+				uplinks, err := n.Cycle(d, nil)
+				if err != nil || len(uplinks) != 1 {
+					t.Fatalf("Cycle() = %d uplinks, %v, want 1", len(uplinks), err)
+				}
+				if freq := uint32(uplinks[0].Frequency * 1000000); !allowed[freq] {
+					t.Errorf("uplink sent on %d Hz, a disabled channel", freq)
+				}
+			}
+		})
+	}
+}
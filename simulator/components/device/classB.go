@@ -0,0 +1,38 @@
+package device
+
+import (
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/metrics"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+// DownlinkReceivedPingSlot processes a downlink received by the class B in a ping slot
+func (d *Device) DownlinkReceivedPingSlot(phy lorawan.PHYPayload) {
+
+	if !d.CanExecute() {
+		return
+	}
+
+	d.Print("Downlink Received in a ping slot", nil, util.PrintBoth)
+	metrics.DownlinksTotal.Inc()
+
+	downlink, err := d.ProcessDownlink(phy)
+	d.countDownlink()
+	if err != nil {
+		d.Print("", err, util.PrintBoth)
+		return
+	}
+
+	if downlink == nil {
+		return
+	}
+
+	d.ExecuteMACCommand(*downlink)
+
+	d.ADRProcedure()
+
+	if d.Info.Status.Mode != util.Retransmission {
+		d.FPendingProcedure(downlink)
+	}
+
+}
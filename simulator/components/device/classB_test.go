@@ -0,0 +1,91 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/classes"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/brocaar/lorawan"
+)
+
+func TestClassBPingSlotDownlink(t *testing.T) {
+	n := testutil.NewNetwork()
+	// Periodicity 0 opens a ping slot about every second
+	d := n.NewClassBDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 10}, lorawan.DevAddr{1, 2, 3, 6},
+		[16]byte{1}, [16]byte{2}, 0)
+	d.Info.Configuration.SendInterval = time.Hour // no class A uplink
+
+	downlink, err := testutil.DataDown(d, lorawan.MACCommand{CID: lorawan.DevStatusReq})
+	if err != nil {
+		t.Fatalf("DataDown() error = %v", err)
+	}
+	raw, err := downlink.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	freq := d.Info.Status.InfoClassB.PingSlot.GetListeningFrequency()
+
+	d.SwitchClass(classes.ClassB)
+	n.Start(d)
+	defer n.Stop(d)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for d.GetCounters().Downlinks == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no downlink received in a ping slot")
+		}
+
+		d.Info.ReceivedDownlink.Mutex.Lock()
+		open := d.Info.ReceivedDownlink.IsOpen
+		d.Info.ReceivedDownlink.Mutex.Unlock()
+
+		if open {
+			n.Forwarder.Downlink(downlink, freq, n.Gateway, nil, raw)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if d.GetCounters().Downlinks != 1 {
+		t.Errorf("device received %d downlinks, want 1", d.GetCounters().Downlinks)
+	}
+}
+
+func TestClassBPingSlotParametersChange(t *testing.T) {
+	n := testutil.NewNetwork()
+	d := n.NewClassBDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 11}, lorawan.DevAddr{1, 2, 3, 7},
+		[16]byte{1}, [16]byte{2}, 0)
+	d.Info.Configuration.SendInterval = time.Hour // no class A uplink
+
+	d.SwitchClass(classes.ClassB)
+	n.Start(d)
+	defer n.Stop(d)
+
+	// The run loop changes the periodicity while the ping slots are open
+	tests := []struct {
+		name        string
+		send        func() error
+		periodicity uint8
+	}{
+		{"queued MAC command", func() error {
+			_, err := d.QueueMACCommands([]models.UplinkMACCommand{{CID: "PingSlotInfoReq", PayloadHex: "01"}})
+			return err
+		}, 1},
+		{"socket MAC command", func() error {
+			return d.SendMACCommand(lorawan.PingSlotInfoReq, 2)
+		}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.send(); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			// Used from the next beacon period
+			devAddr, periodicity := d.Class.(*classes.TypeB).PingSlotParams()
+			if devAddr != d.Info.DevAddr || periodicity != tt.periodicity {
+				t.Errorf("ping slot parameters = %v/%d, want %v/%d", devAddr, periodicity, d.Info.DevAddr, tt.periodicity)
+			}
+		})
+	}
+}
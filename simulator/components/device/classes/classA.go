@@ -1,6 +1,7 @@
 package classes
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -35,7 +36,7 @@ func (a *TypeA) SendData(rxpk pkt.RXPK) {
 
 }
 
-func (a *TypeA) ReceiveWindows(delayRX1 time.Duration, delayRX2 time.Duration) *lorawan.PHYPayload {
+func (a *TypeA) ReceiveWindows(ctx context.Context, delayRX1 time.Duration, delayRX2 time.Duration) *lorawan.PHYPayload {
 
 	for i := 0; i < 2; i++ {
 
@@ -48,7 +49,7 @@ func (a *TypeA) ReceiveWindows(delayRX1 time.Duration, delayRX2 time.Duration) *
 
 		a.Info.Forwarder.Register(a.Info.RX[i].GetListeningFrequency(), a.Info.DevEUI, &a.Info.ReceivedDownlink)
 
-		resp := a.Info.RX[i].OpenWindow(delay, &a.Info.ReceivedDownlink)
+		resp := a.Info.RX[i].OpenWindow(ctx, delay, &a.Info.ReceivedDownlink)
 
 		a.Info.Forwarder.UnRegister(a.Info.RX[i].GetListeningFrequency(), a.Info.DevEUI)
 
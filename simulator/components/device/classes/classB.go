@@ -1,8 +1,10 @@
 package classes
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	dl "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/frames/downlink"
@@ -23,19 +25,34 @@ const (
 	TimeoutClassB = 120
 )
 
-//TypeB è implementata come la classe A
+//TypeB receives like the class A and, in addition, opens the ping slots of every beacon period
 type TypeB struct {
 	Info *models.InformationDevice
+
+	Mutex  sync.Mutex `json:"-"` // held while the radio is used by the class A
+	cancel context.CancelFunc
+
+	paramsMu    sync.Mutex // guards the ping slot parameters, written by the device loop
+	devAddr     lorawan.DevAddr
+	periodicity uint8
 }
 
 func (b *TypeB) Setup(info *models.InformationDevice) {
 	b.Info = info
+	b.SetPingSlotParams(info.DevAddr, info.Status.InfoClassB.Periodicity)
+
+	var ctx context.Context
+	ctx, b.cancel = context.WithCancel(context.Background())
+	go b.PingSlots(ctx)
 }
 
 func (b *TypeB) SendData(rxpk pkt.RXPK) {
 
 	var indexChannelRX1 int
 
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
 	b.Info.Forwarder.Uplink(rxpk, b.Info.DevEUI)
 
 	b.Info.RX[0].DataRate, indexChannelRX1 = b.Info.Configuration.Region.SetupRX1(
@@ -45,7 +62,10 @@ func (b *TypeB) SendData(rxpk pkt.RXPK) {
 	b.Info.RX[0].Channel = b.Info.Configuration.Channels[indexChannelRX1]
 }
 
-func (b *TypeB) ReceiveWindows(delayRX1 time.Duration, delayRX2 time.Duration) *lorawan.PHYPayload {
+func (b *TypeB) ReceiveWindows(ctx context.Context, delayRX1 time.Duration, delayRX2 time.Duration) *lorawan.PHYPayload {
+
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
 
 	for i := 0; i < 2; i++ {
 
@@ -58,7 +78,7 @@ func (b *TypeB) ReceiveWindows(delayRX1 time.Duration, delayRX2 time.Duration) *
 
 		b.Info.Forwarder.Register(b.Info.RX[i].GetListeningFrequency(), b.Info.DevEUI, &b.Info.ReceivedDownlink)
 
-		resp := b.Info.RX[i].OpenWindow(delay, &b.Info.ReceivedDownlink)
+		resp := b.Info.RX[i].OpenWindow(ctx, delay, &b.Info.ReceivedDownlink)
 
 		b.Info.Forwarder.UnRegister(b.Info.RX[i].GetListeningFrequency(), b.Info.DevEUI)
 
@@ -138,4 +158,102 @@ func (b *TypeB) ToString() string {
 	return "B"
 }
 
-func (b *TypeB) CloseRX2() {}
+//CloseRX2 stops the ping slots
+func (b *TypeB) CloseRX2() {
+	if b.cancel != nil {
+		b.cancel()
+	}
+}
+
+//SetPingSlotParams changes the DevAddr and periodicity of the ping slots from the next
+//beacon period. The device loop calls it when it changes them in the device info.
+func (b *TypeB) SetPingSlotParams(devAddr lorawan.DevAddr, periodicity uint8) {
+	b.paramsMu.Lock()
+	defer b.paramsMu.Unlock()
+	b.devAddr, b.periodicity = devAddr, periodicity
+}
+
+//PingSlotParams returns the DevAddr and periodicity of the ping slots
+func (b *TypeB) PingSlotParams() (lorawan.DevAddr, uint8) {
+	b.paramsMu.Lock()
+	defer b.paramsMu.Unlock()
+	return b.devAddr, b.periodicity
+}
+
+//PingSlots opens the ping slots of each beacon period until ctx is cancelled
+func (b *TypeB) PingSlots(ctx context.Context) {
+
+	for {
+
+		now := time.Now()
+		beacon := BeaconTime(now)
+		devAddr, periodicity := b.PingSlotParams()
+
+		for _, slot := range PingSlots(beacon, devAddr, periodicity) {
+
+			if slot.Before(now) {
+				continue
+			}
+
+			if !b.openPingSlot(ctx, slot) {
+				return
+			}
+
+		}
+
+		if !sleepUntil(ctx, BeaconStart(beacon+BeaconPeriod)) {
+			return
+		}
+
+	}
+
+}
+
+//openPingSlot listens in the ping slot starting at the given instant and hands over the
+//downlink received, if any. It reports false if ctx was cancelled.
+func (b *TypeB) openPingSlot(ctx context.Context, at time.Time) bool {
+
+	if !sleepUntil(ctx, at) {
+		return false
+	}
+
+	// Uplinks and the class A receive windows have priority over ping slots
+	if !b.Mutex.TryLock() {
+		return true
+	}
+
+	window := b.Info.Status.InfoClassB.PingSlot
+
+	b.Info.Forwarder.Register(window.GetListeningFrequency(), b.Info.DevEUI, &b.Info.ReceivedDownlink)
+	phy := b.Info.ReceivedDownlink.PullWithin(ctx, window.DurationOpen)
+	b.Info.Forwarder.UnRegister(window.GetListeningFrequency(), b.Info.DevEUI)
+
+	b.Mutex.Unlock()
+
+	if phy == nil {
+		return ctx.Err() == nil
+	}
+
+	select {
+	case b.Info.Status.InfoClassB.Downlinks <- *phy:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+
+}
+
+//sleepUntil waits until t, and reports false if ctx was cancelled first
+func sleepUntil(ctx context.Context, t time.Time) bool {
+
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+
+}
@@ -1,6 +1,7 @@
 package classes
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
@@ -51,14 +52,14 @@ func (c *TypeC) SendData(rxpk pkt.RXPK) {
 	c.Info.RX[0].Channel = c.Info.Configuration.Channels[indexChannelRX1]
 }
 
-func (c *TypeC) ReceiveWindows(delayRX1 time.Duration, delayRX2 time.Duration) *lorawan.PHYPayload {
+func (c *TypeC) ReceiveWindows(ctx context.Context, delayRX1 time.Duration, delayRX2 time.Duration) *lorawan.PHYPayload {
 
 	c.CloseWindow()
 	defer c.OpenWindow()
 
 	c.Info.Forwarder.Register(c.Info.RX[0].GetListeningFrequency(), c.Info.DevEUI, &c.Info.ReceivedDownlink)
 
-	resp := c.Info.RX[0].OpenWindow(0, &c.Info.ReceivedDownlink)
+	resp := c.Info.RX[0].OpenWindow(ctx, 0, &c.Info.ReceivedDownlink)
 
 	c.Info.Forwarder.UnRegister(c.Info.RX[0].GetListeningFrequency(), c.Info.DevEUI)
 
@@ -1,6 +1,7 @@
 package classes
 
 import (
+	"context"
 	"time"
 
 	dl "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/frames/downlink"
@@ -18,7 +19,7 @@ const (
 type Class interface {
 	Setup(*models.InformationDevice)
 	SendData(rxpk pkt.RXPK)
-	ReceiveWindows(time.Duration, time.Duration) *lorawan.PHYPayload
+	ReceiveWindows(context.Context, time.Duration, time.Duration) *lorawan.PHYPayload
 	RetransmissionCData(downlink *dl.InformationDownlink) error
 	RetransmissionUnCData(downlink *dl.InformationDownlink) error
 	GetClass() int
@@ -1,8 +1,11 @@
 package models_classes
 
 import (
+	"time"
+
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
+	"github.com/brocaar/lorawan"
 )
 
 type InfoClassB struct {
@@ -12,11 +15,15 @@ type InfoClassB struct {
 	FrequencyBeacon uint32 `json:"frequencyBeacon"`
 
 	PingSlot features.Window `json:"pingSlot"`
+
+	// Downlinks received in a ping slot, handed over to the device loop
+	Downlinks chan lorawan.PHYPayload `json:"-"`
 }
 
 func (b *InfoClassB) Setup(freqBeacon uint32, freqPingSlot uint32, datarate uint8, minDr uint8, maxDr uint8) {
 
 	b.FrequencyBeacon = freqBeacon //freq
+	b.DataRate = datarate
 
 	channel := channels.Channel{
 		Active:            true,
@@ -29,6 +36,7 @@ func (b *InfoClassB) Setup(freqBeacon uint32, freqPingSlot uint32, datarate uint
 
 	b.PingSlot.Channel = channel
 	b.PingSlot.Delay = 0
-	b.PingSlot.DurationOpen = 30 //30 ms
+	b.PingSlot.DurationOpen = 30 * time.Millisecond
+	b.PingSlot.DataRate = datarate
 
 }
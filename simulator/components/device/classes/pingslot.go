@@ -0,0 +1,69 @@
+package classes
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"time"
+
+	"github.com/brocaar/lorawan"
+)
+
+const (
+	//BeaconReserved is the time reserved for the beacon at the start of each period
+	BeaconReserved = 2120 * time.Millisecond
+	//leapSeconds is the offset of GPS time from UTC
+	leapSeconds = 18
+)
+
+// gpsEpoch is the origin of GPS time, on which beacon periods are aligned
+var gpsEpoch = time.Date(1980, time.January, 6, 0, 0, 0, 0, time.UTC)
+
+// BeaconTime returns the GPS time, in seconds, of the last beacon sent at or before t
+func BeaconTime(t time.Time) uint32 {
+	gps := int64(t.Sub(gpsEpoch)/time.Second) + leapSeconds
+	return uint32(gps - gps%BeaconPeriod)
+}
+
+// BeaconStart returns the instant a beacon period starts
+func BeaconStart(beaconTime uint32) time.Time {
+	return gpsEpoch.Add(time.Duration(int64(beaconTime)-leapSeconds) * time.Second)
+}
+
+// PingOffset returns the first ping slot of a device in the beacon period, randomized
+// with AES as in the LoRaWAN Class B specification so that devices don't collide
+func PingOffset(beaconTime uint32, devAddr lorawan.DevAddr, pingPeriod int) int {
+
+	var key, block, rand [16]byte
+
+	binary.LittleEndian.PutUint32(block[0:4], beaconTime)
+	addr, _ := devAddr.MarshalBinary() // little endian
+	copy(block[4:8], addr)
+
+	cipher, _ := aes.NewCipher(key[:]) // the key length is always valid
+	cipher.Encrypt(rand[:], block[:])
+
+	return (int(rand[0]) + int(rand[1])*256) % pingPeriod
+}
+
+// PingSlots returns the instants at which the device opens its ping slots during the
+// beacon period, 2^(7-periodicity) slots evenly spaced after the beacon
+func PingSlots(beaconTime uint32, devAddr lorawan.DevAddr, periodicity uint8) []time.Time {
+
+	if periodicity > 7 {
+		periodicity = 7
+	}
+
+	pingNb := 1 << (7 - periodicity)
+	pingPeriod := Pingslots / pingNb
+	offset := PingOffset(beaconTime, devAddr, pingPeriod)
+
+	start := BeaconStart(beaconTime).Add(BeaconReserved)
+
+	slots := make([]time.Time, pingNb)
+	for i := range slots {
+		slot := offset + i*pingPeriod
+		slots[i] = start.Add(time.Duration(slot) * PingDuration * time.Millisecond)
+	}
+
+	return slots
+}
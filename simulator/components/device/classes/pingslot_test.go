@@ -0,0 +1,65 @@
+package classes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestBeaconTime(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	beacon := BeaconTime(now)
+	if beacon%BeaconPeriod != 0 {
+		t.Errorf("BeaconTime() = %d, not a multiple of the beacon period", beacon)
+	}
+
+	start := BeaconStart(beacon)
+	if start.After(now) || now.Sub(start) >= BeaconPeriod*time.Second {
+		t.Errorf("BeaconStart() = %v, want the period containing %v", start, now)
+	}
+	if got := BeaconTime(start); got != beacon {
+		t.Errorf("BeaconTime(BeaconStart()) = %d, want %d", got, beacon)
+	}
+}
+
+func TestPingSlots(t *testing.T) {
+	devAddr := lorawan.DevAddr{1, 2, 3, 4}
+	beacon := BeaconTime(time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+	start := BeaconStart(beacon).Add(BeaconReserved)
+	end := BeaconStart(beacon + BeaconPeriod)
+
+	for periodicity := uint8(0); periodicity <= 7; periodicity++ {
+		slots := PingSlots(beacon, devAddr, periodicity)
+
+		if want := 1 << (7 - periodicity); len(slots) != want {
+			t.Fatalf("periodicity %d: %d slots, want %d", periodicity, len(slots), want)
+		}
+
+		period := time.Duration(1<<(5+periodicity)) * PingDuration * time.Millisecond
+		for i, slot := range slots {
+			if slot.Before(start) || !slot.Add(PingDuration*time.Millisecond).Before(end) {
+				t.Errorf("periodicity %d: slot %d at %v is outside the beacon window", periodicity, i, slot)
+			}
+			if i > 0 && slot.Sub(slots[i-1]) != period {
+				t.Errorf("periodicity %d: slot %d is %v after the previous one, want %v",
+					periodicity, i, slot.Sub(slots[i-1]), period)
+			}
+		}
+	}
+
+	// The offset is deterministic, but changes with the beacon and the device
+	if PingOffset(beacon, devAddr, Pingslots) != PingOffset(beacon, devAddr, Pingslots) {
+		t.Error("PingOffset() is not deterministic")
+	}
+	offsets := map[int]bool{
+		PingOffset(beacon, devAddr, Pingslots):                     true,
+		PingOffset(beacon+BeaconPeriod, devAddr, Pingslots):        true,
+		PingOffset(beacon, lorawan.DevAddr{1, 2, 3, 5}, Pingslots): true,
+		PingOffset(beacon+2*BeaconPeriod, devAddr, Pingslots):      true,
+	}
+	if len(offsets) < 3 {
+		t.Errorf("PingOffset() hardly changes with the beacon and the device: %v", offsets)
+	}
+}
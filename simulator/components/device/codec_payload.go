@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/profiles"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/brocaar/lorawan"
 )
 
@@ -20,14 +22,42 @@ func (d *Device) GetSendInterval() time.Duration {
 func (d *Device) SetSendInterval(interval time.Duration) {
 	d.Info.Configuration.SendInterval = interval
 
-	// Signal the device loop to reset its ticker (non-blocking)
-	if d.IntervalChanged != nil {
-		select {
-		case d.IntervalChanged <- struct{}{}:
-		default:
-			// Channel already has a pending signal, skip
-		}
+	// Signal the device loop to reset its ticker
+	d.signalIntervalChanged()
+}
+
+// GetPayloadConfig returns the device's payload config passed to OnUplink (implements codec.DeviceInterface).
+// With a sensor profile, the values generated for the current time are added to a copy of the
+// config, replacing static values with the same key.
+func (d *Device) GetPayloadConfig() map[string]interface{} {
+	if d.Info.Configuration.ProfileID == "" {
+		return d.Info.Configuration.PayloadConfig
+	}
+
+	profile, err := profiles.Get(d.Info.Configuration.ProfileID)
+	if err != nil {
+		d.Print("", err, util.PrintOnlyConsole)
+		return d.Info.Configuration.PayloadConfig
+	}
+
+	values := profile.Generate(time.Now())
+	config := make(map[string]interface{}, len(d.Info.Configuration.PayloadConfig)+len(values))
+	for k, v := range d.Info.Configuration.PayloadConfig {
+		config[k] = v
+	}
+	for k, v := range values {
+		config[k] = v
+	}
+	return config
+}
+
+// codecTimeout returns the max duration of one codec execution for the device,
+// 0 to keep the executor default, also used for an out of bounds value loaded from disk
+func (d *Device) codecTimeout() time.Duration {
+	if !d.Info.Configuration.ValidCodecTimeout() {
+		return 0
 	}
+	return time.Duration(d.Info.Configuration.CodecTimeoutMs) * time.Millisecond
 }
 
 // GenerateCodecPayload generates a payload using the configured codec
@@ -51,6 +81,7 @@ func (d *Device) GenerateCodecPayload() lorawan.Payload {
 		d.Info.Configuration.CodecID,
 		devEUI,
 		d, // Pass device for getSendInterval/setSendInterval
+		d.codecTimeout(),
 	)
 
 	if err != nil {
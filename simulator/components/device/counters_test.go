@@ -0,0 +1,54 @@
+package device_test
+
+import (
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestDeviceCounters(t *testing.T) {
+	util.SetSeed(1)
+
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 10, 1}, lorawan.DevAddr{1, 2, 6, 1},
+		[16]byte{1}, [16]byte{2})
+
+	if c := d.GetCounters(); c.Uplinks != 0 || c.Downlinks != 0 {
+		t.Fatalf("new device counters = %+v, want zero", c)
+	}
+
+	// Each step runs one cycle, the counters accumulate across steps
+	steps := []struct {
+		name          string
+		downlink      bool
+		wantUplinks   uint64
+		wantDownlinks uint64
+	}{
+		{"uplink", false, 1, 0},
+		{"uplink and downlink", true, 2, 1},
+		{"another uplink", false, 3, 1},
+		{"another downlink", true, 4, 2},
+	}
+	for _, step := range steps {
+		var downlink *lorawan.PHYPayload
+		if step.downlink {
+			var err error
+			if downlink, err = testutil.DataDown(d); err != nil {
+				t.Fatalf("%s: DataDown() error = %v", step.name, err)
+			}
+		}
+		if _, err := n.Cycle(d, downlink); err != nil {
+			t.Fatalf("%s: Cycle() error = %v", step.name, err)
+		}
+
+		c := d.GetCounters()
+		if c.Uplinks != step.wantUplinks || c.Downlinks != step.wantDownlinks {
+			t.Errorf("%s: counters = %d up/%d down, want %d/%d", step.name, c.Uplinks, c.Downlinks, step.wantUplinks, step.wantDownlinks)
+		}
+		if c.FCntUp != d.Info.Status.DataUplink.FCnt || c.FCntDown != d.Info.Status.FCntDown {
+			t.Errorf("%s: frame counters = %d/%d, want %d/%d", step.name, c.FCntUp, c.FCntDown, d.Info.Status.DataUplink.FCnt, d.Info.Status.FCntDown)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package device
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/console"
@@ -10,15 +13,18 @@ import (
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/classes"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/webhook"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
 )
 
-const logBufferSize = 50
+const (
+	logBufferSize         = 50
+	downlinkAckLedgerSize = 50
+)
 
 type Device struct {
 	State           int                      `json:"-"`
-	Exit            chan struct{}            `json:"-"`
 	IntervalChanged chan struct{}            `json:"-"` // Signal to reset ticker when interval changes
 	JoinSemaphore   chan struct{}            `json:"-"` // Limits concurrent OTAA joins (nil = unlimited)
 	Id              int                      `json:"id"`
@@ -29,6 +35,27 @@ type Device struct {
 	Console         c.Console                `json:"-"`
 	LogBuffer       []socket.ConsoleLog      `json:"-"`
 	logMu           sync.Mutex               `json:"-"`
+	ackMu           sync.Mutex               `json:"-"`
+	countersMu      sync.Mutex               `json:"-"`
+	timeScale       atomic.Uint64            `json:"-"` // Bits of the float64 dividing the send interval and ACK timeout (0 = unscaled)
+
+	uplinkMu         sync.Mutex    // Guards the uplink buffer and its limits
+	uplinkMinSpacing time.Duration // Minimum time between two queued uplinks (0 = none)
+	uplinkQueueSize  int           // Maximum queued uplinks (0 = DefaultUplinkQueueSize, negative = unlimited)
+	lastQueuedUplink time.Time
+
+	startDelay time.Duration // Wait before the first action of the next run, to stagger startups
+
+	ctx    context.Context    // Context of the current run, cancelled by TurnOFF
+	cancel context.CancelFunc // Cancels ctx
+
+	replayMu      sync.Mutex         // Guards the replay state
+	replayCtx     context.Context    // Context of the replay in progress (nil = none)
+	replayCancel  context.CancelFunc // Stops the replay in progress
+	replayPending *replayFrame       // Replayed uplink due, taken by the next uplink
+	replayTrigger chan struct{}      // Wakes up the run loop when a replayed uplink is due
+
+	loopCalls chan func() // Functions run by the run loop between two uplinks, see runOnLoop
 }
 
 func (d *Device) appendLog(entry socket.ConsoleLog) {
@@ -48,19 +75,181 @@ func (d *Device) GetLogBuffer() []socket.ConsoleLog {
 	return buf
 }
 
+// SetLogBuffer replaces the log history, keeping its last entries if it is too long
+func (d *Device) SetLogBuffer(entries []socket.ConsoleLog) {
+	d.logMu.Lock()
+	defer d.logMu.Unlock()
+	if len(entries) > logBufferSize {
+		entries = entries[len(entries)-logBufferSize:]
+	}
+	d.LogBuffer = append([]socket.ConsoleLog(nil), entries...)
+}
+
+// recordDownlinkAck appends a confirmed downlink to the ACK ledger and returns its index
+func (d *Device) recordDownlinkAck(fcnt uint32) int {
+	d.ackMu.Lock()
+	defer d.ackMu.Unlock()
+	d.Info.Status.DownlinkAcks = append(d.Info.Status.DownlinkAcks, models.DownlinkAck{
+		FCnt:     fcnt,
+		Received: time.Now(),
+	})
+	if len(d.Info.Status.DownlinkAcks) > downlinkAckLedgerSize {
+		d.Info.Status.DownlinkAcks = d.Info.Status.DownlinkAcks[len(d.Info.Status.DownlinkAcks)-downlinkAckLedgerSize:]
+	}
+	return len(d.Info.Status.DownlinkAcks) - 1
+}
+
+// markDownlinkAcked flags the ledger entry at index as acknowledged
+func (d *Device) markDownlinkAcked(index int) {
+	d.ackMu.Lock()
+	defer d.ackMu.Unlock()
+	if index >= 0 && index < len(d.Info.Status.DownlinkAcks) {
+		d.Info.Status.DownlinkAcks[index].AckSent = true
+	}
+}
+
+// GetDownlinkAcks returns a copy of the confirmed downlink ACK ledger
+func (d *Device) GetDownlinkAcks() []models.DownlinkAck {
+	d.ackMu.Lock()
+	defer d.ackMu.Unlock()
+	acks := make([]models.DownlinkAck, len(d.Info.Status.DownlinkAcks))
+	copy(acks, d.Info.Status.DownlinkAcks)
+	return acks
+}
+
+// countUplink records a sent uplink and the current frame counters
+func (d *Device) countUplink() {
+	d.countersMu.Lock()
+	defer d.countersMu.Unlock()
+	d.Info.Status.Counters.Uplinks++
+	now := time.Now()
+	d.Info.Status.LastUplinkAt = &now
+	d.Info.Status.Counters.FCntUp = d.Info.Status.DataUplink.FCnt
+	d.Info.Status.Counters.FCntDown = d.Info.Status.FCntDown
+}
+
+// countDownlink records a received downlink and the current frame counters
+func (d *Device) countDownlink() {
+	d.countersMu.Lock()
+	defer d.countersMu.Unlock()
+	d.Info.Status.Counters.Downlinks++
+	now := time.Now()
+	d.Info.Status.LastDownlinkAt = &now
+	d.Info.Status.Counters.FCntUp = d.Info.Status.DataUplink.FCnt
+	d.Info.Status.Counters.FCntDown = d.Info.Status.FCntDown
+}
+
+// GetCounters returns a copy of the uplink/downlink counters
+func (d *Device) GetCounters() models.Counters {
+	d.countersMu.Lock()
+	defer d.countersMu.Unlock()
+	return d.Info.Status.Counters
+}
+
+// MarshalJSON holds the counters lock, as the counters and the last uplink/downlink
+// times change while the device runs
+func (d *Device) MarshalJSON() ([]byte, error) {
+
+	type Alias Device
+
+	d.countersMu.Lock()
+	defer d.countersMu.Unlock()
+
+	return json.Marshal((*Alias)(d))
+}
+
+// MarshalOnLoop marshals a running device on its run loop, between two uplinks, as the
+// loop changes its status. It returns false, without marshalling, if the device is
+// turned off first.
+func (d *Device) MarshalOnLoop() ([]byte, bool, error) {
+	var data []byte
+	var err error
+	ok := d.runOnLoop(func() { data, err = json.Marshal(d) })
+	return data, ok, err
+}
+
+// SetStartDelay delays the first join or uplink of the next turn-on only
+func (d *Device) SetStartDelay(delay time.Duration) {
+	d.startDelay = delay
+}
+
+// waitStartDelay waits for the start delay, if any, and consumes it. It returns
+// false if the device was turned off meanwhile.
+func (d *Device) waitStartDelay(ctx context.Context) bool {
+	delay := d.startDelay
+	d.startDelay = 0
+	return sleep(ctx, delay)
+}
+
+// wait pauses the current run for delay. It returns false, without waiting for the
+// whole delay, if the device is turned off meanwhile.
+func (d *Device) wait(delay time.Duration) bool {
+	return sleep(d.runContext(), delay)
+}
+
+// runContext returns the context of the current run, or a context that is never
+// cancelled when the device is driven without its run loop (e.g. by simulator/testutil)
+func (d *Device) runContext() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+	return d.ctx
+}
+
+// runOnLoop runs fn on the run loop, between two uplinks, so that it doesn't race with the
+// state the loop changes. fn runs directly when the device is driven without its run loop
+// (e.g. by simulator/testutil). It returns false, without running fn, if the device is
+// turned off first.
+func (d *Device) runOnLoop(fn func()) bool {
+	d.Mutex.Lock()
+	ctx := d.ctx
+	d.Mutex.Unlock()
+	if ctx == nil {
+		fn()
+		return true
+	}
+
+	done := make(chan struct{})
+	select {
+	case d.loopCalls <- func() { fn(); close(done) }:
+	case <-ctx.Done():
+		return false
+	}
+	<-done
+	return true
+}
+
+// sleep waits for delay unless ctx is cancelled first, and reports whether it waited
+func sleep(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // *******************Intern func*******************/
-func (d *Device) Run() {
 
-	defer d.Resources.ExitGroup.Done()
+// Run is the device loop, it returns as soon as ctx is cancelled
+func (d *Device) Run(ctx context.Context) {
 
-	d.OtaaActivation()
+	defer d.Resources.ExitGroup.Done()
 
-	// Initialize the interval change channel if not already done
-	if d.IntervalChanged == nil {
-		d.IntervalChanged = make(chan struct{}, 1)
+	if !d.waitStartDelay(ctx) {
+		d.Print("Turn OFF", nil, util.PrintBoth)
+		return
 	}
 
-	ticker := time.NewTicker(d.Info.Configuration.SendInterval)
+	d.OtaaActivation()
+
+	ticker := time.NewTicker(d.sendInterval())
 	defer ticker.Stop()
 
 	for {
@@ -68,16 +257,32 @@ func (d *Device) Run() {
 		select {
 
 		case <-ticker.C:
+			if d.Replaying() {
+				continue // the replay times the uplinks
+			}
+
+		case <-d.replayTrigger:
 			break
 
+		case call := <-d.loopCalls:
+			call()
+			continue
+
+		case phy := <-d.Info.Status.InfoClassB.Downlinks:
+			d.DownlinkReceivedPingSlot(phy)
+			continue
+
 		case <-d.IntervalChanged:
-			// Interval was changed via downlink, reset the ticker
+			// Interval or time scale was changed, reset the ticker
 			ticker.Stop()
-			ticker = time.NewTicker(d.Info.Configuration.SendInterval)
-			d.Print(fmt.Sprintf("Send interval updated to %v", d.Info.Configuration.SendInterval), nil, util.PrintBoth)
+			ticker = time.NewTicker(d.sendInterval())
+			d.Print(fmt.Sprintf("Send interval updated to %v", d.sendInterval()), nil, util.PrintBoth)
 			continue
 
-		case <-d.Exit:
+		case <-ctx.Done():
+			if d.Class.GetClass() == classes.ClassB {
+				d.SwitchClass(classes.ClassA) // stop the ping slots, until the next start
+			}
 			d.Print("Turn OFF", nil, util.PrintBoth)
 			return
 		}
@@ -153,6 +358,9 @@ func (d *Device) Print(content string, err error, printType int) {
 
 	if err == nil {
 		d.appendLog(data)
+		d.Console.PublishDevice(event, d.Id, d.Info.DevEUI.String(), d.Info.Name, data)
+	} else {
+		d.notify(webhook.EventDeviceError, err.Error())
 	}
 
 	emitToSocket := event == socket.EventError || d.Console.IsWatched(d.Id)
@@ -171,3 +379,20 @@ func (d *Device) Print(content string, err error, printType int) {
 		d.Console.PrintLog(messageLog)
 	}
 }
+
+// emit sends a device event to the socket and to the MQTT broker
+func (d *Device) emit(eventName string, data interface{}) {
+	d.Console.PrintDevice(eventName, d.Id, d.Info.DevEUI.String(), d.Info.Name, data)
+}
+
+// notify sends a webhook event about the device
+func (d *Device) notify(eventType, message string) {
+	d.Console.Notify(webhook.Event{
+		Type:    eventType,
+		Source:  webhook.SourceDevice,
+		ID:      d.Id,
+		EUI:     d.Info.DevEUI.String(),
+		Name:    d.Info.Name,
+		Message: message,
+	})
+}
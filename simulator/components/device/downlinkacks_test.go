@@ -0,0 +1,55 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestDownlinkAckLedger(t *testing.T) {
+	util.SetSeed(1)
+
+	tests := []struct {
+		name     string
+		mtype    lorawan.MType
+		wantAcks int
+	}{
+		{"unconfirmed", lorawan.UnconfirmedDataDown, 0},
+		{"confirmed", lorawan.ConfirmedDataDown, 1},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := testutil.NewNetwork()
+			d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 8, byte(i)}, lorawan.DevAddr{1, 2, 4, byte(i)},
+				[16]byte{1}, [16]byte{2})
+
+			downlink, err := testutil.DataDown(d)
+			if err != nil {
+				t.Fatalf("DataDown() error = %v", err)
+			}
+			downlink.MHDR.MType = tt.mtype
+			if err := downlink.SetDownlinkDataMIC(lorawan.LoRaWAN1_0, 0, d.Info.NwkSKey); err != nil {
+				t.Fatalf("SetDownlinkDataMIC() error = %v", err)
+			}
+			fcnt := d.Info.Status.FCntDown
+
+			before := time.Now()
+			if _, err := n.Cycle(d, downlink); err != nil {
+				t.Fatalf("Cycle() error = %v", err)
+			}
+
+			acks := d.GetDownlinkAcks()
+			if len(acks) != tt.wantAcks {
+				t.Fatalf("ledger has %d entries, want %d", len(acks), tt.wantAcks)
+			}
+			for _, ack := range acks {
+				if ack.FCnt != fcnt || !ack.AckSent || ack.Received.Before(before) {
+					t.Errorf("ledger entry %+v, want FCnt %d acknowledged after %v", ack, fcnt, before)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package device
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+)
+
+// codecSandbox stands for a device while its codec is tested: the send interval and the
+// log messages set by the codec are kept aside instead of reaching the live device
+type codecSandbox struct {
+	device       *Device
+	sendInterval time.Duration
+	logs         []string
+}
+
+func (s *codecSandbox) GetSendInterval() time.Duration { return s.sendInterval }
+
+func (s *codecSandbox) SetSendInterval(interval time.Duration) { s.sendInterval = interval }
+
+func (s *codecSandbox) GetPayloadConfig() map[string]interface{} { return s.device.GetPayloadConfig() }
+
+func (s *codecSandbox) Print(content string, err error, printType int) {
+	s.logs = append(s.logs, strings.TrimPrefix(content, "[CODEC] "))
+}
+
+// TestDownlinkCodec runs a downlink payload through the OnDownlink function of the codec of
+// the device, against a copy of its codec state, and reports the changes it would make.
+// The live device and its codec state are left untouched.
+func (d *Device) TestDownlinkCodec(test models.DownlinkCodecTest) (models.DownlinkCodecTestResult, error) {
+	var result models.DownlinkCodecTestResult
+
+	if Codecs == nil || !d.Info.Configuration.UseCodec || d.Info.Configuration.CodecID == 0 {
+		return result, errors.New("the device does not use a codec")
+	}
+	if test.FPort == 0 || test.FPort > 223 {
+		return result, fmt.Errorf("fPort must be between 1 and 223, got %d", test.FPort)
+	}
+	payload, err := hex.DecodeString(strings.TrimSpace(test.PayloadHex))
+	if err != nil {
+		return result, fmt.Errorf("invalid payload: %w", err)
+	}
+	if len(payload) == 0 {
+		return result, errors.New("the payload is empty, OnDownlink only runs for downlinks carrying data")
+	}
+
+	sandbox := &codecSandbox{device: d, sendInterval: d.GetSendInterval(), logs: []string{}}
+	decoded, before, after, err := Codecs.TestDecode(
+		d.Info.Configuration.CodecID,
+		d.Info.DevEUI.String(),
+		payload,
+		test.FPort,
+		sandbox,
+		d.codecTimeout(),
+	)
+	if err != nil {
+		return result, err
+	}
+
+	result.Decoded = decoded
+	result.Changes = codec.ChangedVariables(before, after)
+	result.SendIntervalBefore = int(d.GetSendInterval() / time.Second)
+	result.SendIntervalAfter = int(sandbox.sendInterval / time.Second)
+	result.Logs = sandbox.logs
+	return result, nil
+}
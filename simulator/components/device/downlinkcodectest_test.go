@@ -0,0 +1,73 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/brocaar/lorawan"
+)
+
+func TestDownlinkCodecTestLeavesDeviceUntouched(t *testing.T) {
+	registry := codec.NewRegistry(nil)
+	defer registry.Close()
+	previous := dev.Codecs
+	dev.Codecs = registry
+	defer func() { dev.Codecs = previous }()
+
+	c := codec.NewCodec("interval", `
+function OnUplink(config) { return [0]; }
+function OnDownlink(bytes, fPort) {
+    setState('commands', (getState('commands') || 0) + 1);
+    setSendInterval(bytes[0]);
+    log('Send interval set to ' + bytes[0] + 's');
+    return { interval: bytes[0] };
+}`)
+	if err := registry.AddCodec(c); err != nil {
+		t.Fatalf("AddCodec() error = %v", err)
+	}
+
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, lorawan.DevAddr{1, 2, 3, 4},
+		[16]byte{1}, [16]byte{2})
+	if _, err := d.TestDownlinkCodec(models.DownlinkCodecTest{PayloadHex: "1e", FPort: 1}); err == nil {
+		t.Fatal("TestDownlinkCodec() succeeded for a device without codec")
+	}
+
+	d.Info.Configuration.UseCodec = true
+	d.Info.Configuration.CodecID = c.ID
+	d.Info.Configuration.SendInterval = time.Minute
+	state := registry.GetOrCreateState(d.Info.DevEUI.String())
+	state.SetVariable("commands", 2)
+
+	result, err := d.TestDownlinkCodec(models.DownlinkCodecTest{PayloadHex: "1e", FPort: 1})
+	if err != nil {
+		t.Fatalf("TestDownlinkCodec() error = %v", err)
+	}
+	if result.SendIntervalBefore != 60 || result.SendIntervalAfter != 30 {
+		t.Errorf("send interval %ds -> %ds, want 60s -> 30s", result.SendIntervalBefore, result.SendIntervalAfter)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].Name != "commands" || result.Changes[0].After != int64(3) {
+		t.Errorf("changes = %+v, want commands 2 -> 3", result.Changes)
+	}
+	if len(result.Logs) != 1 || result.Logs[0] != "Send interval set to 30s" {
+		t.Errorf("logs = %q, want the codec log message", result.Logs)
+	}
+
+	if d.Info.Configuration.SendInterval != time.Minute {
+		t.Errorf("live send interval = %v, want 1m0s", d.Info.Configuration.SendInterval)
+	}
+	if got := state.GetVariable("commands"); got != 2 {
+		t.Errorf("live state commands = %v, want 2", got)
+	}
+
+	if _, err := d.TestDownlinkCodec(models.DownlinkCodecTest{PayloadHex: "1e", FPort: 0}); err == nil {
+		t.Error("TestDownlinkCodec() succeeded on fPort 0")
+	}
+	if _, err := d.TestDownlinkCodec(models.DownlinkCodecTest{PayloadHex: "zz", FPort: 1}); err == nil {
+		t.Error("TestDownlinkCodec() succeeded with an invalid payload")
+	}
+}
@@ -1,7 +1,10 @@
 package device
 
 import (
+	"encoding/hex"
+
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
 
 	act "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/activation"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/classes"
@@ -46,14 +49,20 @@ func (d *Device) ProcessDownlink(phy lorawan.PHYPayload) (*dl.InformationDownlin
 			return nil, err
 		}
 
+		index := d.recordDownlinkAck(d.Info.Status.FCntDown)
+		d.emitDownlinkAck(socket.AckPending)
+
 		d.SendAck()
 
+		d.markDownlinkAcked(index)
+		d.emitDownlinkAck(socket.AckSent)
+
 		// Decode downlink using codec if configured
 		d.decodeDownlinkWithCodec(payload, &phy)
 
 	}
 
-	d.Info.Status.FCntDown = (d.Info.Status.FCntDown + 1) % util.MAXFCNTGAP
+	d.Info.Status.FCntDown = util.NextFCnt(d.Info.Status.FCntDown, d.Info.Configuration.FCntBits)
 
 	switch d.Class.GetClass() {
 
@@ -77,20 +86,26 @@ func (d *Device) ProcessDownlink(phy lorawan.PHYPayload) (*dl.InformationDownlin
 	return payload, err
 }
 
-// decodeDownlinkWithCodec executes the OnDownlink codec function for its side effects
-func (d *Device) decodeDownlinkWithCodec(payload *dl.InformationDownlink, phy *lorawan.PHYPayload) {
-	// Check if codec is configured and payload has data
-	if Codecs == nil || d.Info.Configuration.CodecID == 0 || payload == nil {
-		return
-	}
+// emitDownlinkAck notifies the socket and the MQTT broker of the ACK status of the
+// current confirmed downlink
+func (d *Device) emitDownlinkAck(status string) {
+	d.emit(socket.EventDownlinkAck, socket.DownlinkAck{
+		Id:     d.Id,
+		Name:   d.Info.Name,
+		FCnt:   d.Info.Status.FCntDown,
+		Status: status,
+	})
+}
 
+// decodeDownlinkWithCodec executes the OnDownlink codec function, for its side effects
+// and for the object it returns, then reports the downlink payload to the socket and
+// the MQTT broker
+func (d *Device) decodeDownlinkWithCodec(payload *dl.InformationDownlink, phy *lorawan.PHYPayload) {
 	// Check if there's actual payload data
-	if payload.DataPayload == nil || len(payload.DataPayload) == 0 {
+	if payload == nil || len(payload.DataPayload) == 0 {
 		return
 	}
 
-	devEUI := d.Info.DevEUI.String()
-
 	// Extract FPort from PHYPayload (default to 1 if not set)
 	fPort := uint8(1)
 	if macPL, ok := phy.MACPayload.(*lorawan.MACPayload); ok {
@@ -99,16 +114,31 @@ func (d *Device) decodeDownlinkWithCodec(payload *dl.InformationDownlink, phy *l
 		}
 	}
 
-	// Execute OnDownlink for side effects (log, setState, setSendInterval)
-	err := Codecs.DecodePayload(
-		d.Info.Configuration.CodecID,
-		devEUI,
-		payload.DataPayload,
-		fPort,
-		d,
-	)
-
-	if err != nil {
-		d.Print("Codec OnDownlink failed: "+err.Error(), err, util.PrintBoth)
+	event := socket.Downlink{
+		Id:      d.Id,
+		Name:    d.Info.Name,
+		FCnt:    d.Info.Status.FCntDown,
+		FPort:   fPort,
+		Payload: hex.EncodeToString(payload.DataPayload),
 	}
+
+	// Decode only when the device uses its codec
+	if Codecs != nil && d.Info.Configuration.UseCodec && d.Info.Configuration.CodecID != 0 {
+		decoded, err := Codecs.DecodePayload(
+			d.Info.Configuration.CodecID,
+			d.Info.DevEUI.String(),
+			payload.DataPayload,
+			fPort,
+			d,
+			d.codecTimeout(),
+		)
+		if err != nil {
+			d.Print("Codec OnDownlink failed: "+err.Error(), err, util.PrintBoth)
+			event.Error = err.Error()
+		} else {
+			event.Decoded = decoded
+		}
+	}
+
+	d.emit(socket.EventDownlink, event)
 }
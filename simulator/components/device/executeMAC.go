@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"math/rand"
 	"time"
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/classes"
@@ -178,12 +177,17 @@ func (d *Device) executeLinkADRReq(commands [][]byte) {
 
 	if result {
 
-		d.Info.Status.DataRate = uint8(DataRate)
-		msg := fmt.Sprintf("Set new Datarate: %v", d.Info.Status.DataRate)
-		d.Print(msg, nil, util.PrintBoth)
+		if d.Info.Configuration.DataRateSweep == nil {
+			d.Info.Status.DataRate = uint8(DataRate)
+			msg := fmt.Sprintf("Set new Datarate: %v", d.Info.Status.DataRate)
+			d.Print(msg, nil, util.PrintBoth)
+		} else {
+			msg := fmt.Sprintf("Datarate %v ignored, the data rate sweep sets it", DataRate)
+			d.Print(msg, nil, util.PrintBoth)
+		}
 
 		d.Info.Status.TXPower = TXPower
-		msg = fmt.Sprintf("Set TX Power: %v", TXPower)
+		msg := fmt.Sprintf("Set TX Power: %v", TXPower)
 		d.Print(msg, nil, util.PrintBoth)
 
 		d.Info.Configuration.NbRepUnconfirmedDataUp = NbRep
@@ -308,8 +312,7 @@ func (d *Device) executeRXParamSetupReq(payload []byte) {
 
 func (d *Device) executeDevStatusReq() {
 
-	rand.Seed(time.Now().UTC().UnixNano())
-	margin := int8(rand.Int()) % MaxMargin //range
+	margin := int8(util.RandInt()) % MaxMargin //range
 
 	if margin < 0 {
 		margin = -margin
@@ -0,0 +1,59 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+)
+
+// setupFCnt applies the configured rollover width and, when the session has not
+// sent or received anything yet (new device or renewed keys), the start values.
+// Counters of an existing session are kept across restarts.
+func (d *Device) setupFCnt() {
+	d.Info.Status.DataUplink.FCntBits = d.Info.Configuration.FCntBits
+
+	if d.Info.Status.DataUplink.FCnt == 0 && d.Info.Status.FCntDown == 0 {
+		d.Info.Status.DataUplink.FCnt = d.Info.Configuration.FCntUpStart
+		d.Info.Status.FCntDown = d.Info.Configuration.FCntDownStart
+	}
+}
+
+// GetFrameCounters returns the current uplink and downlink frame counters
+func (d *Device) GetFrameCounters() models.FrameCounters {
+	d.Mutex.Lock()
+	defer d.Mutex.Unlock()
+	return models.FrameCounters{
+		FCntUp:   d.Info.Status.DataUplink.FCnt,
+		FCntDown: d.Info.Status.FCntDown,
+	}
+}
+
+// SetFrameCounters sets the current counters of a stopped device, e.g. to match a
+// network server that remembers a previous session. Nothing is applied if any value
+// exceeds the configured rollover width.
+func (d *Device) SetFrameCounters(update models.FrameCountersUpdate) (models.FrameCounters, error) {
+
+	if d.IsOn() {
+		return models.FrameCounters{}, errors.New("device is running, stop it before setting the frame counters")
+	}
+
+	max := util.MaxFCnt(d.Info.Configuration.FCntBits)
+	if (update.FCntUp != nil && *update.FCntUp > max) || (update.FCntDown != nil && *update.FCntDown > max) {
+		return models.FrameCounters{}, fmt.Errorf("frame counters must not exceed %d", max)
+	}
+
+	d.Mutex.Lock()
+	if update.FCntUp != nil {
+		d.Info.Status.DataUplink.FCnt = *update.FCntUp
+	}
+	if update.FCntDown != nil {
+		d.Info.Status.FCntDown = *update.FCntDown
+	}
+	d.Mutex.Unlock()
+
+	d.Print("Frame counters updated", nil, util.PrintBoth)
+
+	return d.GetFrameCounters(), nil
+}
@@ -1,6 +1,7 @@
 package features
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
@@ -32,31 +33,24 @@ func (w *Window) SetListeningFrequency(freq uint32) {
 	w.Channel.FrequencyDownlink = freq
 }
 
-func (w *Window) OpenWindow(Delay time.Duration, ReceivedDownlink *dl.ReceivedDownlink) *lorawan.PHYPayload {
+//OpenWindow waits for the window delay, then listens for a downlink while the window is open.
+//It returns nil at once if ctx is cancelled.
+func (w *Window) OpenWindow(ctx context.Context, Delay time.Duration, ReceivedDownlink *dl.ReceivedDownlink) *lorawan.PHYPayload {
 
 	if Delay == 0 {
 		Delay = w.Delay
 	}
 
 	timerWindow := time.NewTimer(Delay)
-	<-timerWindow.C //delay
-	timerWindow.Stop()
-
-	for {
-
-		go func(durate time.Duration, buf *dl.ReceivedDownlink) {
-
-			timer := time.NewTimer(durate)
-			<-timer.C
-			timer.Stop()
-
-			buf.Signal()
-
-		}(w.DurationOpen, ReceivedDownlink)
-
-		return ReceivedDownlink.Pull()
+	defer timerWindow.Stop()
 
+	select {
+	case <-timerWindow.C: //delay
+	case <-ctx.Done():
+		return nil
 	}
+
+	return ReceivedDownlink.PullWithin(ctx, w.DurationOpen)
 }
 
 //MarshalJSON of device's Receive window
@@ -1,7 +1,9 @@
 package downlink
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/brocaar/lorawan"
 )
@@ -50,6 +52,37 @@ func (b *ReceivedDownlink) Pull() *lorawan.PHYPayload {
 
 }
 
+// PullWithin waits up to timeout for a downlink, returning nil if none arrived or
+// if ctx was cancelled meanwhile
+func (b *ReceivedDownlink) PullWithin(ctx context.Context, timeout time.Duration) *lorawan.PHYPayload {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	expired := false
+	stop := context.AfterFunc(ctx, func() {
+		b.Mutex.Lock()
+		expired = true
+		b.Notify.Broadcast()
+		b.Mutex.Unlock()
+	})
+	defer stop()
+
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	for b.Downlink == nil && !expired {
+		b.Notify.Wait()
+	}
+
+	phy := b.Downlink
+
+	b.Downlink = nil //reset
+
+	return phy
+
+}
+
 func (b *ReceivedDownlink) Wait() {
 	b.Mutex.Lock()
 	b.Notify.Wait()
@@ -0,0 +1,53 @@
+package downlink
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brocaar/lorawan"
+)
+
+func newBuffer() *ReceivedDownlink {
+	b := &ReceivedDownlink{IsOpen: true}
+	b.Notify = sync.NewCond(&b.Mutex)
+	return b
+}
+
+func TestPullWithinReturnsDownlink(t *testing.T) {
+	b := newBuffer()
+	phy := &lorawan.PHYPayload{}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.Push(phy)
+	}()
+
+	if got := b.PullWithin(context.Background(), time.Second); got != phy {
+		t.Errorf("PullWithin() = %v, want the pushed downlink", got)
+	}
+}
+
+func TestPullWithinTimesOut(t *testing.T) {
+	b := newBuffer()
+	if got := b.PullWithin(context.Background(), 10*time.Millisecond); got != nil {
+		t.Errorf("PullWithin() = %v, want nil", got)
+	}
+}
+
+func TestPullWithinStopsOnCancel(t *testing.T) {
+	b := newBuffer()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	if got := b.PullWithin(ctx, time.Minute); got != nil {
+		t.Errorf("PullWithin() = %v, want nil", got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("PullWithin() returned after %v, want it to stop on cancel", elapsed)
+	}
+}
@@ -13,6 +13,7 @@ type InfoUplink struct {
 	DwellTime     lorawan.DwellTime `json:"-"`
 	ClassB        bool              `json:"-"`
 	FCnt          uint32            `json:"fcnt"`
+	FCntBits      int               `json:"-"` // rollover width, see util.NextFCnt
 	FOpts         []lorawan.Payload `json:"-"`
 	FPort         *uint8            `json:"fport"`
 	ADR           adr.ADRInfo       `json:"-"`
@@ -53,7 +54,7 @@ func (up *InfoUplink) GetFrame(mtype lorawan.MType, payload lorawan.DataPayload,
 		return []byte{}, err
 	}
 
-	up.FCnt = (up.FCnt + 1) % util.MAXFCNTGAP
+	up.FCnt = util.NextFCnt(up.FCnt, up.FCntBits)
 	up.ADR.ADRACKCnt++
 
 	return bytes, nil
@@ -114,32 +115,50 @@ func (up *InfoUplink) IsTherePingSlotInfoReq() bool {
 
 }
 
-func Fragmentation(size int, payload lorawan.Payload) []lorawan.DataPayload {
+// FragmentHeaderSize is the size of the header starting each fragment: the index of
+// the fragment (from 0) and the number of fragments of the payload, one byte each
+const FragmentHeaderSize = 2
+
+// MaxFragments is the largest number of fragments a payload can be split into
+const MaxFragments = 255
 
-	var FRMPayload []lorawan.DataPayload
+// Fragmentation splits the payload into fragments of at most size bytes, each starting
+// with the fragment header. A payload fitting in size is returned whole, without header.
+// It returns nil when the payload can't be fragmented: size leaves no room for data
+// after the header, or more than MaxFragments would be needed.
+func Fragmentation(size int, payload lorawan.Payload) []lorawan.DataPayload {
 
 	payloadBytes, _ := payload.MarshalBinary()
 
-	if size == 0 {
-		return FRMPayload
+	if len(payloadBytes) <= size {
+		return []lorawan.DataPayload{{Bytes: payloadBytes}}
 	}
 
-	nFrame := len(payloadBytes) / size
+	chunk := size - FragmentHeaderSize
+	if chunk <= 0 {
+		return nil
+	}
 
-	for i := 0; i <= nFrame; i++ {
+	nFrame := (len(payloadBytes) + chunk - 1) / chunk
+	if nFrame > MaxFragments {
+		return nil
+	}
 
-		var data lorawan.DataPayload
+	FRMPayload := make([]lorawan.DataPayload, 0, nFrame)
 
-		offset := i * size
+	for i := 0; i < nFrame; i++ {
 
-		if i != nFrame {
-			data.Bytes = payloadBytes[offset : offset+size]
-		} else {
-			data.Bytes = payloadBytes[offset:len(payloadBytes)]
+		offset := i * chunk
+		last := offset + chunk
+		if last > len(payloadBytes) {
+			last = len(payloadBytes)
 		}
 
-		FRMPayload = append(FRMPayload, data)
+		data := lorawan.DataPayload{Bytes: make([]byte, 0, FragmentHeaderSize+last-offset)}
+		data.Bytes = append(data.Bytes, byte(i), byte(nFrame))
+		data.Bytes = append(data.Bytes, payloadBytes[offset:last]...)
 
+		FRMPayload = append(FRMPayload, data)
 	}
 
 	return FRMPayload
@@ -2,8 +2,6 @@ package device
 
 import (
 	"fmt"
-	"math/rand"
-	"time"
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/classes"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/adr"
@@ -11,6 +9,7 @@ import (
 	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/metrics"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
 	"github.com/brocaar/lorawan"
 )
 
@@ -22,6 +21,7 @@ func (d *Device) Execute() {
 	err = nil
 	downlink = nil
 
+	d.sweepDataRate()
 	d.SwitchChannel()
 
 	uplinks := d.CreateUplink()
@@ -31,11 +31,19 @@ func (d *Device) Execute() {
 		d.Class.SendData(data)
 
 		d.Print("Uplink sent", nil, util.PrintBoth)
+		d.emit(socket.EventUplink, socket.Uplink{
+			Id:         d.Id,
+			Name:       d.Info.Name,
+			Frequency:  data.Frequency,
+			DataRate:   data.DatR,
+			PHYPayload: data.Data,
+		})
 		metrics.UplinksTotal.Inc()
+		d.countUplink()
 	}
 
 	d.Print("Open RXs", nil, util.PrintBoth)
-	phy := d.Class.ReceiveWindows(0, 0)
+	phy := d.Class.ReceiveWindows(d.runContext(), 0, 0)
 
 	if phy != nil {
 
@@ -43,6 +51,7 @@ func (d *Device) Execute() {
 		metrics.DownlinksTotal.Inc()
 
 		downlink, err = d.ProcessDownlink(*phy)
+		d.countDownlink()
 		if err != nil {
 			d.Print("", err, util.PrintBoth)
 			return
@@ -62,8 +71,9 @@ func (d *Device) Execute() {
 
 		d.Print("None downlinks Received", nil, util.PrintBoth)
 
-		timerAckTimeout := time.NewTimer(d.Info.Configuration.AckTimeout)
-		<-timerAckTimeout.C
+		if !d.wait(d.ackTimeout()) { //stop
+			return
+		}
 
 		d.Print("ACK Timeout", nil, util.PrintBoth)
 	}
@@ -93,6 +103,7 @@ func (d *Device) Execute() {
 		if d.Info.Status.Mode == util.Retransmission {
 
 			d.Info.Status.DataRate = rp.DecrementDataRate(d.Info.Configuration.Region, d.Info.Status.DataRate)
+			d.emitRetransmission()
 
 		}
 
@@ -133,7 +144,7 @@ func (d *Device) FPendingProcedure(downlink *dl.InformationDownlink) {
 			//ack sent in resolveDownlinks ergo open Receive Windows
 
 			d.Print("Open RXs", nil, util.PrintBoth)
-			phy := d.Class.ReceiveWindows(0, 0)
+			phy := d.Class.ReceiveWindows(d.runContext(), 0, 0)
 
 			if !d.CanExecute() { //stop
 				return
@@ -145,6 +156,7 @@ func (d *Device) FPendingProcedure(downlink *dl.InformationDownlink) {
 				metrics.DownlinksTotal.Inc()
 
 				downlink, err = d.ProcessDownlink(*phy)
+				d.countDownlink()
 				if err != nil {
 					d.Print("", err, util.PrintBoth)
 
@@ -162,8 +174,9 @@ func (d *Device) FPendingProcedure(downlink *dl.InformationDownlink) {
 
 				d.Print("None downlinks Received", nil, util.PrintBoth)
 
-				timerAckTimeout := time.NewTimer(d.Info.Configuration.AckTimeout)
-				<-timerAckTimeout.C
+				if !d.wait(d.ackTimeout()) { //stop
+					return
+				}
 
 				d.Print("ACK Timeout", nil, util.PrintBoth)
 
@@ -193,7 +206,9 @@ func (d *Device) ADRProcedure() {
 	switch code {
 
 	case adr.CodeNoneError:
-		d.Info.Status.DataRate = dr
+		if d.Info.Configuration.DataRateSweep == nil { //the sweep sets the data rate
+			d.Info.Status.DataRate = dr
+		}
 		break
 
 	case adr.CodeADRFlagReqSet:
@@ -217,8 +232,6 @@ func (d *Device) ADRProcedure() {
 
 func (d *Device) SwitchChannel() {
 
-	rand.Seed(time.Now().UTC().UnixNano())
-
 	lenChannels := len(d.Info.Configuration.Channels)
 	chanUsed := make(map[int]bool)
 	lenTrue := 1
@@ -271,14 +284,14 @@ func (d *Device) SwitchChannel() {
 		//random
 		if regionCode == rp.Code_Us915 {
 
-			random = (rand.Int() % 8) + indexGroup*8
+			random = (util.RandInt() % 8) + indexGroup*8
 
 			for random == d.Info.Status.InfoChannelsUS915.ListChannelsLastPass[indexGroup] {
-				random = (rand.Int() % 8) + indexGroup*8
+				random = (util.RandInt() % 8) + indexGroup*8
 			}
 
 		} else {
-			random = rand.Int() % lenChannels
+			random = util.RandInt() % lenChannels
 		}
 
 		if !chanUsed[random] { //evita il loop infinito
@@ -312,6 +325,13 @@ func (d *Device) SwitchChannel() {
 
 	}
 
+	// no other channel is usable: keep the current one if it still is
+	current := d.Info.Configuration.Channels[d.Info.Status.IndexchannelActive]
+	if regionCode != rp.Code_Us915 && current.Active && current.EnableUplink &&
+		current.IsSupportedDR(d.Info.Status.DataRate) == nil {
+		return
+	}
+
 	if lenTrue == lenChannels { //nessun canale abilitato all'uplink supporta il DataRate
 
 		var msg string
@@ -330,6 +350,12 @@ func (d *Device) SwitchChannel() {
 
 		} else {
 			d.Info.Status.IndexchannelActive = uint16(0)
+			for i, ch := range d.Info.Configuration.Channels { // first channel enabled to send uplinks
+				if ch.Active && ch.EnableUplink {
+					d.Info.Status.IndexchannelActive = uint16(i)
+					break
+				}
+			}
 		}
 
 		d.Info.Status.DataRate = d.Info.Configuration.Channels[d.Info.Status.IndexchannelActive].MaxDR
@@ -352,6 +378,8 @@ func (d *Device) SwitchClass(class int) {
 		return
 	}
 
+	d.Class.CloseRX2()
+
 	switch class {
 
 	case classes.ClassA:
@@ -379,6 +407,14 @@ func (d *Device) SwitchClass(class int) {
 
 }
 
+// updatePingSlots hands the DevAddr and periodicity of the device info to its ping
+// slots, if it is in class B. Called on the run loop.
+func (d *Device) updatePingSlots() {
+	if b, ok := d.Class.(*classes.TypeB); ok {
+		b.SetPingSlotParams(d.Info.DevAddr, d.Info.Status.InfoClassB.Periodicity)
+	}
+}
+
 //se il dispositivo non supporta OTAA non può essere unjoined
 func (d *Device) UnJoined() bool {
 
@@ -0,0 +1,93 @@
+package device
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	dl "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/frames/downlink"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/brocaar/lorawan"
+)
+
+// injectableMACCommands are the downlink MAC commands handled by ExecuteMACCommand
+var injectableMACCommands = map[string]lorawan.CID{
+	"LinkCheckAns":       lorawan.LinkCheckAns,
+	"LinkADRReq":         lorawan.LinkADRReq,
+	"DutyCycleReq":       lorawan.DutyCycleReq,
+	"RXParamSetupReq":    lorawan.RXParamSetupReq,
+	"DevStatusReq":       lorawan.DevStatusReq,
+	"NewChannelReq":      lorawan.NewChannelReq,
+	"RXTimingSetupReq":   lorawan.RXTimingSetupReq,
+	"DLChannelReq":       lorawan.DLChannelReq,
+	"TXParamSetupReq":    lorawan.TXParamSetupReq,
+	"DeviceTimeAns":      lorawan.DeviceTimeAns,
+	"PingSlotChannelReq": lorawan.PingSlotChannelReq,
+	"PingSlotInfoAns":    lorawan.PingSlotInfoAns,
+	"BeaconFreqReq":      lorawan.BeaconFreqReq,
+}
+
+// InjectMACCommand executes a downlink MAC command as if the network server had sent
+// it, and returns the answers the device queued for its next uplink
+func (d *Device) InjectMACCommand(injection models.MACInjection) ([]lorawan.Payload, error) {
+
+	if !d.IsOn() {
+		return nil, errors.New("device is not running, start it before injecting MAC commands")
+	}
+
+	name := strings.TrimSpace(injection.CID)
+	cid, ok := injectableMACCommands[name]
+	if !ok {
+		return nil, fmt.Errorf("%q is not a downlink MAC command handled by the device", name)
+	}
+
+	payload, err := hex.DecodeString(strings.TrimSpace(injection.PayloadHex))
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+
+	size := 0
+	if _, s, err := lorawan.GetMACPayloadAndSize(false, cid); err == nil {
+		size = s
+	}
+	if len(payload) != size {
+		return nil, fmt.Errorf("%s needs a payload of %d bytes, got %d", name, size, len(payload))
+	}
+
+	downlink := dl.InformationDownlink{
+		MType: lorawan.UnconfirmedDataDown,
+		FOptsReceived: []lorawan.Payload{
+			&lorawan.DataPayload{Bytes: append([]byte{byte(cid)}, payload...)},
+		},
+	}
+
+	// The run loop changes the same MAC command queues, channels and data rate
+	answers := []lorawan.Payload{}
+	executed := d.runOnLoop(func() {
+		before := d.macAnswerQueues()
+		d.ExecuteMACCommand(downlink)
+
+		for i, queue := range d.macAnswerQueues() {
+			if len(queue) > len(before[i]) {
+				answers = append(answers, queue[len(before[i]):]...)
+			}
+		}
+	})
+	if !executed {
+		return nil, errors.New("device turned off before the MAC command was executed")
+	}
+	return answers, nil
+}
+
+// macAnswerQueues returns the MAC answers waiting for the next uplink: those repeated
+// until a downlink arrives, by command, then the others
+func (d *Device) macAnswerQueues() [][]lorawan.Payload {
+	acks := &d.Info.Status.DataUplink.AckMacCommand
+	return [][]lorawan.Payload{
+		acks.GetRXParamSetupAns(),
+		acks.GetDLChannelAns(),
+		acks.GetRXTimingSetupAns(),
+		d.Info.Status.DataUplink.FOpts,
+	}
+}
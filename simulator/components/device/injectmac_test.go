@@ -0,0 +1,74 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/brocaar/lorawan"
+)
+
+func TestInjectMACCommand(t *testing.T) {
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, lorawan.DevAddr{1, 2, 3, 4},
+		[16]byte{1}, [16]byte{2})
+
+	answers, err := d.InjectMACCommand(models.MACInjection{CID: "DevStatusReq"})
+	if err != nil {
+		t.Fatalf("InjectMACCommand(DevStatusReq) error = %v", err)
+	}
+	if len(answers) != 1 {
+		t.Fatalf("got %d answers, want 1", len(answers))
+	}
+	if cmd, ok := answers[0].(*lorawan.MACCommand); !ok || cmd.CID != lorawan.DevStatusAns {
+		t.Errorf("answer = %v, want a DevStatusAns", answers[0])
+	}
+
+	// RX1 delay of 5 s
+	answers, err = d.InjectMACCommand(models.MACInjection{CID: "RXTimingSetupReq", PayloadHex: "05"})
+	if err != nil {
+		t.Fatalf("InjectMACCommand(RXTimingSetupReq) error = %v", err)
+	}
+	if len(answers) != 1 {
+		t.Fatalf("got %d answers, want 1", len(answers))
+	}
+	if cmd, ok := answers[0].(*lorawan.MACCommand); !ok || cmd.CID != lorawan.RXTimingSetupAns {
+		t.Errorf("answer = %v, want a RXTimingSetupAns", answers[0])
+	}
+
+	invalid := []models.MACInjection{
+		{CID: "LinkCheckReq"},                      // uplink command
+		{CID: "LinkADRReq", PayloadHex: "0102"},    // 4 bytes expected
+		{CID: "RXTimingSetupReq", PayloadHex: "z"}, // not hex
+	}
+	for _, injection := range invalid {
+		if _, err := d.InjectMACCommand(injection); err == nil {
+			t.Errorf("InjectMACCommand(%+v) succeeded, want an error", injection)
+		}
+	}
+}
+
+func TestInjectMACCommandOnRunningDevice(t *testing.T) {
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 10}, lorawan.DevAddr{1, 2, 3, 6},
+		[16]byte{1}, [16]byte{2})
+	d.Info.Configuration.SendInterval = 20 * time.Millisecond
+
+	n.Start(d)
+	defer n.Stop(d)
+
+	// Executed by the run loop between the uplinks, without racing with them
+	for i := 0; i < 5; i++ {
+		answers, err := d.InjectMACCommand(models.MACInjection{CID: "DevStatusReq"})
+		if err != nil {
+			t.Fatalf("InjectMACCommand() error = %v", err)
+		}
+		if len(answers) != 1 {
+			t.Fatalf("got %d answers, want 1", len(answers))
+		}
+		if _, err := n.NextUplink(testutil.CycleTimeout); err != nil {
+			t.Fatalf("uplink %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package device
+
+import (
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+)
+
+// recordJoinAttempt counts a JoinRequest sent by the current activation
+func (d *Device) recordJoinAttempt() {
+	d.countersMu.Lock()
+	d.Info.Status.Join.Attempts++
+	d.Info.Status.Join.DevNonce = uint16(d.Info.DevNonce)
+	d.Info.Status.Join.LastAttempt = time.Now()
+	d.countersMu.Unlock()
+}
+
+// recordJoin stores the time of a successful join
+func (d *Device) recordJoin() {
+	d.countersMu.Lock()
+	d.Info.Status.Join.LastJoin = time.Now()
+	d.countersMu.Unlock()
+}
+
+// GetJoinStatus returns whether the device joined, the JoinRequests sent by the
+// current or last activation and when it last tried and succeeded
+func (d *Device) GetJoinStatus() models.JoinStatus {
+	d.countersMu.Lock()
+	defer d.countersMu.Unlock()
+
+	status := models.JoinStatus{
+		Otaa:     d.Info.Configuration.SupportedOtaa,
+		Joined:   d.Info.Status.Joined,
+		Attempts: d.Info.Status.Join.Attempts,
+		DevNonce: d.Info.Status.Join.DevNonce,
+	}
+	if t := d.Info.Status.Join.LastAttempt; !t.IsZero() {
+		status.LastAttempt = &t
+	}
+	if t := d.Info.Status.Join.LastJoin; !t.IsZero() {
+		status.LastJoin = &t
+	}
+	return status
+}
@@ -0,0 +1,133 @@
+package device_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestJoinStatus(t *testing.T) {
+	util.SetSeed(1)
+
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 14, 0}, lorawan.DevAddr{1, 2, 10, 0}, [16]byte{1}, [16]byte{2})
+
+	if status := d.GetJoinStatus(); status.Otaa || status.Attempts != 0 || status.LastAttempt != nil {
+		t.Fatalf("ABP device join status = %+v, want no OTAA attempt", status)
+	}
+
+	appKey := [16]byte{3}
+	d.Info.Configuration.SupportedOtaa = true
+	d.Info.Configuration.JoinAcceptDelay1 = 10
+	d.Info.Configuration.JoinAcceptDelay2 = 20
+	d.Info.AppKey = appKey
+	d.Info.JoinEUI = lorawan.EUI64{9}
+	d.Info.Status.Joined = false
+
+	done := make(chan struct{})
+	go func() {
+		d.OtaaActivation()
+		close(done)
+	}()
+	defer func() { // ends the activation if the device never joins
+		d.Mutex.Lock()
+		d.State = util.Stopped
+		d.Mutex.Unlock()
+		<-done
+	}()
+
+	steps := []struct {
+		name       string
+		accept     bool // answer the JoinRequest with a Join Accept
+		wantJoined bool
+	}{
+		{"unanswered join request", false, false},
+		{"accepted join request", true, true},
+	}
+	for i, step := range steps {
+		rxpk, err := n.NextUplink(5 * time.Second)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		raw, err := base64.StdEncoding.DecodeString(rxpk.Data)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		var phy lorawan.PHYPayload
+		if err := phy.UnmarshalBinary(raw); err != nil {
+			t.Fatalf("%s: UnmarshalBinary() error = %v", step.name, err)
+		}
+		request, ok := phy.MACPayload.(*lorawan.JoinRequestPayload)
+		if !ok {
+			t.Fatalf("%s: uplink %s, want a JoinRequest", step.name, phy.MHDR.MType)
+		}
+
+		if step.accept {
+			if err := acceptJoin(n, d, d.Info.JoinEUI, request.DevNonce, appKey); err != nil {
+				t.Fatalf("%s: %v", step.name, err)
+			}
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s: the device did not join", step.name)
+			}
+		}
+
+		status := d.GetJoinStatus()
+		for deadline := time.Now().Add(time.Second); status.Attempts < i+1 && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+			status = d.GetJoinStatus()
+		}
+		if !status.Otaa || status.Attempts != i+1 || status.DevNonce != uint16(request.DevNonce) {
+			t.Errorf("%s: status = %+v, want attempt %d with DevNonce %d", step.name, status, i+1, request.DevNonce)
+		}
+		if status.LastAttempt == nil {
+			t.Errorf("%s: no last attempt", step.name)
+		}
+		if status.Joined != step.wantJoined || (status.LastJoin != nil) != step.wantJoined {
+			t.Errorf("%s: joined = %v, last join %v, want joined %v", step.name, status.Joined, status.LastJoin, step.wantJoined)
+		}
+	}
+}
+
+// acceptJoin sends a Join Accept as soon as the first receive window of the join opens
+func acceptJoin(n *testutil.Network, d *dev.Device, joinEUI lorawan.EUI64,
+	devNonce lorawan.DevNonce, appKey lorawan.AES128Key) error {
+
+	phy := lorawan.PHYPayload{
+		MHDR: lorawan.MHDR{MType: lorawan.JoinAccept, Major: lorawan.LoRaWANR1},
+		MACPayload: &lorawan.JoinAcceptPayload{
+			JoinNonce: 1,
+			HomeNetID: lorawan.NetID{0, 0, 1},
+			DevAddr:   lorawan.DevAddr{1, 2, 10, 1},
+		},
+	}
+	if err := phy.SetDownlinkJoinMIC(lorawan.JoinRequestType, joinEUI, devNonce, appKey); err != nil {
+		return err
+	}
+	if err := phy.EncryptJoinAcceptPayload(appKey); err != nil {
+		return err
+	}
+	raw, err := phy.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		d.Info.ReceivedDownlink.Mutex.Lock()
+		open := d.Info.ReceivedDownlink.IsOpen
+		d.Info.ReceivedDownlink.Mutex.Unlock()
+
+		if open {
+			n.Forwarder.Downlink(&phy, d.Info.RX[0].GetListeningFrequency(), n.Gateway, nil, raw)
+			return nil
+		}
+	}
+	return errors.New("the receive window of the join did not open")
+}
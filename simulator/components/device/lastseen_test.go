@@ -0,0 +1,45 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestLastUplinkAndDownlinkTimes(t *testing.T) {
+	util.SetSeed(1)
+
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, lorawan.DevAddr{1, 2, 3, 4},
+		[16]byte{1}, [16]byte{2})
+
+	if d.Info.Status.LastUplinkAt != nil || d.Info.Status.LastDownlinkAt != nil {
+		t.Fatalf("new device has LastUplinkAt/LastDownlinkAt = %v/%v, want nil", d.Info.Status.LastUplinkAt, d.Info.Status.LastDownlinkAt)
+	}
+
+	before := time.Now()
+	if _, err := n.Cycle(d, nil); err != nil {
+		t.Fatalf("Cycle() error = %v", err)
+	}
+	if d.Info.Status.LastUplinkAt == nil || d.Info.Status.LastUplinkAt.Before(before) {
+		t.Errorf("LastUplinkAt = %v after an uplink, want at least %v", d.Info.Status.LastUplinkAt, before)
+	}
+	if d.Info.Status.LastDownlinkAt != nil {
+		t.Errorf("LastDownlinkAt = %v without downlink, want nil", d.Info.Status.LastDownlinkAt)
+	}
+
+	downlink, err := testutil.DataDown(d)
+	if err != nil {
+		t.Fatalf("DataDown() error = %v", err)
+	}
+	before = time.Now()
+	if _, err := n.Cycle(d, downlink); err != nil {
+		t.Fatalf("Cycle() error = %v", err)
+	}
+	if d.Info.Status.LastDownlinkAt == nil || d.Info.Status.LastDownlinkAt.Before(before) {
+		t.Errorf("LastDownlinkAt = %v after a downlink, want at least %v", d.Info.Status.LastDownlinkAt, before)
+	}
+}
@@ -0,0 +1,137 @@
+package device
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+// MaxFOptsSize is the largest size, in bytes, of the MAC commands carried by one uplink
+const MaxFOptsSize = 15
+
+// uplinkMACCommands are the MAC commands of LoRaWAN 1.0.x a device sends to the network server
+var uplinkMACCommands = map[string]lorawan.CID{
+	"LinkCheckReq":       lorawan.LinkCheckReq,
+	"LinkADRAns":         lorawan.LinkADRAns,
+	"DutyCycleAns":       lorawan.DutyCycleAns,
+	"RXParamSetupAns":    lorawan.RXParamSetupAns,
+	"DevStatusAns":       lorawan.DevStatusAns,
+	"NewChannelAns":      lorawan.NewChannelAns,
+	"RXTimingSetupAns":   lorawan.RXTimingSetupAns,
+	"TXParamSetupAns":    lorawan.TXParamSetupAns,
+	"DLChannelAns":       lorawan.DLChannelAns,
+	"DeviceTimeReq":      lorawan.DeviceTimeReq,
+	"PingSlotInfoReq":    lorawan.PingSlotInfoReq,
+	"PingSlotChannelAns": lorawan.PingSlotChannelAns,
+	"BeaconFreqAns":      lorawan.BeaconFreqAns,
+}
+
+// QueueMACCommands queues a batch of MAC commands for the next uplink, all of them or none:
+// the batch fails if a command is invalid or if the FOpts, with the MAC commands already
+// waiting, would exceed 15 bytes. It returns the MAC commands waiting for the next uplink.
+func (d *Device) QueueMACCommands(batch []models.UplinkMACCommand) ([]lorawan.Payload, error) {
+
+	if !d.IsOn() {
+		return nil, errors.New("device is not running, start it before queuing MAC commands")
+	}
+	if len(batch) == 0 {
+		return nil, errors.New("the batch has no MAC command")
+	}
+
+	commands := make([]lorawan.Payload, 0, len(batch))
+	for i, cmd := range batch {
+		command, err := d.uplinkMACCommand(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("command %d: %w", i, err)
+		}
+		commands = append(commands, command)
+	}
+
+	// The run loop sends and clears the same MAC command queues
+	var queue []lorawan.Payload
+	var err error
+	executed := d.runOnLoop(func() {
+		if size := foptsSize(d.waitingMACCommands()) + foptsSize(commands); size > MaxFOptsSize {
+			err = fmt.Errorf("the MAC commands would take %d bytes of FOpts, max %d", size, MaxFOptsSize)
+			return
+		}
+
+		for _, command := range commands {
+			if mac, ok := command.(*lorawan.MACCommand); ok && mac.CID == lorawan.PingSlotInfoReq {
+				d.Info.Status.InfoClassB.Periodicity = mac.Payload.(*lorawan.PingSlotInfoReqPayload).Periodicity
+			}
+		}
+		d.updatePingSlots()
+		d.newMACComands(commands)
+		queue = d.waitingMACCommands()
+	})
+	if !executed {
+		return nil, errors.New("device turned off before the MAC commands were queued")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d.Print(fmt.Sprintf("%d MAC commands will be sent in the next uplink", len(commands)), nil, util.PrintBoth)
+	return queue, nil
+}
+
+// uplinkMACCommand builds a MAC command from its name and hex payload
+func (d *Device) uplinkMACCommand(cmd models.UplinkMACCommand) (lorawan.Payload, error) {
+
+	name := strings.TrimSpace(cmd.CID)
+	cid, ok := uplinkMACCommands[name]
+	if !ok {
+		return nil, fmt.Errorf("%q is not an uplink MAC command", name)
+	}
+	if cid == lorawan.PingSlotInfoReq && !d.Info.Configuration.SupportedClassB {
+		return nil, errors.New("PingSlotInfoReq needs a device supporting class B")
+	}
+
+	payload, err := hex.DecodeString(strings.TrimSpace(cmd.PayloadHex))
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+
+	command := &lorawan.MACCommand{CID: cid}
+	pl, size, err := lorawan.GetMACPayloadAndSize(true, cid)
+	if err != nil { // no payload
+		size = 0
+	}
+	if len(payload) != size {
+		return nil, fmt.Errorf("%s needs a payload of %d bytes, got %d", name, size, len(payload))
+	}
+	if size > 0 {
+		if err := pl.UnmarshalBinary(payload); err != nil {
+			return nil, fmt.Errorf("invalid %s payload: %w", name, err)
+		}
+		command.Payload = pl
+	}
+
+	return command, nil
+}
+
+// waitingMACCommands returns the MAC commands waiting for the next uplink, answers first
+func (d *Device) waitingMACCommands() []lorawan.Payload {
+	var commands []lorawan.Payload
+	for _, queue := range d.macAnswerQueues() {
+		commands = append(commands, queue...)
+	}
+	return commands
+}
+
+// foptsSize returns the size, in bytes, of MAC commands once encoded in FOpts
+func foptsSize(commands []lorawan.Payload) int {
+	size := 0
+	for _, cmd := range commands {
+		if b, err := cmd.MarshalBinary(); err == nil {
+			size += len(b)
+		}
+	}
+	return size
+}
@@ -0,0 +1,57 @@
+package device_test
+
+import (
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/brocaar/lorawan"
+)
+
+func TestQueueMACCommands(t *testing.T) {
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 9}, lorawan.DevAddr{1, 2, 3, 5},
+		[16]byte{1}, [16]byte{2})
+
+	queue, err := d.QueueMACCommands([]models.UplinkMACCommand{
+		{CID: "LinkCheckReq"},
+		{CID: "DeviceTimeReq"},
+		{CID: "DevStatusAns", PayloadHex: "fe05"},
+	})
+	if err != nil {
+		t.Fatalf("QueueMACCommands() error = %v", err)
+	}
+	if len(queue) != 3 {
+		t.Fatalf("got %d waiting commands, want 3", len(queue))
+	}
+
+	invalid := [][]models.UplinkMACCommand{
+		{},                      // empty batch
+		{{CID: "LinkCheckAns"}}, // downlink command
+		{{CID: "DevStatusAns", PayloadHex: "fe"}},                           // 2 bytes expected
+		{{CID: "LinkCheckReq"}, {CID: "PingSlotInfoReq", PayloadHex: "01"}}, // no class B
+	}
+	for _, batch := range invalid {
+		if _, err := d.QueueMACCommands(batch); err == nil {
+			t.Errorf("QueueMACCommands(%+v) succeeded, want an error", batch)
+		}
+	}
+
+	// 5 bytes already waiting: 11 more bytes of DevStatusAns exceed the 15 bytes of FOpts
+	tooLong := make([]models.UplinkMACCommand, 0, 4)
+	for i := 0; i < 4; i++ {
+		tooLong = append(tooLong, models.UplinkMACCommand{CID: "DevStatusAns", PayloadHex: "fe05"})
+	}
+	if _, err := d.QueueMACCommands(tooLong); err == nil {
+		t.Error("QueueMACCommands() over 15 bytes succeeded, want an error")
+	}
+
+	// a failed batch queues nothing
+	queue, err = d.QueueMACCommands([]models.UplinkMACCommand{{CID: "LinkCheckReq"}})
+	if err != nil {
+		t.Fatalf("QueueMACCommands() error = %v", err)
+	}
+	if len(queue) != 4 {
+		t.Errorf("got %d waiting commands, want 4", len(queue))
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+// ChannelInfo describes one channel of the device's current channel plan
+type ChannelInfo struct {
+	Index             int    `json:"index"`
+	Active            bool   `json:"active"`       // Channel is defined (by the region or a NewChannelReq)
+	EnableUplink      bool   `json:"enableUplink"` // Channel can be picked for uplinks
+	FrequencyUplink   uint32 `json:"freqUplink"`
+	FrequencyDownlink uint32 `json:"freqDownlink"`
+	MinDR             uint8  `json:"minDR"`
+	MaxDR             uint8  `json:"maxDR"`
+}
+
+// PayloadLimit is the largest application payload the device can send in its next
+// uplink, at its current data rate
+type PayloadLimit struct {
+	DataRate   uint8 `json:"dataRate"`
+	MaxPayload int   `json:"maxPayload"` // Bytes of FRMPayload, once pending MAC commands are in FOpts
+}
+
+// ChannelsUpdate holds the channel indices to enable or disable for uplinks
+type ChannelsUpdate struct {
+	Enable  []int `json:"enable"`
+	Disable []int `json:"disable"`
+}
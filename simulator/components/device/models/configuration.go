@@ -2,15 +2,25 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/profiles"
 	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+)
+
+// Bounds of the codec timeout of a device, as for the codecTimeoutMs of the server
+const (
+	MinCodecTimeoutMs = 10
+	MaxCodecTimeoutMs = 60000
 )
 
 //Configuration contains conf of device
 type Configuration struct {
-	Region rp.Region `json:"region"`
+	Region       rp.Region `json:"region"`
+	AS923SubBand int       `json:"as923SubBand"` // Frequency plan AS923-1 to AS923-4 when Region is AS923 (0 = AS923-1)
 
 	SendInterval time.Duration `json:"sendInterval"` // interval to send data
 	AckTimeout   time.Duration `json:"ackTimeout"`   // timer to wait ack frame
@@ -19,6 +29,15 @@ type Configuration struct {
 
 	DisableFCntDown bool `json:"disableFCntDown"`
 
+	// Frame counters
+	FCntUpStart   uint32 `json:"fcntUpStart"`   // FCntUp of a new session, e.g. to resume an ABP session
+	FCntDownStart uint32 `json:"fcntDownStart"` // FCntDown of a new session
+	FCntBits      int    `json:"fcntBits"`      // Rollover width: 16, 32 or 0 (wrap at MAXFCNTGAP)
+
+	// Join-accept receive windows, in milliseconds after the JoinRequest (0 = LoRaWAN default, 5 s and 6 s)
+	JoinAcceptDelay1 int `json:"joinAcceptDelay1"`
+	JoinAcceptDelay2 int `json:"joinAcceptDelay2"`
+
 	SupportedOtaa     bool `json:"supportedOtaa"`     //false not supported
 	SupportedADR      bool `json:"supportedADR"`      //false not supported
 	SupportedFragment bool `json:"supportedFragment"` //fragmentation true, false truncate
@@ -26,7 +45,8 @@ type Configuration struct {
 	SupportedClassC   bool `json:"supportedClassC"`   //false not supported
 
 	//uplink
-	DataRateInitial uint8 `json:"dataRate"`
+	DataRateInitial uint8          `json:"dataRate"`
+	DataRateSweep   *DataRateSweep `json:"dataRateSweep,omitempty"` // Cycle the data rate on a schedule (nil = initial data rate and ADR)
 
 	//RX1
 	RX1DROffset uint8 `json:"rx1DROffset"`
@@ -40,6 +60,13 @@ type Configuration struct {
 	CodecID  int  `json:"codecID"`  // ID of codec to use (0 = use raw payload)
 	UseCodec bool `json:"useCodec"` // Enable/disable codec
 
+	// Max duration of one codec execution for this device, in milliseconds, overriding
+	// the codecTimeoutMs of the server (0 = server default)
+	CodecTimeoutMs int `json:"codecTimeoutMs,omitempty"`
+
+	PayloadConfig map[string]interface{} `json:"payloadConfig,omitempty"` // Passed to the codec's OnUplink as its argument
+	ProfileID     string                 `json:"profileId,omitempty"`     // Sensor profile generating time-varying OnUplink input (empty = none)
+
 	// ChirpStack Integration configuration
 	IntegrationEnabled bool   `json:"integrationEnabled"` // Enable ChirpStack integration
 	IntegrationID      int    `json:"integrationId"`      // ID of integration to use (0 = none)
@@ -51,6 +78,40 @@ type Configuration struct {
 	TBDeviceProfileID    string `json:"tbDeviceProfileId"`
 	TBCustomerID         string `json:"tbCustomerId"` // optional; empty = no customer
 	TBDeviceID           string `json:"tbDeviceId"`   // UUID assigned by ThingsBoard on create; needed for delete
+
+	// Set when the decoded JSON omitted the field, so the simulator defaults can be applied
+	regionOmitted bool
+	classOmitted  bool
+}
+
+// Device classes accepted as default class
+const (
+	ClassA = "A"
+	ClassB = "B"
+	ClassC = "C"
+)
+
+// IsValidClass reports whether class is one of the device classes (empty means class A)
+func IsValidClass(class string) bool {
+	switch class {
+	case "", ClassA, ClassB, ClassC:
+		return true
+	}
+	return false
+}
+
+// ApplyDefaults sets the region and the class to the given defaults when the decoded
+// JSON did not specify them. Configurations built in code are left untouched.
+func (c *Configuration) ApplyDefaults(regionCode int, class string) {
+	if c.regionOmitted {
+		c.Region = rp.GetRegionalParameters(regionCode)
+		c.regionOmitted = false
+	}
+	if c.classOmitted {
+		c.SupportedClassB = class == ClassB
+		c.SupportedClassC = class == ClassC
+		c.classOmitted = false
+	}
 }
 
 func (c *Configuration) MarshalJSON() ([]byte, error) {
@@ -77,9 +138,11 @@ func (c *Configuration) UnmarshalJSON(data []byte) error {
 	type Alias Configuration
 
 	aux := &struct {
-		Region       int `json:"region"`
-		SendInterval int `json:"sendInterval"`
-		AckTimeout   int `json:"ackTimeout"`
+		Region          *int  `json:"region"`
+		SendInterval    int   `json:"sendInterval"`
+		AckTimeout      int   `json:"ackTimeout"`
+		SupportedClassB *bool `json:"supportedClassB"`
+		SupportedClassC *bool `json:"supportedClassC"`
 
 		*Alias
 	}{
@@ -90,9 +153,72 @@ func (c *Configuration) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	c.Region = rp.GetRegionalParameters(aux.Region)
+	if !rp.IsSupportedAS923SubBand(c.AS923SubBand) {
+		return fmt.Errorf("invalid AS923 sub-band %d", c.AS923SubBand)
+	}
+
+	if !util.IsValidFCntBits(c.FCntBits) {
+		return fmt.Errorf("invalid frame counter width %d, must be 16 or 32", c.FCntBits)
+	}
+	if max := util.MaxFCnt(c.FCntBits); c.FCntUpStart > max || c.FCntDownStart > max {
+		return fmt.Errorf("frame counter start values must not exceed %d", max)
+	}
+
+	if err := util.ValidateJoinAcceptDelays(c.JoinAcceptDelay1, c.JoinAcceptDelay2); err != nil {
+		return err
+	}
+
+	regionCode := rp.Code_Eu868
+	if aux.Region != nil {
+		regionCode = *aux.Region
+	}
+	c.regionOmitted = aux.Region == nil
+	c.classOmitted = aux.SupportedClassB == nil && aux.SupportedClassC == nil
+	if aux.SupportedClassB != nil {
+		c.SupportedClassB = *aux.SupportedClassB
+	}
+	if aux.SupportedClassC != nil {
+		c.SupportedClassC = *aux.SupportedClassC
+	}
+
+	c.Region = rp.GetRegionalParameters(regionCode)
 	c.SendInterval = time.Duration(aux.SendInterval) * time.Second
 	c.AckTimeout = time.Duration(aux.AckTimeout) * time.Second
 
 	return nil
 }
+
+// Validate checks the settings that refer to the sensor profiles or the region, and the
+// codec timeout bounds. Unmarshalling leaves them to the device update, so that a stale
+// value in a saved configuration doesn't prevent the others from loading.
+func (c *Configuration) Validate() error {
+
+	if c.ProfileID != "" {
+		if _, err := profiles.Get(c.ProfileID); err != nil {
+			return fmt.Errorf("%w: %s", err, c.ProfileID)
+		}
+	}
+
+	if !c.ValidCodecTimeout() {
+		return fmt.Errorf("codecTimeoutMs must be between %d and %d (0 = server default)", MinCodecTimeoutMs, MaxCodecTimeoutMs)
+	}
+
+	if c.DataRateSweep != nil {
+		regionCode := rp.Code_Eu868
+		if c.Region != nil {
+			regionCode = c.Region.GetCode()
+		}
+		region := rp.GetRegionalParameters(regionCode)
+		region.Setup()
+		if err := c.DataRateSweep.Validate(region); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidCodecTimeout reports whether CodecTimeoutMs is 0 or within its bounds
+func (c *Configuration) ValidCodecTimeout() bool {
+	return c.CodecTimeoutMs == 0 || (c.CodecTimeoutMs >= MinCodecTimeoutMs && c.CodecTimeoutMs <= MaxCodecTimeoutMs)
+}
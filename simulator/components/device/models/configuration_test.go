@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
+)
+
+func TestConfigurationApplyDefaults(t *testing.T) {
+	var omitted Configuration
+	if err := json.Unmarshal([]byte(`{"sendInterval": 10}`), &omitted); err != nil {
+		t.Fatal(err)
+	}
+	omitted.ApplyDefaults(rp.Code_Us915, ClassC)
+	if omitted.Region.GetCode() != rp.Code_Us915 {
+		t.Errorf("region = %d, want default %d", omitted.Region.GetCode(), rp.Code_Us915)
+	}
+	if omitted.SupportedClassB || !omitted.SupportedClassC {
+		t.Errorf("classB/classC = %v/%v, want default class C", omitted.SupportedClassB, omitted.SupportedClassC)
+	}
+
+	var explicit Configuration
+	if err := json.Unmarshal([]byte(`{"region": 1, "supportedClassB": false}`), &explicit); err != nil {
+		t.Fatal(err)
+	}
+	explicit.ApplyDefaults(rp.Code_Us915, ClassC)
+	if explicit.Region.GetCode() != rp.Code_Eu868 {
+		t.Errorf("region = %d, want the explicit %d", explicit.Region.GetCode(), rp.Code_Eu868)
+	}
+	if explicit.SupportedClassC {
+		t.Error("default class applied to a configuration that set its class")
+	}
+}
+
+func TestConfigurationDataRateSweepValidation(t *testing.T) {
+	tests := []struct {
+		json  string
+		valid bool
+	}{
+		{`{"region": 1, "dataRateSweep": {"min": 0, "max": 5, "stepInterval": 60}}`, true},
+		{`{"region": 1, "dataRateSweep": {"min": 3, "max": 3, "stepInterval": 1}}`, true},
+		{`{"region": 1, "dataRateSweep": {"min": 0, "max": 5, "stepInterval": 0}}`, false},
+		{`{"region": 1, "dataRateSweep": {"min": 4, "max": 2, "stepInterval": 60}}`, false},
+		{`{"region": 1, "dataRateSweep": {"min": 0, "max": 8, "stepInterval": 60}}`, false}, // EU868 stops at DR7
+		{`{"region": 2, "dataRateSweep": {"min": 3, "max": 5, "stepInterval": 60}}`, false}, // US915 DR5 is RFU
+	}
+
+	for _, tt := range tests {
+		var c Configuration
+		if err := json.Unmarshal([]byte(tt.json), &c); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", tt.json, err)
+		}
+		err := c.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%s: error = %v", tt.json, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: accepted, want an error", tt.json)
+		}
+	}
+}
+
+func TestConfigurationCodecTimeoutValidation(t *testing.T) {
+	tests := []struct {
+		json  string
+		valid bool
+	}{
+		{`{"codecTimeoutMs": 0}`, true},
+		{`{"codecTimeoutMs": 2500}`, true},
+		{`{"codecTimeoutMs": 5}`, false},
+		{`{"codecTimeoutMs": 60001}`, false},
+		{`{"codecTimeoutMs": -1}`, false},
+	}
+	for _, tt := range tests {
+		var c Configuration
+		if err := json.Unmarshal([]byte(tt.json), &c); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", tt.json, err)
+		}
+		if err := c.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%s) error = %v, want valid %v", tt.json, err, tt.valid)
+		}
+	}
+}
+
+func TestConfigurationProfileValidation(t *testing.T) {
+	tests := []struct {
+		json  string
+		valid bool
+	}{
+		{`{}`, true},
+		{`{"profileId": "indoor-climate"}`, true},
+		{`{"profileId": "removed-profile"}`, false},
+	}
+	for _, tt := range tests {
+		// A stale profile doesn't prevent the configuration from loading
+		var c Configuration
+		if err := json.Unmarshal([]byte(tt.json), &c); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", tt.json, err)
+		}
+		if err := c.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%s) error = %v, want valid %v", tt.json, err, tt.valid)
+		}
+	}
+}
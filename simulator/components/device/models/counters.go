@@ -0,0 +1,10 @@
+package models
+
+// Counters holds the frames a device sent and received since the simulator started,
+// along with its frame counters at the time of the last frame
+type Counters struct {
+	Uplinks   uint64 `json:"uplinks"`   // Uplinks sent, retransmissions included
+	Downlinks uint64 `json:"downlinks"` // Downlinks received in the receive windows
+	FCntUp    uint32 `json:"fcntUp"`    // Uplink frame counter
+	FCntDown  uint32 `json:"fcntDown"`  // Downlink frame counter
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"fmt"
+
+	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
+)
+
+// DataRateSweep makes the device ramp its uplink data rate up and down between Min and
+// Max, one step every StepInterval seconds, whatever ADR or the network requests
+type DataRateSweep struct {
+	Min          uint8 `json:"min"`
+	Max          uint8 `json:"max"`
+	StepInterval int   `json:"stepInterval"` // seconds between two data rate changes
+}
+
+// Validate checks that the sweep range holds uplink data rates of the region, which
+// must have been set up
+func (s *DataRateSweep) Validate(region rp.Region) error {
+	if s.StepInterval <= 0 {
+		return fmt.Errorf("data rate sweep step interval must be positive")
+	}
+	if s.Min > s.Max {
+		return fmt.Errorf("data rate sweep min DR%d is above max DR%d", s.Min, s.Max)
+	}
+	if s.Min < region.GetMinDataRate() || s.Max > region.GetMaxDataRate() {
+		return fmt.Errorf("data rate sweep must stay within DR%d-DR%d", region.GetMinDataRate(), region.GetMaxDataRate())
+	}
+	for dr := int(s.Min); dr <= int(s.Max); dr++ {
+		if err := region.DataRateSupported(uint8(dr)); err != nil {
+			return fmt.Errorf("data rate sweep DR%d: %w", dr, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// DownlinkAck records a confirmed downlink and whether the device acknowledged it
+type DownlinkAck struct {
+	FCnt     uint32    `json:"fcnt"`     // FCntDown of the confirmed downlink
+	Received time.Time `json:"received"` // When the downlink was received
+	AckSent  bool      `json:"ackSent"`  // Whether an uplink ACK was sent in response
+}
@@ -0,0 +1,21 @@
+package models
+
+import "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
+
+// DownlinkCodecTest is a downlink payload to run through the codec of a device
+type DownlinkCodecTest struct {
+	PayloadHex string `json:"payloadHex"`
+	FPort      uint8  `json:"fPort"`
+}
+
+// DownlinkCodecTestResult reports what the OnDownlink function of a codec would do with a downlink
+type DownlinkCodecTestResult struct {
+	Decoded interface{}            `json:"decoded"` // Object returned by OnDownlink, if any
+	Changes []codec.VariableChange `json:"changes"` // State variables changed by setState and setUplinkField
+
+	// Uplink interval of the device, in seconds, before and after setSendInterval
+	SendIntervalBefore int `json:"sendIntervalBefore"`
+	SendIntervalAfter  int `json:"sendIntervalAfter"`
+
+	Logs []string `json:"logs"` // Messages printed with log
+}
@@ -0,0 +1,13 @@
+package models
+
+// FrameCounters holds the current frame counters of a device
+type FrameCounters struct {
+	FCntUp   uint32 `json:"fcntUp"`   // Counter of the next uplink
+	FCntDown uint32 `json:"fcntDown"` // Counter expected on the next downlink
+}
+
+// FrameCountersUpdate holds the counters to change; nil fields are left unchanged
+type FrameCountersUpdate struct {
+	FCntUp   *uint32 `json:"fcntUp"`
+	FCntDown *uint32 `json:"fcntDown"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// JoinInfo tracks the OTAA activation of a device
+type JoinInfo struct {
+	Attempts    int       // JoinRequests sent by the current or last activation
+	DevNonce    uint16    // DevNonce of the last JoinRequest
+	LastAttempt time.Time // When the last JoinRequest was sent
+	LastJoin    time.Time // When the last valid Join Accept was received
+}
+
+// JoinStatus reports whether an OTAA device joined the network and how it got there
+type JoinStatus struct {
+	Otaa        bool       `json:"otaa"`
+	Joined      bool       `json:"joined"`
+	Attempts    int        `json:"attempts"`    // JoinRequests sent by the current or last activation
+	DevNonce    uint16     `json:"devNonce"`    // DevNonce of the last JoinRequest
+	LastAttempt *time.Time `json:"lastAttempt"` // Null if no JoinRequest was sent
+	LastJoin    *time.Time `json:"lastJoin"`    // Null if the device never joined
+}
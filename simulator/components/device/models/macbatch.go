@@ -0,0 +1,7 @@
+package models
+
+// UplinkMACCommand is a MAC command to send to the network server in the FOpts of the next uplink
+type UplinkMACCommand struct {
+	CID        string `json:"cid"`        // Command name, e.g. "LinkCheckReq"
+	PayloadHex string `json:"payloadHex"` // Command payload without the CID byte
+}
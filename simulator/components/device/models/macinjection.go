@@ -0,0 +1,7 @@
+package models
+
+// MACInjection is a downlink MAC command to execute on a device without a network server
+type MACInjection struct {
+	CID        string `json:"cid"`        // Command name, e.g. "LinkADRReq"
+	PayloadHex string `json:"payloadHex"` // Command payload without the CID byte
+}
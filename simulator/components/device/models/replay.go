@@ -0,0 +1,14 @@
+package models
+
+// ReplayUplink is a recorded uplink to play back
+type ReplayUplink struct {
+	DelayMs    int    `json:"delayMs"` // Wait after the previous uplink, or after the start of the replay
+	FPort      uint8  `json:"fPort"`
+	PayloadHex string `json:"payloadHex"`
+}
+
+// Replay holds recorded uplinks to play back in order through a device
+type Replay struct {
+	Uplinks []ReplayUplink `json:"uplinks"`
+	Loop    bool           `json:"loop"` // Start over after the last uplink, until the replay is stopped
+}
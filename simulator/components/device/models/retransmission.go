@@ -0,0 +1,13 @@
+package models
+
+// Retransmission holds the confirmed-uplink retry settings of a device
+type Retransmission struct {
+	NbRetransmission int `json:"nbRetransmission"` // Times a ConfirmedDataUp is resent while no ACK is received
+	AckTimeout       int `json:"ackTimeout"`       // Seconds waited for an ACK after the receive windows
+}
+
+// RetransmissionUpdate holds the retry settings to change; nil fields are left unchanged
+type RetransmissionUpdate struct {
+	NbRetransmission *int `json:"nbRetransmission"`
+	AckTimeout       *int `json:"ackTimeout"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// RXWindowSettings holds the overrides of one receive window; nil fields are left unchanged
+type RXWindowSettings struct {
+	Delay        *int    `json:"delay"`        // Delay after the uplink in milliseconds
+	DurationOpen *int    `json:"durationOpen"` // How long the window stays open in milliseconds
+	DataRate     *uint8  `json:"dataRate"`     // RX2 only, RX1 follows the uplink data rate and RX1DROffset
+	Frequency    *uint32 `json:"frequency"`    // RX2 only, RX1 follows the uplink channel
+}
+
+// RXWindowsUpdate holds the overrides of the RX1 and RX2 windows of a device
+type RXWindowsUpdate struct {
+	RX1 *RXWindowSettings `json:"rx1"`
+	RX2 *RXWindowSettings `json:"rx2"`
+}
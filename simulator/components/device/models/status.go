@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/base64"
 	"encoding/json"
+	"time"
 
 	modelClass "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/classes/models_classes"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
@@ -22,13 +23,24 @@ type Status struct {
 	Payload       lorawan.Payload `json:"payload"` // from UI
 	BufferUplinks []mup.InfoFrame `json:"-"`       // from socket
 
+	PayloadTemplate string `json:"payloadTemplate,omitempty"` // Payload with {{counter}}/{{timestamp}} placeholders, resolved at every uplink (from socket)
+
 	DataDownlink dl.InformationDownlink `json:"-"`
 	FCntDown     uint32                 `json:"fcntDown"`
+	DownlinkAcks []DownlinkAck          `json:"-"` // ledger of confirmed downlinks
+	Counters     Counters               `json:"-"` // frames sent and received since the simulator started
+	Join         JoinInfo               `json:"-"` // OTAA join attempts and times
+
+	LastUplinkAt   *time.Time `json:"lastUplinkAt,omitempty"`   // when the last uplink was sent (nil = never)
+	LastDownlinkAt *time.Time `json:"lastDownlinkAt,omitempty"` // when the last downlink was received (nil = never)
 
 	DataRate uint8 `json:"-"`
 	TXPower  uint8 `json:"-"`
 	Battery  uint8 `json:"-"`
 
+	SweepNext time.Time `json:"-"` // when the data rate sweep takes its next step (zero = not started)
+	SweepDown bool      `json:"-"` // the data rate sweep is ramping down
+
 	InfoClassB         modelClass.InfoClassB      `json:"-"`
 	InfoClassC         modelClass.InfoClassC      `json:"-"`
 	IndexchannelActive uint16                     `json:"-"`
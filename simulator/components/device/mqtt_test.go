@@ -0,0 +1,173 @@
+package device_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/output/mqtt"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
+	"github.com/brocaar/lorawan"
+)
+
+// mqttBroker acknowledges the CONNECT of one client and sends the topic and payload of
+// each PUBLISH to published
+func mqttBroker(ln net.Listener, published chan<- [2]string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	for {
+		header, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		length, multiplier := 0, 1
+		for {
+			b, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+			length += int(b&0x7F) * multiplier
+			if b&0x80 == 0 {
+				break
+			}
+			multiplier *= 128
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return
+		}
+
+		switch header & 0xF0 {
+		case 0x10: // CONNECT
+			conn.Write([]byte{0x20, 2, 0, 0})
+		case 0x30: // PUBLISH, QoS 0
+			n := int(body[0])<<8 | int(body[1])
+			published <- [2]string{string(body[2 : 2+n]), string(body[2+n:])}
+		}
+	}
+}
+
+func TestDeviceEventsPublishedToMQTT(t *testing.T) {
+	util.SetSeed(1)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	published := make(chan [2]string, mqtt.QueueSize)
+	go mqttBroker(ln, published)
+
+	publisher := mqtt.NewPublisher(mqtt.Config{Enabled: true, BrokerURL: "tcp://" + ln.Addr().String(), TopicPrefix: "sim"})
+	defer publisher.Close()
+
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 17, 0}, lorawan.DevAddr{1, 2, 13, 0}, [16]byte{1}, [16]byte{2})
+	d.Console.MQTT = publisher
+
+	// A downlink carrying data on port 1
+	downlink, err := testutil.DataDown(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fPort := uint8(1)
+	macPayload := downlink.MACPayload.(*lorawan.MACPayload)
+	macPayload.FPort = &fPort
+	macPayload.FRMPayload = []lorawan.Payload{&lorawan.DataPayload{Bytes: []byte{0xAB}}}
+	if err := downlink.EncryptFRMPayload(d.Info.AppSKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := downlink.SetDownlinkDataMIC(lorawan.LoRaWAN1_0, 0, d.Info.NwkSKey); err != nil {
+		t.Fatal(err)
+	}
+
+	uplinks, err := n.Cycle(d, downlink)
+	if err != nil {
+		t.Fatalf("Cycle() error = %v", err)
+	}
+	if len(uplinks) != 1 {
+		t.Fatalf("Cycle() sent %d uplinks, want 1", len(uplinks))
+	}
+
+	prefix := "sim/device/" + d.Info.DevEUI.String() + "/"
+	tests := []struct {
+		eventType string
+		check     func(data json.RawMessage) string // returns what is wrong
+	}{
+		{socket.EventUplink, func(data json.RawMessage) string {
+			var uplink socket.Uplink
+			json.Unmarshal(data, &uplink)
+			if uplink.PHYPayload != uplinks[0].Data || uplink.DataRate != uplinks[0].DatR {
+				return "does not describe the frame received by the gateway"
+			}
+			return ""
+		}},
+		{socket.EventDownlink, func(data json.RawMessage) string {
+			var dl socket.Downlink
+			json.Unmarshal(data, &dl)
+			if dl.FPort != 1 || dl.Payload != "ab" {
+				return "does not carry the port and payload of the downlink"
+			}
+			return ""
+		}},
+		{socket.EventDev, func(data json.RawMessage) string {
+			var log socket.ConsoleLog
+			json.Unmarshal(data, &log)
+			if !strings.Contains(log.Msg, d.Info.Name) {
+				return "is not a console message of the device"
+			}
+			return ""
+		}},
+	}
+
+	got := map[string]json.RawMessage{}
+	missing := func() bool {
+		for _, tt := range tests {
+			if _, ok := got[tt.eventType]; !ok {
+				return true
+			}
+		}
+		return false
+	}
+	timeout := time.After(3 * time.Second)
+	for missing() {
+		select {
+		case msg := <-published:
+			if !strings.HasPrefix(msg[0], prefix) {
+				t.Fatalf("topic %q, want prefix %q", msg[0], prefix)
+			}
+			var event struct {
+				Data json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(msg[1]), &event); err != nil {
+				t.Fatalf("payload of %s: %v", msg[0], err)
+			}
+			eventType := strings.TrimPrefix(msg[0], prefix)
+			if _, ok := got[eventType]; !ok {
+				got[eventType] = event.Data
+			}
+		case <-timeout:
+			t.Fatalf("not every event type published in time, got %d types", len(got))
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			data := got[tt.eventType]
+			if problem := tt.check(data); problem != "" {
+				t.Errorf("%s event %s: %s", tt.eventType, problem, data)
+			}
+		})
+	}
+}
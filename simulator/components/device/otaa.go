@@ -1,7 +1,6 @@
 package device
 
 import (
-	"math/rand"
 	"time"
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
@@ -9,13 +8,14 @@ import (
 	act "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/activation"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/classes"
 	dl "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/frames/downlink"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/webhook"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/metrics"
 	"github.com/brocaar/lorawan"
 )
 
 const (
-	JOINACCEPTDELAY1 = time.Duration(5 * time.Second)
-	JOINACCEPTDELAY2 = time.Duration(6 * time.Second)
+	JOINACCEPTDELAY1 = util.JoinAcceptDelay1Default
+	JOINACCEPTDELAY2 = util.JoinAcceptDelay2Default
 )
 
 func (d *Device) OtaaActivation() {
@@ -32,6 +32,10 @@ func (d *Device) OtaaActivation() {
 		defer d.releaseJoinSlot()
 	}
 
+	d.countersMu.Lock()
+	d.Info.Status.Join.Attempts = 0
+	d.countersMu.Unlock()
+
 	for !d.Info.Status.Joined {
 
 		d.Info.Status.Mode = util.Activation
@@ -43,10 +47,12 @@ func (d *Device) OtaaActivation() {
 		d.SwitchClass(classes.ClassA)
 
 		d.SendJoinRequest()
+		d.recordJoinAttempt()
 
 		d.Print("Open RXs", nil, util.PrintBoth)
 
-		phy := d.Class.ReceiveWindows(JOINACCEPTDELAY1, JOINACCEPTDELAY2)
+		delay1, delay2 := util.JoinAcceptDelays(d.Info.Configuration.JoinAcceptDelay1, d.Info.Configuration.JoinAcceptDelay2)
+		phy := d.Class.ReceiveWindows(d.runContext(), delay1, delay2)
 		if phy != nil {
 
 			d.Print("Downlink received", nil, util.PrintBoth)
@@ -55,8 +61,9 @@ func (d *Device) OtaaActivation() {
 			if err != nil {
 				d.Print("", err, util.PrintBoth)
 
-				timerAckTimeout := time.NewTimer(d.Info.Configuration.AckTimeout)
-				<-timerAckTimeout.C
+				if !d.wait(d.ackTimeout()) { //stop simulator
+					return
+				}
 
 				d.Print("ACK Timeout", nil, util.PrintBoth)
 			}
@@ -66,16 +73,21 @@ func (d *Device) OtaaActivation() {
 
 		if d.Info.Status.Joined {
 
+			d.recordJoin()
 			d.Print("Joined", nil, util.PrintBoth)
+			d.notify(webhook.EventJoinAccepted, "")
 			d.Info.Status.Mode = util.Normal
 
 			return
 		}
 
 		d.Print("Unjoined", nil, util.PrintBoth)
+		d.notify(webhook.EventJoinFailed, "no valid Join Accept received")
 
-		backoff := 500 + rand.Intn(1500)
-		time.Sleep(time.Duration(backoff) * time.Millisecond)
+		backoff := 500 + util.RandIntn(1500)
+		if !d.wait(time.Duration(backoff) * time.Millisecond) { //stop simulator
+			return
+		}
 	}
 
 	return
@@ -83,15 +95,11 @@ func (d *Device) OtaaActivation() {
 
 func (d *Device) acquireJoinSlot() bool {
 	d.Print("Waiting for join slot...", nil, util.PrintOnlyConsole)
-	for {
-		select {
-		case d.JoinSemaphore <- struct{}{}:
-			return true
-		case <-time.After(500 * time.Millisecond):
-			if !d.CanExecute() {
-				return false
-			}
-		}
+	select {
+	case d.JoinSemaphore <- struct{}{}:
+		return true
+	case <-d.runContext().Done():
+		return false
 	}
 }
 
@@ -101,8 +109,7 @@ func (d *Device) releaseJoinSlot() {
 
 func (d *Device) CreateJoinRequest() []byte {
 
-	rand.Seed(time.Now().UTC().UnixNano())
-	random := uint16(rand.Int())
+	random := uint16(util.RandInt())
 
 	DevNonce := lorawan.DevNonce(random)
 	d.Info.DevNonce = DevNonce
@@ -206,6 +213,7 @@ func (d *Device) ProcessJoinAccept(JoinAccPayload *lorawan.JoinAcceptPayload) (*
 	d.Info.DevAddr = JoinAccPayload.DevAddr
 	d.Info.NetID = JoinAccPayload.HomeNetID
 	d.Info.Forwarder.UpdateDevAddr(d.Info.DevEUI, d.Info.DevAddr)
+	d.updatePingSlots()
 
 	Delay := 1000
 	if JoinAccPayload.RXDelay != 0 {
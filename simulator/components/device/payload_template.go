@@ -0,0 +1,72 @@
+package device
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brocaar/lorawan"
+)
+
+// Placeholders resolved in templated payloads, at every transmission
+const (
+	PlaceholderCounter   = "{{counter}}"   // Incremented at every transmission, kept in the codec state
+	PlaceholderTimestamp = "{{timestamp}}" // Unix time in seconds
+)
+
+// payloadCounterVariable is the codec state variable holding the {{counter}} value
+const payloadCounterVariable = "payloadCounter"
+
+// IsPayloadTemplate reports whether a payload contains placeholders to resolve
+func IsPayloadTemplate(payload string) bool {
+	return strings.Contains(payload, PlaceholderCounter) || strings.Contains(payload, PlaceholderTimestamp)
+}
+
+// SetPayloadTemplate makes the periodic uplinks resolve the placeholders of the
+// payload at every transmission. A payload without placeholders clears the template.
+func (d *Device) SetPayloadTemplate(payload string) {
+	if !IsPayloadTemplate(payload) {
+		payload = ""
+	}
+	d.Info.Status.PayloadTemplate = payload
+}
+
+// staticPayload returns the payload sent when no codec is used,
+// with the placeholders of the payload template resolved
+func (d *Device) staticPayload() lorawan.Payload {
+	if d.Info.Status.PayloadTemplate == "" {
+		return d.Info.Status.Payload
+	}
+	return &lorawan.DataPayload{Bytes: []byte(d.renderPayload(d.Info.Status.PayloadTemplate))}
+}
+
+// renderPayload replaces the placeholders of a templated payload
+func (d *Device) renderPayload(payload string) string {
+	if strings.Contains(payload, PlaceholderCounter) {
+		payload = strings.ReplaceAll(payload, PlaceholderCounter, strconv.FormatUint(d.nextPayloadCounter(), 10))
+	}
+	if strings.Contains(payload, PlaceholderTimestamp) {
+		payload = strings.ReplaceAll(payload, PlaceholderTimestamp, strconv.FormatInt(time.Now().Unix(), 10))
+	}
+	return payload
+}
+
+// nextPayloadCounter increments the {{counter}} value stored in the device's codec state
+func (d *Device) nextPayloadCounter() uint64 {
+	if Codecs == nil {
+		return 0
+	}
+	state := Codecs.GetOrCreateState(d.Info.DevEUI.String())
+
+	var counter uint64
+	switch v := state.GetVariable(payloadCounterVariable).(type) {
+	case uint64:
+		counter = v
+	case float64: // Restored from the persisted state
+		counter = uint64(v)
+	}
+	counter++
+	state.SetVariable(payloadCounterVariable, counter)
+
+	return counter
+}
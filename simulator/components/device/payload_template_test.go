@@ -0,0 +1,67 @@
+package device_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestPayloadTemplate(t *testing.T) {
+	util.SetSeed(1)
+
+	registry := codec.NewRegistry(nil)
+	defer registry.Close()
+	previous := dev.Codecs
+	dev.Codecs = registry
+	defer func() { dev.Codecs = previous }()
+
+	tests := []struct {
+		name     string
+		template string
+		want     []string // Payload of two successive uplinks, NOW standing for the time of the uplink
+	}{
+		{"counter", "n={{counter}}", []string{"n=1", "n=2"}},
+		{"timestamp", "t={{timestamp}}", []string{"t=NOW", "t=NOW"}},
+		{"both", "{{counter}}@{{timestamp}}", []string{"1@NOW", "2@NOW"}},
+		{"no placeholder", "static", []string{"\x01", "\x01"}}, // the device payload is kept
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := testutil.NewNetwork()
+			d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 12, byte(i)}, lorawan.DevAddr{1, 2, 8, byte(i)},
+				[16]byte{1}, [16]byte{2})
+			d.SetPayloadTemplate(tt.template)
+
+			for _, want := range tt.want {
+				before := time.Now().Unix()
+				uplinks, err := n.Cycle(d, nil)
+				after := time.Now().Unix()
+				if err != nil || len(uplinks) != 1 {
+					t.Fatalf("Cycle() = %d uplinks, %v, want 1", len(uplinks), err)
+				}
+				phy, err := testutil.Decode(uplinks[0])
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				if err := phy.DecryptFRMPayload(d.Info.AppSKey); err != nil {
+					t.Fatalf("DecryptFRMPayload() error = %v", err)
+				}
+				got := string(phy.MACPayload.(*lorawan.MACPayload).FRMPayload[0].(*lorawan.DataPayload).Bytes)
+				matched := false
+				for now := before; now <= after; now++ {
+					matched = matched || got == strings.ReplaceAll(want, "NOW", strconv.FormatInt(now, 10))
+				}
+				if !matched {
+					t.Errorf("payload = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package regional_parameters
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// FrameOverhead is the number of bytes a data frame adds to its application payload:
+// MHDR, FHDR without FOpts, FPort and MIC
+const FrameOverhead = 13
+
+const (
+	preambleSymbols = 8 // LoRaWAN preamble length
+	fskPreamble     = 5 // bytes
+	fskSyncWord     = 3 // bytes
+)
+
+// TimeOnAir returns the time a frame of size bytes (the whole PHYPayload) takes on air
+// at the given data rate of region, with explicit header and CRC as for uplinks
+func TimeOnAir(region Region, datarate uint8, size int) (time.Duration, error) {
+
+	if size < 0 {
+		return 0, errors.New("size can't be negative")
+	}
+
+	modulation, configuration := region.GetDataRate(datarate)
+
+	switch modulation {
+
+	case "LORA":
+
+		var sf, bw int
+		if _, err := fmt.Sscanf(configuration, "SF%dBW%d", &sf, &bw); err != nil {
+			return 0, fmt.Errorf("unexpected LoRa data rate %q", configuration)
+		}
+
+		var cr int
+		if _, err := fmt.Sscanf(region.GetCodR(datarate), "4/%d", &cr); err != nil {
+			return 0, fmt.Errorf("unexpected coding rate %q", region.GetCodR(datarate))
+		}
+
+		return loraTimeOnAir(sf, bw*1000, cr-4, size), nil
+
+	case "FSK":
+
+		bitrate, err := strconv.Atoi(configuration)
+		if err != nil || bitrate <= 0 {
+			return 0, fmt.Errorf("unexpected FSK data rate %q", configuration)
+		}
+
+		// preamble, sync word, length, payload and CRC
+		bits := (fskPreamble + fskSyncWord + 1 + size + 2) * 8
+		return time.Duration(float64(bits) / float64(bitrate) * float64(time.Second)), nil
+
+	}
+
+	return 0, fmt.Errorf("data rate %d is not defined for the region", datarate)
+}
+
+// loraTimeOnAir applies the time-on-air formula of the Semtech SX127x datasheet, where
+// codingRate is 1 for 4/5 up to 4 for 4/8
+func loraTimeOnAir(sf, bandwidth, codingRate, size int) time.Duration {
+
+	symbol := math.Pow(2, float64(sf)) / float64(bandwidth) // seconds
+
+	// low data rate optimization is mandated when a symbol lasts more than 16 ms
+	de := 0
+	if symbol > 0.016 {
+		de = 1
+	}
+
+	preamble := (float64(preambleSymbols) + 4.25) * symbol
+
+	crc, header := 1, 0 // CRC on, explicit header
+	payloadSymbols := 8 + math.Max(math.Ceil(float64(8*size-4*sf+28+16*crc-20*header)/
+		float64(4*(sf-2*de)))*float64(codingRate+4), 0)
+
+	return time.Duration((preamble + payloadSymbols*symbol) * float64(time.Second))
+}
@@ -0,0 +1,44 @@
+package regional_parameters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeOnAir(t *testing.T) {
+	tests := []struct {
+		region   int
+		datarate uint8
+		size     int
+		want     time.Duration
+	}{
+		{Code_Eu868, 5, 10 + FrameOverhead, 61696 * time.Microsecond},   // SF7BW125
+		{Code_Eu868, 0, 10 + FrameOverhead, 1482752 * time.Microsecond}, // SF12BW125, low data rate optimization
+		{Code_Eu868, 6, 10 + FrameOverhead, 30848 * time.Microsecond},   // SF7BW250
+		{Code_Eu868, 7, 10 + FrameOverhead, 5440 * time.Microsecond},    // FSK 50 kbps
+		{Code_Us915, 4, 10 + FrameOverhead, 28288 * time.Microsecond},   // SF8BW500
+	}
+
+	for _, tt := range tests {
+		region := GetRegionalParameters(tt.region)
+		region.Setup()
+
+		got, err := TimeOnAir(region, tt.datarate, tt.size)
+		if err != nil {
+			t.Errorf("region %d DR%d: error = %v", tt.region, tt.datarate, err)
+			continue
+		}
+		if diff := got - tt.want; diff < -time.Microsecond || diff > time.Microsecond {
+			t.Errorf("region %d DR%d: time on air = %v, want %v", tt.region, tt.datarate, got, tt.want)
+		}
+	}
+}
+
+func TestTimeOnAirUndefinedDataRate(t *testing.T) {
+	region := GetRegionalParameters(Code_Eu868)
+	region.Setup()
+
+	if _, err := TimeOnAir(region, 12, 20); err == nil {
+		t.Error("TimeOnAir() on an undefined data rate succeeded, want an error")
+	}
+}
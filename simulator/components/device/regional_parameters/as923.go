@@ -3,24 +3,68 @@ package regional_parameters
 import (
 	"errors"
 	"fmt"
-	"math/rand"
-	"time"
 
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
 	models "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters/models_rp"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/brocaar/lorawan"
 )
 
+// AS923 sub-bands (frequency plans AS923-1 to AS923-4). They share the channel
+// layout of AS923-1, shifted by a fixed offset, within their own frequency range.
+const (
+	AS923_1 = iota + 1
+	AS923_2
+	AS923_3
+	AS923_4
+)
+
+type as923SubBand struct {
+	offset       int32  // Shift of the default channels, RX2 and beacon from AS923-1 (Hz)
+	minFrequency uint32 // Lowest frequency usable by the plan (Hz)
+	maxFrequency uint32 // Highest frequency usable by the plan (Hz)
+}
+
+var as923SubBands = map[int]as923SubBand{
+	AS923_1: {0, 915000000, 928000000},
+	AS923_2: {-1800000, 920000000, 923000000},
+	AS923_3: {-6600000, 915000000, 921000000},
+	AS923_4: {-5900000, 917000000, 920000000},
+}
+
+// IsSupportedAS923SubBand reports whether subBand is one of AS923_1..AS923_4 (0 means AS923-1)
+func IsSupportedAS923SubBand(subBand int) bool {
+	if subBand == 0 {
+		return true
+	}
+	_, ok := as923SubBands[subBand]
+	return ok
+}
+
+// SetSubBand selects the frequency plan of regions that have several variants
+// (AS923-1 to AS923-4) and is ignored by the other regions. Setup must be called afterwards.
+func SetSubBand(region Region, subBand int) {
+	if as, ok := region.(*As923); ok {
+		as.SubBand = subBand
+	}
+}
+
 type As923 struct {
-	Info models.Parameters
+	Info    models.Parameters
+	SubBand int // One of AS923_1..AS923_4 (0 or unknown = AS923-1)
 }
 
-//manca un setup
 func (as *As923) Setup() {
+	sb, ok := as923SubBands[as.SubBand]
+	if !ok {
+		sb = as923SubBands[AS923_1]
+	}
+	shift := func(frequency int32) uint32 { return uint32(frequency + sb.offset) }
+
 	as.Info.Code = Code_As923
-	as.Info.MinFrequency = 923000000
-	as.Info.MaxFrequency = 923500000
-	as.Info.FrequencyRX2 = 923200000
+	as.Info.MinFrequency = sb.minFrequency
+	as.Info.MaxFrequency = sb.maxFrequency
+	as.Info.FrequencyRX2 = shift(923200000)
 	as.Info.DataRateRX2 = 2
 	as.Info.MinDataRate = 0
 	as.Info.MaxDataRate = 7
@@ -29,14 +73,14 @@ func (as *As923) Setup() {
 	as.Info.InfoGroupChannels = []models.InfoGroupChannels{
 		{
 			EnableUplink:       true,
-			InitialFrequency:   923200000,
+			InitialFrequency:   shift(923200000),
 			OffsetFrequency:    200000,
 			MinDataRate:        0,
 			MaxDataRate:        5,
 			NbReservedChannels: 2,
 		},
 	}
-	as.Info.InfoClassB.Setup(923400000, 923400000, 3, as.Info.MinDataRate, as.Info.MaxDataRate)
+	as.Info.InfoClassB.Setup(shift(923400000), shift(923400000), 3, as.Info.MinDataRate, as.Info.MaxDataRate)
 
 }
 
@@ -157,10 +201,8 @@ func (as *As923) SetupRX1(datarate uint8, rx1offset uint8, indexChannel int, dti
 
 func (as *As923) SetupInfoRequest(indexChannel int) (string, int) {
 
-	rand.Seed(time.Now().UTC().UnixNano())
-
 	if indexChannel >= as.GetNbReservedChannels() {
-		indexChannel = rand.Int() % as.GetNbReservedChannels()
+		indexChannel = util.RandInt() % as.GetNbReservedChannels()
 	}
 
 	_, datarate := as.GetDataRate(5)
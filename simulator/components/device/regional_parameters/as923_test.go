@@ -0,0 +1,44 @@
+package regional_parameters
+
+import "testing"
+
+func TestAs923SubBands(t *testing.T) {
+	tests := []struct {
+		subBand int
+		rx2     uint32
+		first   uint32 // Frequency of the first default channel
+	}{
+		{0, 923200000, 923200000},
+		{AS923_1, 923200000, 923200000},
+		{AS923_2, 921400000, 921400000},
+		{AS923_3, 916600000, 916600000},
+		{AS923_4, 917300000, 917300000},
+	}
+
+	for _, tt := range tests {
+		region := GetRegionalParameters(Code_As923)
+		SetSubBand(region, tt.subBand)
+		region.Setup()
+
+		if got := region.GetParameters().FrequencyRX2; got != tt.rx2 {
+			t.Errorf("sub-band %d: RX2 frequency = %d, want %d", tt.subBand, got, tt.rx2)
+		}
+		channels := region.GetChannels()
+		if len(channels) == 0 || channels[0].FrequencyUplink != tt.first {
+			t.Errorf("sub-band %d: first channel = %v, want %d", tt.subBand, channels, tt.first)
+		}
+		if err := region.FrequencySupported(tt.first); err != nil {
+			t.Errorf("sub-band %d: default channel %d not supported: %v", tt.subBand, tt.first, err)
+		}
+	}
+}
+
+func TestAs923SubBandFrequencyRange(t *testing.T) {
+	region := GetRegionalParameters(Code_As923)
+	SetSubBand(region, AS923_4)
+	region.Setup()
+
+	if err := region.FrequencySupported(923200000); err == nil {
+		t.Error("AS923-4 accepted a frequency of AS923-1")
+	}
+}
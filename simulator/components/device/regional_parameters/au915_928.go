@@ -3,11 +3,10 @@ package regional_parameters
 import (
 	"errors"
 	"fmt"
-	"math/rand"
-	"time"
 
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
 	models "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters/models_rp"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/brocaar/lorawan"
 )
 
@@ -171,11 +170,9 @@ func (au *Au915) SetupRX1(datarate uint8, rx1offset uint8, indexChannel int, dti
 
 func (au *Au915) SetupInfoRequest(indexChannel int) (string, int) {
 
-	rand.Seed(time.Now().UTC().UnixNano())
-
 	datarate := uint8(2)
 
-	indexChannel = rand.Int() % au.GetNbReservedChannels()
+	indexChannel = util.RandInt() % au.GetNbReservedChannels()
 	if indexChannel >= au.Info.InfoGroupChannels[0].NbReservedChannels {
 		datarate = uint8(6)
 	}
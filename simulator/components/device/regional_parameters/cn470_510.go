@@ -3,11 +3,10 @@ package regional_parameters
 import (
 	"errors"
 	"fmt"
-	"math/rand"
-	"time"
 
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
 	models "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters/models_rp"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/brocaar/lorawan"
 )
 
@@ -227,10 +226,8 @@ func (cn *Cn470) SetupRX1(datarate uint8, rx1offset uint8, indexChannel int, dti
 
 func (cn *Cn470) SetupInfoRequest(indexChannel int) (string, int) {
 
-	rand.Seed(time.Now().UTC().UnixNano())
-
 	if indexChannel >= cn.GetNbReservedChannels() {
-		indexChannel = rand.Int() % cn.GetNbReservedChannels()
+		indexChannel = util.RandInt() % cn.GetNbReservedChannels()
 	}
 
 	_, drString := cn.GetDataRate(5)
@@ -3,11 +3,10 @@ package regional_parameters
 import (
 	"errors"
 	"fmt"
-	"math/rand"
-	"time"
 
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
 	models "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters/models_rp"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/brocaar/lorawan"
 )
 
@@ -138,10 +137,8 @@ func (eu *Eu433) SetupRX1(datarate uint8, rx1offset uint8, indexChannel int, dti
 
 func (eu *Eu433) SetupInfoRequest(indexChannel int) (string, int) {
 
-	rand.Seed(time.Now().UTC().UnixNano())
-
 	if indexChannel >= eu.GetNbReservedChannels() {
-		indexChannel = rand.Int() % eu.GetNbReservedChannels()
+		indexChannel = util.RandInt() % eu.GetNbReservedChannels()
 	}
 
 	_, drString := eu.GetDataRate(5)
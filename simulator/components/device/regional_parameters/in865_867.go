@@ -3,11 +3,10 @@ package regional_parameters
 import (
 	"errors"
 	"fmt"
-	"math/rand"
-	"time"
 
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
 	models "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters/models_rp"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/brocaar/lorawan"
 )
 
@@ -94,14 +93,14 @@ func (in *In865) GetChannels() []c.Channel {
 
 	for _, group := range in.Info.InfoGroupChannels {
 		for i := 0; i < group.NbReservedChannels; i++ {
-			frequency := in.Info.InfoGroupChannels[0].InitialFrequency + in.Info.InfoGroupChannels[0].OffsetFrequency*uint32(i)
+			frequency := group.InitialFrequency + group.OffsetFrequency*uint32(i)
 			ch := c.Channel{
 				Active:            true,
-				EnableUplink:      in.Info.InfoGroupChannels[0].EnableUplink,
+				EnableUplink:      group.EnableUplink,
 				FrequencyUplink:   frequency,
 				FrequencyDownlink: frequency,
-				MinDR:             0,
-				MaxDR:             5,
+				MinDR:             group.MinDataRate,
+				MaxDR:             group.MaxDataRate,
 			}
 			channels = append(channels, ch)
 		}
@@ -166,10 +165,8 @@ func (in *In865) SetupRX1(datarate uint8, rx1offset uint8, indexChannel int, dti
 
 func (in *In865) SetupInfoRequest(indexChannel int) (string, int) {
 
-	rand.Seed(time.Now().UTC().UnixNano())
-
 	if indexChannel >= in.GetNbReservedChannels() {
-		indexChannel = rand.Int() % in.GetNbReservedChannels()
+		indexChannel = util.RandInt() % in.GetNbReservedChannels()
 	}
 
 	_, drString := in.GetDataRate(5)
@@ -192,7 +189,7 @@ func (in *In865) GetPayloadSize(datarate uint8, dTime lorawan.DwellTime) (int, i
 		return 59, 51
 	case 3:
 		return 123, 115
-	case 4, 5, 6, 7:
+	case 4, 5, 7: // DR6 is RFU
 		return 230, 222
 	}
 
@@ -0,0 +1,75 @@
+package regional_parameters
+
+import "testing"
+
+func TestIn865DefaultChannels(t *testing.T) {
+	region := GetRegionalParameters(Code_In865)
+	region.Setup()
+
+	want := []uint32{865062500, 865402500, 865985000}
+	channels := region.GetChannels()
+	if len(channels) != len(want) || region.GetNbReservedChannels() != len(want) {
+		t.Fatalf("got %d channels (%d reserved), want %d", len(channels), region.GetNbReservedChannels(), len(want))
+	}
+	for i, frequency := range want {
+		ch := channels[i]
+		if ch.FrequencyUplink != frequency || ch.FrequencyDownlink != frequency {
+			t.Errorf("channel %d: uplink %d, downlink %d, want %d", i, ch.FrequencyUplink, ch.FrequencyDownlink, frequency)
+		}
+		if ch.MinDR != 0 || ch.MaxDR != 5 || !ch.Active || !ch.EnableUplink {
+			t.Errorf("channel %d = %+v, want an active uplink channel for DR0-5", i, ch)
+		}
+		if err := region.FrequencySupported(frequency); err != nil {
+			t.Errorf("channel %d: %v", i, err)
+		}
+	}
+
+	params := region.GetParameters()
+	if params.FrequencyRX2 != 866550000 || params.DataRateRX2 != 2 {
+		t.Errorf("RX2 = %d Hz DR%d, want 866550000 Hz DR2", params.FrequencyRX2, params.DataRateRX2)
+	}
+}
+
+func TestIn865DataRates(t *testing.T) {
+	region := GetRegionalParameters(Code_In865)
+	region.Setup()
+
+	if err := region.DataRateSupported(6); err == nil {
+		t.Error("DR6 is RFU but is supported")
+	}
+	if m, n := region.GetPayloadSize(6, 0); m != 0 || n != 0 {
+		t.Errorf("DR6 payload size = %d/%d, want 0/0", m, n)
+	}
+	for dr, want := range map[uint8]int{0: 59, 3: 123, 5: 230, 7: 230} {
+		if err := region.DataRateSupported(dr); err != nil {
+			t.Errorf("DR%d: %v", dr, err)
+		}
+		if m, _ := region.GetPayloadSize(dr, 0); m != want {
+			t.Errorf("DR%d payload size = %d, want %d", dr, m, want)
+		}
+	}
+}
+
+func TestEu433DefaultChannels(t *testing.T) {
+	region := GetRegionalParameters(Code_Eu433)
+	region.Setup()
+
+	want := []uint32{433175000, 433375000, 433575000}
+	channels := region.GetChannels()
+	if len(channels) != len(want) {
+		t.Fatalf("got %d channels, want %d", len(channels), len(want))
+	}
+	for i, frequency := range want {
+		if channels[i].FrequencyUplink != frequency {
+			t.Errorf("channel %d: frequency %d, want %d", i, channels[i].FrequencyUplink, frequency)
+		}
+	}
+
+	params := region.GetParameters()
+	if params.FrequencyRX2 != 434665000 || params.DataRateRX2 != 0 {
+		t.Errorf("RX2 = %d Hz DR%d, want 434665000 Hz DR0", params.FrequencyRX2, params.DataRateRX2)
+	}
+	if region.GetFrequencyBeacon() != 434665000 || region.GetDataRateBeacon() != 3 {
+		t.Errorf("beacon = %d Hz DR%d, want 434665000 Hz DR3", region.GetFrequencyBeacon(), region.GetDataRateBeacon())
+	}
+}
@@ -3,11 +3,10 @@ package regional_parameters
 import (
 	"errors"
 	"fmt"
-	"math/rand"
-	"time"
 
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
 	models "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters/models_rp"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/brocaar/lorawan"
 )
 
@@ -152,10 +151,8 @@ func (kr *Kr920) SetupRX1(datarate uint8, rx1offset uint8, indexChannel int, dti
 
 func (kr *Kr920) SetupInfoRequest(indexChannel int) (string, int) {
 
-	rand.Seed(time.Now().UTC().UnixNano())
-
 	if indexChannel >= kr.GetNbReservedChannels() {
-		indexChannel = rand.Int() % kr.GetNbReservedChannels()
+		indexChannel = util.RandInt() % kr.GetNbReservedChannels()
 	}
 
 	_, drString := kr.GetDataRate(5)
@@ -16,6 +16,12 @@ type Parameters struct {
 	MaxRX1DROffset    uint8               `json:"maxRX1DROffset"`
 }
 
+// RegionSummary identifies a supported region
+type RegionSummary struct {
+	Code int    `json:"code"`
+	Name string `json:"name"`
+}
+
 type Informations struct {
 	MaxRX1DROffset     uint8      `json:"maxRX1DROffset"`
 	DataRate           [14]int    `json:"dataRate"`
@@ -3,6 +3,7 @@ package regional_parameters
 import (
 	"errors"
 	"fmt"
+	"sort"
 
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
 	models "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters/models_rp"
@@ -50,20 +51,39 @@ type Region interface {
 }
 
 type regionInfo struct {
+	name string
 	info func() Region
 }
 
 var regionRegistry = map[int]regionInfo{
-	Code_Eu868: {func() Region { return &Eu868{} }},
-	Code_Us915: {func() Region { return &Us915{} }},
-	Code_Cn779: {func() Region { return &Cn779{} }},
-	Code_Eu433: {func() Region { return &Eu433{} }},
-	Code_Au915: {func() Region { return &Au915{} }},
-	Code_Cn470: {func() Region { return &Cn470{} }},
-	Code_As923: {func() Region { return &As923{} }},
-	Code_Kr920: {func() Region { return &Kr920{} }},
-	Code_In865: {func() Region { return &In865{} }},
-	Code_Ru864: {func() Region { return &Ru864{} }},
+	Code_Eu868: {"EU868", func() Region { return &Eu868{} }},
+	Code_Us915: {"US915", func() Region { return &Us915{} }},
+	Code_Cn779: {"CN779", func() Region { return &Cn779{} }},
+	Code_Eu433: {"EU433", func() Region { return &Eu433{} }},
+	Code_Au915: {"AU915", func() Region { return &Au915{} }},
+	Code_Cn470: {"CN470", func() Region { return &Cn470{} }},
+	Code_As923: {"AS923", func() Region { return &As923{} }},
+	Code_Kr920: {"KR920", func() Region { return &Kr920{} }},
+	Code_In865: {"IN865", func() Region { return &In865{} }},
+	Code_Ru864: {"RU864", func() Region { return &Ru864{} }},
+}
+
+// Regions returns the code and name of every supported region, ordered by code
+func Regions() []models.RegionSummary {
+
+	regions := make([]models.RegionSummary, 0, len(regionRegistry))
+	for code, r := range regionRegistry {
+		regions = append(regions, models.RegionSummary{Code: code, Name: r.name})
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Code < regions[j].Code })
+
+	return regions
+}
+
+// IsSupported reports whether the code identifies a supported region
+func IsSupported(Code int) bool {
+	_, ok := regionRegistry[Code]
+	return ok
 }
 
 func GetRegionalParameters(Code int) Region {
@@ -3,11 +3,10 @@ package regional_parameters
 import (
 	"errors"
 	"fmt"
-	"math/rand"
-	"time"
 
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features/channels"
 	models "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters/models_rp"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/brocaar/lorawan"
 )
 
@@ -136,10 +135,8 @@ func (ru *Ru864) SetupRX1(datarate uint8, rx1offset uint8, indexChannel int, dti
 
 func (ru *Ru864) SetupInfoRequest(indexChannel int) (string, int) {
 
-	rand.Seed(time.Now().UTC().UnixNano())
-
 	if indexChannel >= ru.GetNbReservedChannels() {
-		indexChannel = rand.Int() % ru.GetNbReservedChannels()
+		indexChannel = util.RandInt() % ru.GetNbReservedChannels()
 	}
 
 	_, drString := ru.GetDataRate(5)
@@ -0,0 +1,139 @@
+package device
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+)
+
+// replayFrame is a recorded uplink, decoded
+type replayFrame struct {
+	delay   time.Duration
+	fPort   uint8
+	payload []byte
+}
+
+// Replay plays recorded uplinks in order through the uplink path of the device, in
+// place of its periodic ones and without its codec. A replay in progress is replaced.
+// The replay ends when the device is turned off.
+func (d *Device) Replay(replay models.Replay) error {
+
+	d.Mutex.Lock()
+	runCtx := d.ctx
+	running := d.State == util.Running && runCtx != nil
+	d.Mutex.Unlock()
+
+	if !running {
+		return errors.New("device is not running, start it before replaying uplinks")
+	}
+	if len(replay.Uplinks) == 0 {
+		return errors.New("no uplinks to replay")
+	}
+
+	frames := make([]replayFrame, len(replay.Uplinks))
+	for i, uplink := range replay.Uplinks {
+		if uplink.DelayMs < 0 {
+			return fmt.Errorf("uplink %d: delay can't be negative", i)
+		}
+		if uplink.FPort == 0 || uplink.FPort > 223 {
+			return fmt.Errorf("uplink %d: fPort must be between 1 and 223", i)
+		}
+		payload, err := hex.DecodeString(strings.TrimSpace(uplink.PayloadHex))
+		if err != nil {
+			return fmt.Errorf("uplink %d: invalid payload: %w", i, err)
+		}
+		frames[i] = replayFrame{
+			delay:   time.Duration(uplink.DelayMs) * time.Millisecond,
+			fPort:   uplink.FPort,
+			payload: payload,
+		}
+	}
+
+	d.StopReplay()
+
+	ctx, cancel := context.WithCancel(runCtx)
+
+	d.replayMu.Lock()
+	d.replayCtx = ctx
+	d.replayCancel = cancel
+	d.replayMu.Unlock()
+
+	go d.replay(ctx, frames, replay.Loop)
+
+	return nil
+}
+
+// StopReplay stops the replay in progress, if any. The device resumes its periodic uplinks.
+func (d *Device) StopReplay() {
+	d.replayMu.Lock()
+	cancel := d.replayCancel
+	d.replayCtx = nil
+	d.replayCancel = nil
+	d.replayPending = nil
+	d.replayMu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Replaying reports whether a replay is in progress
+func (d *Device) Replaying() bool {
+	d.replayMu.Lock()
+	defer d.replayMu.Unlock()
+	return d.replayCtx != nil && d.replayCtx.Err() == nil
+}
+
+// replay hands the frames to the run loop one at a time, each after its delay
+func (d *Device) replay(ctx context.Context, frames []replayFrame, loop bool) {
+
+	d.Print(fmt.Sprintf("Replay of %d uplinks started", len(frames)), nil, util.PrintBoth)
+
+	for {
+		for i := range frames {
+
+			if !sleep(ctx, d.scaled(frames[i].delay)) {
+				return
+			}
+
+			d.replayMu.Lock()
+			d.replayPending = &frames[i]
+			d.replayMu.Unlock()
+
+			select {
+			case d.replayTrigger <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if !loop {
+			break
+		}
+	}
+
+	d.replayMu.Lock()
+	if d.replayCtx == ctx {
+		d.replayCtx = nil
+		d.replayCancel = nil
+	}
+	d.replayMu.Unlock()
+
+	d.Print("Replay finished", nil, util.PrintBoth)
+}
+
+// takeReplayFrame returns the replayed uplink due, if any
+func (d *Device) takeReplayFrame() (*replayFrame, bool) {
+	d.replayMu.Lock()
+	defer d.replayMu.Unlock()
+
+	frame := d.replayPending
+	d.replayPending = nil
+	return frame, frame != nil
+}
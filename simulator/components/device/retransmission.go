@@ -0,0 +1,70 @@
+package device
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
+)
+
+const (
+	// MaxNbRetransmission is the largest number of retries of a confirmed uplink (NbTrans is at most 15)
+	MaxNbRetransmission = 15
+	// MaxAckTimeout is the largest ACK timeout accepted, as in the device form
+	MaxAckTimeout = 3 * time.Second
+)
+
+// GetRetransmission returns the confirmed-uplink retry settings
+func (d *Device) GetRetransmission() models.Retransmission {
+	d.Mutex.Lock()
+	defer d.Mutex.Unlock()
+	return models.Retransmission{
+		NbRetransmission: d.Info.Configuration.NbRepConfirmedDataUp,
+		AckTimeout:       int(d.Info.Configuration.AckTimeout / time.Second),
+	}
+}
+
+// SetRetransmission validates and applies the retry settings. Nothing is applied if
+// any value is invalid. Running devices use the new values from the next uplink.
+func (d *Device) SetRetransmission(update models.RetransmissionUpdate) (models.Retransmission, error) {
+
+	if update.NbRetransmission != nil && (*update.NbRetransmission < 0 || *update.NbRetransmission > MaxNbRetransmission) {
+		return models.Retransmission{}, fmt.Errorf("nbRetransmission must be between 0 and %d", MaxNbRetransmission)
+	}
+	if update.AckTimeout != nil && (*update.AckTimeout < 0 || time.Duration(*update.AckTimeout)*time.Second > MaxAckTimeout) {
+		return models.Retransmission{}, fmt.Errorf("ackTimeout must be between 0 and %d seconds", int(MaxAckTimeout/time.Second))
+	}
+
+	apply := func() {
+		d.Mutex.Lock()
+		defer d.Mutex.Unlock()
+		if update.NbRetransmission != nil {
+			d.Info.Configuration.NbRepConfirmedDataUp = *update.NbRetransmission
+		}
+		if update.AckTimeout != nil {
+			d.Info.Configuration.AckTimeout = time.Duration(*update.AckTimeout) * time.Second
+		}
+	}
+	// The run loop reads both values while sending confirmed uplinks
+	if !d.IsOn() || !d.runOnLoop(apply) {
+		apply()
+	}
+
+	d.Print("Retransmission settings updated", nil, util.PrintBoth)
+
+	return d.GetRetransmission(), nil
+}
+
+// emitRetransmission notifies the socket and the MQTT broker that the last confirmed
+// uplink is being resent
+func (d *Device) emitRetransmission() {
+	d.emit(socket.EventRetransmission, socket.Retransmission{
+		Id:          d.Id,
+		Name:        d.Info.Name,
+		FCnt:        d.Info.Status.DataUplink.FCnt,
+		Attempt:     d.Info.Status.CounterRepConfirmedDataUp,
+		MaxAttempts: d.Info.Configuration.NbRepConfirmedDataUp,
+	})
+}
@@ -0,0 +1,49 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/brocaar/lorawan"
+)
+
+func TestSetRetransmissionOnRunningDevice(t *testing.T) {
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 11}, lorawan.DevAddr{1, 2, 3, 7},
+		[16]byte{1}, [16]byte{2})
+	d.Info.Status.MType = lorawan.ConfirmedDataUp // never acknowledged, so resent
+	d.Info.Configuration.SendInterval = 20 * time.Millisecond
+
+	n.Start(d)
+	defer n.Stop(d)
+
+	tests := []struct {
+		nbRetransmission int
+		ackTimeout       int
+		valid            bool
+	}{
+		{2, 0, true},
+		{0, 1, true},
+		{16, 0, false},
+		{1, 4, false},
+	}
+	for _, tt := range tests {
+		nb, ack := tt.nbRetransmission, tt.ackTimeout
+		got, err := d.SetRetransmission(models.RetransmissionUpdate{NbRetransmission: &nb, AckTimeout: &ack})
+		if (err == nil) != tt.valid {
+			t.Fatalf("SetRetransmission(%d, %d) error = %v, want valid %v", nb, ack, err, tt.valid)
+		}
+		if tt.valid && (got.NbRetransmission != nb || got.AckTimeout != ack) {
+			t.Errorf("SetRetransmission(%d, %d) = %+v", nb, ack, got)
+		}
+		if _, err := n.NextUplink(testutil.CycleTimeout); err != nil {
+			t.Fatalf("uplink after SetRetransmission(%d, %d): %v", nb, ack, err)
+		}
+	}
+
+	if got := d.GetRetransmission(); got.NbRetransmission != 0 || got.AckTimeout != 1 {
+		t.Errorf("GetRetransmission() = %+v, want the last valid settings", got)
+	}
+}
@@ -0,0 +1,102 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+)
+
+const (
+	// MaxRXDelay is the largest receive delay accepted (RXTimingSetupReq allows up to 15 s)
+	MaxRXDelay = 15 * time.Second
+	// MaxRXDurationOpen is the largest time a receive window can be kept open
+	MaxRXDurationOpen = 10 * time.Second
+)
+
+// SetRXWindows validates the overrides against the device region and applies them to RX1/RX2.
+// Nothing is applied if any value is invalid. Running devices use the new values from the next uplink.
+func (d *Device) SetRXWindows(update models.RXWindowsUpdate) ([]features.Window, error) {
+
+	if len(d.Info.RX) < 2 {
+		return nil, errors.New("device has no receive windows configured")
+	}
+
+	if update.RX1 != nil {
+		if update.RX1.DataRate != nil || update.RX1.Frequency != nil {
+			return nil, errors.New("rx1: data rate and frequency follow the uplink, set rx1DROffset instead")
+		}
+		if err := validateRXTiming(update.RX1); err != nil {
+			return nil, fmt.Errorf("rx1: %w", err)
+		}
+	}
+
+	if update.RX2 != nil {
+		if err := validateRXTiming(update.RX2); err != nil {
+			return nil, fmt.Errorf("rx2: %w", err)
+		}
+		if update.RX2.DataRate != nil {
+			if err := d.isSupportedDR(*update.RX2.DataRate); err != nil {
+				return nil, fmt.Errorf("rx2: %w", err)
+			}
+		}
+		if update.RX2.Frequency != nil {
+			if err := d.isSupportedFrequency(*update.RX2.Frequency); err != nil {
+				return nil, fmt.Errorf("rx2: %w", err)
+			}
+		}
+	}
+
+	d.Mutex.Lock()
+	applyRXSettings(&d.Info.RX[0], update.RX1)
+	applyRXSettings(&d.Info.RX[1], update.RX2)
+	windows := []features.Window{d.Info.RX[0], d.Info.RX[1]}
+	d.Mutex.Unlock()
+
+	d.Print("RX windows updated", nil, util.PrintBoth)
+
+	return windows, nil
+}
+
+// validateRXTiming checks the delay and open duration of a window
+func validateRXTiming(settings *models.RXWindowSettings) error {
+
+	if settings.Delay != nil {
+		delay := time.Duration(*settings.Delay) * time.Millisecond
+		if delay <= 0 || delay > MaxRXDelay {
+			return fmt.Errorf("delay must be between 1 and %d ms", MaxRXDelay.Milliseconds())
+		}
+	}
+
+	if settings.DurationOpen != nil {
+		duration := time.Duration(*settings.DurationOpen) * time.Millisecond
+		if duration <= 0 || duration > MaxRXDurationOpen {
+			return fmt.Errorf("durationOpen must be between 1 and %d ms", MaxRXDurationOpen.Milliseconds())
+		}
+	}
+
+	return nil
+}
+
+// applyRXSettings copies the set fields of the overrides into the window
+func applyRXSettings(w *features.Window, settings *models.RXWindowSettings) {
+
+	if settings == nil {
+		return
+	}
+	if settings.Delay != nil {
+		w.Delay = time.Duration(*settings.Delay) * time.Millisecond
+	}
+	if settings.DurationOpen != nil {
+		w.DurationOpen = time.Duration(*settings.DurationOpen) * time.Millisecond
+	}
+	if settings.DataRate != nil {
+		w.DataRate = *settings.DataRate
+	}
+	if settings.Frequency != nil {
+		w.SetListeningFrequency(*settings.Frequency)
+	}
+}
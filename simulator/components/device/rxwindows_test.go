@@ -0,0 +1,66 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/brocaar/lorawan"
+)
+
+func TestSetRXWindows(t *testing.T) {
+	ms := func(v int) *int { return &v }
+	dr := func(v uint8) *uint8 { return &v }
+	freq := func(v uint32) *uint32 { return &v }
+
+	tests := []struct {
+		name       string
+		update     models.RXWindowsUpdate
+		wantErr    bool
+		wantDelay1 time.Duration
+		wantDelay2 time.Duration
+		wantDR2    uint8
+		wantFreq2  uint32
+	}{
+		{"nothing", models.RXWindowsUpdate{}, false, testutil.RXDelay, testutil.RXDelay, 0, testutil.RX2Frequency},
+		{"rx1 delay", models.RXWindowsUpdate{RX1: &models.RXWindowSettings{Delay: ms(2000)}},
+			false, 2 * time.Second, testutil.RXDelay, 0, testutil.RX2Frequency},
+		{"rx2 settings", models.RXWindowsUpdate{RX2: &models.RXWindowSettings{Delay: ms(3000), DataRate: dr(3), Frequency: freq(869525000)}},
+			false, testutil.RXDelay, 3 * time.Second, 3, 869525000},
+		{"rx1 data rate", models.RXWindowsUpdate{RX1: &models.RXWindowSettings{DataRate: dr(3)}},
+			true, testutil.RXDelay, testutil.RXDelay, 0, testutil.RX2Frequency},
+		{"delay too long", models.RXWindowsUpdate{RX2: &models.RXWindowSettings{Delay: ms(16000)}},
+			true, testutil.RXDelay, testutil.RXDelay, 0, testutil.RX2Frequency},
+		{"zero duration", models.RXWindowsUpdate{RX2: &models.RXWindowSettings{DurationOpen: ms(0)}},
+			true, testutil.RXDelay, testutil.RXDelay, 0, testutil.RX2Frequency},
+		{"data rate out of region", models.RXWindowsUpdate{RX2: &models.RXWindowSettings{DataRate: dr(15)}},
+			true, testutil.RXDelay, testutil.RXDelay, 0, testutil.RX2Frequency},
+		{"frequency out of region", models.RXWindowsUpdate{RX1: &models.RXWindowSettings{Delay: ms(2000)},
+			RX2: &models.RXWindowSettings{Frequency: freq(915000000)}},
+			true, testutil.RXDelay, testutil.RXDelay, 0, testutil.RX2Frequency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := testutil.NewNetwork()
+			d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 9, 1}, lorawan.DevAddr{1, 2, 5, 1},
+				[16]byte{1}, [16]byte{2})
+
+			windows, err := d.SetRXWindows(tt.update)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetRXWindows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (len(windows) != 2 || windows[1].Delay != d.Info.RX[1].Delay) {
+				t.Errorf("SetRXWindows() returned %+v, want the applied windows", windows)
+			}
+
+			rx1, rx2 := d.Info.RX[0], d.Info.RX[1]
+			if rx1.Delay != tt.wantDelay1 || rx2.Delay != tt.wantDelay2 {
+				t.Errorf("delays = %v/%v, want %v/%v", rx1.Delay, rx2.Delay, tt.wantDelay1, tt.wantDelay2)
+			}
+			if rx2.DataRate != tt.wantDR2 || rx2.GetListeningFrequency() != tt.wantFreq2 {
+				t.Errorf("RX2 = DR%d %d Hz, want DR%d %d Hz", rx2.DataRate, rx2.GetListeningFrequency(), tt.wantDR2, tt.wantFreq2)
+			}
+		})
+	}
+}
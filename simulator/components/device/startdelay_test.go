@@ -0,0 +1,61 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestStartDelay(t *testing.T) {
+	util.SetSeed(1)
+
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 16, 0}, lorawan.DevAddr{1, 2, 12, 0}, [16]byte{1}, [16]byte{2})
+	d.Info.Configuration.SendInterval = 50 * time.Millisecond
+
+	// Each step starts the device again, after the previous step stopped it
+	steps := []struct {
+		name       string
+		delay      time.Duration // set before the start, 0 = not set
+		wantAfter  time.Duration // minimum time between the start and the first uplink
+		wantUplink bool          // within 2s of the start
+	}{
+		{"delayed start", 500 * time.Millisecond, 500 * time.Millisecond, true},
+		{"the delay is used once", 0, 0, true},
+		{"stopped during the delay", time.Hour, 0, false},
+	}
+	for _, step := range steps {
+		if step.delay > 0 {
+			d.SetStartDelay(step.delay)
+		}
+
+		start := time.Now()
+		n.Start(d)
+		_, err := n.NextUplink(2 * time.Second)
+		elapsed := time.Since(start)
+
+		stopped := make(chan struct{})
+		go func() {
+			n.Stop(d)
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s: the device did not stop", step.name)
+		}
+
+		if got := err == nil; got != step.wantUplink {
+			t.Fatalf("%s: uplink received = %v, want %v", step.name, got, step.wantUplink)
+		}
+		if step.wantUplink && elapsed < step.wantAfter {
+			t.Errorf("%s: first uplink %v after the start, want at least %v", step.name, elapsed, step.wantAfter)
+		}
+		if step.wantUplink && step.wantAfter == 0 && elapsed > 400*time.Millisecond {
+			t.Errorf("%s: first uplink %v after the start, want no delay", step.name, elapsed)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package device
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
+)
+
+// sweepDataRate applies the data rate sweep before an uplink. The sweep starts at the
+// current data rate, or at Min when it is out of range, then moves one step each time
+// the step interval elapses, reversing at Min and Max. ADR and LinkADRReq leave the data
+// rate to the sweep while one is configured.
+func (d *Device) sweepDataRate() {
+
+	sweep := d.Info.Configuration.DataRateSweep
+	if sweep == nil || sweep.Validate(d.Info.Configuration.Region) != nil { //invalid sweeps loaded from disk are ignored
+		return
+	}
+
+	now := time.Now()
+	status := &d.Info.Status
+	if !status.SweepNext.IsZero() && now.Before(status.SweepNext) {
+		return
+	}
+
+	dr := status.DataRate
+	switch {
+	case dr < sweep.Min || dr > sweep.Max:
+		dr, status.SweepDown = sweep.Min, false
+	case status.SweepNext.IsZero(), sweep.Min == sweep.Max:
+		// first uplink of the sweep, keep the current data rate
+	case !status.SweepDown && dr == sweep.Max:
+		dr, status.SweepDown = dr-1, true
+	case status.SweepDown && dr == sweep.Min:
+		dr, status.SweepDown = dr+1, false
+	case status.SweepDown:
+		dr--
+	default:
+		dr++
+	}
+
+	status.SweepNext = now.Add(d.scaled(time.Duration(sweep.StepInterval) * time.Second))
+
+	if dr == status.DataRate {
+		return
+	}
+
+	previous := status.DataRate
+	status.DataRate = dr
+
+	d.Print(fmt.Sprintf("Data rate sweep: DR%d to DR%d", previous, dr), nil, util.PrintBoth)
+	d.emit(socket.EventDataRateChanged, socket.DataRateChange{
+		Id:       d.Id,
+		Name:     d.Info.Name,
+		Previous: previous,
+		DataRate: dr,
+	})
+}
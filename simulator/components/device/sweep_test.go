@@ -0,0 +1,99 @@
+package device_test
+
+import (
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestDataRateSweepRampsUpAndDown(t *testing.T) {
+	util.SetSeed(1)
+
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, lorawan.DevAddr{1, 2, 3, 4},
+		[16]byte{1}, [16]byte{2})
+	d.Info.Configuration.DataRateSweep = &models.DataRateSweep{Min: 0, Max: 2, StepInterval: 1}
+	d.SetTimeScale(1e9) // each step interval is already over at the next uplink
+
+	want := []string{"SF12BW125", "SF11BW125", "SF10BW125", "SF11BW125", "SF12BW125", "SF11BW125"}
+	for i, datr := range want {
+		uplinks, err := n.Cycle(d, nil)
+		if err != nil {
+			t.Fatalf("uplink %d: Cycle() error = %v", i, err)
+		}
+		if len(uplinks) != 1 {
+			t.Fatalf("uplink %d: cycle sent %d uplinks, want 1", i, len(uplinks))
+		}
+		if got := uplinks[0].DatR; got != datr {
+			t.Errorf("uplink %d: data rate = %s, want %s", i, got, datr)
+		}
+	}
+}
+
+func TestDataRateSweepOverridesADR(t *testing.T) {
+	var chMask lorawan.ChMask
+	for i := 0; i < 4; i++ {
+		chMask[i] = true
+	}
+
+	tests := []struct {
+		name  string
+		sweep *models.DataRateSweep
+		kept  bool // the data rate stays at DR3
+	}{
+		{"no sweep", nil, false},
+		{"sweep", &models.DataRateSweep{Min: 3, Max: 3, StepInterval: 3600}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"/ADR", func(t *testing.T) {
+			n := testutil.NewNetwork()
+			d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, lorawan.DevAddr{1, 2, 3, 4},
+				[16]byte{1}, [16]byte{2})
+			d.Info.Configuration.DataRateSweep = tt.sweep
+			d.Info.Status.DataRate = 3
+
+			// Without ADR support the device backs off one data rate after this many uplinks
+			d.Info.Status.DataUplink.ADR.ADRACKCnt = 96
+			d.ADRProcedure()
+			if kept := d.Info.Status.DataRate == 3; kept != tt.kept {
+				t.Errorf("data rate after the ADR backoff = %d, kept %v, want kept %v", d.Info.Status.DataRate, kept, tt.kept)
+			}
+		})
+
+		t.Run(tt.name+"/LinkADRReq", func(t *testing.T) {
+			util.SetSeed(1)
+			n := testutil.NewNetwork()
+			d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, lorawan.DevAddr{1, 2, 3, 4},
+				[16]byte{1}, [16]byte{2})
+			d.Info.Configuration.DataRateSweep = tt.sweep
+
+			// EU868 only acknowledges a data rate on a channel beyond the default ones
+			downlinks := []lorawan.MACCommand{
+				{CID: lorawan.NewChannelReq, Payload: &lorawan.NewChannelReqPayload{ChIndex: 3, Freq: 867100000, MaxDR: 5}},
+				{CID: lorawan.LinkADRReq, Payload: &lorawan.LinkADRReqPayload{DataRate: 2, TXPower: 2, ChMask: chMask, Redundancy: lorawan.Redundancy{NbRep: 1}}},
+			}
+			for _, command := range downlinks {
+				downlink, err := testutil.DataDown(d, command)
+				if err != nil {
+					t.Fatalf("DataDown() error = %v", err)
+				}
+				if _, err := n.Cycle(d, downlink); err != nil {
+					t.Fatalf("Cycle() error = %v", err)
+				}
+			}
+			want := uint8(2)
+			if tt.kept {
+				want = 3
+			}
+			if got := d.Info.Status.DataRate; got != want {
+				t.Errorf("data rate after LinkADRReq = %d, want %d", got, want)
+			}
+			if d.Info.Status.TXPower != 2 {
+				t.Errorf("TX power after LinkADRReq = %d, want 2", d.Info.Status.TXPower)
+			}
+		})
+	}
+}
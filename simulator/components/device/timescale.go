@@ -0,0 +1,43 @@
+package device
+
+import (
+	"math"
+	"time"
+)
+
+// SetTimeScale sets the factor dividing the send interval and the ACK timeout
+// (e.g. 10 makes the device transmit 10x faster) and reschedules the device loop
+func (d *Device) SetTimeScale(scale float64) {
+	d.timeScale.Store(math.Float64bits(scale))
+	d.signalIntervalChanged()
+}
+
+// scaled divides a duration by the time scale (unset or invalid scales count as 1)
+func (d *Device) scaled(duration time.Duration) time.Duration {
+	scale := math.Float64frombits(d.timeScale.Load())
+	if scale <= 0 || scale == 1 {
+		return duration
+	}
+	return time.Duration(float64(duration) / scale)
+}
+
+// sendInterval returns the send interval with the time scale applied
+func (d *Device) sendInterval() time.Duration {
+	return d.scaled(d.Info.Configuration.SendInterval)
+}
+
+// ackTimeout returns the ACK timeout with the time scale applied
+func (d *Device) ackTimeout() time.Duration {
+	return d.scaled(d.Info.Configuration.AckTimeout)
+}
+
+// signalIntervalChanged makes the device loop reset its ticker (non-blocking)
+func (d *Device) signalIntervalChanged() {
+	if d.IntervalChanged != nil {
+		select {
+		case d.IntervalChanged <- struct{}{}:
+		default:
+			// Channel already has a pending signal, skip
+		}
+	}
+}
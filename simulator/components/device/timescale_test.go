@@ -0,0 +1,41 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestSetTimeScaleReschedulesRunningDevice(t *testing.T) {
+	util.SetSeed(1)
+
+	tests := []struct {
+		name        string
+		scale       float64
+		wantUplinks bool
+	}{
+		{"real time", 1, false},
+		{"100x faster", 100, true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := testutil.NewNetwork()
+			d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 11, byte(i)}, lorawan.DevAddr{1, 2, 7, byte(i)},
+				[16]byte{1}, [16]byte{2})
+			d.Info.Configuration.SendInterval = 10 * time.Second
+
+			n.Start(d)
+			defer n.Stop(d)
+			time.Sleep(50 * time.Millisecond) // the loop waits for the first 10s tick
+
+			d.SetTimeScale(tt.scale)
+			_, err := n.NextUplink(2 * time.Second)
+			if got := err == nil; got != tt.wantUplinks {
+				t.Errorf("uplink within 2s of the 10s interval scaled by %v: %v, want %v", tt.scale, got, tt.wantUplinks)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package device
+
+import (
+	"errors"
+	"time"
+
+	mup "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/frames/uplink/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
+)
+
+// DefaultUplinkQueueSize is the number of uplinks a device can have queued when no size is configured
+const DefaultUplinkQueueSize = 32
+
+var (
+	// ErrUplinkQueueFull is returned when a device already has the maximum number of uplinks queued
+	ErrUplinkQueueFull = errors.New("uplink queue is full")
+	// ErrUplinkTooSoon is returned when an uplink is queued before the minimum spacing elapsed
+	ErrUplinkTooSoon = errors.New("uplink queued too soon after the previous one")
+)
+
+// SetUplinkLimits sets the minimum time between two queued uplinks (0 = none) and the
+// maximum number of queued uplinks (0 = DefaultUplinkQueueSize, negative = unlimited)
+func (d *Device) SetUplinkLimits(minSpacing time.Duration, queueSize int) {
+	d.uplinkMu.Lock()
+	defer d.uplinkMu.Unlock()
+	d.uplinkMinSpacing = minSpacing
+	d.uplinkQueueSize = queueSize
+}
+
+// queueUplink appends an uplink to the buffer unless it exceeds the limits, in which
+// case the uplink is dropped and an uplink-throttled event is emitted
+func (d *Device) queueUplink(info mup.InfoFrame) error {
+	d.uplinkMu.Lock()
+
+	queued := len(d.Info.Status.BufferUplinks)
+	size := d.uplinkQueueSize
+	if size == 0 {
+		size = DefaultUplinkQueueSize
+	}
+
+	var err error
+	switch {
+	case size > 0 && queued >= size:
+		err = ErrUplinkQueueFull
+	case d.uplinkMinSpacing > 0 && time.Since(d.lastQueuedUplink) < d.uplinkMinSpacing:
+		err = ErrUplinkTooSoon
+	default:
+		d.Info.Status.BufferUplinks = append(d.Info.Status.BufferUplinks, info)
+		d.lastQueuedUplink = time.Now()
+	}
+	d.uplinkMu.Unlock()
+
+	if err != nil {
+		d.emit(socket.EventUplinkThrottled, socket.UplinkThrottled{
+			Id:     d.Id,
+			Name:   d.Info.Name,
+			Reason: err.Error(),
+			Queued: queued,
+		})
+	}
+	return err
+}
+
+// popUplink removes and returns the oldest queued uplink, if any
+func (d *Device) popUplink() (mup.InfoFrame, bool) {
+	d.uplinkMu.Lock()
+	defer d.uplinkMu.Unlock()
+
+	if len(d.Info.Status.BufferUplinks) == 0 {
+		return mup.InfoFrame{}, false
+	}
+
+	info := d.Info.Status.BufferUplinks[0]
+	switch len(d.Info.Status.BufferUplinks) {
+	case 1:
+		d.Info.Status.BufferUplinks = d.Info.Status.BufferUplinks[:0]
+
+	default:
+		d.Info.Status.BufferUplinks = d.Info.Status.BufferUplinks[1:]
+
+	}
+	return info, true
+}
@@ -0,0 +1,72 @@
+package device_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestUplinkLimits(t *testing.T) {
+	util.SetSeed(1)
+
+	tests := []struct {
+		name       string
+		minSpacing time.Duration
+		queueSize  int
+		uplinks    int   // uplinks queued in a row
+		wantQueued int   // accepted before the first error
+		wantErr    error // returned by the uplinks after
+	}{
+		{"default queue size", 0, 0, dev.DefaultUplinkQueueSize + 1, dev.DefaultUplinkQueueSize, dev.ErrUplinkQueueFull},
+		{"queue size", 0, 2, 3, 2, dev.ErrUplinkQueueFull},
+		{"unlimited queue", 0, -1, dev.DefaultUplinkQueueSize + 1, dev.DefaultUplinkQueueSize + 1, nil},
+		{"minimum spacing", time.Hour, 0, 2, 1, dev.ErrUplinkTooSoon},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := testutil.NewNetwork()
+			d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 15, byte(i)}, lorawan.DevAddr{1, 2, 11, byte(i)},
+				[16]byte{1}, [16]byte{2})
+			d.SetUplinkLimits(tt.minSpacing, tt.queueSize)
+
+			for uplink := 0; uplink < tt.uplinks; uplink++ {
+				err := d.NewUplink(lorawan.UnconfirmedDataUp, string(rune('a'+uplink%26)))
+				want := tt.wantErr
+				if uplink < tt.wantQueued {
+					want = nil
+				}
+				if !errors.Is(err, want) {
+					t.Fatalf("uplink %d: NewUplink() error = %v, want %v", uplink, err, want)
+				}
+			}
+			if queued := len(d.Info.Status.BufferUplinks); queued != tt.wantQueued {
+				t.Fatalf("%d uplinks queued, want %d", queued, tt.wantQueued)
+			}
+
+			// Sending the oldest queued uplink frees its place in the queue
+			uplinks, err := n.Cycle(d, nil)
+			if err != nil || len(uplinks) != 1 {
+				t.Fatalf("Cycle() = %d uplinks, %v, want 1", len(uplinks), err)
+			}
+			phy, err := testutil.Decode(uplinks[0])
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if err := phy.DecryptFRMPayload(d.Info.AppSKey); err != nil {
+				t.Fatalf("DecryptFRMPayload() error = %v", err)
+			}
+			payload := phy.MACPayload.(*lorawan.MACPayload).FRMPayload[0].(*lorawan.DataPayload).Bytes
+			if string(payload) != "a" {
+				t.Errorf("uplink payload = %q, want the oldest queued %q", payload, "a")
+			}
+			if queued := len(d.Info.Status.BufferUplinks); queued != tt.wantQueued-1 {
+				t.Errorf("%d uplinks queued after the cycle, want %d", queued, tt.wantQueued-1)
+			}
+		})
+	}
+}
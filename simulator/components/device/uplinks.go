@@ -1,6 +1,9 @@
 package device
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/classes"
 	up "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/frames/uplink"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
@@ -35,19 +38,19 @@ func (d *Device) CreateUplink() [][]byte {
 
 	case util.Normal: //new uplink
 
-		if len(d.Info.Status.BufferUplinks) > 0 {
+		if frame, ok := d.takeReplayFrame(); ok {
 
-			mtype = d.Info.Status.BufferUplinks[0].MType
-			payload = d.Info.Status.BufferUplinks[0].Payload
+			mtype = d.Info.Status.MType
+			payload = &lorawan.DataPayload{Bytes: frame.payload}
 
-			switch len(d.Info.Status.BufferUplinks) {
-			case 1:
-				d.Info.Status.BufferUplinks = d.Info.Status.BufferUplinks[:0]
+			fPort := d.Info.Status.DataUplink.FPort
+			d.Info.Status.DataUplink.FPort = &frame.fPort
+			defer func() { d.Info.Status.DataUplink.FPort = fPort }()
 
-			default:
-				d.Info.Status.BufferUplinks = d.Info.Status.BufferUplinks[1:]
+		} else if queued, ok := d.popUplink(); ok {
 
-			}
+			mtype = queued.MType
+			payload = queued.Payload
 
 		} else {
 			mtype = d.Info.Status.MType
@@ -58,7 +61,7 @@ func (d *Device) CreateUplink() [][]byte {
 				payload = d.GenerateCodecPayload()
 			} else {
 				// Use static payload from configuration
-				payload = d.Info.Status.Payload
+				payload = d.staticPayload()
 			}
 		}
 
@@ -66,24 +69,30 @@ func (d *Device) CreateUplink() [][]byte {
 
 	}
 
-	m, n := d.Info.Configuration.Region.GetPayloadSize(d.Info.Status.DataRate, d.Info.Status.DataUplink.DwellTime)
+	size := d.maxPayloadSize()
 
 	if d.Info.Configuration.SupportedFragment { //frammentazione
 
-		if len(d.Info.Status.DataUplink.FOpts) > 0 {
-			DataPayload = up.Fragmentation(n, payload)
-		} else {
-			DataPayload = up.Fragmentation(m, payload)
+		DataPayload = up.Fragmentation(size, payload)
+
+		if len(DataPayload) > 1 {
+			msg := fmt.Sprintf("Payload exceeds the maximum of %d bytes at DR%d, split into %d fragments",
+				size, d.Info.Status.DataRate, len(DataPayload))
+			d.Print(msg, nil, util.PrintBoth)
 		}
 
-	} else { //troncamento
+	}
 
-		if len(d.Info.Status.DataUplink.FOpts) > 0 {
-			DataPayload = append(DataPayload, up.Truncate(n, payload))
-		} else {
-			DataPayload = append(DataPayload, up.Truncate(m, payload))
+	if DataPayload == nil { //troncamento
+
+		if payloadBytes, err := payload.MarshalBinary(); err == nil && len(payloadBytes) > size {
+			msg := fmt.Sprintf("Payload of %d bytes exceeds the maximum of %d bytes at DR%d, truncated",
+				len(payloadBytes), size, d.Info.Status.DataRate)
+			d.Print("", errors.New(msg), util.PrintBoth)
 		}
 
+		DataPayload = append(DataPayload, up.Truncate(size, payload))
+
 	}
 
 	for i := 0; i < len(DataPayload); i++ {
@@ -158,3 +167,21 @@ func (d *Device) SendJoinRequest() {
 	d.Class.SendData(info)
 	d.Print("JOIN REQUEST sent", nil, util.PrintBoth)
 }
+
+// maxPayloadSize returns the largest application payload the next uplink can carry at
+// the current data rate: N of the region, less the MAC commands waiting for FOpts
+func (d *Device) maxPayloadSize() int {
+
+	_, size := d.Info.Configuration.Region.GetPayloadSize(d.Info.Status.DataRate, d.Info.Status.DataUplink.DwellTime)
+
+	fopts := foptsSize(d.waitingMACCommands())
+	if fopts > MaxFOptsSize {
+		fopts = MaxFOptsSize
+	}
+
+	if size -= fopts; size < 0 {
+		size = 0
+	}
+
+	return size
+}
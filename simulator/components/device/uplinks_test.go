@@ -0,0 +1,136 @@
+package device_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/testutil"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestUplinkPayloadTruncatedToMaxSize(t *testing.T) {
+	util.SetSeed(1)
+
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, lorawan.DevAddr{1, 2, 3, 4},
+		[16]byte{1}, [16]byte{2})
+
+	// EU868 DR0 carries at most 51 bytes of application payload
+	if limit := d.GetPayloadLimit(); limit.DataRate != 0 || limit.MaxPayload != 51 {
+		t.Fatalf("GetPayloadLimit() = %+v, want DR0 and 51 bytes", limit)
+	}
+
+	// The DevStatusAns waiting for FOpts takes 3 of them
+	if _, err := d.InjectMACCommand(models.MACInjection{CID: "DevStatusReq"}); err != nil {
+		t.Fatalf("InjectMACCommand(DevStatusReq) error = %v", err)
+	}
+	if limit := d.GetPayloadLimit(); limit.MaxPayload != 48 {
+		t.Fatalf("MaxPayload with a pending DevStatusAns = %d, want 48", limit.MaxPayload)
+	}
+
+	payload := bytes.Repeat([]byte{0xAB}, 60)
+	d.Info.Status.Payload = &lorawan.DataPayload{Bytes: payload}
+
+	uplinks, err := n.Cycle(d, nil)
+	if err != nil {
+		t.Fatalf("Cycle() error = %v", err)
+	}
+	if len(uplinks) != 1 {
+		t.Fatalf("cycle sent %d uplinks, want 1", len(uplinks))
+	}
+
+	phy, err := testutil.Decode(uplinks[0])
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if err := phy.DecryptFRMPayload(d.Info.AppSKey); err != nil {
+		t.Fatalf("DecryptFRMPayload() error = %v", err)
+	}
+
+	frm := phy.MACPayload.(*lorawan.MACPayload).FRMPayload
+	if len(frm) != 1 {
+		t.Fatalf("FRMPayload has %d parts, want 1", len(frm))
+	}
+	got := frm[0].(*lorawan.DataPayload).Bytes
+	if !bytes.Equal(got, payload[:48]) {
+		t.Errorf("FRMPayload = %d bytes, want the first 48 bytes of the payload", len(got))
+	}
+}
+
+func TestUplinkPayloadFragmented(t *testing.T) {
+	util.SetSeed(1)
+
+	n := testutil.NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, lorawan.DevAddr{1, 2, 3, 4},
+		[16]byte{1}, [16]byte{2})
+	d.Info.Configuration.SupportedFragment = true
+
+	// At EU868 DR0, each fragment carries 49 bytes after its 2 header bytes
+	payload := make([]byte, 120)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	d.Info.Status.Payload = &lorawan.DataPayload{Bytes: payload}
+
+	uplinks, err := n.Cycle(d, nil)
+	if err != nil {
+		t.Fatalf("Cycle() error = %v", err)
+	}
+	if len(uplinks) != 3 {
+		t.Fatalf("cycle sent %d uplinks, want 3 fragments", len(uplinks))
+	}
+
+	var reassembled []byte
+	var fCnt uint32
+	for i, rxpk := range uplinks {
+		phy, err := testutil.Decode(rxpk)
+		if err != nil {
+			t.Fatalf("Decode(fragment %d) error = %v", i, err)
+		}
+		if err := phy.DecryptFRMPayload(d.Info.AppSKey); err != nil {
+			t.Fatalf("DecryptFRMPayload(fragment %d) error = %v", i, err)
+		}
+
+		macPayload := phy.MACPayload.(*lorawan.MACPayload)
+		if i > 0 && macPayload.FHDR.FCnt != fCnt+1 {
+			t.Errorf("fragment %d has FCnt %d, want %d", i, macPayload.FHDR.FCnt, fCnt+1)
+		}
+		fCnt = macPayload.FHDR.FCnt
+
+		got := macPayload.FRMPayload[0].(*lorawan.DataPayload).Bytes
+		if len(got) > 51 {
+			t.Errorf("fragment %d is %d bytes, want at most 51", i, len(got))
+		}
+		if got[0] != byte(i) || got[1] != 3 {
+			t.Errorf("fragment %d header = % x, want %02x 03", i, got[:2], i)
+		}
+		reassembled = append(reassembled, got[2:]...)
+	}
+
+	if !bytes.Equal(reassembled, payload) {
+		t.Errorf("reassembled fragments = % x, want % x", reassembled, payload)
+	}
+
+	// A payload that fits is sent whole, without header
+	d.Info.Status.Payload = &lorawan.DataPayload{Bytes: payload[:51]}
+
+	uplinks, err = n.Cycle(d, nil)
+	if err != nil {
+		t.Fatalf("Cycle() error = %v", err)
+	}
+	if len(uplinks) != 1 {
+		t.Fatalf("cycle sent %d uplinks for a payload that fits, want 1", len(uplinks))
+	}
+	phy, err := testutil.Decode(uplinks[0])
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if err := phy.DecryptFRMPayload(d.Info.AppSKey); err != nil {
+		t.Fatalf("DecryptFRMPayload() error = %v", err)
+	}
+	if got := phy.MACPayload.(*lorawan.MACPayload).FRMPayload[0].(*lorawan.DataPayload).Bytes; !bytes.Equal(got, payload[:51]) {
+		t.Errorf("FRMPayload = % x, want the payload unchanged", got)
+	}
+}
@@ -1,11 +1,15 @@
 package forwarder
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/shared"
 	dl "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/frames/downlink"
 	m "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/metrics"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/buffer"
 	pkt "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/packets"
 	"github.com/brocaar/lorawan"
@@ -93,6 +97,7 @@ func (f *Forwarder) DeleteGateway(g m.InfoGateway) {
 	shared.DebugPrint(fmt.Sprintf("Delete gateway %v from Forwarder", g.MACAddress))
 	delete(f.gateways, g.MACAddress)
 	f.gwMu.Unlock()
+	metrics.GatewayUplinkBufferDepth.DeleteLabelValues(g.MACAddress.String())
 
 	// Remove gateway links from all shards
 	for _, s := range f.shards {
@@ -163,13 +168,54 @@ func (f *Forwarder) Uplink(data pkt.RXPK, DevEUI lorawan.EUI64) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	for _, up := range s.devToGw[DevEUI] {
-		up.Push(rxpk)
+	f.gwMu.RLock()
+	defer f.gwMu.RUnlock()
+
+	for mac, up := range s.devToGw[DevEUI] {
+		g := f.gateways[mac]
+
+		gwRxpk := rxpk
+		gwRxpk.Tmst += uint32(g.ClockOffset)
+
+		delay := g.Delay()
+		if delay <= 0 {
+			pushUplink(mac, up, gwRxpk)
+			continue
+		}
+
+		mac, up := mac, up
+		time.AfterFunc(delay, func() {
+			pushUplink(mac, up, gwRxpk)
+		})
 	}
 }
 
+// pushUplink hands an uplink to the buffer of a gateway
+func pushUplink(mac lorawan.EUI64, up *buffer.BufferUplink, rxpk pkt.RXPK) {
+	up.Push(rxpk)
+	metrics.GatewayUplinkBufferDepth.WithLabelValues(mac.String()).Set(float64(up.Len()))
+}
+
 func (f *Forwarder) Downlink(data *lorawan.PHYPayload, freq uint32,
 	macAddress lorawan.EUI64, tmst *uint32, rawData []byte) bool {
+	return f.DownlinkUnlessStopped(nil, data, freq, macAddress, tmst, rawData)
+}
+
+// DownlinkUnlessStopped is Downlink, dropping the downlink if stop is closed while it
+// waits for the network server delay
+func (f *Forwarder) DownlinkUnlessStopped(stop <-chan struct{}, data *lorawan.PHYPayload, freq uint32,
+	macAddress lorawan.EUI64, tmst *uint32, rawData []byte) bool {
+
+	// Network server latency: a downlink arriving after the RX window closed is dropped
+	if delay := f.DownlinkDelay(); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-stop:
+			return false
+		}
+	}
 
 	// DevAddr-based matching for data frames
 	if macPL, ok := data.MACPayload.(*lorawan.MACPayload); ok {
@@ -188,8 +234,8 @@ func (f *Forwarder) Downlink(data *lorawan.PHYPayload, freq uint32,
 					buf := make([]byte, len(rawData))
 					copy(buf, rawData)
 					clone := &lorawan.PHYPayload{}
-					if err := clone.UnmarshalBinary(buf); err == nil {
-						recvDl.Push(clone)
+					if err := clone.UnmarshalBinary(buf); err == nil && !recvDl.Push(clone) {
+						metrics.DownlinksMissedTotal.Inc()
 					}
 				}
 			}
@@ -203,8 +249,13 @@ func (f *Forwarder) Downlink(data *lorawan.PHYPayload, freq uint32,
 	// RX1 = 5 s, RX2 = 6 s (LoRaWAN §6.2.6, non-configurable).
 	// Data frames are resolved by DevAddr above or fall through to broadcast below.
 	if data.MHDR.MType == lorawan.JoinAccept && tmst != nil {
+		// The network server scheduled it on the clock of the gateway
+		f.gwMu.RLock()
+		offset := uint32(f.gateways[macAddress].ClockOffset)
+		f.gwMu.RUnlock()
+
 		for _, delay := range [...]uint32{5_000_000, 6_000_000} {
-			uplinkTmst := *tmst - delay
+			uplinkTmst := *tmst - delay - offset
 
 			f.tmstMapMu.RLock()
 			targetEUI, found := f.tmstMap[uplinkTmst]
@@ -223,7 +274,9 @@ func (f *Forwarder) Downlink(data *lorawan.PHYPayload, freq uint32,
 						copy(buf, rawData)
 						clone := &lorawan.PHYPayload{}
 						if err := clone.UnmarshalBinary(buf); err == nil {
-							d.Push(clone)
+							if !d.Push(clone) {
+								metrics.DownlinksMissedTotal.Inc()
+							}
 							s.mu.RUnlock()
 							return true
 						}
@@ -279,3 +332,24 @@ func (f *Forwarder) Reset() {
 	clear(f.tmstMap)
 	f.tmstMapMu.Unlock()
 }
+
+// Topology returns a snapshot of the routing table: for every registered device,
+// the MAC addresses of the gateways currently in range (empty if none)
+func (f *Forwarder) Topology() map[lorawan.EUI64][]lorawan.EUI64 {
+	topology := make(map[lorawan.EUI64][]lorawan.EUI64)
+	for _, s := range f.shards {
+		s.mu.RLock()
+		for devEUI := range s.devices {
+			gateways := make([]lorawan.EUI64, 0, len(s.devToGw[devEUI]))
+			for mac := range s.devToGw[devEUI] {
+				gateways = append(gateways, mac)
+			}
+			sort.Slice(gateways, func(i, j int) bool {
+				return bytes.Compare(gateways[i][:], gateways[j][:]) < 0
+			})
+			topology[devEUI] = gateways
+		}
+		s.mu.RUnlock()
+	}
+	return topology
+}
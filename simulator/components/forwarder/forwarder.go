@@ -29,6 +29,10 @@ type Forwarder struct {
 	// tmstMap maps uplink tmst -> DevEUI for JoinAccept routing.
 	tmstMap   map[uint32]lorawan.EUI64
 	tmstMapMu sync.RWMutex
+
+	// downlinkDelay models the network server latency, in nanoseconds: downlinks are
+	// delivered to the devices that long after the gateway received them.
+	downlinkDelay atomic.Int64
 }
 
 // GPSOffset compensates for the drift between UTC and GPS time
@@ -59,11 +63,23 @@ func createPacket(info pkt.RXPK) pkt.RXPK {
 }
 
 func inRange(d m.InfoDevice, g m.InfoGateway) bool {
-	distance := loc.GetDistance(d.Location.Latitude, d.Location.Longitude,
-		g.Location.Latitude, g.Location.Longitude)
-	return distance <= (d.Range / 1000.0)
+	return loc.InRange(d.Location, g.Location, d.Range)
 }
 
 func (f *Forwarder) getShard(eui lorawan.EUI64) *RoutingShard {
 	return f.shards[shardIndex(eui, f.numShards)]
 }
+
+// SetDownlinkDelay sets the latency added to every downlink before it reaches the devices.
+// A downlink still arriving after the receive window of the device closed is dropped.
+func (f *Forwarder) SetDownlinkDelay(delay time.Duration) {
+	if delay < 0 {
+		delay = 0
+	}
+	f.downlinkDelay.Store(int64(delay))
+}
+
+// DownlinkDelay returns the latency added to every downlink (0 = none)
+func (f *Forwarder) DownlinkDelay() time.Duration {
+	return time.Duration(f.downlinkDelay.Load())
+}
@@ -1,9 +1,19 @@
 package forwarder
 
 import (
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	dl "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/frames/downlink"
+	m "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/metrics"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/buffer"
+	pkt "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/packets"
+	loc "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/location"
 	"github.com/brocaar/lorawan"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestShardDistribution(t *testing.T) {
@@ -41,3 +51,132 @@ func TestSetupCreatesShards(t *testing.T) {
 		}
 	}
 }
+
+func TestTopologyListsInRangeGateways(t *testing.T) {
+	f := Setup()
+	near := lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}
+	far := lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 2}
+	f.AddGateway(m.InfoGateway{MACAddress: near, Location: loc.Location{Latitude: 45.0, Longitude: 7.0}})
+	f.AddGateway(m.InfoGateway{MACAddress: far, Location: loc.Location{Latitude: 46.0, Longitude: 7.0}})
+
+	covered := lorawan.EUI64{1, 0, 0, 0, 0, 0, 0, 1}
+	silent := lorawan.EUI64{1, 0, 0, 0, 0, 0, 0, 2}
+	f.AddDevice(m.InfoDevice{DevEUI: covered, Location: loc.Location{Latitude: 45.001, Longitude: 7.0}, Range: 1000})
+	f.AddDevice(m.InfoDevice{DevEUI: silent, Location: loc.Location{Latitude: 40.0, Longitude: 7.0}, Range: 1000})
+
+	topology := f.Topology()
+	if len(topology) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(topology))
+	}
+	if got := topology[covered]; len(got) != 1 || got[0] != near {
+		t.Errorf("covered device: got %v, want [%v]", got, near)
+	}
+	if got, ok := topology[silent]; !ok || len(got) != 0 {
+		t.Errorf("silent device: got %v (present=%v), want empty", got, ok)
+	}
+}
+
+func TestUplinkLatencyAndClockOffset(t *testing.T) {
+	f := Setup()
+	gwEUI := lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}
+	up := buffer.NewBufferUplink(10)
+	f.AddGateway(m.InfoGateway{
+		MACAddress:  gwEUI,
+		Buffer:      up,
+		Location:    loc.Location{Latitude: 45.0, Longitude: 7.0},
+		Latency:     50 * time.Millisecond,
+		ClockOffset: 1000,
+	})
+
+	devEUI := lorawan.EUI64{1, 0, 0, 0, 0, 0, 0, 1}
+	f.AddDevice(m.InfoDevice{DevEUI: devEUI, Location: loc.Location{Latitude: 45.0, Longitude: 7.0}, Range: 1000})
+
+	before := atomic.LoadUint32(&tmstCounter)
+	f.Uplink(pkt.RXPK{}, devEUI)
+
+	if up.Len() != 0 {
+		t.Fatal("uplink reached the gateway before its latency")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if up.Len() != 1 {
+		t.Fatalf("gateway buffer has %d uplinks after the latency, want 1", up.Len())
+	}
+
+	rxpk, _ := up.Pop()
+	if want := before + 1 + 1000; rxpk.Tmst != want {
+		t.Errorf("tmst = %d, want %d (forwarder clock + offset)", rxpk.Tmst, want)
+	}
+
+	// A join-accept scheduled on the drifted clock reaches the device in RX1
+	var rDownlink dl.ReceivedDownlink
+	rDownlink.Notify = sync.NewCond(&rDownlink.Mutex)
+	f.Register(868100000, devEUI, &rDownlink)
+
+	phy := &lorawan.PHYPayload{
+		MHDR:       lorawan.MHDR{MType: lorawan.JoinAccept, Major: lorawan.LoRaWANR1},
+		MACPayload: &lorawan.DataPayload{Bytes: make([]byte, 16)},
+	}
+	raw, err := phy.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmst := rxpk.Tmst + 5_000_000
+	if !f.Downlink(phy, 868100000, gwEUI, &tmst, raw) {
+		t.Fatal("join-accept on the gateway clock was not delivered")
+	}
+	if _, ok := f.tmstMap[before+1]; ok {
+		t.Error("join-accept was broadcast instead of routed by tmst")
+	}
+}
+
+func TestDownlinkDelayMissesClosedWindow(t *testing.T) {
+	f := Setup()
+	f.SetDownlinkDelay(50 * time.Millisecond)
+	gwEUI := lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}
+	f.AddGateway(m.InfoGateway{MACAddress: gwEUI, Buffer: buffer.NewBufferUplink(10), Location: loc.Location{Latitude: 45.0, Longitude: 7.0}})
+
+	devEUI := lorawan.EUI64{1, 0, 0, 0, 0, 0, 0, 1}
+	devAddr := lorawan.DevAddr{1, 2, 3, 4}
+	f.AddDevice(m.InfoDevice{DevEUI: devEUI, DevAddr: devAddr, Location: loc.Location{Latitude: 45.0, Longitude: 7.0}, Range: 1000})
+
+	var rDownlink dl.ReceivedDownlink
+	rDownlink.Notify = sync.NewCond(&rDownlink.Mutex)
+	f.Register(868100000, devEUI, &rDownlink)
+
+	phy := &lorawan.PHYPayload{
+		MHDR:       lorawan.MHDR{MType: lorawan.UnconfirmedDataDown, Major: lorawan.LoRaWANR1},
+		MACPayload: &lorawan.MACPayload{FHDR: lorawan.FHDR{DevAddr: devAddr}},
+	}
+	raw, err := phy.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The window stays open longer than the delay: the downlink arrives late but in time
+	rDownlink.Open()
+	start := time.Now()
+	f.Downlink(phy, 868100000, gwEUI, nil, raw)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("downlink delivered after %v, want at least the 50ms delay", elapsed)
+	}
+	if rDownlink.Downlink == nil {
+		t.Fatal("downlink not delivered to the open window")
+	}
+	rDownlink.Downlink = nil
+
+	// The window closes before the delay elapses: the downlink is dropped
+	missed := promtest.ToFloat64(metrics.DownlinksMissedTotal)
+	time.AfterFunc(10*time.Millisecond, rDownlink.Close)
+	f.Downlink(phy, 868100000, gwEUI, nil, raw)
+	if rDownlink.Downlink != nil {
+		t.Error("downlink delivered after the window closed")
+	}
+	if got := promtest.ToFloat64(metrics.DownlinksMissedTotal); got != missed+1 {
+		t.Errorf("missed downlinks = %v, want %v", got, missed+1)
+	}
+
+	f.SetDownlinkDelay(-time.Second)
+	if f.DownlinkDelay() != 0 {
+		t.Errorf("DownlinkDelay() = %v after a negative delay, want 0", f.DownlinkDelay())
+	}
+}
@@ -1,8 +1,11 @@
 package models
 
 import (
+	"time"
+
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/buffer"
 	loc "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/location"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/brocaar/lorawan"
 )
 
@@ -19,4 +22,16 @@ type InfoGateway struct {
 	MACAddress lorawan.EUI64        // Gateway MAC address
 	Buffer     *buffer.BufferUplink // Gateway buffer
 	Location   loc.Location         // Gateway location
+
+	Latency       time.Duration // Delay of the uplinks before they reach the buffer
+	LatencyJitter time.Duration // Random extra delay, up to this
+	ClockOffset   int32         // Drift of the gateway clock, added to the tmst of the uplinks (microseconds)
+}
+
+// Delay returns the latency of an uplink through the gateway, jitter included
+func (g InfoGateway) Delay() time.Duration {
+	if g.LatencyJitter <= 0 {
+		return g.Latency
+	}
+	return g.Latency + time.Duration(util.RandIntn(int(g.LatencyJitter)+1))
 }
@@ -1,18 +1,26 @@
 package gateway
 
 import (
+	"errors"
+	"sync"
+
 	f "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder"
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/console"
 	res "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/buffer"
-	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/udp"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 )
 
 func (g *Gateway) Setup(BridgeAddress *string,
 	Resources *res.Resources, Forwarder *f.Forwarder) {
 
+	if g.stateMu == nil { //kept across off/on cycles: goroutines of the last run may still hold it
+		g.stateMu = &sync.Mutex{}
+		g.statMu = &sync.Mutex{}
+	}
+	g.stateMu.Lock()
 	g.State = util.Stopped
+	g.stateMu.Unlock()
 
 	g.Info.BridgeAddress = BridgeAddress
 
@@ -20,6 +28,7 @@ func (g *Gateway) Setup(BridgeAddress *string,
 	g.Forwarder = Forwarder
 
 	g.BufferUplink = buffer.NewBufferUplink(0)
+	g.connMu = &sync.Mutex{}
 
 	g.Print("Setup OK!", nil, util.PrintOnlyConsole)
 
@@ -31,47 +40,83 @@ func (g *Gateway) SetConsole(console *c.Console) {
 
 func (g *Gateway) TurnON() {
 
-	var err error
-
+	g.stateMu.Lock()
 	g.State = util.Running
+	stop := make(chan struct{})
+	g.stop = stop
+	g.stateMu.Unlock()
 
 	//udp
-	if g.Info.TypeGateway { //real
-		g.Info.Connection, err = udp.ConnectTo(g.Info.AddrIP + ":" + g.Info.Port)
-	} else { //virtual
-		g.Info.Connection, err = udp.ConnectTo(*g.Info.BridgeAddress)
-	}
-
-	if err != nil {
+	if err := g.connect(); err != nil {
 		g.Print("", err, util.PrintOnlyConsole)
 	} else {
-		g.Print("UDP connection with "+g.Info.Connection.RemoteAddr().String(), nil, util.PrintOnlyConsole)
+		g.Print("UDP connection with "+g.connection().RemoteAddr().String(), nil, util.PrintOnlyConsole)
 	}
 
-	go g.Receiver()
+	var reports sync.WaitGroup // keep-alive and stat report, waited for by the receiver
 
 	if g.Info.TypeGateway { //real
 		go g.SenderReal()
 	} else { //virtual
 		go g.SenderVirtual()
+
+		reports.Add(1)
+		go func() {
+			defer reports.Done()
+			g.KeepAlive(stop)
+		}()
+
+		if g.Info.StatInterval > 0 {
+			reports.Add(1)
+			go func() {
+				defer reports.Done()
+				g.StatReport(stop)
+			}()
+		}
 	}
 
+	go g.Receiver(stop, &reports)
+
 	g.Print("Turn ON", nil, util.PrintBoth)
 }
 
 func (g *Gateway) TurnOFF() {
 
+	g.stateMu.Lock()
+	if g.State != util.Stopped && g.stop != nil {
+		close(g.stop) //signal to delayed downlinks, keep-alive and stat report
+	}
 	g.State = util.Stopped
+	g.stateMu.Unlock()
 
 	g.BufferUplink.Signal() //signal to sender
-	if g.Info.Connection != nil {
-		g.Info.Connection.Close() //signal to receiver
+	g.disconnect()          //signal to receiver
+
+}
+
+// Reconnect drops the current UDP connection so that the receiver re-establishes it
+// immediately, e.g. after the bridge restarted
+func (g *Gateway) Reconnect() error {
+
+	if !g.IsOn() {
+		return errors.New("Gateway is not running")
 	}
 
+	g.disconnect()
+	g.Print("Reconnecting", nil, util.PrintBoth)
+
+	return nil
 }
 
 func (g *Gateway) IsOn() bool {
 
+	if g.stateMu == nil { //never set up, so never turned on
+		return false
+	}
+
+	g.stateMu.Lock()
+	defer g.stateMu.Unlock()
+
 	if g.State == util.Running {
 		return true
 	}
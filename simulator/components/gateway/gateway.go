@@ -1,14 +1,20 @@
 package gateway
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	f "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/gateway/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/webhook"
 	c "github.com/R3DPanda1/LWN-Sim-Plus/simulator/console"
 	res "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/buffer"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/udp"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
 )
@@ -17,19 +23,133 @@ type Gateway struct {
 	Id   int                `json:"id"`
 	Info models.InfoGateway `json:"info"`
 
-	State int `json:"-"`
+	State   int         `json:"-"`
+	stateMu *sync.Mutex // guards State, read by the goroutines of the gateway while it is turned on and off
 
 	Resources *res.Resources `json:"-"` //is a pointer
 	Forwarder *f.Forwarder   `json:"-"` //is a pointer
 
-	Stat models.Stat `json:"-"`
+	Stat   models.Stat `json:"-"`
+	statMu *sync.Mutex // guards Stat, updated by the receiver, the sender and the delayed downlinks
+
+	lastSeenAt atomic.Pointer[time.Time] // when a datagram was last exchanged with the bridge (nil = never)
 
 	BufferUplink *buffer.BufferUplink `json:"-"`
 	Console      c.Console           `json:"-"`
+
+	bufferSaturated bool        // a saturation warning was printed and the buffer hasn't drained yet
+	disconnected    bool        // a disconnection was notified and nothing has been received since
+	connMu          *sync.Mutex // guards Info.Connection, which the reconnect request replaces while running
+
+	stop chan struct{} // closed by TurnOFF to stop the goroutines of the current run
+}
+
+// notifyDisconnected sends a gateway-disconnected webhook once per outage
+func (g *Gateway) notifyDisconnected(message string) {
+	if g.disconnected {
+		return
+	}
+	g.disconnected = true
+	g.Console.Notify(webhook.Event{
+		Type:    webhook.EventGatewayDisconnected,
+		Source:  webhook.SourceGateway,
+		ID:      g.Id,
+		EUI:     g.Info.MACAddress.String(),
+		Name:    g.Info.Name,
+		Message: message,
+	})
+}
+
+// seen records that a datagram was just exchanged with the bridge
+func (g *Gateway) seen() {
+	now := time.Now()
+	g.lastSeenAt.Store(&now)
+}
+
+// LastSeenAt returns when a datagram was last exchanged with the bridge (nil = never)
+func (g *Gateway) LastSeenAt() *time.Time {
+	return g.lastSeenAt.Load()
+}
+
+func (g *Gateway) MarshalJSON() ([]byte, error) {
+
+	type Alias Gateway
+
+	return json.Marshal(&struct {
+		LastSeenAt *time.Time `json:"lastSeenAt,omitempty"`
+		*Alias
+	}{
+		LastSeenAt: g.LastSeenAt(),
+		Alias:      (*Alias)(g),
+	})
+
+}
+
+func (g *Gateway) UnmarshalJSON(data []byte) error {
+
+	type Alias Gateway
+
+	aux := &struct {
+		LastSeenAt *time.Time `json:"lastSeenAt"`
+		*Alias
+	}{
+		Alias: (*Alias)(g),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	g.lastSeenAt.Store(aux.LastSeenAt)
+
+	return nil
+}
+
+// updateStat changes the statistics with the lock held
+func (g *Gateway) updateStat(update func(stat *models.Stat)) {
+	g.statMu.Lock()
+	defer g.statMu.Unlock()
+	update(&g.Stat)
+}
+
+// connection returns the current UDP connection (nil while disconnected)
+func (g *Gateway) connection() *net.UDPConn {
+	g.connMu.Lock()
+	defer g.connMu.Unlock()
+	return g.Info.Connection
+}
+
+// connect opens a new UDP connection to the bridge (virtual) or to the real gateway
+func (g *Gateway) connect() error {
+	address := *g.Info.BridgeAddress
+	if g.Info.TypeGateway { //real
+		address = g.Info.AddrIP + ":" + g.Info.Port
+	}
+	conn, err := udp.ConnectTo(address)
+	if err != nil {
+		return err
+	}
+	g.connMu.Lock()
+	g.Info.Connection = conn
+	g.connMu.Unlock()
+	return nil
+}
+
+// disconnect closes the current UDP connection, which unblocks the receiver
+func (g *Gateway) disconnect() {
+	g.connMu.Lock()
+	defer g.connMu.Unlock()
+	if g.Info.Connection != nil {
+		g.Info.Connection.Close()
+		g.Info.Connection = nil
+	}
 }
 
 func (g *Gateway) CanExecute() bool {
 
+	g.stateMu.Lock()
+	defer g.stateMu.Unlock()
+
 	if g.State == util.Stopped {
 		return false
 	}
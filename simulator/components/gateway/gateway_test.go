@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	f "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder"
+	res "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources"
+	pkt "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/packets"
+)
+
+func TestGatewayJSONLastSeenAt(t *testing.T) {
+	seenAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		lastSeen *time.Time
+		wantKey  bool
+	}{
+		{"never seen", nil, false},
+		{"seen", &seenAt, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Gateway{Id: 3}
+			g.lastSeenAt.Store(tt.lastSeen)
+
+			data, err := json.Marshal(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(string(data), `"lastSeenAt"`); got != tt.wantKey {
+				t.Fatalf("lastSeenAt in %s = %v, want %v", data, got, tt.wantKey)
+			}
+
+			var loaded Gateway
+			if err := json.Unmarshal(data, &loaded); err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Id != 3 {
+				t.Errorf("Id = %d after a round trip, want 3", loaded.Id)
+			}
+			got := loaded.LastSeenAt()
+			if (got == nil) != (tt.lastSeen == nil) || (got != nil && !got.Equal(*tt.lastSeen)) {
+				t.Errorf("LastSeenAt() = %v after a round trip, want %v", got, tt.lastSeen)
+			}
+		})
+	}
+}
+
+func TestLastSeenAtWhileRunning(t *testing.T) {
+	b := newFakeBridge(t)
+	resources := &res.Resources{}
+	g := newTestGateway(b, resources, f.Setup())
+
+	g.TurnON()
+	_, addr := b.next(t, pkt.TypePullData, time.Second)
+
+	// The receiver records the datagrams of the bridge while the gateway is marshalled
+	pullAck := []byte{pkt.PVersion, 0, 0, pkt.TypePullAck}
+	deadline := time.Now().Add(time.Second)
+	for g.LastSeenAt() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("LastSeenAt() still nil after the bridge answered")
+		}
+		if _, err := b.conn.WriteToUDP(pullAck, addr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := json.Marshal(g); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	resources.ExitGroup.Add(1)
+	g.TurnOFF()
+	resources.ExitGroup.Wait()
+}
@@ -17,10 +17,17 @@ type InfoGateway struct {
 	MACAddress    lorawan.EUI64 `json:"macAddress"`
 	Location      loc.Location  `json:"location"`
 	KeepAlive     time.Duration `json:"keepAlive"`
+	StatInterval  time.Duration `json:"statInterval"` // period of the stat-only PUSH_DATA (0 = stats only with the uplinks)
 	Connection    *net.UDPConn  `json:"-"`
 	AddrIP        string        `json:"ip"`
 	Port          string        `json:"port"`
-	BridgeAddress *string       `json:"-"` //is a pointer
+	BridgeAddress *string       `json:"-"`      //is a pointer
+	Bridge        string        `json:"bridge"` // Bridge of this virtual gateway (host:port), empty to use the simulator's one
+
+	// Imperfections of a real gateway, applied to the uplinks it receives
+	Latency       time.Duration `json:"latency"`       // delay before an uplink is forwarded (ms)
+	LatencyJitter time.Duration `json:"latencyJitter"` // random extra delay, up to this (ms)
+	ClockOffset   int32         `json:"clockOffset"`   // drift of the concentrator clock, added to tmst (µs)
 
 	IntegrationEnabled bool `json:"integrationEnabled"`
 	IntegrationID      int  `json:"integrationId"`
@@ -31,13 +38,19 @@ func (g *InfoGateway) MarshalJSON() ([]byte, error) {
 	type Alias InfoGateway
 
 	return json.Marshal(&struct {
-		MACAddress string `json:"macAddress"`
-		KeepAlive  int    `json:"keepAlive"`
+		MACAddress    string `json:"macAddress"`
+		KeepAlive     int    `json:"keepAlive"`
+		StatInterval  int    `json:"statInterval"`
+		Latency       int64  `json:"latency"`
+		LatencyJitter int64  `json:"latencyJitter"`
 
 		*Alias
 	}{
-		MACAddress: hex.EncodeToString(g.MACAddress[:]),
-		KeepAlive:  int(g.KeepAlive / time.Second),
+		MACAddress:    hex.EncodeToString(g.MACAddress[:]),
+		KeepAlive:     int(g.KeepAlive / time.Second),
+		StatInterval:  int(g.StatInterval / time.Second),
+		Latency:       g.Latency.Milliseconds(),
+		LatencyJitter: g.LatencyJitter.Milliseconds(),
 
 		Alias: (*Alias)(g),
 	})
@@ -49,8 +62,11 @@ func (g *InfoGateway) UnmarshalJSON(data []byte) error {
 	type Alias InfoGateway
 
 	aux := &struct {
-		MACAddress string `json:"macAddress"`
-		KeepAlive  int    `json:"keepAlive"`
+		MACAddress    string `json:"macAddress"`
+		KeepAlive     int    `json:"keepAlive"`
+		StatInterval  int    `json:"statInterval"`
+		Latency       int64  `json:"latency"`
+		LatencyJitter int64  `json:"latencyJitter"`
 		*Alias
 	}{
 		Alias: (*Alias)(g),
@@ -64,6 +80,9 @@ func (g *InfoGateway) UnmarshalJSON(data []byte) error {
 	copy(g.MACAddress[:8], MACAddressTmp)
 
 	g.KeepAlive = time.Duration(aux.KeepAlive) * time.Second
+	g.StatInterval = time.Duration(aux.StatInterval) * time.Second
+	g.Latency = time.Duration(aux.Latency) * time.Millisecond
+	g.LatencyJitter = time.Duration(aux.LatencyJitter) * time.Millisecond
 
 	return nil
 }
@@ -3,7 +3,9 @@ package gateway
 import (
 	"errors"
 	"fmt"
+	"sync"
 
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/gateway/models"
 	pkt "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/packets"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/udp"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
@@ -26,11 +28,18 @@ var (
 	})
 )
 
-func (g *Gateway) Receiver() {
+// Receiver reads the datagrams of the bridge until the gateway is turned off, that is
+// until stop is closed, then waits for the downlinks it is still delaying and for the
+// reports of the run
+func (g *Gateway) Receiver(stop <-chan struct{}, reports *sync.WaitGroup) {
 
 	ReceiveBuffer := make([]byte, 1024)
 
+	var downlinks sync.WaitGroup // downlinks waiting for the network server delay
+
 	defer g.Resources.ExitGroup.Done()
+	defer reports.Wait()
+	defer downlinks.Wait()
 
 	for {
 		var n int
@@ -43,7 +52,7 @@ func (g *Gateway) Receiver() {
 
 		}
 
-		for g.Info.Connection == nil {
+		for g.connection() == nil {
 
 			if !g.CanExecute() {
 
@@ -52,19 +61,26 @@ func (g *Gateway) Receiver() {
 
 			}
 
-			g.Info.Connection, err = udp.ConnectTo(*g.Info.BridgeAddress) //stabilish new connection
+			err = g.connect() //stabilish new connection
 			if err != nil {
 
-				msg := fmt.Sprintf("Unable Connect to %v", g.Info.BridgeAddress)
+				msg := fmt.Sprintf("Unable Connect to %v", *g.Info.BridgeAddress)
 				g.Print("", errors.New(msg), util.PrintBoth)
 
 				continue
 
 			}
 
+			g.Print("UDP connection with "+g.connection().RemoteAddr().String(), nil, util.PrintOnlyConsole)
+
+		}
+
+		conn := g.connection()
+		if conn == nil {
+			continue
 		}
 
-		n, _, err = g.Info.Connection.ReadFromUDP(ReceiveBuffer)
+		n, _, err = conn.ReadFromUDP(ReceiveBuffer)
 
 		if !g.CanExecute() {
 			g.Print("Turn OFF", nil, util.PrintBoth)
@@ -73,16 +89,23 @@ func (g *Gateway) Receiver() {
 
 		if err != nil {
 
+			if g.connection() == nil { //closed on purpose to reconnect
+				continue
+			}
+
 			msg := fmt.Sprintf("No connection with %v, it may be off", *g.Info.BridgeAddress)
 			g.Print("", errors.New(msg), util.PrintBoth)
+			g.notifyDisconnected(msg)
 
 			continue
 
 		}
 
+		g.disconnected = false
+		g.seen()
 		receivedPack := ReceiveBuffer[:n]
 
-		g.Stat.DWNb++
+		g.updateStat(func(stat *models.Stat) { stat.DWNb++ })
 
 		err = pkt.ParseReceivePacket(receivedPack)
 		if err != nil {
@@ -97,7 +120,7 @@ func (g *Gateway) Receiver() {
 		switch *typepkt {
 
 		case pkt.TypePushAck:
-			g.Stat.ACKR++
+			g.updateStat(func(stat *models.Stat) { stat.ACKR++ })
 			pushAckCounter.Inc()
 
 		case pkt.TypePullAck:
@@ -106,52 +129,78 @@ func (g *Gateway) Receiver() {
 
 		case pkt.TypePullResp:
 
-			phy, freq, tmst, rawData, err := pkt.GetInfoPullResp(receivedPack)
-			if err != nil {
-				g.Print("", err, util.PrintBoth)
+			if g.Forwarder.DownlinkDelay() > 0 {
+				// Delayed by the forwarder: the next packets are not held up meanwhile.
+				// Dropped if the gateway is turned off before the delay elapses.
+				downlinks.Add(1)
+				go func(pack []byte) {
+					defer downlinks.Done()
+					g.forwardDownlink(pack, stop)
+				}(append([]byte(nil), receivedPack...))
 				continue
 			}
 
-			delivered := g.Forwarder.Downlink(phy, *freq, g.Info.MACAddress, tmst, rawData)
+			if !g.forwardDownlink(receivedPack, stop) {
+				g.Print("Turn OFF", nil, util.PrintBoth)
+				return
+			}
 
-			g.Stat.RXFW++
+		default:
+			g.Print("Packet not supported", nil, util.PrintBoth)
 
-			pullRespCounter.Inc()
+		}
 
-			// Only send TX ACK if at least one device received the downlink
-			if !delivered {
-				g.Print("No device listening, TX ACK not sent", nil, util.PrintBoth)
-				continue
-			}
+	}
 
-			//TX ACK
-			packet, err := pkt.CreatePacket(pkt.TypeTxAck, g.Info.MACAddress, pkt.Stat{}, nil, pkt.GetTokenFromPullResp(receivedPack))
-			if err != nil {
-				g.Print("", err, util.PrintBoth)
-			}
+}
 
-			_, err = udp.SendDataUDP(g.Info.Connection, packet)
+// forwardDownlink delivers the downlink of a PULL RESP to the devices and, if one of them
+// received it, acknowledges it with a TX ACK. It returns false if the gateway was turned off.
+func (g *Gateway) forwardDownlink(receivedPack []byte, stop <-chan struct{}) bool {
 
-			if !g.CanExecute() {
-				g.Print("Turn OFF", nil, util.PrintBoth)
-				return
-			}
+	phy, freq, tmst, rawData, err := pkt.GetInfoPullResp(receivedPack)
+	if err != nil {
+		g.Print("", err, util.PrintBoth)
+		return true
+	}
 
-			if err != nil {
-				msg := fmt.Sprintf("No connection with %v, it may be off", *g.Info.BridgeAddress)
-				g.Print("", errors.New(msg), util.PrintBoth)
-			} else {
+	delivered := g.Forwarder.DownlinkUnlessStopped(stop, phy, *freq, g.Info.MACAddress, tmst, rawData)
+	if !g.CanExecute() {
+		return false
+	}
 
-				g.Stat.TXNb++
-				g.Print("TX ACK sent", nil, util.PrintBoth)
+	g.updateStat(func(stat *models.Stat) { stat.RXFW++ })
 
-			}
+	pullRespCounter.Inc()
 
-		default:
-			g.Print("Packet not supported", nil, util.PrintBoth)
+	// Only send TX ACK if at least one device received the downlink
+	if !delivered {
+		g.Print("No device listening, TX ACK not sent", nil, util.PrintBoth)
+		return true
+	}
 
-		}
+	//TX ACK
+	packet, err := pkt.CreatePacket(pkt.TypeTxAck, g.Info.MACAddress, pkt.Stat{}, nil, pkt.GetTokenFromPullResp(receivedPack))
+	if err != nil {
+		g.Print("", err, util.PrintBoth)
+	}
+
+	_, err = udp.SendDataUDP(g.connection(), packet)
+
+	if !g.CanExecute() {
+		return false
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("No connection with %v, it may be off", *g.Info.BridgeAddress)
+		g.Print("", errors.New(msg), util.PrintBoth)
+	} else {
+
+		g.updateStat(func(stat *models.Stat) { stat.TXNb++ })
+		g.seen()
+		g.Print("TX ACK sent", nil, util.PrintBoth)
 
 	}
 
+	return true
 }
@@ -0,0 +1,129 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	f "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder"
+	res "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources"
+	pkt "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/packets"
+	"github.com/brocaar/lorawan"
+)
+
+// fakeBridge is a UDP bridge recording the datagrams of a gateway
+type fakeBridge struct {
+	conn    *net.UDPConn
+	gateway chan *net.UDPAddr // address of the gateway, once per datagram
+	packets chan []byte
+}
+
+func newFakeBridge(t *testing.T) *fakeBridge {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	b := &fakeBridge{conn: conn, gateway: make(chan *net.UDPAddr, 64), packets: make(chan []byte, 64)}
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			select {
+			case b.packets <- append([]byte(nil), buf[:n]...):
+				b.gateway <- addr
+			default: // not read by the test
+			}
+		}
+	}()
+	return b
+}
+
+// newTestGateway returns a virtual gateway connected to the bridge, not turned on
+func newTestGateway(b *fakeBridge, resources *res.Resources, forwarder *f.Forwarder) *Gateway {
+	address := b.conn.LocalAddr().String()
+	g := &Gateway{Id: 1}
+	g.Info.Name = "gw"
+	g.Info.MACAddress = lorawan.EUI64{1, 1, 1, 1, 1, 1, 1, 1}
+	g.Info.KeepAlive = time.Hour
+	g.Setup(&address, resources, forwarder)
+	return g
+}
+
+// next returns the next datagram of a given type sent to the bridge, and who sent it
+func (b *fakeBridge) next(t *testing.T, typ byte, timeout time.Duration) ([]byte, *net.UDPAddr) {
+	t.Helper()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case p := <-b.packets:
+			addr := <-b.gateway
+			if len(p) > 3 && p[3] == typ {
+				return p, addr
+			}
+		case <-deadline:
+			t.Fatalf("no datagram of type %d within %v", typ, timeout)
+			return nil, nil
+		}
+	}
+}
+
+func TestTurnOFFDropsDelayedDownlinks(t *testing.T) {
+	b := newFakeBridge(t)
+	forwarder := f.Setup()
+	forwarder.SetDownlinkDelay(time.Hour)
+	resources := &res.Resources{}
+	g := newTestGateway(b, resources, forwarder)
+
+	g.TurnON()
+	_, addr := b.next(t, pkt.TypePullData, time.Second)
+
+	phy := lorawan.PHYPayload{
+		MHDR:       lorawan.MHDR{MType: lorawan.UnconfirmedDataDown, Major: lorawan.LoRaWANR1},
+		MACPayload: &lorawan.MACPayload{FHDR: lorawan.FHDR{DevAddr: lorawan.DevAddr{1, 2, 3, 4}}},
+	}
+	data, err := phy.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(pkt.PullRespPayload{TXPK: pkt.TXPK{Freq: 869.525, Data: data}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pullResp := append([]byte{pkt.PVersion, 0, 0, pkt.TypePullResp}, payload...)
+	if _, err := b.conn.WriteToUDP(pullResp, addr); err != nil {
+		t.Fatal(err)
+	}
+
+	// The receiver goes on while the downlink waits for the network server delay
+	deadline := time.Now().Add(time.Second)
+	for g.stat().DWNb == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("PULL RESP not received")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Turning the gateway off drops the downlink instead of waiting for the delay
+	stopped := make(chan struct{})
+	resources.ExitGroup.Add(1)
+	go func() {
+		g.TurnOFF()
+		resources.ExitGroup.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the receiver kept waiting for the delayed downlink")
+	}
+	if stat := g.stat(); stat.RXFW != 0 {
+		t.Errorf("RXFW = %d, want the delayed downlink dropped", stat.RXFW)
+	}
+}
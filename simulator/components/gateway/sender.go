@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/gateway/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/metrics"
 	pkt "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/packets"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/udp"
 	"github.com/prometheus/client_golang/prometheus"
@@ -24,34 +26,55 @@ var (
 	})
 )
 
+// UplinkBufferWarnRatio is the buffer fill ratio above which a saturation warning is printed
+const UplinkBufferWarnRatio = 0.8
+
+// checkBufferDepth publishes the uplink buffer depth and warns once each time it crosses
+// the saturation threshold (re-armed when the buffer drains below half of it)
+func (g *Gateway) checkBufferDepth() {
+	depth := g.BufferUplink.Len()
+	metrics.GatewayUplinkBufferDepth.WithLabelValues(g.Info.MACAddress.String()).Set(float64(depth))
+
+	threshold := int(float64(g.BufferUplink.Cap()) * UplinkBufferWarnRatio)
+	if depth >= threshold && !g.bufferSaturated {
+		g.bufferSaturated = true
+		msg := fmt.Sprintf("Uplink buffer saturated: %d/%d packets waiting, the bridge may be stalled", depth, g.BufferUplink.Cap())
+		g.Print("", errors.New(msg), util.PrintBoth)
+	} else if depth < threshold/2 {
+		g.bufferSaturated = false
+	}
+}
+
 func (g *Gateway) SenderVirtual() {
 
 	defer g.Print("Sender Turn OFF", nil, util.PrintOnlyConsole)
 
-	go g.KeepAlive()
-
 	for {
 
 		rxpk, ok := g.BufferUplink.Pop() //wait uplink
 		if !ok || !g.CanExecute() {
 			return
 		}
+		g.checkBufferDepth()
 
-		g.Stat.RXNb++
-		g.Stat.RXOK++
+		g.updateStat(func(stat *models.Stat) {
+			stat.RXNb++
+			stat.RXOK++
+		})
 
 		packet, err := g.createPacket(rxpk)
 		if err != nil {
 			g.Print("", err, util.PrintBoth)
 		}
 
-		_, err = udp.SendDataUDP(g.Info.Connection, packet)
+		_, err = udp.SendDataUDP(g.connection(), packet)
 		if err != nil {
 
 			msg := fmt.Sprintf("Unable to send data to %v, it may be off", *g.Info.BridgeAddress)
 			g.Print("", errors.New(msg), util.PrintBoth)
 
 		} else {
+			g.seen()
 			g.Print("PUSH DATA send", nil, util.PrintBoth)
 			pushDataCounter.Inc()
 		}
@@ -70,22 +93,26 @@ func (g *Gateway) SenderReal() {
 		if !ok || !g.CanExecute() {
 			return
 		}
+		g.checkBufferDepth()
 
-		g.Stat.RXNb++
-		g.Stat.RXOK++
+		g.updateStat(func(stat *models.Stat) {
+			stat.RXNb++
+			stat.RXOK++
+		})
 
 		packet, err := g.createPacket(rxpk)
 		if err != nil {
 			g.Print("", err, util.PrintBoth)
 		}
 
-		_, err = udp.SendDataUDP(g.Info.Connection, packet)
+		_, err = udp.SendDataUDP(g.connection(), packet)
 		if err != nil {
 
 			msg := fmt.Sprintf("Unable to send data to %v, it may be off", *g.Info.BridgeAddress)
 			g.Print("", errors.New(msg), util.PrintBoth)
 
 		} else {
+			g.seen()
 			msg := fmt.Sprintf("Forward PUSH DATA to %v:%v", g.Info.AddrIP, g.Info.Port)
 			g.Print(msg, nil, util.PrintBoth)
 
@@ -103,14 +130,27 @@ func (g *Gateway) sendPullData() error {
 
 	pulldata, _ := pkt.CreatePacket(pkt.TypePullData, g.Info.MACAddress, pkt.Stat{}, nil, 0)
 
-	_, err := udp.SendDataUDP(g.Info.Connection, pulldata)
+	_, err := udp.SendDataUDP(g.connection(), pulldata)
 
 	return err
 }
 
 func (g *Gateway) createPacket(info pkt.RXPK) ([]byte, error) {
 
-	stat := pkt.Stat{
+	rxpks := []pkt.RXPK{
+		info,
+	}
+
+	return pkt.CreatePacket(pkt.TypePushData, g.Info.MACAddress, g.stat(), rxpks, 0)
+}
+
+// stat returns the statistics reported in the PUSH_DATA of the gateway
+func (g *Gateway) stat() pkt.Stat {
+
+	g.statMu.Lock()
+	defer g.statMu.Unlock()
+
+	return pkt.Stat{
 		Time: pkt.GetTime(),
 		Lati: g.Info.Location.Latitude,
 		Long: g.Info.Location.Longitude,
@@ -122,17 +162,51 @@ func (g *Gateway) createPacket(info pkt.RXPK) ([]byte, error) {
 		DWNb: g.Stat.DWNb,
 		TXNb: g.Stat.TXNb,
 	}
+}
 
-	rxpks := []pkt.RXPK{
-		info,
-	}
+// StatReport sends a PUSH_DATA carrying only the statistics every StatInterval, as
+// packet forwarders do, for network servers that expect them between uplinks. It
+// returns when stop is closed.
+func (g *Gateway) StatReport(stop <-chan struct{}) {
+
+	tickerStat := time.NewTicker(g.Info.StatInterval)
+	defer tickerStat.Stop()
+
+	for {
 
-	return pkt.CreatePacket(pkt.TypePushData, g.Info.MACAddress, stat, rxpks, 0)
+		select {
+		case <-tickerStat.C:
+		case <-stop:
+			return
+		}
+
+		if !g.CanExecute() {
+			return
+		}
+
+		packet, err := pkt.CreatePacket(pkt.TypePushData, g.Info.MACAddress, g.stat(), nil, 0)
+		if err != nil {
+			g.Print("", err, util.PrintBoth)
+			continue
+		}
+
+		_, err = udp.SendDataUDP(g.connection(), packet)
+		if err != nil {
+			msg := fmt.Sprintf("Unable to send stat to %v, it may be off", *g.Info.BridgeAddress)
+			g.Print("", errors.New(msg), util.PrintBoth)
+		} else {
+			g.seen()
+			g.Print("PUSH DATA stat send", nil, util.PrintBoth)
+			pushDataCounter.Inc()
+		}
+	}
 }
 
-func (g *Gateway) KeepAlive() {
+// KeepAlive sends a PULL_DATA every KeepAlive until stop is closed
+func (g *Gateway) KeepAlive(stop <-chan struct{}) {
 
 	tickerKeepAlive := time.NewTicker(g.Info.KeepAlive)
+	defer tickerKeepAlive.Stop()
 
 	for {
 		if !g.CanExecute() {
@@ -145,13 +219,18 @@ func (g *Gateway) KeepAlive() {
 			if err != nil {
 				g.Print("", err, util.PrintBoth)
 			} else {
+				g.seen()
 				g.Print("PULL DATA send", nil, util.PrintBoth)
 				pullDataCounter.Inc()
 			}
 
 		}
 
-		<-tickerKeepAlive.C
+		select {
+		case <-tickerKeepAlive.C:
+		case <-stop:
+			return
+		}
 	}
 
 }
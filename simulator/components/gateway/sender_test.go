@@ -0,0 +1,63 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	f "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder"
+	res "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources"
+	pkt "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/packets"
+)
+
+// countDatagrams counts the datagrams of a given type sent to the bridge during a period
+func (b *fakeBridge) countDatagrams(typ byte, period time.Duration) int {
+	count := 0
+	deadline := time.After(period)
+	for {
+		select {
+		case p := <-b.packets:
+			<-b.gateway
+			if len(p) > 3 && p[3] == typ {
+				count++
+			}
+		case <-deadline:
+			return count
+		}
+	}
+}
+
+func TestStatReportStopsOnTurnOFF(t *testing.T) {
+	const interval = 20 * time.Millisecond
+
+	b := newFakeBridge(t)
+	resources := &res.Resources{}
+	g := newTestGateway(b, resources, f.Setup())
+	g.Info.StatInterval = interval
+
+	turnOFF := func() {
+		// TurnOFF returns once the stat report is over
+		resources.ExitGroup.Add(1)
+		g.TurnOFF()
+		resources.ExitGroup.Wait()
+	}
+
+	tests := []struct {
+		name   string
+		toggle func()
+		want   func(reports int) bool
+	}{
+		{"on", g.TurnON, func(reports int) bool { return reports > 0 }},
+		// A stat report left over would double the rate: 10 reports expected over 10 intervals
+		{"off and on", func() { turnOFF(); g.TurnON() }, func(reports int) bool { return reports > 0 && reports <= 14 }},
+		{"off", turnOFF, func(reports int) bool { return reports == 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.toggle()
+			b.countDatagrams(pkt.TypePushData, interval) // sent before the toggle
+			if got := b.countDatagrams(pkt.TypePushData, 10*interval); !tt.want(got) {
+				t.Errorf("%d stat reports in %v", got, 10*interval)
+			}
+		})
+	}
+}
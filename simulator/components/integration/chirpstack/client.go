@@ -3,17 +3,37 @@ package chirpstack
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// Default retry policy applied to new clients
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryDelay = 500 * time.Millisecond
+)
+
 // Client is a ChirpStack v4 API client
 type Client struct {
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+	maxRetries int           // Extra attempts after the first one for retryable requests
+	retryDelay time.Duration // Base delay, doubled after every failed attempt
+}
+
+// APIError is returned when ChirpStack answers with an HTTP error status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, e.Message)
 }
 
 // NewClient creates a new ChirpStack API client
@@ -24,17 +44,58 @@ func NewClient(baseURL, apiKey string) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		maxRetries: DefaultMaxRetries,
+		retryDelay: DefaultRetryDelay,
+	}
+}
+
+// SetRetryPolicy configures how many times retryable requests are repeated and the base backoff delay
+func (c *Client) SetRetryPolicy(maxRetries int, baseDelay time.Duration) {
+	if maxRetries < 0 {
+		maxRetries = 0
 	}
+	c.maxRetries = maxRetries
+	c.retryDelay = baseDelay
 }
 
-// doRequest performs an HTTP request with proper authentication
+// doRequest performs an HTTP request, retrying idempotent verbs (GET/DELETE) on transient failures
 func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error) {
-	var reqBody io.Reader
+	retry := method == http.MethodGet || method == http.MethodDelete
+	return c.doRequestWithRetry(method, path, body, retry)
+}
+
+// doRequestWithRetry performs an HTTP request and, when retry is set, repeats it with
+// exponential backoff while the failure is a network error or a 5xx response. A repeated
+// POST answered "already exists" succeeds: an earlier attempt created the resource
+// before failing, e.g. a timeout after ChirpStack committed it.
+func (c *Client) doRequestWithRetry(method, path string, body interface{}, retry bool) ([]byte, error) {
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request: %w", err)
 		}
+	}
+
+	delay := c.retryDelay
+	for attempt := 0; ; attempt++ {
+		respBody, err := c.send(method, path, jsonBody)
+		if attempt > 0 && method == http.MethodPost && isAlreadyExists(err) {
+			return nil, nil
+		}
+		if err == nil || !retry || attempt >= c.maxRetries || !isRetryable(err) {
+			return respBody, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// send performs a single authenticated HTTP request
+func (c *Client) send(method, path string, jsonBody []byte) ([]byte, error) {
+	var reqBody io.Reader
+	if jsonBody != nil {
 		reqBody = bytes.NewReader(jsonBody)
 	}
 
@@ -60,14 +121,33 @@ func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error
 	if resp.StatusCode >= 400 {
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Message)
+			return nil, &APIError{StatusCode: resp.StatusCode, Message: errResp.Message}
 		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: string(respBody)}
 	}
 
 	return respBody, nil
 }
 
+// isRetryable reports whether a failed request may succeed if repeated:
+// server errors (5xx) and transport errors are retryable, client errors (4xx) are not
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+// isAlreadyExists reports whether ChirpStack refused to create a resource that exists
+func isAlreadyExists(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusConflict || strings.Contains(strings.ToLower(apiErr.Message), "already exists")
+}
+
 // TestConnection verifies the API key and connectivity by listing device profiles
 func (c *Client) TestConnection(tenantID string) error {
 	_, err := c.doRequest("GET", "/api/device-profiles?limit=1&tenantId="+tenantID, nil)
@@ -77,7 +157,7 @@ func (c *Client) TestConnection(tenantID string) error {
 // CreateDevice creates a device in ChirpStack
 func (c *Client) CreateDevice(device *Device) error {
 	req := DeviceCreateRequest{Device: *device}
-	_, err := c.doRequest("POST", "/api/devices", req)
+	_, err := c.doRequestWithRetry("POST", "/api/devices", req, true)
 	return err
 }
 
@@ -115,28 +195,55 @@ func (c *Client) ActivateDeviceABP(devEUI, devAddr, nwkSKey, appSKey string) err
 	return err
 }
 
-// ListDeviceProfiles returns available device profiles for a tenant
+// ListDeviceProfiles returns all device profiles for a tenant, fetching them
+// in pages of the given size until the reported total count is reached
 func (c *Client) ListDeviceProfiles(tenantID string, limit int) ([]DeviceProfile, error) {
-	path := fmt.Sprintf("/api/device-profiles?limit=%d&tenantId=%s", limit, tenantID)
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var profiles []DeviceProfile
+	for offset := 0; ; offset += limit {
+		page, total, err := c.ListDeviceProfilesPage(tenantID, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, page...)
+		if len(page) == 0 || len(profiles) >= total {
+			break
+		}
+	}
+
+	return profiles, nil
+}
+
+// ListDeviceProfilesPage returns a single page of device profiles for a tenant
+// along with the total number of profiles reported by ChirpStack
+func (c *Client) ListDeviceProfilesPage(tenantID string, limit, offset int) ([]DeviceProfile, int, error) {
+	path := fmt.Sprintf("/api/device-profiles?limit=%d&offset=%d&tenantId=%s", limit, offset, tenantID)
 	respBody, err := c.doRequest("GET", path, nil)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var resp DeviceProfileListResponse
 	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, 0, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	return resp.Result, nil
+	return resp.Result, resp.TotalCount, nil
 }
 
-// DeviceExists checks if a device exists in ChirpStack
+// DeviceExists checks if a device exists in ChirpStack. Only a 404 answer means the
+// device is missing; other failures are returned so they are not mistaken for it.
 func (c *Client) DeviceExists(devEUI string) (bool, error) {
 	_, err := c.doRequest("GET", "/api/devices/"+devEUI, nil)
 	if err != nil {
-		// Check if it's a 404 error
-		return false, nil
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
 	}
 	return true, nil
 }
@@ -0,0 +1,150 @@
+package chirpstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestListDeviceProfilesPaginates(t *testing.T) {
+	const total = 3
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		resp := DeviceProfileListResponse{TotalCount: total}
+		for i := offset; i < offset+limit && i < total; i++ {
+			resp.Result = append(resp.Result, DeviceProfile{ID: fmt.Sprintf("dp-%d", i), Name: fmt.Sprintf("Profile %d", i)})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key")
+	profiles, err := client.ListDeviceProfiles("tenant", 2)
+	if err != nil {
+		t.Fatalf("ListDeviceProfiles: %v", err)
+	}
+	if len(profiles) != total {
+		t.Fatalf("expected %d profiles, got %d", total, len(profiles))
+	}
+	if requests != 2 {
+		t.Fatalf("expected 2 page requests, got %d", requests)
+	}
+	for i, p := range profiles {
+		if want := fmt.Sprintf("dp-%d", i); p.ID != want {
+			t.Errorf("profile %d: got ID %q, want %q", i, p.ID, want)
+		}
+	}
+}
+
+func TestDoRequestRetriesServerErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key")
+	client.SetRetryPolicy(3, time.Millisecond)
+	if err := client.CreateDevice(&Device{DevEUI: "0102030405060708"}); err != nil {
+		t.Fatalf("CreateDevice: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestCreateDeviceRetryFindsDeviceCreated(t *testing.T) {
+	type response struct {
+		status int
+		body   string
+	}
+	tests := []struct {
+		name      string
+		responses []response // one per attempt
+		wantErr   bool
+	}{
+		{"conflict on a retry", []response{{http.StatusGatewayTimeout, ""}, {http.StatusConflict, `{"message":"object already exists"}`}}, false},
+		{"already exists on a retry", []response{{http.StatusBadGateway, ""}, {http.StatusBadRequest, `{"message":"Object already exists"}`}}, false},
+		{"other client error on a retry", []response{{http.StatusBadGateway, ""}, {http.StatusBadRequest, `{"message":"invalid dev_eui"}`}}, true},
+		{"conflict on the first attempt", []response{{http.StatusConflict, `{"message":"object already exists"}`}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := tt.responses[attempts]
+				attempts++
+				w.WriteHeader(resp.status)
+				_, _ = w.Write([]byte(resp.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, "key")
+			client.SetRetryPolicy(3, time.Millisecond)
+			err := client.CreateDevice(&Device{DevEUI: "0102030405060708"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateDevice() error = %v, want error %v", err, tt.wantErr)
+			}
+			if attempts != len(tt.responses) {
+				t.Errorf("%d attempts, want %d", attempts, len(tt.responses))
+			}
+		})
+	}
+}
+
+func TestDoRequestDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key")
+	client.SetRetryPolicy(3, time.Millisecond)
+	if err := client.DeleteDevice("0102030405060708"); err == nil {
+		t.Fatal("expected error for 404 response")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestDeviceExistsOnlyTreatsNotFoundAsMissing(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key")
+	client.SetRetryPolicy(0, 0)
+	for _, tc := range []struct {
+		status  int
+		exists  bool
+		wantErr bool
+	}{
+		{http.StatusOK, true, false},
+		{http.StatusNotFound, false, false},
+		{http.StatusInternalServerError, false, true},
+	} {
+		status = tc.status
+		exists, err := client.DeviceExists("0102030405060708")
+		if exists != tc.exists || (err != nil) != tc.wantErr {
+			t.Errorf("status %d: DeviceExists = %v, %v; want %v, error %v", tc.status, exists, err, tc.exists, tc.wantErr)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package integration
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 )
 
@@ -55,9 +56,11 @@ func (i *Integration) Validate() error {
 	if strings.TrimSpace(i.Name) == "" {
 		return fmt.Errorf("%w: name is required", ErrInvalidIntegration)
 	}
-	if strings.TrimSpace(i.URL) == "" {
-		return fmt.Errorf("%w: URL is required", ErrInvalidIntegration)
+	normalized, err := NormalizeURL(i.URL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidIntegration, err)
 	}
+	i.URL = normalized
 	if strings.TrimSpace(i.APIKey) == "" {
 		return fmt.Errorf("%w: API key is required", ErrInvalidIntegration)
 	}
@@ -72,6 +75,15 @@ func (i *Integration) Validate() error {
 	return nil
 }
 
+// RedactedAPIKey is the placeholder the UI shows instead of a stored key; sending it back keeps the key
+const RedactedAPIKey = "********"
+
+// KeepsAPIKey reports whether an update value means "keep the stored API key"
+func KeepsAPIKey(apiKey string) bool {
+	apiKey = strings.TrimSpace(apiKey)
+	return apiKey == "" || apiKey == RedactedAPIKey
+}
+
 // PublicCopy returns a copy without the API key for public responses
 func (i *Integration) PublicCopy() *Integration {
 	return &Integration{
@@ -100,7 +112,40 @@ func (i *Integration) Clone() *Integration {
 	}
 }
 
-// normalizeURL ensures the URL doesn't have a trailing slash
-func normalizeURL(url string) string {
-	return strings.TrimSuffix(strings.TrimSpace(url), "/")
+// NormalizeURL trims the URL, adds http:// when the scheme is missing and strips the
+// trailing slashes, since the clients append the API paths to it. URLs that are not
+// absolute http(s) URLs are rejected.
+func NormalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("URL is required")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported URL scheme %q, use http or https", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", errors.New("URL has no host")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", errors.New("URL must not contain a query or a fragment")
+	}
+
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// normalizeURL returns the normalized URL, or the trimmed input if it is invalid
+// so that Validate reports the error
+func normalizeURL(raw string) string {
+	if normalized, err := NormalizeURL(raw); err == nil {
+		return normalized
+	}
+	return strings.TrimSpace(raw)
 }
@@ -0,0 +1,57 @@
+package integration
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"trailing slash", "http://host/", "http://host", false},
+		{"missing scheme", "host:8080", "http://host:8080", false},
+		{"https with path", " https://tb.example.com/api/ ", "https://tb.example.com/api", false},
+		{"several trailing slashes", "http://host:8090//", "http://host:8090", false},
+		{"empty", "", "", true},
+		{"blank", "   ", "", true},
+		{"unsupported scheme", "ftp://host", "", true},
+		{"no host", "http://", "", true},
+		{"query", "http://host?x=1", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeURL(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeURL(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeURL(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNormalizesURL(t *testing.T) {
+	integ := NewIntegration("cs", IntegrationTypeChirpStack, "localhost:8090/", "key", "tenant", "app")
+	if integ.URL != "http://localhost:8090" {
+		t.Errorf("NewIntegration URL = %q, want http://localhost:8090", integ.URL)
+	}
+
+	// Updates assign the URL directly, Validate must normalize it as well
+	integ.URL = "https://cs.example.com/"
+	if err := integ.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if integ.URL != "https://cs.example.com" {
+		t.Errorf("validated URL = %q, want https://cs.example.com", integ.URL)
+	}
+
+	integ.URL = ""
+	if err := integ.Validate(); !errors.Is(err, ErrInvalidIntegration) {
+		t.Errorf("Validate with empty URL error = %v, want ErrInvalidIntegration", err)
+	}
+}
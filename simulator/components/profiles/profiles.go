@@ -0,0 +1,129 @@
+package profiles
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+)
+
+var ErrProfileNotFound = errors.New("sensor profile not found")
+
+// Profile generates the object passed to a codec's OnUplink from the time of day,
+// so that devices report values following a daily pattern
+type Profile struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Fields      []string `json:"fields"` // Keys of the generated object
+
+	generate func(t time.Time) map[string]interface{}
+}
+
+// Generate returns the sensor values at time t
+func (p *Profile) Generate(t time.Time) map[string]interface{} {
+	return p.generate(t)
+}
+
+// builtin lists the profiles in the order they are presented
+var builtin = []*Profile{
+	{
+		ID:          "indoor-climate",
+		Name:        "Indoor climate",
+		Description: "Heated room: 20-23.5 °C peaking mid-afternoon, humidity 45-55 % peaking at dawn",
+		Fields:      []string{"temperature", "humidity"},
+		generate: func(t time.Time) map[string]interface{} {
+			return map[string]interface{}{
+				"temperature": noisy(daily(t, 20, 23.5, 16), 0.1, 1),
+				"humidity":    noisy(daily(t, 45, 55, 6), 0.5, 0),
+			}
+		},
+	},
+	{
+		ID:          "outdoor-climate",
+		Name:        "Outdoor climate",
+		Description: "Outside air: 8-22 °C peaking at 15:00, humidity 55-90 % peaking before sunrise",
+		Fields:      []string{"temperature", "humidity"},
+		generate: func(t time.Time) map[string]interface{} {
+			return map[string]interface{}{
+				"temperature": noisy(daily(t, 8, 22, 15), 0.3, 1),
+				"humidity":    noisy(daily(t, 55, 90, 5), 1, 0),
+			}
+		},
+	},
+	{
+		ID:          "office-co2",
+		Name:        "Office CO2",
+		Description: "Meeting room: CO2 rising up to 1100 ppm during working hours on weekdays, 420 ppm otherwise",
+		Fields:      []string{"co2", "occupied"},
+		generate: func(t time.Time) map[string]interface{} {
+			co2 := 420 + 680*workingHours(t)
+			return map[string]interface{}{
+				"co2":      noisy(co2, 15, 0),
+				"occupied": co2 > 600,
+			}
+		},
+	},
+	{
+		ID:          "soil-moisture",
+		Name:        "Soil moisture",
+		Description: "Irrigated field: moisture drying from 40 % to 28 % until the 06:00 watering, soil at 12-16 °C",
+		Fields:      []string{"moisture", "temperature"},
+		generate: func(t time.Time) map[string]interface{} {
+			sinceWatering := math.Mod(hourOfDay(t)-6+24, 24) / 24
+			return map[string]interface{}{
+				"moisture":    noisy(40-12*sinceWatering, 0.2, 1),
+				"temperature": noisy(daily(t, 12, 16, 17), 0.1, 1),
+			}
+		},
+	},
+}
+
+// List returns the built-in profiles
+func List() []Profile {
+	list := make([]Profile, 0, len(builtin))
+	for _, p := range builtin {
+		list = append(list, *p)
+	}
+	return list
+}
+
+// Get returns the profile with the given ID
+func Get(id string) (*Profile, error) {
+	for _, p := range builtin {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, ErrProfileNotFound
+}
+
+// hourOfDay returns the local time as fractional hours since midnight
+func hourOfDay(t time.Time) float64 {
+	return float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+}
+
+// daily returns a value oscillating once a day between min and max, reaching max at peakHour
+func daily(t time.Time, min, max, peakHour float64) float64 {
+	phase := 2 * math.Pi * (hourOfDay(t) - peakHour) / 24
+	return (min+max)/2 + (max-min)/2*math.Cos(phase)
+}
+
+// workingHours returns 0 outside 08:00-18:00 on weekdays and rises to 1 in the middle of the day
+func workingHours(t time.Time) float64 {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return 0
+	}
+	h := hourOfDay(t)
+	if h < 8 || h > 18 {
+		return 0
+	}
+	return math.Sin(math.Pi * (h - 8) / 10)
+}
+
+// noisy adds gaussian noise to v and rounds it to the given number of decimals
+func noisy(v, stddev float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round((v+stddev*util.RandNormFloat64())*scale) / scale
+}
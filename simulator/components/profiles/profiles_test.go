@@ -0,0 +1,55 @@
+package profiles
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProfilesGenerateDeclaredFields(t *testing.T) {
+	now := time.Date(2024, time.March, 12, 14, 30, 0, 0, time.UTC)
+	for _, p := range List() {
+		values := p.Generate(now)
+		if len(values) != len(p.Fields) {
+			t.Errorf("%s: generated %d values, declared %d fields", p.ID, len(values), len(p.Fields))
+		}
+		for _, field := range p.Fields {
+			if _, ok := values[field]; !ok {
+				t.Errorf("%s: field %q not generated", p.ID, field)
+			}
+		}
+	}
+}
+
+func TestDaily(t *testing.T) {
+	day := func(hour int) time.Time { return time.Date(2024, time.March, 12, hour, 0, 0, 0, time.UTC) }
+	if got := daily(day(15), 8, 22, 15); got != 22 {
+		t.Errorf("value at peak = %v, want 22", got)
+	}
+	if got := daily(day(3), 8, 22, 15); got != 8 {
+		t.Errorf("value 12 hours after peak = %v, want 8", got)
+	}
+}
+
+func TestOfficeCO2FollowsWorkingHours(t *testing.T) {
+	p, err := Get("office-co2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	night := p.Generate(time.Date(2024, time.March, 12, 2, 0, 0, 0, time.UTC))
+	noon := p.Generate(time.Date(2024, time.March, 12, 13, 0, 0, 0, time.UTC))
+	sunday := p.Generate(time.Date(2024, time.March, 17, 13, 0, 0, 0, time.UTC))
+
+	if night["co2"].(float64) >= noon["co2"].(float64) {
+		t.Errorf("co2 at night (%v) should be below co2 at noon (%v)", night["co2"], noon["co2"])
+	}
+	if noon["occupied"] != true || night["occupied"] != false || sunday["occupied"] != false {
+		t.Errorf("unexpected occupancy: night %v, noon %v, sunday %v", night["occupied"], noon["occupied"], sunday["occupied"])
+	}
+}
+
+func TestGetUnknownProfile(t *testing.T) {
+	if _, err := Get("missing"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrProfileNotFound", err)
+	}
+}
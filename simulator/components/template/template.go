@@ -1,9 +1,13 @@
 package template
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/profiles"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 )
 
 var (
@@ -53,6 +57,15 @@ type DeviceTemplate struct {
 	NbRetransmission int   `json:"nbRetransmission"`
 	MType            int   `json:"mtype"` // 0=UnconfirmedDataUp, 1=ConfirmedDataUp
 
+	// Frame counters
+	FCntUpStart   uint32 `json:"fcntUpStart"`   // FCntUp of a new session
+	FCntDownStart uint32 `json:"fcntDownStart"` // FCntDown of a new session
+	FCntBits      int    `json:"fcntBits"`      // Rollover width: 16, 32 or 0 (default)
+
+	// Join-accept windows (milliseconds, 0 = LoRaWAN default)
+	JoinAcceptDelay1 int `json:"joinAcceptDelay1"`
+	JoinAcceptDelay2 int `json:"joinAcceptDelay2"`
+
 	// Payload settings
 	SupportedFragment bool `json:"supportedFragment"` // true=fragment, false=truncate
 
@@ -60,6 +73,13 @@ type DeviceTemplate struct {
 	UseCodec bool `json:"useCodec"`
 	CodecID  int  `json:"codecId"`
 
+	// Static payload (hex) used when no codec is configured
+	StaticPayloadHex string `json:"staticPayloadHex,omitempty"`
+	// Payload config passed to the codec's OnUplink when UseCodec is true
+	PayloadConfig map[string]interface{} `json:"payloadConfig,omitempty"`
+	// Sensor profile whose values are added to the payload config at every uplink
+	ProfileID string `json:"profileId,omitempty"`
+
 	// ChirpStack Integration configuration
 	IntegrationEnabled bool   `json:"integrationEnabled"`
 	IntegrationID      int    `json:"integrationId"`
@@ -100,8 +120,8 @@ func NewDeviceTemplate(name string) *DeviceTemplate {
 
 // Validate checks if the template has all required fields
 func (t *DeviceTemplate) Validate() error {
-	if strings.TrimSpace(t.Name) == "" {
-		return fmt.Errorf("%w: name is required", ErrInvalidTemplate)
+	if err := util.ValidateName(t.Name); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
 	}
 	if t.Region < 1 || t.Region > 10 {
 		return fmt.Errorf("%w: invalid region code", ErrInvalidTemplate)
@@ -112,6 +132,23 @@ func (t *DeviceTemplate) Validate() error {
 	if t.Range <= 0 {
 		return fmt.Errorf("%w: range must be positive", ErrInvalidTemplate)
 	}
+	if !util.IsValidFCntBits(t.FCntBits) {
+		return fmt.Errorf("%w: frame counter width must be 16 or 32", ErrInvalidTemplate)
+	}
+	if max := util.MaxFCnt(t.FCntBits); t.FCntUpStart > max || t.FCntDownStart > max {
+		return fmt.Errorf("%w: frame counter start values must not exceed %d", ErrInvalidTemplate, max)
+	}
+	if err := util.ValidateJoinAcceptDelays(t.JoinAcceptDelay1, t.JoinAcceptDelay2); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	if t.ProfileID != "" {
+		if _, err := profiles.Get(t.ProfileID); err != nil {
+			return fmt.Errorf("%w: unknown sensor profile %q", ErrInvalidTemplate, t.ProfileID)
+		}
+	}
+	if _, err := hex.DecodeString(strings.TrimSpace(t.StaticPayloadHex)); err != nil {
+		return fmt.Errorf("%w: static payload is not valid hex", ErrInvalidTemplate)
+	}
 	return nil
 }
 
@@ -139,9 +176,17 @@ func (t *DeviceTemplate) Clone() *DeviceTemplate {
 		FPort:              t.FPort,
 		NbRetransmission:   t.NbRetransmission,
 		MType:              t.MType,
+		FCntUpStart:        t.FCntUpStart,
+		FCntDownStart:      t.FCntDownStart,
+		FCntBits:           t.FCntBits,
+		JoinAcceptDelay1:   t.JoinAcceptDelay1,
+		JoinAcceptDelay2:   t.JoinAcceptDelay2,
 		SupportedFragment:  t.SupportedFragment,
 		UseCodec:           t.UseCodec,
 		CodecID:            t.CodecID,
+		StaticPayloadHex:   t.StaticPayloadHex,
+		PayloadConfig:      clonePayloadConfig(t.PayloadConfig),
+		ProfileID:          t.ProfileID,
 		IntegrationEnabled:   t.IntegrationEnabled,
 		IntegrationID:        t.IntegrationID,
 		DeviceProfileID:      t.DeviceProfileID,
@@ -152,6 +197,45 @@ func (t *DeviceTemplate) Clone() *DeviceTemplate {
 	}
 }
 
+// Dependencies lists the codec and integrations a template relies on
+type Dependencies struct {
+	TemplateID     int   `json:"templateId"`
+	CodecIDs       []int `json:"codecIds"`       // Codec used to generate the payloads (empty if none)
+	IntegrationIDs []int `json:"integrationIds"` // ChirpStack and ThingsBoard integrations the devices are provisioned to
+}
+
+// Dependencies returns the codec and integrations enabled on the template
+func (t *DeviceTemplate) Dependencies() Dependencies {
+	deps := Dependencies{TemplateID: t.ID, CodecIDs: []int{}, IntegrationIDs: []int{}}
+	if t.UseCodec && t.CodecID > 0 {
+		deps.CodecIDs = append(deps.CodecIDs, t.CodecID)
+	}
+	if t.IntegrationEnabled && t.IntegrationID > 0 {
+		deps.IntegrationIDs = append(deps.IntegrationIDs, t.IntegrationID)
+	}
+	if t.TBIntegrationEnabled && t.TBIntegrationID > 0 && t.TBIntegrationID != t.IntegrationID {
+		deps.IntegrationIDs = append(deps.IntegrationIDs, t.TBIntegrationID)
+	}
+	return deps
+}
+
+// UsesIntegration reports whether the template provisions its devices to the integration
+func (t *DeviceTemplate) UsesIntegration(id int) bool {
+	return (t.IntegrationEnabled && t.IntegrationID == id) || (t.TBIntegrationEnabled && t.TBIntegrationID == id)
+}
+
+// clonePayloadConfig returns a copy of the top level of a payload config map
+func clonePayloadConfig(config map[string]interface{}) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	clone := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		clone[k] = v
+	}
+	return clone
+}
+
 // GetDefaultTemplates returns built-in default templates for common device types
 // codecLookup is an optional function to resolve codec names to IDs
 func GetDefaultTemplates(codecLookup func(name string) int) []*DeviceTemplate {
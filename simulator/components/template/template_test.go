@@ -0,0 +1,45 @@
+package template
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateStaticPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantErr bool
+	}{
+		{"none", "", false},
+		{"hex", "01ff", false},
+		{"surrounding spaces", " 0a0b ", false},
+		{"odd length", "abc", true},
+		{"not hex", "zz", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := NewDeviceTemplate("sensor")
+			tmpl.StaticPayloadHex = tt.payload
+			err := tmpl.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTemplate) {
+				t.Errorf("Validate() error = %v, want ErrInvalidTemplate", err)
+			}
+		})
+	}
+}
+
+func TestCloneCopiesPayloadConfig(t *testing.T) {
+	tmpl := NewDeviceTemplate("sensor")
+	tmpl.PayloadConfig = map[string]interface{}{"mode": 1}
+
+	clone := tmpl.Clone()
+	clone.PayloadConfig["mode"] = 2
+
+	if tmpl.PayloadConfig["mode"] != 1 {
+		t.Errorf("changing the clone changed the template payload config: %v", tmpl.PayloadConfig)
+	}
+}
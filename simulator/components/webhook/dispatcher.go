@@ -0,0 +1,214 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Default delivery policy
+const (
+	QueueSize         = 256
+	DefaultMaxRetries = 3
+	DefaultRetryDelay = time.Second
+	RequestTimeout    = 10 * time.Second
+)
+
+// DeviceErrorInterval is the minimum time between two device-error events of the same
+// device; the ones in between are dropped, so that a failing device can't flood the webhooks
+const DeviceErrorInterval = 10 * time.Second
+
+// Dispatcher posts events to the configured webhooks, each from its own background
+// worker and queue. Publish never blocks: events are dropped when the queue of a
+// webhook is full, so a slow endpoint cannot stall the devices, the gateways or the
+// other webhooks.
+type Dispatcher struct {
+	mu         sync.RWMutex
+	targets    []*target
+	httpClient *http.Client
+	maxRetries int           // Extra attempts after the first one
+	retryDelay time.Duration // Base delay, doubled after every failed attempt
+
+	errorsMu      sync.Mutex
+	lastErrors    map[string]time.Time // Time of the last device-error event sent, per device
+	errorInterval time.Duration
+}
+
+// target is a webhook with the queue of the events waiting to be posted to it
+type target struct {
+	config Config // Guarded by Dispatcher.mu, the worker only uses url
+	url    string
+	queue  chan queuedEvent
+	stop   chan struct{}
+}
+
+type queuedEvent struct {
+	eventType string
+	name      string
+	body      []byte
+}
+
+// NewDispatcher creates a dispatcher and starts the workers of the webhooks
+func NewDispatcher(configs []Config) *Dispatcher {
+	d := &Dispatcher{
+		httpClient:    &http.Client{Timeout: RequestTimeout},
+		maxRetries:    DefaultMaxRetries,
+		retryDelay:    DefaultRetryDelay,
+		lastErrors:    make(map[string]time.Time),
+		errorInterval: DeviceErrorInterval,
+	}
+	d.SetConfigs(configs)
+	return d
+}
+
+// SetRetryPolicy configures how many times a failed delivery is repeated and the base backoff delay
+func (d *Dispatcher) SetRetryPolicy(maxRetries int, baseDelay time.Duration) {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	d.mu.Lock()
+	d.maxRetries = maxRetries
+	d.retryDelay = baseDelay
+	d.mu.Unlock()
+}
+
+// SetConfigs replaces the webhooks events are sent to. The webhooks already configured
+// keep their worker and pending events; the workers of the removed ones are stopped.
+func (d *Dispatcher) SetConfigs(configs []Config) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	previous := make(map[string]*target, len(d.targets))
+	for _, t := range d.targets {
+		previous[t.url] = t
+	}
+	targets := make([]*target, 0, len(configs))
+	for _, config := range configs {
+		config.Events = append([]string(nil), config.Events...)
+		if t, ok := previous[config.URL]; ok {
+			delete(previous, config.URL)
+			t.config = config
+			targets = append(targets, t)
+			continue
+		}
+		t := &target{
+			config: config,
+			url:    config.URL,
+			queue:  make(chan queuedEvent, QueueSize),
+			stop:   make(chan struct{}),
+		}
+		go d.worker(t)
+		targets = append(targets, t)
+	}
+	for _, t := range previous {
+		close(t.stop)
+	}
+	d.targets = targets
+}
+
+// Configs returns a copy of the configured webhooks
+func (d *Dispatcher) Configs() []Config {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	configs := make([]Config, 0, len(d.targets))
+	for _, t := range d.targets {
+		config := t.config
+		config.Events = append([]string(nil), config.Events...)
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// Publish queues the event for every webhook subscribed to its type
+func (d *Dispatcher) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.Type == EventDeviceError && !d.allowDeviceError(event) {
+		return
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Webhook: unable to encode %s event: %v", event.Type, err)
+		return
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, t := range d.targets {
+		if !t.config.Matches(event.Type) {
+			continue
+		}
+		select {
+		case t.queue <- queuedEvent{eventType: event.Type, name: event.Name, body: body}:
+		default:
+			log.Printf("Webhook %s: queue full, %s event of %s dropped", t.url, event.Type, event.Name)
+		}
+	}
+}
+
+// allowDeviceError reports whether a device-error event can be sent, at most one per
+// device every errorInterval
+func (d *Dispatcher) allowDeviceError(event Event) bool {
+	key := fmt.Sprintf("%s/%d", event.Source, event.ID)
+
+	d.errorsMu.Lock()
+	defer d.errorsMu.Unlock()
+	if last, ok := d.lastErrors[key]; ok && event.Time.Sub(last) < d.errorInterval {
+		return false
+	}
+	d.lastErrors[key] = event.Time
+	return true
+}
+
+func (d *Dispatcher) worker(t *target) {
+	for {
+		select {
+		case <-t.stop:
+			return
+		case event := <-t.queue:
+			if err := d.deliver(t, event.body); err != nil {
+				log.Printf("Webhook %s: %s event of %s not delivered: %v", t.url, event.eventType, event.name, err)
+			}
+		}
+	}
+}
+
+// deliver posts the body, retrying with exponential backoff on transport errors and 5xx
+// responses until the webhook is removed
+func (d *Dispatcher) deliver(t *target, body []byte) error {
+	d.mu.RLock()
+	retries, delay := d.maxRetries, d.retryDelay
+	d.mu.RUnlock()
+
+	for attempt := 0; ; attempt++ {
+		retryable, err := d.post(t.url, body)
+		if err == nil || !retryable || attempt >= retries {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+		case <-t.stop:
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// post performs a single request and reports whether a failure may succeed if repeated
+func (d *Dispatcher) post(url string, body []byte) (bool, error) {
+	resp, err := d.httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode >= 500, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return false, nil
+}
@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDispatcherRetriesAndFilters(t *testing.T) {
+	received := make(chan Event, 4)
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	d := NewDispatcher([]Config{{URL: server.URL, Events: []string{EventJoinAccepted}}})
+	d.SetRetryPolicy(2, time.Millisecond)
+
+	d.Publish(Event{Type: EventDeviceError, Source: SourceDevice, ID: 1, Name: "dev"})
+	d.Publish(Event{Type: EventJoinAccepted, Source: SourceDevice, ID: 1, Name: "dev"})
+
+	select {
+	case event := <-received:
+		if event.Type != EventJoinAccepted || event.Name != "dev" || event.Time.IsZero() {
+			t.Errorf("unexpected event %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("event not delivered")
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"all events", Config{URL: "http://localhost:8080/hook"}, false},
+		{"filtered", Config{URL: "https://example.com", Events: []string{EventJoinFailed, EventGatewayDisconnected}}, false},
+		{"relative url", Config{URL: "/hook"}, true},
+		{"unsupported scheme", Config{URL: "ftp://example.com"}, true},
+		{"unknown event", Config{URL: "http://example.com", Events: []string{"uplink"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDispatcherSlowWebhook(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	received := make(chan struct{}, QueueSize)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer fast.Close()
+
+	d := NewDispatcher([]Config{{URL: slow.URL}, {URL: fast.URL}})
+	for i := 0; i < 5; i++ {
+		d.Publish(Event{Type: EventStateChanged, Source: SourceDevice, ID: i, Name: "dev"})
+	}
+	for i := 0; i < 5; i++ {
+		select {
+		case <-received:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("fast webhook got %d events out of 5", i)
+		}
+	}
+}
+
+func TestDispatcherDeviceErrorRateLimit(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		name  string
+		event Event
+		want  bool
+	}{
+		{"first error", Event{Type: EventDeviceError, Source: SourceDevice, ID: 1, Time: start}, true},
+		{"same device too soon", Event{Type: EventDeviceError, Source: SourceDevice, ID: 1, Time: start.Add(time.Second)}, false},
+		{"other device", Event{Type: EventDeviceError, Source: SourceDevice, ID: 2, Time: start.Add(time.Second)}, true},
+		{"same device later", Event{Type: EventDeviceError, Source: SourceDevice, ID: 1, Time: start.Add(DeviceErrorInterval)}, true},
+		{"other event type", Event{Type: EventJoinFailed, Source: SourceDevice, ID: 1, Time: start.Add(DeviceErrorInterval)}, true},
+	}
+
+	received := make(chan Event, len(tests))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	d := NewDispatcher([]Config{{URL: server.URL}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d.Publish(tt.event)
+			select {
+			case event := <-received:
+				if !tt.want {
+					t.Errorf("event %+v delivered, want dropped", event)
+				}
+			case <-time.After(200 * time.Millisecond):
+				if tt.want {
+					t.Error("event dropped, want delivered")
+				}
+			}
+		})
+	}
+}
+
+func TestSetConfigsKeepsWebhooks(t *testing.T) {
+	d := NewDispatcher([]Config{{URL: "http://a.example"}, {URL: "http://b.example"}})
+	a := d.targets[0]
+	b := d.targets[1]
+
+	d.SetConfigs([]Config{{URL: "http://a.example", Events: []string{EventJoinFailed}}, {URL: "http://c.example"}})
+
+	if d.targets[0] != a {
+		t.Error("the worker of a kept webhook was replaced")
+	}
+	if !d.targets[0].config.Matches(EventJoinFailed) || d.targets[0].config.Matches(EventDeviceError) {
+		t.Errorf("kept webhook not updated: %+v", d.targets[0].config)
+	}
+	select {
+	case <-b.stop:
+	default:
+		t.Error("the worker of a removed webhook was not stopped")
+	}
+	if got := d.Configs(); len(got) != 2 || got[1].URL != "http://c.example" {
+		t.Errorf("Configs() = %+v", got)
+	}
+}
+
+func TestValidateConfigs(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   []Config
+		wantValid []string
+		wantErr   bool
+	}{
+		{"none", nil, []string{}, false},
+		{"valid", []Config{{URL: "http://a.example"}, {URL: "http://b.example"}}, []string{"http://a.example", "http://b.example"}, false},
+		{"invalid", []Config{{URL: "/hook"}, {URL: "http://b.example"}}, []string{"http://b.example"}, true},
+		{"duplicate", []Config{{URL: "http://a.example"}, {URL: "http://a.example", Events: []string{EventJoinFailed}}}, []string{"http://a.example"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateConfigs(tt.configs); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfigs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			valid, _ := ValidConfigs(tt.configs)
+			if len(valid) != len(tt.wantValid) {
+				t.Fatalf("ValidConfigs() = %+v, want %v", valid, tt.wantValid)
+			}
+			for i := range valid {
+				if valid[i].URL != tt.wantValid[i] {
+					t.Errorf("ValidConfigs()[%d] = %s, want %s", i, valid[i].URL, tt.wantValid[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Event types that can be forwarded to a webhook
+const (
+	EventJoinAccepted        = "join-accepted"        // OTAA device joined the network
+	EventJoinFailed          = "join-failed"          // OTAA join attempt without a valid Join Accept
+	EventDeviceError         = "device-error"         // Error reported by a device
+	EventGatewayDisconnected = "gateway-disconnected" // Gateway lost the connection with the bridge
+	EventStateChanged        = "state-changed"        // Simulator, device or gateway turned on or off
+)
+
+// EventTypes lists every event type a webhook can subscribe to
+var EventTypes = []string{EventJoinAccepted, EventJoinFailed, EventDeviceError, EventGatewayDisconnected, EventStateChanged}
+
+// Sources of an event
+const (
+	SourceDevice    = "device"
+	SourceGateway   = "gateway"
+	SourceSimulator = "simulator"
+)
+
+// States reported by a state-changed event
+const (
+	StateRunning = "running"
+	StateStopped = "stopped"
+)
+
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// Config is an URL the simulator posts events to
+type Config struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"` // Event types to send (empty = all)
+}
+
+// Event is the JSON body posted to a webhook
+type Event struct {
+	Type    string      `json:"type"`
+	Time    time.Time   `json:"time"`
+	Source  string      `json:"source"` // "device", "gateway" or "simulator"
+	ID      int         `json:"id"`
+	EUI     string      `json:"eui"` // DevEUI of a device, MAC address of a gateway
+	Name    string      `json:"name"`
+	State   string      `json:"state,omitempty"` // New state of a state-changed event
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"` // Body of a device socket event, only published to MQTT
+}
+
+// Validate checks the URL and the event filter
+func (c *Config) Validate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	for _, e := range c.Events {
+		if !isEventType(e) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, e)
+		}
+	}
+	return nil
+}
+
+// ValidateConfigs checks every webhook and that no URL is configured twice
+func ValidateConfigs(configs []Config) error {
+	if _, errs := ValidConfigs(configs); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ValidConfigs returns the webhooks that are valid and not a duplicate of a previous
+// URL, with an error for each one left out
+func ValidConfigs(configs []Config) ([]Config, []error) {
+	valid := make([]Config, 0, len(configs))
+	var errs []error
+	seen := make(map[string]bool, len(configs))
+	for i := range configs {
+		if err := configs[i].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %d: %w", i, err))
+			continue
+		}
+		if seen[configs[i].URL] {
+			errs = append(errs, fmt.Errorf("webhook %d: %w: duplicate url %s", i, ErrInvalidWebhook, configs[i].URL))
+			continue
+		}
+		seen[configs[i].URL] = true
+		valid = append(valid, configs[i])
+	}
+	return valid, errs
+}
+
+// Matches reports whether the webhook subscribed to the event type
+func (c *Config) Matches(eventType string) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func isEventType(eventType string) bool {
+	for _, e := range EventTypes {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/chirpstack"
+	"github.com/brocaar/lorawan"
+)
+
+// Run with -race: devices are added and deleted while others list them
+func TestConcurrentDeviceAccess(t *testing.T) {
+	s := newTestSimulator(t)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				device := newTestDevice(fmt.Sprintf("dev-%d-%d", w, i), lorawan.EUI64{1, byte(w), 0, 0, 0, 0, 0, byte(i + 1)})
+				if _, id, err := s.SetDevice(device, false); err != nil {
+					t.Errorf("SetDevice: %v", err)
+				} else if i%2 == 0 {
+					s.DeleteDevice(id)
+				}
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				s.GetDevices()
+				s.SearchDevices(models.DeviceFilter{})
+				s.Health()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(s.GetDevices()); got != 4*12 {
+		t.Errorf("got %d devices, want %d", got, 4*12)
+	}
+}
+
+// The simulator stays usable while a device is being provisioned to a slow network server
+func TestProvisioningReleasesLock(t *testing.T) {
+	s := newTestSimulator(t)
+
+	requested := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requested <- struct{}{}
+			<-release
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	s.Integrations[1] = &integration.Integration{ID: 1, Name: "cs", Type: integration.IntegrationTypeChirpStack, Enabled: true}
+	s.IntegrationClients[1] = chirpstack.NewClient(server.URL, "key")
+
+	device := newTestDevice("slow", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1})
+	device.Info.Configuration.IntegrationEnabled = true
+	device.Info.Configuration.IntegrationID = 1
+
+	added := make(chan error, 1)
+	go func() {
+		_, _, err := s.SetDevice(device, false)
+		added <- err
+	}()
+	<-requested
+
+	listed := make(chan int, 1)
+	go func() { listed <- len(s.GetDevices()) }()
+	select {
+	case n := <-listed:
+		if n != 1 {
+			t.Errorf("GetDevices() returned %d devices during the provisioning, want 1", n)
+		}
+	case <-time.After(time.Second):
+		t.Error("GetDevices() blocked by the provisioning")
+	}
+
+	close(release)
+	if err := <-added; err != nil {
+		t.Fatalf("SetDevice() error = %v", err)
+	}
+}
+
+// The simulator stays usable while the devices of a bulk deletion or a reset are
+// de-provisioned from a slow network server
+func TestDeletionReleasesLock(t *testing.T) {
+	tests := []struct {
+		name   string
+		delete func(s *Simulator) error
+	}{
+		{"delete all devices", func(s *Simulator) error { _, err := s.DeleteAllDevices(); return err }},
+		{"reset", func(s *Simulator) error { _, err := s.Reset(); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSimulator(t)
+			previous := dev.Codecs
+			dev.Codecs = codec.NewRegistry(nil) // Reset restores the default codecs
+			defer func() { dev.Codecs = previous }()
+
+			requested := make(chan struct{}, 1)
+			release := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodDelete {
+					requested <- struct{}{}
+					<-release
+				}
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+
+			s.Integrations[1] = &integration.Integration{ID: 1, Name: "cs", Type: integration.IntegrationTypeChirpStack, Enabled: true}
+			s.IntegrationClients[1] = chirpstack.NewClient(server.URL, "key")
+			device := newTestDevice("slow", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1})
+			device.Info.Configuration.IntegrationEnabled = true
+			device.Info.Configuration.IntegrationID = 1
+			s.Devices[0] = device
+
+			deleted := make(chan error, 1)
+			go func() { deleted <- tt.delete(s) }()
+			<-requested
+
+			listed := make(chan int, 1)
+			go func() { listed <- len(s.GetDevices()) }()
+			select {
+			case n := <-listed:
+				if n != 1 {
+					t.Errorf("GetDevices() returned %d devices during the deletion, want 1", n)
+				}
+			case <-time.After(time.Second):
+				t.Error("GetDevices() blocked by the deletion")
+			}
+			if err := tt.delete(s); err == nil {
+				t.Error("a second deletion started during the first one")
+			}
+
+			close(release)
+			if err := <-deleted; err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if n := len(s.GetDevices()); n != 0 {
+				t.Errorf("%d devices left, want none", n)
+			}
+		})
+	}
+}
@@ -2,29 +2,95 @@ package console
 
 import (
 	"log"
+	"sync/atomic"
 
+	"github.com/R3DPanda1/LWN-Sim-Plus/output/mqtt"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/webhook"
 	socketio "github.com/googollee/go-socket.io"
 )
 
 type Console struct {
-	WebSocket *socketio.Conn // Pointer so all device/gateway copies share the same connection
-	WatchedID *int           // Pointer so all device copies share the same value
+	WebSocket  *socketio.Conn            // Pointer so all device/gateway copies share the same connection
+	WatchedID  *int                      // Pointer so all device copies share the same value
+	EventTypes *atomic.Pointer[[]string] // Pointer so all copies share the same event filter, swapped while events are emitted; nil or empty forwards everything
+
+	Webhooks *webhook.Dispatcher // Outbound notifications of significant events; nil disables them
+	MQTT     *mqtt.Publisher     // Same events published to an MQTT broker; nil disables them
 }
 
 func (c *Console) IsWatched(deviceID int) bool {
 	return c.WatchedID != nil && *c.WatchedID == deviceID
 }
 
+// SetEventTypes replaces the event type filter shared by all copies of the console
+func (c *Console) SetEventTypes(types []string) {
+	types = append([]string(nil), types...)
+	c.EventTypes.Store(&types)
+}
+
+// IsForwarded reports whether the event passes the current event type filter
+func (c *Console) IsForwarded(eventName string) bool {
+	if c.EventTypes == nil {
+		return true
+	}
+	types := c.EventTypes.Load()
+	if types == nil || len(*types) == 0 {
+		return true
+	}
+	for _, t := range *types {
+		if t == eventName {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Console) PrintLog(message string) {
 	log.Println(message)
 }
 
 func (c *Console) PrintSocket(eventName string, data ...interface{}) {
+	if !c.IsForwarded(eventName) {
+		return
+	}
 	if c.WebSocket != nil && *c.WebSocket != nil {
 		(*c.WebSocket).Emit(eventName, data...)
 	}
 }
 
+// Notify sends the event to the webhooks subscribed to its type and to the MQTT
+// broker without blocking
+func (c *Console) Notify(event webhook.Event) {
+	if c.Webhooks != nil {
+		c.Webhooks.Publish(event)
+	}
+	if c.MQTT != nil {
+		c.MQTT.Publish(event)
+	}
+}
+
+// PrintDevice emits a device event to the socket and publishes it to the MQTT broker
+func (c *Console) PrintDevice(eventName string, id int, devEUI, name string, data interface{}) {
+	c.PrintSocket(eventName, data)
+	c.PublishDevice(eventName, id, devEUI, name, data)
+}
+
+// PublishDevice publishes a device event to the MQTT broker on
+// prefix/device/{devEUI}/{eventName} without blocking. Webhooks don't receive it.
+func (c *Console) PublishDevice(eventName string, id int, devEUI, name string, data interface{}) {
+	if c.MQTT == nil {
+		return
+	}
+	c.MQTT.Publish(webhook.Event{
+		Type:   eventName,
+		Source: webhook.SourceDevice,
+		ID:     id,
+		EUI:    devEUI,
+		Name:   name,
+		Data:   data,
+	})
+}
+
 func (c *Console) SetupWebSocket(WebSocket *socketio.Conn) {
 	*c.WebSocket = *WebSocket
 }
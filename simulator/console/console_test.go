@@ -0,0 +1,61 @@
+package console
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestIsForwarded(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		event string
+		want  bool
+	}{
+		{"no filter", nil, "console-sim", true},
+		{"empty filter", []string{}, "console-sim", true},
+		{"listed", []string{"console-sim", "log-dev"}, "log-dev", true},
+		{"not listed", []string{"console-sim"}, "log-dev", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var eventTypes atomic.Pointer[[]string]
+			c := Console{EventTypes: &eventTypes}
+			if tt.types != nil {
+				c.SetEventTypes(tt.types)
+			}
+			if got := c.IsForwarded(tt.event); got != tt.want {
+				t.Errorf("IsForwarded(%q) = %v, want %v", tt.event, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetEventTypesWhileEmitting(t *testing.T) {
+	var eventTypes atomic.Pointer[[]string]
+	c := Console{EventTypes: &eventTypes}
+	copied := c // devices and gateways hold copies of the console
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			c.SetEventTypes([]string{"console-sim"})
+			c.SetEventTypes(nil)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			copied.IsForwarded("console-sim")
+		}
+	}()
+	wg.Wait()
+
+	copied.SetEventTypes([]string{"log-dev"})
+	if c.IsForwarded("console-sim") {
+		t.Error("the filter set on a copy is not shared")
+	}
+}
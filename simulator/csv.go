@@ -0,0 +1,241 @@
+package simulator
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+// csvFlushEvery is the number of rows written before flushing the CSV writer
+const csvFlushEvery = 100
+
+// DeviceCSVHeader lists the columns used by the device CSV export and import
+var DeviceCSVHeader = []string{"DevEUI", "DevAddr", "Name", "Region", "Class", "SendInterval", "Active", "Lat", "Lng"}
+
+// WriteDevicesCSV streams every device as a CSV row to w, ordered by device ID
+func (s *Simulator) WriteDevicesCSV(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writer := csv.NewWriter(w)
+	if err := writer.Write(DeviceCSVHeader); err != nil {
+		return err
+	}
+
+	ids := make([]int, 0, len(s.Devices))
+	for id := range s.Devices {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for i, id := range ids {
+		if err := writer.Write(deviceCSVRecord(s.Devices[id])); err != nil {
+			return err
+		}
+		if (i+1)%csvFlushEvery == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// deviceCSVRecord converts a device to a row matching DeviceCSVHeader
+func deviceCSVRecord(d *dev.Device) []string {
+	region := ""
+	if d.Info.Configuration.Region != nil {
+		region = strconv.Itoa(d.Info.Configuration.Region.GetCode())
+	}
+	return []string{
+		hex.EncodeToString(d.Info.DevEUI[:]),
+		hex.EncodeToString(d.Info.DevAddr[:]),
+		d.Info.Name,
+		region,
+		deviceClass(d),
+		strconv.Itoa(int(d.Info.Configuration.SendInterval / time.Second)),
+		strconv.FormatBool(d.Info.Status.Active),
+		strconv.FormatFloat(d.Info.Location.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(d.Info.Location.Longitude, 'f', -1, 64),
+	}
+}
+
+// deviceClass returns the highest LoRaWAN class supported by the device
+func deviceClass(d *dev.Device) string {
+	switch {
+	case d.Info.Configuration.SupportedClassC:
+		return "C"
+	case d.Info.Configuration.SupportedClassB:
+		return "B"
+	default:
+		return "A"
+	}
+}
+
+// ImportDevicesCSV creates a device for every row of a CSV using the DeviceCSVHeader columns.
+// Rows are validated independently: a failing row is recorded in the summary and the import continues.
+// Missing DevEUIs and keys are generated; rows with a DevAddr are ABP devices, the others OTAA.
+func (s *Simulator) ImportDevicesCSV(r io.Reader) (models.CSVImportSummary, error) {
+	summary := models.CSVImportSummary{DeviceIDs: []int{}, Errors: []models.CSVRowError{}}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return summary, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return summary, errors.New("CSV header must contain a Name column")
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line := 0
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.StartLine
+			}
+			summary.Failed++
+			summary.Errors = append(summary.Errors, models.CSVRowError{Line: line, Error: err.Error()})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[strings.ToLower(name)]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		device, err := s.deviceFromCSV(field)
+		if err == nil {
+			var id int
+			_, id, err = s.SetDevice(device, false)
+			if err == nil {
+				summary.Created++
+				summary.DeviceIDs = append(summary.DeviceIDs, id)
+				continue
+			}
+		}
+		summary.Failed++
+		summary.Errors = append(summary.Errors, models.CSVRowError{Line: line, Error: err.Error()})
+	}
+
+	s.Print(fmt.Sprintf("CSV import: %d created, %d failed", summary.Created, summary.Failed), nil, util.PrintOnlyConsole)
+	return summary, nil
+}
+
+// deviceFromCSV builds a device from the fields of a CSV row
+func (s *Simulator) deviceFromCSV(field func(string) string) (*dev.Device, error) {
+	tmpl := template.NewDeviceTemplate(field("Name"))
+
+	if v := field("Region"); v != "" {
+		region, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Region %q", v)
+		}
+		tmpl.Region = region
+	}
+	switch strings.ToUpper(field("Class")) {
+	case "", "A":
+	case "B":
+		tmpl.SupportedClassB = true
+	case "C":
+		tmpl.SupportedClassC = true
+	default:
+		return nil, fmt.Errorf("invalid Class %q", field("Class"))
+	}
+	if v := field("SendInterval"); v != "" {
+		interval, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SendInterval %q", v)
+		}
+		tmpl.SendInterval = interval
+	}
+	if err := tmpl.Validate(); err != nil {
+		return nil, err
+	}
+
+	var lat, lng float64
+	var err error
+	if v := field("Lat"); v != "" {
+		if lat, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("invalid Lat %q", v)
+		}
+	}
+	if v := field("Lng"); v != "" {
+		if lng, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("invalid Lng %q", v)
+		}
+	}
+
+	var devEUI lorawan.EUI64
+	if v := field("DevEUI"); v != "" {
+		if err := devEUI.UnmarshalText([]byte(v)); err != nil {
+			return nil, fmt.Errorf("invalid DevEUI %q", v)
+		}
+	} else if devEUI, err = generateRandomEUI64(); err != nil {
+		return nil, err
+	}
+
+	var devAddr lorawan.DevAddr
+	if v := field("DevAddr"); v != "" {
+		if err := devAddr.UnmarshalText([]byte(v)); err != nil {
+			return nil, fmt.Errorf("invalid DevAddr %q", v)
+		}
+	}
+
+	var device *dev.Device
+	if devAddr == (lorawan.DevAddr{}) {
+		appKey, err := generateRandomKey()
+		if err != nil {
+			return nil, err
+		}
+		device = s.createDeviceFromTemplateOTAA(tmpl, tmpl.Name, devEUI, appKey, lat, lng, 0)
+	} else {
+		nwkSKey, err := generateRandomKey()
+		if err != nil {
+			return nil, err
+		}
+		appSKey, err := generateRandomKey()
+		if err != nil {
+			return nil, err
+		}
+		device = s.createDeviceFromTemplateABP(tmpl, tmpl.Name, devEUI, nwkSKey, appSKey, devAddr, lat, lng, 0)
+	}
+
+	if active := field("Active"); active != "" {
+		isActive, err := strconv.ParseBool(active)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Active %q", active)
+		}
+		device.Info.Status.Active = isActive
+	}
+
+	return device, nil
+}
@@ -0,0 +1,126 @@
+package simulator
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
+	"github.com/brocaar/lorawan"
+)
+
+func TestWriteDevicesCSV(t *testing.T) {
+	s := newTestSimulator(t)
+
+	devices := []struct {
+		name    string
+		region  int
+		classB  bool
+		classC  bool
+		active  bool
+		devAddr lorawan.DevAddr
+	}{
+		{"kitchen", rp.Code_Eu868, false, false, true, lorawan.DevAddr{1, 2, 3, 4}},
+		{"garage", rp.Code_Us915, true, false, false, lorawan.DevAddr{}},
+		{"attic", rp.Code_Eu868, true, true, true, lorawan.DevAddr{0, 0, 0, 1}},
+	}
+	for i, d := range devices {
+		device := newTestDevice(d.name, lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, byte(i + 1)})
+		device.Info.DevAddr = d.devAddr
+		device.Info.Configuration.Region = rp.GetRegionalParameters(d.region)
+		device.Info.Configuration.SupportedClassB = d.classB
+		device.Info.Configuration.SupportedClassC = d.classC
+		device.Info.Configuration.SendInterval = time.Duration(i+1) * time.Minute
+		device.Info.Status.Active = d.active
+		device.Info.Location.Latitude = 45.5
+		device.Info.Location.Longitude = float64(-i)
+		s.Devices[3-i] = device // IDs in reverse order of the names
+	}
+
+	var out strings.Builder
+	if err := s.WriteDevicesCSV(&out); err != nil {
+		t.Fatalf("WriteDevicesCSV() error = %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("the export is not valid CSV: %v", err)
+	}
+
+	want := [][]string{
+		DeviceCSVHeader,
+		{"0000000000000003", "00000001", "attic", "1", "C", "180", "true", "45.5", "-2"},
+		{"0000000000000002", "00000000", "garage", "2", "B", "120", "false", "45.5", "-1"},
+		{"0000000000000001", "01020304", "kitchen", "1", "A", "60", "true", "45.5", "0"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("export has %d rows, want %d:\n%s", len(records), len(want), out.String())
+	}
+	for i := range want {
+		if strings.Join(records[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d = %v, want %v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestImportDevicesCSV(t *testing.T) {
+	rows := []struct {
+		record  string
+		wantErr string // Empty when the row creates a device
+	}{
+		{"0102030405060708,01020304,kitchen,1,C,30,false,45.5,7.25", ""},
+		{",,garage,,,,,,", ""},
+		{",,cellar,1,D,,,,", "invalid Class"},
+		{"xyz,,attic,,,,,,", "invalid DevEUI"},
+		{"0102030405060708,,copy,,,,,,", "DevEUI already used"},
+		{",,lobby,x,,,,,", "invalid Region"},
+		{",,hall,,,,maybe,,", "invalid Active"},
+	}
+
+	lines := []string{strings.Join(DeviceCSVHeader, ",")}
+	for _, row := range rows {
+		lines = append(lines, row.record)
+	}
+
+	s := newTestSimulator(t)
+	summary, err := s.ImportDevicesCSV(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatalf("ImportDevicesCSV() error = %v", err)
+	}
+	if summary.Created != 2 || summary.Failed != 5 || len(summary.DeviceIDs) != 2 || len(summary.Errors) != 5 {
+		t.Fatalf("summary = %+v, want 2 created and 5 failed", summary)
+	}
+
+	errs := make(map[int]string, len(summary.Errors))
+	for _, e := range summary.Errors {
+		errs[e.Line] = e.Error
+	}
+	for i, row := range rows {
+		line := i + 2 // after the header
+		got, failed := errs[line]
+		switch {
+		case row.wantErr == "" && failed:
+			t.Errorf("line %d failed: %s", line, got)
+		case row.wantErr != "" && !strings.Contains(got, row.wantErr):
+			t.Errorf("line %d error = %q, want %q", line, got, row.wantErr)
+		}
+	}
+
+	kitchen := s.Devices[summary.DeviceIDs[0]]
+	if kitchen.Info.Name != "kitchen" || kitchen.Info.DevEUI != (lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}) ||
+		kitchen.Info.DevAddr != (lorawan.DevAddr{1, 2, 3, 4}) || kitchen.Info.Configuration.SupportedOtaa {
+		t.Errorf("kitchen imported as %s (DevEUI %s, DevAddr %s, OTAA %v), want an ABP device with its DevEUI and DevAddr",
+			kitchen.Info.Name, kitchen.Info.DevEUI, kitchen.Info.DevAddr, kitchen.Info.Configuration.SupportedOtaa)
+	}
+	if !kitchen.Info.Configuration.SupportedClassC || kitchen.Info.Configuration.SendInterval != 30*time.Second ||
+		kitchen.Info.Status.Active || kitchen.Info.Location.Latitude != 45.5 || kitchen.Info.Location.Longitude != 7.25 {
+		t.Errorf("kitchen configuration not imported: %+v", kitchen.Info.Configuration)
+	}
+
+	garage := s.Devices[summary.DeviceIDs[1]]
+	if garage.Info.Name != "garage" || garage.Info.DevEUI == (lorawan.EUI64{}) ||
+		!garage.Info.Configuration.SupportedOtaa || garage.Info.AppKey == ([16]byte{}) {
+		t.Errorf("garage imported as %s (DevEUI %s, OTAA %v, AppKey %x), want an OTAA device with generated DevEUI and AppKey",
+			garage.Info.Name, garage.Info.DevEUI, garage.Info.Configuration.SupportedOtaa, garage.Info.AppKey)
+	}
+}
@@ -0,0 +1,135 @@
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/codes"
+	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+// ValidateDeviceUpdate runs the checks of an update of device id to the proposed
+// configuration, and lists the fields it would change, without applying anything.
+// Unlike SetDevice it reports every problem, not only the first one.
+func (s *Simulator) ValidateDeviceUpdate(id int, proposed *dev.Device) (models.DeviceUpdateCheck, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	current, ok := s.Devices[id]
+	if !ok {
+		return models.DeviceUpdateCheck{}, errors.New("device not found")
+	}
+	proposed.Id = id
+
+	var problems []models.UpdateProblem
+	problem := func(code int, err error) {
+		problems = append(problems, models.UpdateProblem{Code: code, Error: err.Error()})
+	}
+
+	if proposed.Info.DevEUI == (lorawan.EUI64{}) {
+		problem(codes.CodeErrorAddress, errors.New("Error: DevEUI invalid"))
+	} else if code, err := s.searchAddress(proposed.Info.DevEUI, id, false); err != nil {
+		problem(code, err)
+	}
+
+	if err := s.checkDeviceReferences(&proposed.Info.Configuration); err != nil {
+		problem(codes.CodeErrorReference, err)
+	}
+
+	if err := proposed.Info.Configuration.Validate(); err != nil {
+		problem(codes.CodeErrorInvalidRequest, err)
+	}
+
+	if current.IsOn() {
+		problem(codes.CodeErrorDeviceActive, errors.New("Device is running, unable update"))
+	}
+
+	if err := util.ValidateName(proposed.Info.Name); err != nil {
+		problem(codes.CodeErrorName, err)
+	} else {
+		proposed.Info.Name = strings.TrimSpace(proposed.Info.Name)
+		if code, err := s.searchName(proposed.Info.Name, id, false); err != nil {
+			problem(code, err)
+		}
+	}
+
+	// The defaults SetDevice would give the device are not a change
+	s.applyRetransmissionDefaults(proposed)
+
+	changes, err := diffJSON(&current.Info, &proposed.Info)
+	if err != nil {
+		return models.DeviceUpdateCheck{}, err
+	}
+
+	return models.DeviceUpdateCheck{
+		Allowed:  len(problems) == 0,
+		Problems: problems,
+		Changes:  changes,
+	}, nil
+}
+
+// diffJSON returns the fields whose JSON encoding differs between old and new. Objects
+// are compared field by field, any other value (arrays included) as a whole.
+func diffJSON(old, new interface{}) ([]models.FieldChange, error) {
+
+	oldValue, err := toJSONValue(old)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := toJSONValue(new)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []models.FieldChange{}
+	diffValues("", oldValue, newValue, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes, nil
+}
+
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+func diffValues(path string, old, new interface{}, changes *[]models.FieldChange) {
+
+	oldObject, oldIsObject := old.(map[string]interface{})
+	newObject, newIsObject := new.(map[string]interface{})
+
+	if !oldIsObject || !newIsObject {
+		if !reflect.DeepEqual(old, new) {
+			*changes = append(*changes, models.FieldChange{Field: path, Old: old, New: new})
+		}
+		return
+	}
+
+	for key, value := range oldObject {
+		diffValues(joinPath(path, key), value, newObject[key], changes)
+	}
+	for key, value := range newObject {
+		if _, ok := oldObject[key]; !ok {
+			diffValues(joinPath(path, key), nil, value, changes)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
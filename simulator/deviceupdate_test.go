@@ -0,0 +1,104 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/codes"
+	devModels "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/brocaar/lorawan"
+)
+
+func TestValidateDeviceUpdate(t *testing.T) {
+	s := newTestSimulator(t)
+
+	_, first, err := s.SetDevice(newTestDevice("first", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}), false)
+	if err != nil {
+		t.Fatalf("SetDevice(first) error = %v", err)
+	}
+	if _, _, err := s.SetDevice(newTestDevice("second", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 2}), false); err != nil {
+		t.Fatalf("SetDevice(second) error = %v", err)
+	}
+
+	// Same configuration
+	check, err := s.ValidateDeviceUpdate(first, newTestDevice("first", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}))
+	if err != nil {
+		t.Fatalf("ValidateDeviceUpdate() error = %v", err)
+	}
+	if !check.Allowed || len(check.Problems) != 0 || len(check.Changes) != 0 {
+		t.Errorf("unchanged device: check = %+v, want allowed without changes", check)
+	}
+
+	// Name and DevEUI of the second device, and a new interval
+	proposed := newTestDevice("second", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 2})
+	proposed.Info.Configuration.SendInterval = time.Minute
+
+	check, err = s.ValidateDeviceUpdate(first, proposed)
+	if err != nil {
+		t.Fatalf("ValidateDeviceUpdate() error = %v", err)
+	}
+	if check.Allowed {
+		t.Error("update to the name and DevEUI of another device is allowed")
+	}
+
+	problems := map[int]bool{}
+	for _, p := range check.Problems {
+		problems[p.Code] = true
+	}
+	if len(check.Problems) != 2 || !problems[codes.CodeErrorName] || !problems[codes.CodeErrorAddress] {
+		t.Errorf("problems = %+v, want a name and an address conflict", check.Problems)
+	}
+
+	want := []string{"configuration.sendInterval", "devEUI", "name"}
+	if len(check.Changes) != len(want) {
+		t.Fatalf("changes = %+v, want %v", check.Changes, want)
+	}
+	for i, field := range want {
+		if check.Changes[i].Field != field {
+			t.Errorf("change %d = %q, want %q", i, check.Changes[i].Field, field)
+		}
+	}
+	if check.Changes[0].Old != float64(10) || check.Changes[0].New != float64(60) {
+		t.Errorf("sendInterval change = %v -> %v, want 10 -> 60", check.Changes[0].Old, check.Changes[0].New)
+	}
+
+	// Nothing was applied
+	if s.Devices[first].Info.Name != "first" {
+		t.Errorf("device name = %q after a validation, want first", s.Devices[first].Info.Name)
+	}
+
+	if _, err := s.ValidateDeviceUpdate(99, proposed); err == nil {
+		t.Error("ValidateDeviceUpdate() of a missing device succeeded, want an error")
+	}
+}
+
+func TestSetDeviceValidatesConfiguration(t *testing.T) {
+	s := newTestSimulator(t)
+
+	tests := []struct {
+		name   string
+		change func(conf *devModels.Configuration)
+		valid  bool
+	}{
+		{"valid", func(conf *devModels.Configuration) {}, true},
+		{"unknown profile", func(conf *devModels.Configuration) { conf.ProfileID = "removed-profile" }, false},
+		{"codec timeout", func(conf *devModels.Configuration) { conf.CodecTimeoutMs = 5 }, false},
+		{"data rate sweep", func(conf *devModels.Configuration) {
+			conf.DataRateSweep = &devModels.DataRateSweep{Min: 4, Max: 2, StepInterval: 60}
+		}, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDevice(tt.name, lorawan.EUI64{0, 0, 0, 0, 0, 0, 1, byte(i)})
+			tt.change(&d.Info.Configuration)
+
+			code, _, err := s.SetDevice(d, false)
+			if (err == nil) != tt.valid {
+				t.Fatalf("SetDevice() error = %v, want valid %v", err, tt.valid)
+			}
+			if !tt.valid && code != codes.CodeErrorInvalidRequest {
+				t.Errorf("SetDevice() code = %d, want CodeErrorInvalidRequest", code)
+			}
+		})
+	}
+}
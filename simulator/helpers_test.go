@@ -0,0 +1,51 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	devModels "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
+	gw "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/gateway"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/chirpstack"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/thingsboard"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+// newTestSimulator returns an empty simulator whose saves stay in memory for the test
+func newTestSimulator(t *testing.T) *Simulator {
+	t.Helper()
+	util.SetInMemory(true)
+	t.Cleanup(func() { util.SetInMemory(false) })
+
+	return &Simulator{
+		Devices:            map[int]*dev.Device{},
+		Gateways:           map[int]*gw.Gateway{},
+		ActiveDevices:      map[int]int{},
+		ActiveGateways:     map[int]int{},
+		Integrations:       map[int]*integration.Integration{},
+		IntegrationClients: map[int]*chirpstack.Client{},
+		ThingsBoardClients: map[int]*thingsboard.Client{},
+		Templates:          map[int]*template.DeviceTemplate{},
+	}
+}
+
+// newTestDevice returns a valid EU868 device sending every 10 seconds on FPort 1
+func newTestDevice(name string, devEUI lorawan.EUI64) *dev.Device {
+	fport := uint8(1)
+	d := &dev.Device{Info: devModels.InformationDevice{
+		Name:   name,
+		DevEUI: devEUI,
+		Status: devModels.Status{Payload: &lorawan.DataPayload{}},
+		Configuration: devModels.Configuration{
+			Region:       rp.GetRegionalParameters(rp.Code_Eu868),
+			SendInterval: 10 * time.Second,
+		},
+	}}
+	d.Info.Status.DataUplink.FPort = &fport
+	return d
+}
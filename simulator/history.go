@@ -0,0 +1,225 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/shared"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
+)
+
+// Defaults of the history settings
+const (
+	DefaultHistoryInterval = time.Minute
+	DefaultHistorySizeKB   = 1024
+)
+
+// historyFile is the file of the config directory the device console history is saved to
+const historyFile = "/history.json"
+
+// SetupHistory restores the console history of the devices saved by the previous run and
+// saves it every interval from now on, until Shutdown, if persistence is enabled. Nothing
+// is saved or restored in in-memory mode.
+func (s *Simulator) SetupHistory(config models.HistoryConfig) {
+	if !config.Persist || util.IsInMemory() {
+		return
+	}
+
+	interval := DefaultHistoryInterval
+	if config.IntervalSec > 0 {
+		interval = time.Duration(config.IntervalSec) * time.Second
+	}
+	stop := make(chan struct{})
+
+	s.mu.Lock()
+	s.stopHistory()
+	s.history = config
+	s.historyStop = stop
+	s.restoreHistory()
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.mu.RLock()
+				s.saveHistory()
+				s.mu.RUnlock()
+			}
+		}
+	}()
+	shared.DebugPrint(fmt.Sprintf("Device history saved every %v", interval))
+}
+
+// stopHistory stops the periodic saves started by SetupHistory. Called with s.mu held.
+func (s *Simulator) stopHistory() {
+	if s.historyStop != nil {
+		close(s.historyStop)
+		s.historyStop = nil
+	}
+}
+
+// restoreHistory loads the saved history into the devices still existing, with s.mu held
+func (s *Simulator) restoreHistory() {
+	pathDir, err := util.GetPath()
+	if err != nil {
+		shared.DebugPrint(fmt.Sprintf("Warning: failed to load device history: %v", err))
+		return
+	}
+	path := pathDir + historyFile
+	if !util.ConfigFileExists(path) {
+		return
+	}
+
+	history := make(map[int][]socket.ConsoleLog)
+	if err := util.RecoverConfigFile(path, &history); err != nil {
+		shared.DebugPrint(fmt.Sprintf("Warning: failed to load device history: %v", err))
+		return
+	}
+	for id, entries := range history {
+		if d, ok := s.Devices[id]; ok {
+			d.SetLogBuffer(entries)
+		}
+	}
+	shared.DebugPrint("Device history loaded from disk")
+}
+
+// saveHistory writes the console history of the devices to disk if persistence is
+// enabled, with s.mu held
+func (s *Simulator) saveHistory() {
+	if !s.history.Persist {
+		return
+	}
+
+	history := make(map[int][]socket.ConsoleLog, len(s.Devices))
+	for id, d := range s.Devices {
+		if entries := d.GetLogBuffer(); len(entries) > 0 {
+			history[id] = entries
+		}
+	}
+
+	maxSize := DefaultHistorySizeKB
+	if s.history.MaxSizeKB > 0 {
+		maxSize = s.history.MaxSizeKB
+	}
+	data, err := marshalHistory(history, maxSize<<10)
+	if err != nil {
+		shared.DebugPrint(fmt.Sprintf("Warning: failed to save device history: %v", err))
+		return
+	}
+
+	pathDir, err := util.GetPath()
+	if err != nil {
+		shared.DebugPrint(fmt.Sprintf("Warning: failed to save device history: %v", err))
+		return
+	}
+	if err := util.WriteConfigFile(pathDir+historyFile, data); err != nil {
+		shared.DebugPrint(fmt.Sprintf("Warning: failed to save device history: %v", err))
+	}
+}
+
+// marshalHistory encodes the history in at most maxSize bytes, dropping the oldest entries
+// of the longest histories first, the lowest device IDs first among equally long ones.
+// The histories are cut to a common length, found by a binary search, instead of
+// dropping one entry at a time.
+func marshalHistory(history map[int][]socket.ConsoleLog, maxSize int) ([]byte, error) {
+	ids := make([]int, 0, len(history))
+	longest := 0
+	for id, entries := range history {
+		ids = append(ids, id)
+		if len(entries) > longest {
+			longest = len(entries)
+		}
+	}
+	sort.Ints(ids)
+
+	// newest[id][n] is the size of the newest n entries of a device, with their separators
+	newest := make(map[int][]int, len(history))
+	for id, entries := range history {
+		sizes := make([]int, len(entries)+1)
+		for n := 1; n <= len(entries); n++ {
+			b, err := json.Marshal(entries[len(entries)-n])
+			if err != nil {
+				return nil, err
+			}
+			sizes[n] = sizes[n-1] + len(b)
+			if n > 1 {
+				sizes[n]++ // separator
+			}
+		}
+		newest[id] = sizes
+	}
+
+	// Size of the compact encoding, {"id":[entry,...],...}, of the newest limit entries of every device
+	deviceSize := func(id, n int) int {
+		if n == 0 {
+			return 0
+		}
+		return len(strconv.Itoa(id)) + 5 + newest[id][n]
+	}
+	size := func(limit int) (total, devices int) {
+		total = 2
+		for _, id := range ids {
+			if n := min(len(history[id]), limit); n > 0 {
+				total += deviceSize(id, n)
+				devices++
+			}
+		}
+		if devices > 1 {
+			total += devices - 1
+		}
+		return total, devices
+	}
+
+	// The first common length that doesn't fit: the histories are cut to it, then the
+	// ones that reach it lose one more entry, in the order of their IDs, until it fits
+	limit := sort.Search(longest+1, func(limit int) bool {
+		total, _ := size(limit)
+		return total > maxSize
+	})
+	if limit > longest {
+		return json.Marshal(history)
+	}
+
+	trimmed := make(map[int]bool)
+	if limit > 0 {
+		total, devices := size(limit)
+		for _, id := range ids {
+			if total <= maxSize {
+				break
+			}
+			if len(history[id]) < limit {
+				continue
+			}
+			trimmed[id] = true
+			total -= deviceSize(id, limit) - deviceSize(id, limit-1)
+			if limit == 1 {
+				if devices > 1 {
+					total-- // separator
+				}
+				devices--
+			}
+		}
+	}
+
+	kept := make(map[int][]socket.ConsoleLog, len(history))
+	for _, id := range ids {
+		n := min(len(history[id]), limit)
+		if trimmed[id] {
+			n = limit - 1
+		}
+		if n > 0 {
+			kept[id] = history[id][len(history[id])-n:]
+		}
+	}
+	return json.Marshal(kept)
+}
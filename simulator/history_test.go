@@ -0,0 +1,160 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
+)
+
+func TestMarshalHistory(t *testing.T) {
+	newHistory := func() map[int][]socket.ConsoleLog {
+		history := map[int][]socket.ConsoleLog{}
+		for id, n := range map[int]int{1: 10, 2: 4, 3: 1} {
+			for i := 0; i < n; i++ {
+				history[id] = append(history[id], socket.ConsoleLog{
+					Name: fmt.Sprintf("dev-%d", id),
+					Msg:  fmt.Sprintf("message %d", i),
+				})
+			}
+		}
+		return history
+	}
+
+	full, err := json.Marshal(newHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fitting: encoded as is
+	data, err := marshalHistory(newHistory(), len(full))
+	if err != nil {
+		t.Fatalf("marshalHistory() error = %v", err)
+	}
+	if string(data) != string(full) {
+		t.Errorf("marshalHistory() = %s, want %s", data, full)
+	}
+
+	for _, maxSize := range []int{len(full) - 1, len(full) / 2, 200, 50, 2} {
+		data, err := marshalHistory(newHistory(), maxSize)
+		if err != nil {
+			t.Fatalf("marshalHistory(%d) error = %v", maxSize, err)
+		}
+		if len(data) > maxSize && maxSize >= len("{}") {
+			t.Errorf("marshalHistory(%d) = %d bytes, want at most %d", maxSize, len(data), maxSize)
+		}
+
+		var got map[int][]socket.ConsoleLog
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("marshalHistory(%d) is not valid JSON: %v", maxSize, err)
+		}
+		// The newest entries are kept
+		for id, entries := range got {
+			want := newHistory()[id]
+			if last := entries[len(entries)-1]; last != want[len(want)-1] {
+				t.Errorf("marshalHistory(%d): last entry of device %d = %+v, want %+v", maxSize, id, last, want[len(want)-1])
+			}
+		}
+	}
+
+	// The longest history is trimmed first
+	data, err = marshalHistory(newHistory(), len(full)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[int][]socket.ConsoleLog
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got[1]) != 9 || len(got[2]) != 4 || len(got[3]) != 1 {
+		t.Errorf("history lengths = %d/%d/%d, want 9/4/1", len(got[1]), len(got[2]), len(got[3]))
+	}
+}
+
+// trimHistory is the reference for marshalHistory: it drops the oldest entry of the
+// longest history, the lowest device ID first, one at a time until the encoding fits.
+func trimHistory(t *testing.T, history map[int][]socket.ConsoleLog, maxSize int) []byte {
+	for {
+		data, err := json.Marshal(history)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) <= maxSize || len(history) == 0 {
+			return data
+		}
+		longest := -1
+		for id, entries := range history {
+			if longest == -1 || len(entries) > len(history[longest]) ||
+				len(entries) == len(history[longest]) && id < longest {
+				longest = id
+			}
+		}
+		if len(history[longest]) == 1 {
+			delete(history, longest)
+		} else {
+			history[longest] = history[longest][1:]
+		}
+	}
+}
+
+func TestMarshalHistoryTrimming(t *testing.T) {
+	tests := []struct {
+		name    string
+		lengths map[int]int
+	}{
+		{"single device", map[int]int{7: 20}},
+		{"equal lengths", map[int]int{1: 5, 2: 5, 3: 5}},
+		{"mixed lengths", map[int]int{1: 10, 2: 4, 3: 1, 12: 7}},
+		{"many devices", map[int]int{1: 3, 2: 30, 3: 12, 4: 12, 10: 1, 100: 25, 1000: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newHistory := func() map[int][]socket.ConsoleLog {
+				history := map[int][]socket.ConsoleLog{}
+				for id, n := range tt.lengths {
+					for i := 0; i < n; i++ {
+						history[id] = append(history[id], socket.ConsoleLog{
+							Name: fmt.Sprintf("dev-%d", id),
+							Msg:  fmt.Sprintf("message %d", i*i), // entries of different sizes
+						})
+					}
+				}
+				return history
+			}
+			full, err := json.Marshal(newHistory())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for maxSize := 0; maxSize <= len(full)+1; maxSize++ {
+				data, err := marshalHistory(newHistory(), maxSize)
+				if err != nil {
+					t.Fatalf("marshalHistory(%d) error = %v", maxSize, err)
+				}
+				if want := trimHistory(t, newHistory(), maxSize); string(data) != string(want) {
+					t.Fatalf("marshalHistory(%d) = %s, want %s", maxSize, data, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStopHistory(t *testing.T) {
+	s := newTestSimulator(t)
+	stop := make(chan struct{})
+	s.historyStop = stop
+
+	s.Shutdown()
+
+	select {
+	case <-stop:
+	default:
+		t.Error("Shutdown() did not stop the history saves")
+	}
+	if s.historyStop != nil {
+		t.Error("historyStop is still set after Shutdown()")
+	}
+	s.Shutdown() // stopping twice doesn't panic
+}
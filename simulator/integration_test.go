@@ -0,0 +1,26 @@
+package simulator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+)
+
+func TestDeleteIntegrationReferencedByTemplate(t *testing.T) {
+	s := newTestSimulator(t)
+	s.Integrations[1] = &integration.Integration{ID: 1, Name: "cs"}
+	s.Templates[1] = &template.DeviceTemplate{ID: 1, Name: "sensor", IntegrationEnabled: true, IntegrationID: 1}
+
+	err := s.DeleteIntegration(1)
+	if err == nil {
+		t.Fatal("DeleteIntegration() succeeded, want an error for the template reference")
+	}
+	if !strings.Contains(err.Error(), "1 template(s)") {
+		t.Errorf("DeleteIntegration() error = %q, want it to mention the template", err)
+	}
+	if _, ok := s.Integrations[1]; !ok {
+		t.Error("integration was deleted despite the template reference")
+	}
+}
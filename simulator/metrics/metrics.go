@@ -26,8 +26,18 @@ var (
 		Help: "Total downlinks received",
 	})
 
+	DownlinksMissedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "lwnsim_downlinks_missed_total",
+		Help: "Total downlinks dropped because the receive window of the device was closed",
+	})
+
 	OtaaJoinsTotal = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "lwnsim_otaa_joins_total",
 		Help: "Total successful OTAA joins",
 	})
+
+	GatewayUplinkBufferDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gateway_uplink_buffer_depth",
+		Help: "Number of uplinks waiting in the gateway buffer",
+	}, []string{"gateway"})
 )
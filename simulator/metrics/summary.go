@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Summary is a JSON snapshot of the simulator counters
+type Summary struct {
+	Uplinks         uint64 `json:"uplinks"`
+	Downlinks       uint64 `json:"downlinks"`
+	DownlinksMissed uint64 `json:"downlinksMissed"`
+	OtaaJoins       uint64 `json:"otaaJoins"`
+	GatewayPushData uint64 `json:"gatewayPushData"`
+	GatewayPushAck  uint64 `json:"gatewayPushAck"`
+	GatewayPullData uint64 `json:"gatewayPullData"`
+	GatewayPullAck  uint64 `json:"gatewayPullAck"`
+	GatewayPullResp uint64 `json:"gatewayPullResp"`
+}
+
+// fields maps the registered counter names to the summary fields
+func (s *Summary) fields() map[string]*uint64 {
+	return map[string]*uint64{
+		"lwnsim_uplinks_total":          &s.Uplinks,
+		"lwnsim_downlinks_total":        &s.Downlinks,
+		"lwnsim_downlinks_missed_total": &s.DownlinksMissed,
+		"lwnsim_otaa_joins_total":       &s.OtaaJoins,
+		"gateway_data_sent_total":       &s.GatewayPushData,
+		"gateway_push_ack_total":        &s.GatewayPushAck,
+		"gateway_pull_data_total":       &s.GatewayPullData,
+		"gateway_pull_ack_total":        &s.GatewayPullAck,
+		"gateway_pull_resp_total":       &s.GatewayPullResp,
+	}
+}
+
+// GatherSummary reads the current counter values from the gatherer (usually
+// prometheus.DefaultGatherer). Counters not registered yet are reported as 0.
+func GatherSummary(gatherer prometheus.Gatherer) (Summary, error) {
+	var summary Summary
+
+	families, err := gatherer.Gather()
+	if err != nil {
+		return summary, err
+	}
+
+	fields := summary.fields()
+	for _, family := range families {
+		field, ok := fields[family.GetName()]
+		if !ok {
+			continue
+		}
+		*field = uint64(sumCounters(family.GetMetric()))
+	}
+
+	return summary, nil
+}
+
+// sumCounters adds the values of every label combination of a counter
+func sumCounters(metrics []*dto.Metric) float64 {
+	var total float64
+	for _, m := range metrics {
+		total += m.GetCounter().GetValue()
+	}
+	return total
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestGatherSummary(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	uplinks := prometheus.NewCounter(prometheus.CounterOpts{Name: "lwnsim_uplinks_total"})
+	pushData := prometheus.NewCounter(prometheus.CounterOpts{Name: "gateway_data_sent_total"})
+	other := prometheus.NewCounter(prometheus.CounterOpts{Name: "unrelated_total"})
+	registry.MustRegister(uplinks, pushData, other)
+
+	uplinks.Add(3)
+	pushData.Add(2)
+	other.Add(10)
+
+	summary, err := GatherSummary(registry)
+	if err != nil {
+		t.Fatalf("GatherSummary: %v", err)
+	}
+	want := Summary{Uplinks: 3, GatewayPushData: 2}
+	if summary != want {
+		t.Errorf("GatherSummary() = %+v, want %+v", summary, want)
+	}
+}
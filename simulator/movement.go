@@ -0,0 +1,148 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/location"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
+)
+
+const (
+	// MovementInterval is how often a moving device updates its position
+	MovementInterval = time.Second
+	// MaxMovementSpeed is the highest accepted speed in meters per second
+	MaxMovementSpeed = 350.0
+)
+
+// StartMovement moves a running device along the given waypoints, replacing any movement in progress.
+// The movement ends at the last waypoint (unless looping), on StopMovement or when the device is turned off.
+func (s *Simulator) StartMovement(m socket.Movement) error {
+
+	d, ok := s.device(m.Id)
+	if !ok {
+		return errors.New("device not found")
+	}
+	if !d.IsOn() {
+		return errors.New("device must be running to move")
+	}
+	if len(m.Waypoints) < 2 {
+		return errors.New("at least two waypoints are required")
+	}
+	if m.Speed <= 0 || m.Speed > MaxMovementSpeed {
+		return fmt.Errorf("speed must be between 0 and %v m/s", MaxMovementSpeed)
+	}
+
+	path := location.Path{Loop: m.Loop}
+	for i, w := range m.Waypoints {
+		if w.Latitude < -90 || w.Latitude > 90 || w.Longitude < -180 || w.Longitude > 180 {
+			return fmt.Errorf("waypoint %d: invalid coordinates", i)
+		}
+		path.Waypoints = append(path.Waypoints, location.Location{
+			Latitude:  w.Latitude,
+			Longitude: w.Longitude,
+			Altitude:  w.Altitude,
+		})
+	}
+	if path.Length() == 0 {
+		return errors.New("waypoints must not all be at the same position")
+	}
+
+	stop := make(chan struct{})
+	s.movementMu.Lock()
+	if s.movements == nil {
+		s.movements = make(map[int]chan struct{})
+	}
+	if old, ok := s.movements[m.Id]; ok {
+		close(old)
+	}
+	s.movements[m.Id] = stop
+	s.movementMu.Unlock()
+
+	d.Print(fmt.Sprintf("Moving along %d waypoints at %v m/s", len(path.Waypoints), m.Speed), nil, util.PrintBoth)
+
+	// Start from the first waypoint
+	s.moveDevice(m.Id, path.Waypoints[0])
+	go s.runMovement(m.Id, path, m.Speed, stop)
+
+	return nil
+}
+
+// StopMovement stops the movement of a device, leaving it at its current position
+func (s *Simulator) StopMovement(id int) bool {
+	s.movementMu.Lock()
+	defer s.movementMu.Unlock()
+
+	stop, ok := s.movements[id]
+	if !ok {
+		return false
+	}
+	close(stop)
+	delete(s.movements, id)
+
+	return true
+}
+
+// runMovement advances the device along the path every MovementInterval until stopped
+func (s *Simulator) runMovement(id int, path location.Path, speed float64, stop chan struct{}) {
+	ticker := time.NewTicker(MovementInterval)
+	defer ticker.Stop()
+	defer s.endMovement(id, stop)
+
+	step := speed * MovementInterval.Seconds()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			position, arrived := path.Advance(step)
+			if !s.moveDevice(id, position) {
+				return
+			}
+			if arrived {
+				if d, ok := s.device(id); ok {
+					d.Print("Reached the last waypoint", nil, util.PrintBoth)
+				}
+				return
+			}
+		}
+	}
+}
+
+// moveDevice changes the location of the device and notifies the UI; it fails if the device is gone or turned off
+func (s *Simulator) moveDevice(id int, position location.Location) bool {
+	d, ok := s.device(id)
+	if !ok {
+		return false
+	}
+	if !s.ChangeLocation(socket.NewLocation{
+		Id:        id,
+		Latitude:  position.Latitude,
+		Longitude: position.Longitude,
+		Altitude:  position.Altitude,
+	}) {
+		return false
+	}
+
+	s.Console.PrintSocket(socket.EventDevLocation, socket.DevLocation{
+		Id:        id,
+		DevEUI:    d.Info.DevEUI,
+		Latitude:  position.Latitude,
+		Longitude: position.Longitude,
+		Altitude:  position.Altitude,
+	})
+
+	return true
+}
+
+// endMovement forgets the movement of the device, unless it has already been replaced
+func (s *Simulator) endMovement(id int, stop chan struct{}) {
+	s.movementMu.Lock()
+	defer s.movementMu.Unlock()
+
+	if s.movements[id] == stop {
+		delete(s.movements, id)
+	}
+}
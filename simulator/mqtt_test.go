@@ -0,0 +1,38 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/output/mqtt"
+)
+
+func TestSetMQTT(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       mqtt.Config
+		wantErr      bool
+		wantPassword string
+	}{
+		{"invalid broker", mqtt.Config{Enabled: true, BrokerURL: "http://broker"}, true, "stored"},
+		{"disabled with invalid broker", mqtt.Config{BrokerURL: "http://broker"}, false, ""},
+		{"redacted password kept", mqtt.Config{BrokerURL: "tcp://broker", Password: mqtt.RedactedPassword}, false, "stored"},
+		{"new password", mqtt.Config{BrokerURL: "tcp://broker", Password: "new"}, false, "new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSimulator(t)
+			s.MQTT = mqtt.Config{BrokerURL: "tcp://broker", Password: "stored"}
+			s.Console.MQTT = mqtt.NewPublisher(mqtt.Config{})
+
+			if err := s.SetMQTT(tt.config); (err != nil) != tt.wantErr {
+				t.Fatalf("SetMQTT() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if s.MQTT.Password != tt.wantPassword {
+				t.Errorf("stored password = %q, want %q", s.MQTT.Password, tt.wantPassword)
+			}
+			if got := s.GetMQTT().Password; got != "" && got != mqtt.RedactedPassword {
+				t.Errorf("GetMQTT() returned the password %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/shared"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+)
+
+// SaveDelay is how long changes are collected before being written to disk, so that
+// bulk operations write each file once instead of once per component
+const SaveDelay = time.Second
+
+// pendingSaves holds the latest content of the files waiting to be written
+type pendingSaves struct {
+	mu      sync.Mutex
+	files   map[string][]byte
+	timer   *time.Timer
+	writeMu sync.Mutex // Keeps flushes in order, so that an older content never overwrites a newer one
+}
+
+// saveComponent serializes the component right away and schedules the write of the
+// file. Writes happen at most once per SaveDelay, with the latest content.
+// Nothing is saved in in-memory mode.
+func (s *Simulator) saveComponent(path string, v interface{}) {
+	if util.IsInMemory() {
+		return
+	}
+	bytes, err := json.MarshalIndent(&v, "", "\t")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s.saves.mu.Lock()
+	defer s.saves.mu.Unlock()
+	if s.saves.files == nil {
+		s.saves.files = make(map[string][]byte)
+	}
+	s.saves.files[path] = bytes
+	if s.saves.timer == nil {
+		s.saves.timer = time.AfterFunc(SaveDelay, s.flushSaves)
+	}
+}
+
+// flushSaves writes the pending files to disk now
+func (s *Simulator) flushSaves() {
+	s.saves.writeMu.Lock()
+	defer s.saves.writeMu.Unlock()
+
+	s.saves.mu.Lock()
+	files := s.saves.files
+	s.saves.files = nil
+	if s.saves.timer != nil {
+		s.saves.timer.Stop()
+		s.saves.timer = nil
+	}
+	s.saves.mu.Unlock()
+
+	for path, bytes := range files {
+		shared.DebugPrint(fmt.Sprintf("Saving component %s on disk", path))
+		if err := util.WriteConfigFile(path, bytes); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package simulator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+)
+
+func TestShutdownWritesPendingSaves(t *testing.T) {
+	s := newTestSimulator(t)
+	util.SetInMemory(false) // the file is written by path, without the config directory
+
+	// Writing reads the compression setting from config.json, in the working directory
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	path := filepath.Join(dir, "devices.json")
+	s.saves.files = map[string][]byte{path: []byte("{}")}
+	s.saves.timer = time.AfterFunc(time.Hour, s.flushSaves) // not due before the process exits
+
+	s.Shutdown()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("pending save not written: %v", err)
+	}
+	if string(data) != "{}" {
+		t.Errorf("file content = %q, want {}", data)
+	}
+	if s.saves.timer != nil || len(s.saves.files) != 0 {
+		t.Error("saves still pending after Shutdown()")
+	}
+}
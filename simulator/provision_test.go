@@ -0,0 +1,159 @@
+package simulator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/chirpstack"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/thingsboard"
+	"github.com/brocaar/lorawan"
+)
+
+func TestAddDeviceSkipProvisioning(t *testing.T) {
+	s := newTestSimulator(t)
+
+	var created atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound) // looked up before being provisioned
+		} else if r.Method == http.MethodPost && r.URL.Path == "/api/devices" {
+			created.Add(1)
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	s.Integrations[1] = &integration.Integration{ID: 1, Name: "cs", Type: integration.IntegrationTypeChirpStack, Enabled: true}
+	s.IntegrationClients[1] = chirpstack.NewClient(server.URL, "key")
+
+	newDevice := func(name string, devEUI lorawan.EUI64, integrationEnabled bool) *dev.Device {
+		d := newTestDevice(name, devEUI)
+		d.Info.Configuration.IntegrationEnabled = integrationEnabled
+		d.Info.Configuration.IntegrationID = 1
+		return d
+	}
+
+	_, id, err := s.AddDevice(newDevice("later", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1}, true), true)
+	if err != nil {
+		t.Fatalf("AddDevice(skipProvisioning) error = %v", err)
+	}
+	if n := created.Load(); n != 0 {
+		t.Fatalf("AddDevice(skipProvisioning) created %d devices in ChirpStack, want 0", n)
+	}
+
+	if err := s.ProvisionDeviceIntegrations(id); err != nil {
+		t.Fatalf("ProvisionDeviceIntegrations() error = %v", err)
+	}
+	if n := created.Load(); n != 1 {
+		t.Errorf("ProvisionDeviceIntegrations() created %d devices in ChirpStack, want 1", n)
+	}
+
+	if _, _, err := s.AddDevice(newDevice("now", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 2}, true), false); err != nil {
+		t.Fatalf("AddDevice() error = %v", err)
+	}
+	if n := created.Load(); n != 2 {
+		t.Errorf("AddDevice() created %d devices in ChirpStack in total, want 2", n)
+	}
+
+	_, local, err := s.AddDevice(newDevice("local", lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 3}, false), true)
+	if err != nil {
+		t.Fatalf("AddDevice(local) error = %v", err)
+	}
+	if err := s.ProvisionDeviceIntegrations(local); err == nil {
+		t.Error("ProvisionDeviceIntegrations() succeeded for a device without integration, want an error")
+	}
+	if err := s.ProvisionDeviceIntegrations(99); err == nil {
+		t.Error("ProvisionDeviceIntegrations() succeeded for an unknown device, want an error")
+	}
+}
+
+func TestProvisionDeviceIntegrationsAgain(t *testing.T) {
+	s := newTestSimulator(t)
+
+	var csLookup, csCreated, tbCreated, tbDeleted atomic.Int32
+	chirpStack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(int(csLookup.Load()))
+		} else if r.Method == http.MethodPost && r.URL.Path == "/api/devices" {
+			csCreated.Add(1)
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer chirpStack.Close()
+	thingsBoard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			tbCreated.Add(1)
+			w.Write([]byte(`{"id":{"id":"tb-device"}}`))
+		case r.Method == http.MethodDelete:
+			tbDeleted.Add(1)
+		default:
+			w.Write([]byte(`{"credentialsType":"ACCESS_TOKEN","credentialsId":"token"}`))
+		}
+	}))
+	defer thingsBoard.Close()
+
+	client := chirpstack.NewClient(chirpStack.URL, "key")
+	client.SetRetryPolicy(0, 0)
+	s.Integrations[1] = &integration.Integration{ID: 1, Name: "cs", Type: integration.IntegrationTypeChirpStack, Enabled: true}
+	s.Integrations[2] = &integration.Integration{ID: 2, Name: "tb", Type: integration.IntegrationTypeThingsBoard, Enabled: true}
+	s.IntegrationClients[1] = client
+	s.ThingsBoardClients[2] = thingsboard.NewClient(thingsBoard.URL, "key")
+
+	tests := []struct {
+		name          string
+		csLookup      int // status of the ChirpStack device lookup
+		tbDeviceID    string
+		wantErr       bool
+		wantCSCreated int32
+		wantTBCreated int32
+		wantTBDeleted int32
+		wantTBDevice  string
+	}{
+		// ThingsBoard failed the first time: ChirpStack already has the device
+		{"in ChirpStack", http.StatusOK, "", false, 0, 1, 0, "tb-device"},
+		{"missing everywhere", http.StatusNotFound, "", false, 1, 1, 0, "tb-device"},
+		{"everywhere", http.StatusOK, "tb-device", false, 0, 0, 0, "tb-device"},
+		{"ChirpStack unreachable", http.StatusInternalServerError, "", true, 0, 1, 1, ""},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csLookup.Store(int32(tt.csLookup))
+			csCreated.Store(0)
+			tbCreated.Store(0)
+			tbDeleted.Store(0)
+
+			d := newTestDevice(tt.name, lorawan.EUI64{0, 0, 0, 0, 0, 0, 1, byte(i)})
+			d.Info.Configuration.IntegrationEnabled = true
+			d.Info.Configuration.IntegrationID = 1
+			d.Info.Configuration.TBIntegrationEnabled = true
+			d.Info.Configuration.TBIntegrationID = 2
+			d.Info.Configuration.TBDeviceID = tt.tbDeviceID
+			_, id, err := s.AddDevice(d, true)
+			if err != nil {
+				t.Fatalf("AddDevice() error = %v", err)
+			}
+
+			err = s.ProvisionDeviceIntegrations(id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProvisionDeviceIntegrations() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := csCreated.Load(); got != tt.wantCSCreated {
+				t.Errorf("%d devices created in ChirpStack, want %d", got, tt.wantCSCreated)
+			}
+			if got := tbCreated.Load(); got != tt.wantTBCreated {
+				t.Errorf("%d devices created in ThingsBoard, want %d", got, tt.wantTBCreated)
+			}
+			if got := tbDeleted.Load(); got != tt.wantTBDeleted {
+				t.Errorf("%d devices deleted from ThingsBoard, want %d", got, tt.wantTBDeleted)
+			}
+			if got := s.Devices[id].Info.Configuration.TBDeviceID; got != tt.wantTBDevice {
+				t.Errorf("TBDeviceID = %q, want %q", got, tt.wantTBDevice)
+			}
+		})
+	}
+}
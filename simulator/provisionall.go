@@ -0,0 +1,118 @@
+package simulator
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/chirpstack"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+)
+
+// ProvisionIntegrationDevices provisions to a ChirpStack integration every device linked to it
+// that is missing there, with OTAA or ABP depending on the device. It recovers from devices
+// imported or added while the network server was unreachable; devices already present are
+// left untouched.
+func (s *Simulator) ProvisionIntegrationDevices(integrationID int) (models.ProvisionSummary, error) {
+	client, err := s.chirpStackClient(integrationID)
+	if err != nil {
+		return models.ProvisionSummary{}, err
+	}
+
+	// The requests can take a while for many devices, so they are made without holding s.mu
+	s.mu.RLock()
+	var devices []*dev.Device
+	for _, d := range s.Devices {
+		conf := d.Info.Configuration
+		if conf.IntegrationEnabled && conf.IntegrationID == integrationID {
+			devices = append(devices, d)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Id < devices[j].Id })
+
+	summary := models.ProvisionSummary{Results: make([]models.ProvisionResult, 0, len(devices))}
+	for _, d := range devices {
+		result := s.provisionMissingDevice(client, d)
+		switch result.Status {
+		case models.ProvisionStatusProvisioned:
+			summary.Provisioned++
+		case models.ProvisionStatusExists:
+			summary.Existing++
+		default:
+			summary.Failed++
+		}
+		summary.Results = append(summary.Results, result)
+	}
+
+	s.Print(fmt.Sprintf("Provisioned %d devices to integration %d (%d already present, %d failed)",
+		summary.Provisioned, integrationID, summary.Existing, summary.Failed), nil, util.PrintOnlyConsole)
+	return summary, nil
+}
+
+// provisionMissingDevice creates a device in ChirpStack unless it is already there. The
+// ThingsBoard access token of the device, if it has one, is passed as in provisionDevice.
+func (s *Simulator) provisionMissingDevice(client *chirpstack.Client, d *dev.Device) models.ProvisionResult {
+	devEUI := hex.EncodeToString(d.Info.DevEUI[:])
+	result := models.ProvisionResult{ID: d.Id, Name: d.Info.Name, DevEUI: devEUI}
+	fail := func(err error) models.ProvisionResult {
+		result.Status = models.ProvisionStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	exists, err := client.DeviceExists(devEUI)
+	if err != nil {
+		return fail(fmt.Errorf("failed to check the device: %w", err))
+	}
+	if exists {
+		result.Status = models.ProvisionStatusExists
+		return result
+	}
+
+	var variables map[string]string
+	conf := d.Info.Configuration
+	if conf.TBIntegrationEnabled && conf.TBDeviceID != "" {
+		tbClient, ok := s.thingsBoardClient(conf.TBIntegrationID)
+		if !ok {
+			return fail(errors.New("ThingsBoard client not initialized for this integration"))
+		}
+		token, err := tbClient.GetDeviceCredentials(conf.TBDeviceID)
+		if err != nil {
+			return fail(fmt.Errorf("ThingsBoard access-token fetch failed: %w", err))
+		}
+		variables = map[string]string{"ThingsBoardAccessToken": token}
+	}
+
+	if err := s.provisionChirpStack(d, variables); err != nil {
+		return fail(err)
+	}
+	result.Status = models.ProvisionStatusProvisioned
+	return result
+}
+
+// chirpStackClient returns the client of an enabled ChirpStack integration
+func (s *Simulator) chirpStackClient(integrationID int) (*chirpstack.Client, error) {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
+
+	integ, ok := s.Integrations[integrationID]
+	if !ok {
+		return nil, integration.ErrIntegrationNotFound
+	}
+	if integ.Type != integration.IntegrationTypeChirpStack {
+		return nil, errors.New("devices can only be provisioned in bulk to a ChirpStack integration")
+	}
+	if !integ.Enabled {
+		return nil, errors.New("integration is disabled")
+	}
+	client, ok := s.IntegrationClients[integrationID]
+	if !ok {
+		return nil, errors.New("client not initialized for this integration")
+	}
+	return client, nil
+}
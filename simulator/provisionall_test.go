@@ -0,0 +1,101 @@
+package simulator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/chirpstack"
+	"github.com/brocaar/lorawan"
+)
+
+func TestProvisionIntegrationDevices(t *testing.T) {
+	s := newTestSimulator(t)
+
+	// ChirpStack knows the device ...01 and fails to look up ...04
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Method == http.MethodGet {
+			switch {
+			case strings.HasSuffix(r.URL.Path, "01"):
+			case strings.HasSuffix(r.URL.Path, "04"):
+				w.WriteHeader(http.StatusInternalServerError)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	client := chirpstack.NewClient(server.URL, "key")
+	client.SetRetryPolicy(0, 0)
+	s.Integrations[1] = &integration.Integration{ID: 1, Name: "cs", Type: integration.IntegrationTypeChirpStack, Enabled: true}
+	s.Integrations[2] = &integration.Integration{ID: 2, Name: "tb", Type: integration.IntegrationTypeThingsBoard, Enabled: true}
+	s.Integrations[3] = &integration.Integration{ID: 3, Name: "other", Type: integration.IntegrationTypeChirpStack, Enabled: true}
+	s.IntegrationClients[1] = client
+
+	addDevice := func(name string, last byte, otaa bool, integrationID int) {
+		t.Helper()
+		d := newTestDevice(name, lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, last})
+		d.Info.Configuration.SupportedOtaa = otaa
+		d.Info.Configuration.IntegrationEnabled = true
+		d.Info.Configuration.IntegrationID = integrationID
+		if _, _, err := s.AddDevice(d, true); err != nil {
+			t.Fatalf("AddDevice(%s) error = %v", name, err)
+		}
+	}
+	addDevice("present", 1, false, 1)
+	addDevice("otaa", 2, true, 1)
+	addDevice("abp", 3, false, 1)
+	addDevice("unreachable", 4, false, 1)
+	addDevice("elsewhere", 5, false, 3)
+
+	summary, err := s.ProvisionIntegrationDevices(1)
+	if err != nil {
+		t.Fatalf("ProvisionIntegrationDevices() error = %v", err)
+	}
+	if summary.Provisioned != 2 || summary.Existing != 1 || summary.Failed != 1 {
+		t.Errorf("summary = %d provisioned, %d existing, %d failed, want 2, 1, 1",
+			summary.Provisioned, summary.Existing, summary.Failed)
+	}
+	want := []string{
+		models.ProvisionStatusExists,
+		models.ProvisionStatusProvisioned,
+		models.ProvisionStatusProvisioned,
+		models.ProvisionStatusFailed,
+	}
+	if len(summary.Results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(summary.Results), len(want), summary.Results)
+	}
+	for i, status := range want {
+		if r := summary.Results[i]; r.Status != status {
+			t.Errorf("result of %s = %q (%s), want %q", r.Name, r.Status, r.Error, status)
+		}
+	}
+
+	joined := strings.Join(requests, "\n")
+	for _, req := range []string{"POST /api/devices/0000000000000002/keys", "POST /api/devices/0000000000000003/activate"} {
+		if !strings.Contains(joined, req) {
+			t.Errorf("missing request %q in:\n%s", req, joined)
+		}
+	}
+	if strings.Contains(joined, "0000000000000005") {
+		t.Errorf("device of another integration was provisioned:\n%s", joined)
+	}
+
+	if _, err := s.ProvisionIntegrationDevices(2); err == nil {
+		t.Error("ProvisionIntegrationDevices() succeeded for a ThingsBoard integration, want an error")
+	}
+	if _, err := s.ProvisionIntegrationDevices(9); err == nil {
+		t.Error("ProvisionIntegrationDevices() succeeded for an unknown integration, want an error")
+	}
+}
@@ -0,0 +1,77 @@
+package simulator
+
+import (
+	"fmt"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+)
+
+// checkTemplateReferences returns an error if the codec or an integration enabled on
+// the template no longer exists, so that no device is created with a broken reference
+func (s *Simulator) checkTemplateReferences(tmpl *template.DeviceTemplate) error {
+	if tmpl.UseCodec {
+		if err := s.checkCodec(tmpl.CodecID); err != nil {
+			return fmt.Errorf("template '%s': %w", tmpl.Name, err)
+		}
+	}
+	if tmpl.IntegrationEnabled {
+		if err := s.checkIntegration(tmpl.IntegrationID); err != nil {
+			return fmt.Errorf("template '%s': %w", tmpl.Name, err)
+		}
+	}
+	if tmpl.TBIntegrationEnabled {
+		if err := s.checkIntegration(tmpl.TBIntegrationID); err != nil {
+			return fmt.Errorf("template '%s': %w", tmpl.Name, err)
+		}
+	}
+	return nil
+}
+
+// checkDeviceReferences returns an error if the codec or an integration enabled on
+// the device configuration does not exist
+func (s *Simulator) checkDeviceReferences(config *models.Configuration) error {
+	if config.UseCodec {
+		if err := s.checkCodec(config.CodecID); err != nil {
+			return err
+		}
+	}
+	if config.IntegrationEnabled {
+		if err := s.checkIntegration(config.IntegrationID); err != nil {
+			return err
+		}
+	}
+	if config.TBIntegrationEnabled {
+		if err := s.checkIntegration(config.TBIntegrationID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCodec returns an error if the codec is not in the library (0 means no codec)
+func (s *Simulator) checkCodec(id int) error {
+	if id == 0 {
+		return nil
+	}
+	if dev.Codecs == nil {
+		return fmt.Errorf("%w: %d", codec.ErrCodecNotFound, id)
+	}
+	if _, err := dev.Codecs.GetCodec(id); err != nil {
+		return fmt.Errorf("%w: %d", codec.ErrCodecNotFound, id)
+	}
+	return nil
+}
+
+// checkIntegration returns an error if the integration does not exist
+func (s *Simulator) checkIntegration(id int) error {
+	s.integrationsMu.RLock()
+	defer s.integrationsMu.RUnlock()
+	if _, exists := s.Integrations[id]; !exists {
+		return fmt.Errorf("%w: %d", integration.ErrIntegrationNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package simulator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/codes"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+	"github.com/brocaar/lorawan"
+)
+
+// referencesTests are the codec and integration references checked before a device is created;
+// codec 1, one of the defaults, and integration 1 exist
+var referencesTests = []struct {
+	name            string
+	codecID         int
+	integrationID   int
+	tbIntegrationID int
+	wantErr         error
+}{
+	{"existing references", 1, 1, 1, nil},
+	{"no references", 0, 0, 0, nil},
+	{"missing codec", 999, 1, 1, codec.ErrCodecNotFound},
+	{"missing integration", 1, 2, 1, integration.ErrIntegrationNotFound},
+	{"missing ThingsBoard integration", 1, 1, 2, integration.ErrIntegrationNotFound},
+}
+
+// newReferencesSimulator returns a simulator with the default codecs and integration 1,
+// disabled so that no device is provisioned
+func newReferencesSimulator(t *testing.T) *Simulator {
+	t.Helper()
+	s := newTestSimulator(t)
+
+	previous := dev.Codecs
+	dev.Codecs = codec.NewRegistry(nil)
+	t.Cleanup(func() { dev.Codecs = previous })
+
+	s.Integrations[1] = &integration.Integration{ID: 1, Name: "network server", Type: integration.IntegrationTypeChirpStack}
+	return s
+}
+
+func TestCreateDevicesFromTemplateReferences(t *testing.T) {
+	for _, tt := range referencesTests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newReferencesSimulator(t)
+			tmpl := template.NewDeviceTemplate("sensor")
+			tmpl.ID = 1
+			tmpl.UseCodec = tt.codecID != 0
+			tmpl.CodecID = tt.codecID
+			tmpl.IntegrationEnabled = tt.integrationID != 0
+			tmpl.IntegrationID = tt.integrationID
+			tmpl.TBIntegrationEnabled = tt.tbIntegrationID != 0
+			tmpl.TBIntegrationID = tt.tbIntegrationID
+			s.Templates[1] = tmpl
+
+			ids, _, err := s.CreateDevicesFromTemplate(1, 2, "sensor", 45, 7, 0, 0, SpreadSquare)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateDevicesFromTemplate() error = %v, want %v", err, tt.wantErr)
+			}
+			wantDevices := 2
+			if tt.wantErr != nil {
+				wantDevices = 0
+			}
+			if len(ids) != wantDevices || len(s.Devices) != wantDevices {
+				t.Errorf("%d IDs returned and %d devices created, want %d", len(ids), len(s.Devices), wantDevices)
+			}
+		})
+	}
+}
+
+func TestSetDeviceReferences(t *testing.T) {
+	for i, tt := range referencesTests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newReferencesSimulator(t)
+			d := newTestDevice("sensor", lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, byte(i + 1)})
+			d.Info.Configuration.UseCodec = tt.codecID != 0
+			d.Info.Configuration.CodecID = tt.codecID
+			d.Info.Configuration.IntegrationEnabled = tt.integrationID != 0
+			d.Info.Configuration.IntegrationID = tt.integrationID
+			d.Info.Configuration.TBIntegrationEnabled = tt.tbIntegrationID != 0
+			d.Info.Configuration.TBIntegrationID = tt.tbIntegrationID
+
+			code, _, err := s.SetDevice(d, false)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetDevice() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && (code != codes.CodeErrorReference || len(s.Devices) != 0) {
+				t.Errorf("code = %d with %d devices, want %d and none", code, len(s.Devices), codes.CodeErrorReference)
+			}
+		})
+	}
+}
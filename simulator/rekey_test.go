@@ -0,0 +1,141 @@
+package simulator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/chirpstack"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestRekeyDevice(t *testing.T) {
+	nwkSKey := [16]byte{1}
+	appSKey := [16]byte{2}
+
+	tests := []struct {
+		name       string
+		otaa       bool
+		running    bool
+		missing    bool
+		wantErr    bool
+		wantJoined bool
+	}{
+		{"abp", false, false, false, false, true},
+		{"otaa", true, false, false, false, false},
+		{"running", false, true, false, true, true},
+		{"not found", false, false, true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSimulator(t)
+			d := newTestDevice("sensor", lorawan.EUI64{1})
+			d.Info.Configuration.SupportedOtaa = tt.otaa
+			d.Info.DevAddr = lorawan.DevAddr{1, 2, 3, 4}
+			d.Info.NwkSKey, d.Info.AppSKey = nwkSKey, appSKey
+			d.Info.Status.Joined = true
+			d.Info.Status.FCntDown = 7
+			d.Info.Status.DataUplink.FCnt = 9
+			s.Devices[1] = d
+			if tt.running {
+				d.State = util.Running
+			}
+
+			id := 1
+			if tt.missing {
+				id = 2
+			}
+			err := s.RekeyDevice(id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RekeyDevice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if d.Info.Status.Joined != tt.wantJoined {
+				t.Errorf("Joined = %v, want %v", d.Info.Status.Joined, tt.wantJoined)
+			}
+
+			switch {
+			case tt.wantErr:
+				if d.Info.NwkSKey != nwkSKey || d.Info.AppSKey != appSKey || d.Info.Status.FCntDown != 7 {
+					t.Error("the session of a refused device changed")
+				}
+			case tt.otaa:
+				if d.Info.DevAddr != (lorawan.DevAddr{}) || d.Info.NwkSKey != [16]byte{} || d.Info.AppSKey != [16]byte{} {
+					t.Error("the OTAA session was not cleared")
+				}
+				if d.Info.Status.Mode != util.Activation {
+					t.Errorf("Mode = %d, want activation", d.Info.Status.Mode)
+				}
+			default:
+				if d.Info.NwkSKey == nwkSKey || d.Info.AppSKey == appSKey || d.Info.NwkSKey == d.Info.AppSKey {
+					t.Error("the ABP session keys were not regenerated")
+				}
+				if d.Info.DevAddr != (lorawan.DevAddr{1, 2, 3, 4}) {
+					t.Errorf("DevAddr = %v, want it kept", d.Info.DevAddr)
+				}
+				if d.Info.Status.FCntDown != 0 || d.Info.Status.DataUplink.FCnt != 0 {
+					t.Errorf("frame counters = %d/%d, want 0/0", d.Info.Status.DataUplink.FCnt, d.Info.Status.FCntDown)
+				}
+			}
+		})
+	}
+}
+
+func TestRekeyDeviceChirpStack(t *testing.T) {
+	nwkSKey := [16]byte{1}
+	appSKey := [16]byte{2}
+
+	tests := []struct {
+		name     string
+		status   int // answer of ChirpStack to the activation
+		wantKeys bool
+	}{
+		{"activated", http.StatusOK, true},
+		{"activation refused", http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSimulator(t)
+
+			var activated, unlocked bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/activate") {
+					activated = true
+					if unlocked = s.mu.TryLock(); unlocked {
+						s.mu.Unlock()
+					}
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+			s.Integrations[1] = &integration.Integration{ID: 1, Name: "cs", Type: integration.IntegrationTypeChirpStack, Enabled: true}
+			s.IntegrationClients[1] = chirpstack.NewClient(server.URL, "key")
+
+			d := newTestDevice("sensor", lorawan.EUI64{1})
+			d.Info.DevAddr = lorawan.DevAddr{1, 2, 3, 4}
+			d.Info.NwkSKey, d.Info.AppSKey = nwkSKey, appSKey
+			d.Info.Status.DataUplink.FCnt = 9
+			d.Info.Configuration.IntegrationEnabled = true
+			d.Info.Configuration.IntegrationID = 1
+			s.Devices[1] = d
+
+			err := s.RekeyDevice(1)
+			if (err != nil) == tt.wantKeys {
+				t.Fatalf("RekeyDevice() error = %v, want error %v", err, !tt.wantKeys)
+			}
+			if !activated || !unlocked {
+				t.Errorf("activation requested = %v, simulator unlocked meanwhile = %v, want both", activated, unlocked)
+			}
+			if changed := d.Info.NwkSKey != nwkSKey && d.Info.AppSKey != appSKey; changed != tt.wantKeys {
+				t.Errorf("session keys changed = %v, want %v", changed, tt.wantKeys)
+			}
+			if reset := d.Info.Status.DataUplink.FCnt == 0; reset != tt.wantKeys {
+				t.Errorf("frame counter reset = %v, want %v", reset, tt.wantKeys)
+			}
+		})
+	}
+}
@@ -48,6 +48,16 @@ func (bu *BufferUplink) Pop() (packets.RXPK, bool) {
 	}
 }
 
+// Len returns the number of packets waiting in the buffer
+func (bu *BufferUplink) Len() int {
+	return len(bu.ch)
+}
+
+// Cap returns the capacity of the buffer
+func (bu *BufferUplink) Cap() int {
+	return cap(bu.ch)
+}
+
 func (bu *BufferUplink) Signal() {
 	select {
 	case bu.done <- struct{}{}:
@@ -42,6 +42,23 @@ func TestBufferBackpressure(t *testing.T) {
 	}
 }
 
+func TestBufferLen(t *testing.T) {
+	buf := NewBufferUplink(2)
+	if buf.Len() != 0 || buf.Cap() != 2 {
+		t.Fatalf("expected len 0 cap 2, got len %d cap %d", buf.Len(), buf.Cap())
+	}
+	buf.Push(packets.RXPK{Data: "a"})
+	buf.Push(packets.RXPK{Data: "b"})
+	buf.Push(packets.RXPK{Data: "c"})
+	if buf.Len() != 2 {
+		t.Errorf("expected len 2 after overflow, got %d", buf.Len())
+	}
+	buf.Pop()
+	if buf.Len() != 1 {
+		t.Errorf("expected len 1 after pop, got %d", buf.Len())
+	}
+}
+
 func TestBufferSignal(t *testing.T) {
 	buf := NewBufferUplink(10)
 
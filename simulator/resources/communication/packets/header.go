@@ -2,8 +2,8 @@ package packets
 
 import (
 	"encoding/binary"
-	"math/rand"
 
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
 	"github.com/brocaar/lorawan"
 )
 
@@ -20,7 +20,7 @@ type Header struct {
 
 func GetHeader(IDPacket uint8, GatewayMACAddr lorawan.EUI64, token uint16) []byte {
 
-	randomNumber := rand.Int()
+	randomNumber := util.RandInt()
 	randomToken := token
 	if token == 0 {
 		randomToken = uint16(randomNumber)
@@ -26,3 +26,8 @@ func GetDistance(lat1 float64, lon1 float64, lat2 float64, lon2 float64) float64
 	angle := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
 	return angle * RADIUS
 }
+
+// InRange reports whether b is within rangeMeters of a, the check used to route uplinks to gateways
+func InRange(a Location, b Location, rangeMeters float64) bool {
+	return GetDistance(a.Latitude, a.Longitude, b.Latitude, b.Longitude) <= rangeMeters/1000.0
+}
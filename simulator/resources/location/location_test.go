@@ -0,0 +1,15 @@
+package location
+
+import "testing"
+
+func TestInRange(t *testing.T) {
+	origin := eastward(0)
+	point := eastward(1000)
+
+	if !InRange(origin, point, 1001) {
+		t.Error("point 1000 m away should be within a 1001 m range")
+	}
+	if InRange(origin, point, 999) {
+		t.Error("point 1000 m away should be outside a 999 m range")
+	}
+}
@@ -0,0 +1,82 @@
+package location
+
+import "math"
+
+// Path is a sequence of waypoints a component moves along
+type Path struct {
+	Waypoints []Location // Points visited in order
+	Loop      bool       // Go back to the first waypoint after the last one instead of stopping
+	segment   int        // Index of the waypoint the current segment starts from
+	offset    float64    // Meters traveled on the current segment
+}
+
+// Length returns the length of the path in meters, including the closing segment of a loop
+func (p *Path) Length() float64 {
+	total := 0.0
+	for i := 0; i < p.segments(); i++ {
+		total += p.segmentLength(i)
+	}
+	return total
+}
+
+// Advance moves along the path by the given meters and returns the new position.
+// The returned flag is true once the end of the path is reached; a loop never ends.
+func (p *Path) Advance(meters float64) (Location, bool) {
+	n := len(p.Waypoints)
+	if n == 0 {
+		return Location{}, true
+	}
+	total := p.Length()
+	if total == 0 {
+		return p.Waypoints[0], true
+	}
+
+	p.offset += meters
+	if p.Loop && p.offset > total {
+		p.offset = math.Mod(p.offset, total)
+	}
+
+	for {
+		length := p.segmentLength(p.segment)
+		if p.offset < length {
+			from := p.Waypoints[p.segment]
+			to := p.Waypoints[(p.segment+1)%n]
+			return interpolate(from, to, p.offset/length), false
+		}
+
+		p.offset -= length
+		p.segment++
+		if p.segment == p.segments() {
+			if !p.Loop {
+				p.segment--
+				p.offset = p.segmentLength(p.segment)
+				return p.Waypoints[n-1], true
+			}
+			p.segment = 0
+		}
+	}
+}
+
+// segments returns the number of segments of the path
+func (p *Path) segments() int {
+	if p.Loop {
+		return len(p.Waypoints)
+	}
+	return len(p.Waypoints) - 1
+}
+
+// segmentLength returns the length in meters of the segment starting at waypoint i
+func (p *Path) segmentLength(i int) float64 {
+	from := p.Waypoints[i]
+	to := p.Waypoints[(i+1)%len(p.Waypoints)]
+	return GetDistance(from.Latitude, from.Longitude, to.Latitude, to.Longitude) * 1000
+}
+
+// interpolate returns the point at the given fraction of the straight line between from and to
+func interpolate(from Location, to Location, fraction float64) Location {
+	return Location{
+		Latitude:  from.Latitude + (to.Latitude-from.Latitude)*fraction,
+		Longitude: from.Longitude + (to.Longitude-from.Longitude)*fraction,
+		Altitude:  from.Altitude + int32(math.Round(float64(to.Altitude-from.Altitude)*fraction)),
+	}
+}
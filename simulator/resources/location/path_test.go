@@ -0,0 +1,66 @@
+package location
+
+import (
+	"math"
+	"testing"
+)
+
+// eastward returns a point the given meters east of the origin, on the equator
+func eastward(meters float64) Location {
+	return Location{Longitude: meters / 1000 / RADIUS * 180 / math.Pi}
+}
+
+func TestPathAdvanceInterpolates(t *testing.T) {
+	p := Path{Waypoints: []Location{eastward(0), eastward(1000)}}
+
+	pos, done := p.Advance(250)
+	if done {
+		t.Fatal("path ended after 250 of 1000 meters")
+	}
+	if d := GetDistance(0, 0, pos.Latitude, pos.Longitude) * 1000; math.Abs(d-250) > 0.5 {
+		t.Errorf("distance from start = %v m, want 250", d)
+	}
+}
+
+func TestPathAdvanceStopsAtEnd(t *testing.T) {
+	end := eastward(1000)
+	end.Altitude = 40
+	p := Path{Waypoints: []Location{eastward(0), eastward(500), end}}
+
+	pos, done := p.Advance(1200)
+	if !done {
+		t.Fatal("path didn't end after 1200 of 1000 meters")
+	}
+	if pos != end {
+		t.Errorf("position = %+v, want the last waypoint %+v", pos, end)
+	}
+}
+
+func TestPathLoopWrapsAround(t *testing.T) {
+	p := Path{Waypoints: []Location{eastward(0), eastward(1000)}, Loop: true}
+	if length := p.Length(); math.Abs(length-2000) > 0.5 {
+		t.Fatalf("loop length = %v m, want 2000", length)
+	}
+
+	// 2000 m closes the loop, 300 m more lands on the first segment again
+	pos, done := p.Advance(2300)
+	if done {
+		t.Fatal("loop ended")
+	}
+	if d := GetDistance(0, 0, pos.Latitude, pos.Longitude) * 1000; math.Abs(d-300) > 0.5 {
+		t.Errorf("distance from start = %v m, want 300", d)
+	}
+
+	// the closing segment goes back towards the first waypoint
+	pos, _ = p.Advance(1000)
+	if d := GetDistance(0, 0, pos.Latitude, pos.Longitude) * 1000; math.Abs(d-700) > 0.5 {
+		t.Errorf("distance from start = %v m, want 700 on the way back", d)
+	}
+}
+
+func TestPathWithoutLength(t *testing.T) {
+	p := Path{Waypoints: []Location{eastward(0), eastward(0)}, Loop: true}
+	if _, done := p.Advance(10); !done {
+		t.Error("a path of zero length should end immediately")
+	}
+}
@@ -0,0 +1,78 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+	"github.com/brocaar/lorawan"
+)
+
+func TestRetransmissionDefaults(t *testing.T) {
+	s := newTestSimulator(t)
+	s.DefaultAckTimeout = 3
+	s.DefaultNbRetransmission = 4
+
+	newDevice := func(name string, i byte, ackTimeout time.Duration, nbRetransmission int) *dev.Device {
+		d := newTestDevice(name, lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, i})
+		d.Info.Configuration.AckTimeout = ackTimeout
+		d.Info.Configuration.NbRepConfirmedDataUp = nbRetransmission
+		return d
+	}
+	fromTemplate := func(name string, i byte, ackTimeout, nbRetransmission int) *dev.Device {
+		tmpl := &template.DeviceTemplate{
+			Name:             name,
+			Region:           rp.Code_Eu868,
+			SendInterval:     10,
+			AckTimeout:       ackTimeout,
+			NbRetransmission: nbRetransmission,
+		}
+		return s.buildDeviceFromTemplate(tmpl, name, lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, i}, 0, 0, 0)
+	}
+
+	tests := []struct {
+		name             string
+		device           *dev.Device
+		ackTimeout       time.Duration
+		nbRetransmission int
+	}{
+		{"device values", newDevice("device", 1, time.Second, 2), time.Second, 2},
+		{"template values", fromTemplate("template", 2, 1, 1), time.Second, 1},
+		{"template zero", fromTemplate("template-zero", 3, 0, 0), 3 * time.Second, 4},
+		{"device zero", newDevice("device-zero", 4, 0, 0), 3 * time.Second, 4},
+	}
+	for _, tt := range tests {
+		_, id, err := s.SetDevice(tt.device, false)
+		if err != nil {
+			t.Fatalf("%s: SetDevice() error = %v", tt.name, err)
+		}
+		conf := s.Devices[id].Info.Configuration
+		if conf.AckTimeout != tt.ackTimeout || conf.NbRepConfirmedDataUp != tt.nbRetransmission {
+			t.Errorf("%s: ackTimeout %v, nbRetransmission %d, want %v and %d", tt.name,
+				conf.AckTimeout, conf.NbRepConfirmedDataUp, tt.ackTimeout, tt.nbRetransmission)
+		}
+	}
+
+	// Validating the same update of a device given the defaults shows no change
+	check, err := s.ValidateDeviceUpdate(tests[3].device.Id, newDevice("device-zero", 4, 0, 0))
+	if err != nil {
+		t.Fatalf("ValidateDeviceUpdate() error = %v", err)
+	}
+	if len(check.Changes) != 0 {
+		t.Errorf("changes = %+v, want none for the defaults", check.Changes)
+	}
+
+	// Defaults out of the bounds of a device are ignored
+	s.DefaultAckTimeout = 10
+	s.DefaultNbRetransmission = 20
+	_, id, err := s.SetDevice(newDevice("out-of-bounds", 5, 0, 0), false)
+	if err != nil {
+		t.Fatalf("SetDevice() error = %v", err)
+	}
+	if conf := s.Devices[id].Info.Configuration; conf.AckTimeout != 0 || conf.NbRepConfirmedDataUp != 0 {
+		t.Errorf("ackTimeout %v, nbRetransmission %d, want the invalid defaults ignored",
+			conf.AckTimeout, conf.NbRepConfirmedDataUp)
+	}
+}
@@ -0,0 +1,62 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
+	"github.com/brocaar/lorawan"
+)
+
+func TestSearchDevices(t *testing.T) {
+	s := newTestSimulator(t)
+
+	devices := []struct {
+		name   string
+		region int
+		classC bool
+	}{
+		{"kitchen", rp.Code_Eu868, false},
+		{"garage", rp.Code_Us915, true},
+		{"Kitchen sink", rp.Code_Eu868, true},
+		{"attic", rp.Code_Eu868, false},
+	}
+	for i, d := range devices {
+		device := newTestDevice(d.name, lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, byte(i + 1)})
+		device.Info.Configuration.Region = rp.GetRegionalParameters(d.region)
+		device.Info.Configuration.SupportedClassC = d.classC
+		if _, _, err := s.SetDevice(device, false); err != nil {
+			t.Fatalf("SetDevice(%s) error = %v", d.name, err)
+		}
+	}
+
+	eu868 := rp.Code_Eu868
+	tests := []struct {
+		name   string
+		filter models.DeviceFilter
+		want   []string
+	}{
+		{"all", models.DeviceFilter{}, []string{"kitchen", "garage", "Kitchen sink", "attic"}},
+		{"name", models.DeviceFilter{Name: " KITCHEN "}, []string{"kitchen", "Kitchen sink"}},
+		{"region", models.DeviceFilter{Region: &eu868}, []string{"kitchen", "Kitchen sink", "attic"}},
+		{"class", models.DeviceFilter{Class: "c"}, []string{"garage", "Kitchen sink"}},
+		{"region and class", models.DeviceFilter{Region: &eu868, Class: "C"}, []string{"Kitchen sink"}},
+		{"none", models.DeviceFilter{Name: "cellar"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Sorted by ID whatever the map order, so run it more than once
+			for run := 0; run < 5; run++ {
+				got := s.SearchDevices(tt.filter)
+				if len(got) != len(tt.want) {
+					t.Fatalf("SearchDevices() returned %d devices, want %v", len(got), tt.want)
+				}
+				for i, d := range got {
+					if d.Info.Name != tt.want[i] {
+						t.Fatalf("device %d = %q, want %q in %v", i, d.Info.Name, tt.want[i], tt.want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestSetDevicesActive(t *testing.T) {
+	s := newTestSimulator(t)
+
+	var ids []int
+	for i, name := range []string{"first", "second"} {
+		_, id, err := s.SetDevice(newTestDevice(name, lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, byte(i + 1)}), false)
+		if err != nil {
+			t.Fatalf("SetDevice(%s) error = %v", name, err)
+		}
+		ids = append(ids, id)
+	}
+
+	results := s.SetDevicesActive([]int{ids[0], ids[1], 99}, true)
+	if len(results) != 3 {
+		t.Fatalf("SetDevicesActive() returned %d results, want 3", len(results))
+	}
+	for _, r := range results[:2] {
+		if !r.Active || r.Running || r.Error != "" {
+			t.Errorf("result %+v, want active and not running with the simulation stopped", r)
+		}
+		if _, ok := s.ActiveDevices[r.ID]; !ok || !s.Devices[r.ID].Info.Status.Active {
+			t.Errorf("device %d not activated", r.ID)
+		}
+	}
+	if results[2].ID != 99 || results[2].Error == "" {
+		t.Errorf("unknown device: result %+v, want an error", results[2])
+	}
+
+	results = s.SetDevicesActive([]int{ids[0]}, false)
+	if len(results) != 1 || results[0].Active || results[0].Error != "" {
+		t.Errorf("SetDevicesActive(false) = %+v, want one inactive device", results)
+	}
+	if _, ok := s.ActiveDevices[ids[0]]; ok || s.Devices[ids[0]].Info.Status.Active {
+		t.Errorf("device %d still active", ids[0])
+	}
+	if _, ok := s.ActiveDevices[ids[1]]; !ok {
+		t.Errorf("device %d deactivated, want it left active", ids[1])
+	}
+}
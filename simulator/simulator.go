@@ -1,18 +1,21 @@
 package simulator
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/codes"
+	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/output/mqtt"
 	"github.com/R3DPanda1/LWN-Sim-Plus/shared"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/chirpstack"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration/thingsboard"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/webhook"
 	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
 	f "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder"
 	mfw "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder/models"
@@ -40,6 +43,19 @@ type Simulator struct {
 	NextIDCodec           int                 `json:"nextIDCodec"`       // Next codec ID
 	BridgeAddress         string              `json:"bridgeAddress"`     // Bridge address used to connect to a network
 	MaxConcurrentJoins    int                 `json:"maxConcurrentJoins"` // Max OTAA devices joining at once (0 = default 100, negative = unlimited)
+	MaxDevices            int                 `json:"maxDevices"`         // Max number of devices that can be created (0 = unlimited)
+	DefaultRegion         int                 `json:"defaultRegion"`      // Region code given to new devices that don't specify one (0 = EU868)
+	DefaultClass          string              `json:"defaultClass"`       // Class ("A", "B" or "C") given to new devices that don't specify one (empty = A)
+	TimeScale             float64             `json:"timeScale"`          // Divides the send interval and ACK timeout of every device (1 = real time)
+	UplinkMinSpacing      int                 `json:"uplinkMinSpacing"`   // Minimum milliseconds between two uplinks queued on a device (0 = none)
+	UplinkQueueSize       int                 `json:"uplinkQueueSize"`    // Max uplinks queued on a device (0 = default 32, negative = unlimited)
+	StartupStagger        int                 `json:"startupStagger"`     // Milliseconds between the first join or uplink of two devices started by Run (0 = all at once)
+	AutoSaveInterval      int                 `json:"autoSaveInterval"`   // Seconds between two saves of the status while running, on top of the ones on changes and Stop (0 = off)
+	NetworkServerDelay    int                 `json:"networkServerDelay"` // Milliseconds added to every downlink to model the network server latency (0 = none)
+	DefaultAckTimeout     int                 `json:"defaultAckTimeout"`       // ACK timeout, in seconds, of the devices and templates leaving it at 0 (0 = none)
+	DefaultNbRetransmission int               `json:"defaultNbRetransmission"` // Retransmissions of a confirmed uplink for the devices and templates leaving them at 0 (0 = none)
+	Webhooks              []webhook.Config    `json:"webhooks"`           // URLs notified of joins, device errors, gateway disconnections and state changes
+	MQTT                  mqtt.Config         `json:"mqtt"`               // Broker the same events are published to, when enabled
 	joinSemaphore         chan struct{}        `json:"-"`                 // Runtime semaphore for OTAA join concurrency
 	Resources             res.Resources       `json:"-"`                 // Resources used for managing the simulator
 	Console               c.Console           `json:"-"`                 // Console instance, used for logging in the web terminal
@@ -49,6 +65,29 @@ type Simulator struct {
 	ThingsBoardClients map[int]*thingsboard.Client      `json:"-"` // ThingsBoard clients for each integration
 	// Template management (like Devices/Gateways pattern)
 	Templates map[int]*template.DeviceTemplate `json:"-"` // A collection of device templates
+	// Guards the Devices, Gateways, ActiveDevices, ActiveGateways and Templates maps.
+	// When both are needed, mu is always taken before integrationsMu.
+	mu sync.RWMutex
+	// Set while Stop waits for the components to exit with mu released, guarded by mu
+	stopping bool
+	// Set while Reset or DeleteAllDevices de-provision the components with mu released, guarded by mu
+	resetting bool
+	// Number of devices and templates using each codec, guarded by mu (nil = to be counted)
+	codecUsage map[int]int
+	// Guards the Integrations, IntegrationClients and ThingsBoardClients maps
+	integrationsMu sync.RWMutex
+	// Devices moving along a path, with the channel that stops them
+	movements  map[int]chan struct{}
+	movementMu sync.Mutex
+	// Files waiting to be written to disk
+	saves pendingSaves
+	// Saving of the device console history, set by SetupHistory
+	history models.HistoryConfig
+	// Stops the periodic saves of the history (nil = not started), guarded by mu
+	historyStop chan struct{}
+	// Stops the automatic saves while running (nil = not started), and keeps two of them from overlapping
+	autoSaveStop chan struct{}
+	autoSaveMu   sync.Mutex
 }
 
 // setup loads and initializes the simulator maps for gateways and devices. It also initializes the console
@@ -91,8 +130,14 @@ func (s *Simulator) SetupConsole() {
 	}
 }
 
-// loadData retrieves the simulator configuration, devices, gateways, integrations, and templates from JSON files
+// loadData retrieves the simulator configuration, devices, gateways, integrations, and templates from JSON files.
+// In in-memory mode nothing is loaded and the simulator starts empty.
 func (s *Simulator) loadData() {
+	if util.IsInMemory() {
+		s.Gateways = make(map[int]*gw.Gateway)
+		s.Devices = make(map[int]*dev.Device)
+		return
+	}
 	path, err := util.GetPath()
 	if err != nil {
 		log.Fatal(err)
@@ -170,6 +215,8 @@ func (s *Simulator) loadDefaultTemplates() {
 		return dev.Codecs.GetCodecIDByName(name)
 	})
 	for _, t := range defaults {
+		s.trackTemplateCodec(s.Templates[t.ID], -1)
+		s.trackTemplateCodec(t, 1)
 		s.Templates[t.ID] = t
 		if t.ID >= s.NextIDTemplate {
 			s.NextIDTemplate = t.ID + 1
@@ -178,6 +225,14 @@ func (s *Simulator) loadDefaultTemplates() {
 	shared.DebugPrint("Default templates loaded")
 }
 
+// device returns the device with the given ID
+func (s *Simulator) device(id int) (*dev.Device, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.Devices[id]
+	return d, ok
+}
+
 func (s *Simulator) searchName(Name string, Id int, gwFlag bool) (int, error) {
 
 	for _, g := range s.Gateways {
@@ -235,23 +290,15 @@ func (s *Simulator) searchAddress(address lorawan.EUI64, Id int, gwFlag bool) (i
 	return codes.CodeOK, nil
 }
 
-// saveComponent saves a configuration of the provided interface to a JSON file
-func (s *Simulator) saveComponent(path string, v interface{}) {
-	shared.DebugPrint(fmt.Sprintf("Saving component %s on disk", path))
-	bytes, err := json.MarshalIndent(&v, "", "\t")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	err = util.WriteConfigFile(path, bytes)
-	if err != nil {
-		log.Fatal(err)
-	}
-
+// saveStatus saves the simulator status, devices, gateways, integrations, and templates to JSON files right away
+func (s *Simulator) saveStatus() {
+	s.saveStatusFiles(&s.Devices)
+	s.Print("Status saved", nil, util.PrintOnlyConsole)
 }
 
-// saveStatus saves the simulator status, devices, gateways, integrations, and templates to JSON files
-func (s *Simulator) saveStatus() {
+// saveStatusFiles writes the status files without printing, with the devices as they
+// are to be saved
+func (s *Simulator) saveStatusFiles(devices interface{}) {
 	shared.DebugPrint("Saving status on disk")
 	pathDir, err := util.GetPath()
 	if err != nil {
@@ -260,18 +307,26 @@ func (s *Simulator) saveStatus() {
 	path := pathDir + "/simulator.json"
 	s.saveComponent(path, &s)
 	path = pathDir + "/devices.json"
-	s.saveComponent(path, &s.Devices)
+	s.saveComponent(path, devices)
 	path = pathDir + "/gateways.json"
 	s.saveComponent(path, &s.Gateways)
 	path = pathDir + "/integrations.json"
 	s.saveComponent(path, &s.Integrations)
 	path = pathDir + "/templates.json"
 	s.saveComponent(path, &s.Templates)
-	s.Print("Status saved", nil, util.PrintOnlyConsole)
+	s.flushSaves()
 }
 
 // turnONDevice activates a device by adding it to the Forwarder and turning it on
 func (s *Simulator) turnONDevice(Id int) {
+	if s.stopping {
+		s.Print("", errors.New(s.Devices[Id].Info.Name+" not turned on, the simulator is stopping"), util.PrintBoth)
+		return
+	}
+	if s.resetting {
+		s.Print("", errors.New(s.Devices[Id].Info.Name+" not turned on, the devices are being deleted"), util.PrintBoth)
+		return
+	}
 	infoDev := mfw.InfoDevice{
 		DevEUI:   s.Devices[Id].Info.DevEUI,
 		DevAddr:  s.Devices[Id].Info.DevAddr,
@@ -281,8 +336,11 @@ func (s *Simulator) turnONDevice(Id int) {
 	s.Forwarder.AddDevice(infoDev)
 	s.Devices[Id].Setup(&s.Resources, &s.Forwarder)
 	s.Devices[Id].JoinSemaphore = s.joinSemaphore
+	s.Devices[Id].SetTimeScale(s.TimeScale)
+	s.Devices[Id].SetUplinkLimits(time.Duration(s.UplinkMinSpacing)*time.Millisecond, s.UplinkQueueSize)
 	s.Devices[Id].TurnON()
 	s.Console.PrintSocket(socket.EventResponseCommand, s.Devices[Id].Info.Name+" Turn ON")
+	s.notifyState(webhook.SourceDevice, Id, s.Devices[Id].Info.DevEUI.String(), s.Devices[Id].Info.Name, webhook.StateRunning)
 }
 
 // turnOFFDevice deactivates a device by removing it from the Forwarder and turning it off
@@ -304,19 +362,36 @@ func (s *Simulator) turnOFFDevice(Id int) {
 	}
 	s.Console.PrintSocket(socket.EventSaveStatus, status)
 	s.Console.PrintSocket(socket.EventResponseCommand, s.Devices[Id].Info.Name+" Turn OFF")
+	s.notifyState(webhook.SourceDevice, Id, s.Devices[Id].Info.DevEUI.String(), s.Devices[Id].Info.Name, webhook.StateStopped)
+}
+
+// bridgeOf returns the bridge address used by a gateway: its own one if set, the simulator's otherwise
+func (s *Simulator) bridgeOf(g *gw.Gateway) *string {
+	if g.Info.Bridge != "" {
+		return &g.Info.Bridge
+	}
+	return &s.BridgeAddress
 }
 
 // turnONGateway activates a gateway by adding it to the Forwarder and turning it on
 func (s *Simulator) turnONGateway(Id int) {
-	s.Gateways[Id].Setup(&s.BridgeAddress, &s.Resources, &s.Forwarder)
+	if s.stopping {
+		s.Print("", errors.New(s.Gateways[Id].Info.Name+" not turned on, the simulator is stopping"), util.PrintBoth)
+		return
+	}
+	s.Gateways[Id].Setup(s.bridgeOf(s.Gateways[Id]), &s.Resources, &s.Forwarder)
 	infoGw := mfw.InfoGateway{
-		MACAddress: s.Gateways[Id].Info.MACAddress,
-		Buffer:     s.Gateways[Id].BufferUplink,
-		Location:   s.Gateways[Id].Info.Location,
+		MACAddress:    s.Gateways[Id].Info.MACAddress,
+		Buffer:        s.Gateways[Id].BufferUplink,
+		Location:      s.Gateways[Id].Info.Location,
+		Latency:       s.Gateways[Id].Info.Latency,
+		LatencyJitter: s.Gateways[Id].Info.LatencyJitter,
+		ClockOffset:   s.Gateways[Id].Info.ClockOffset,
 	}
 	s.Forwarder.AddGateway(infoGw)
 	s.Gateways[Id].TurnON()
 	s.Console.PrintSocket(socket.EventResponseCommand, s.Gateways[Id].Info.Name+" Turn ON")
+	s.notifyState(webhook.SourceGateway, Id, s.Gateways[Id].Info.MACAddress.String(), s.Gateways[Id].Info.Name, webhook.StateRunning)
 }
 
 // turnOFFGateway deactivates a gateway by removing it from the Forwarder and turning it off
@@ -334,6 +409,26 @@ func (s *Simulator) turnOFFGateway(Id int) {
 	}
 	s.Forwarder.DeleteGateway(infoGw)
 	s.Console.PrintSocket(socket.EventResponseCommand, s.Gateways[Id].Info.Name+" Turn OFF")
+	s.notifyState(webhook.SourceGateway, Id, s.Gateways[Id].Info.MACAddress.String(), s.Gateways[Id].Info.Name, webhook.StateStopped)
+}
+
+// notifyState reports that the simulator, a device or a gateway was turned on or off,
+// to the web socket and to the webhooks and MQTT broker
+func (s *Simulator) notifyState(source string, id int, eui string, name string, state string) {
+	s.Console.PrintSocket(socket.EventStateChanged, socket.StateChange{
+		Source: source,
+		Id:     id,
+		Name:   name,
+		State:  state,
+	})
+	s.Console.Notify(webhook.Event{
+		Type:   webhook.EventStateChanged,
+		Source: source,
+		ID:     id,
+		EUI:    eui,
+		Name:   name,
+		State:  state,
+	})
 }
 
 // reset removes all devices and gateways from the ActiveDevices and ActiveGateways maps
@@ -0,0 +1,76 @@
+package simulator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/location"
+)
+
+// distanceMeters returns the distance between two points in meters
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	return location.GetDistance(lat1, lng1, lat2, lng2) * 1000
+}
+
+func TestSpreadCircleStaysWithinRadius(t *testing.T) {
+	const baseLat, baseLng, radius = 45.0, 9.0, 500.0
+	for i := 0; i < 500; i++ {
+		lat, lng := spreadCoordinates(SpreadCircle, baseLat, baseLng, radius, i, 500)
+		if d := distanceMeters(baseLat, baseLng, lat, lng); d > radius*1.01 {
+			t.Fatalf("point %d is %v m from the center, radius is %v m", i, d, radius)
+		}
+	}
+}
+
+func TestSpreadGridIsCenteredAndEvenlySpaced(t *testing.T) {
+	const spread = 100.0
+
+	// 9 points: a 3x3 grid with 100 m spacing, the middle one on the base point
+	north, east := gridOffset(spread, 4, 9)
+	if north != 0 || east != 0 {
+		t.Errorf("middle point offset = (%v, %v), want (0, 0)", north, east)
+	}
+	north, east = gridOffset(spread, 0, 9)
+	if north != spread || east != -spread {
+		t.Errorf("first point offset = (%v, %v), want (%v, %v)", north, east, spread, -spread)
+	}
+	north, east = gridOffset(spread, 8, 9)
+	if north != -spread || east != spread {
+		t.Errorf("last point offset = (%v, %v), want (%v, %v)", north, east, -spread, spread)
+	}
+}
+
+func TestSpreadGridIsDeterministicAndDistinct(t *testing.T) {
+	const count = 10
+	seen := make(map[[2]float64]bool)
+	for i := 0; i < count; i++ {
+		lat, lng := spreadCoordinates(SpreadGrid, 45, 9, 200, i, count)
+		lat2, lng2 := spreadCoordinates(SpreadGrid, 45, 9, 200, i, count)
+		if lat != lat2 || lng != lng2 {
+			t.Fatalf("point %d moved between calls", i)
+		}
+		key := [2]float64{lat, lng}
+		if seen[key] {
+			t.Fatalf("point %d overlaps another point", i)
+		}
+		seen[key] = true
+	}
+}
+
+func TestSpreadSingleGridPointOnBase(t *testing.T) {
+	lat, lng := spreadCoordinates(SpreadGrid, 45, 9, 200, 0, 1)
+	if math.Abs(lat-45) > 1e-12 || math.Abs(lng-9) > 1e-12 {
+		t.Errorf("single grid point = (%v, %v), want the base point", lat, lng)
+	}
+}
+
+func TestValidSpreadShape(t *testing.T) {
+	for _, shape := range []string{"", SpreadSquare, SpreadCircle, SpreadGrid} {
+		if !validSpreadShape(shape) {
+			t.Errorf("validSpreadShape(%q) = false", shape)
+		}
+	}
+	if validSpreadShape("hexagon") {
+		t.Error("validSpreadShape(\"hexagon\") = true")
+	}
+}
@@ -0,0 +1,60 @@
+package simulator
+
+import (
+	"testing"
+
+	f "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestStartStopDevice(t *testing.T) {
+	s := newTestSimulator(t)
+	s.Forwarder = *f.Setup()
+	s.TimeScale = 1
+	_, id, err := s.SetDevice(newTestDevice("sensor", lorawan.EUI64{1}), false)
+	if err != nil {
+		t.Fatalf("SetDevice() error = %v", err)
+	}
+	defer s.StopDevice(id)
+
+	// Each step runs on the state left by the previous ones
+	steps := []struct {
+		name        string
+		simRunning  bool
+		start       bool
+		id          int
+		wantRunning bool
+		wantErr     bool
+	}{
+		{"start while the simulator is stopped", false, true, id, false, true},
+		{"start", true, true, id, true, false},
+		{"start again", true, true, id, true, false},
+		{"stop", true, false, id, false, false},
+		{"stop again", true, false, id, false, false},
+		{"start unknown", true, true, id + 1, false, true},
+		{"stop unknown", true, false, id + 1, false, true},
+	}
+	for _, step := range steps {
+		s.State = util.Stopped
+		if step.simRunning {
+			s.State = util.Running
+		}
+
+		var running bool
+		if step.start {
+			running, err = s.StartDevice(step.id)
+		} else {
+			running, err = s.StopDevice(step.id)
+		}
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		if running != step.wantRunning || s.Devices[id].IsOn() != step.wantRunning {
+			t.Errorf("%s: running = %v (device on: %v), want %v", step.name, running, s.Devices[id].IsOn(), step.wantRunning)
+		}
+		if _, active := s.ActiveDevices[id]; active != step.wantRunning {
+			t.Errorf("%s: device in ActiveDevices = %v, want %v", step.name, active, step.wantRunning)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package simulator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+	"github.com/brocaar/lorawan"
+)
+
+func TestBuildDeviceFromTemplatePayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		useCodec    bool
+		payloadHex  string
+		config      map[string]interface{}
+		wantPayload []byte
+		wantConfig  bool
+	}{
+		{"empty", false, "", nil, []byte{}, false},
+		{"static payload", false, "01ff", nil, []byte{0x01, 0xff}, false},
+		{"codec with config", true, "01ff", map[string]interface{}{"mode": 1}, []byte{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSimulator(t)
+			tmpl := template.NewDeviceTemplate("sensor")
+			tmpl.UseCodec = tt.useCodec
+			tmpl.StaticPayloadHex = tt.payloadHex
+			tmpl.PayloadConfig = tt.config
+
+			d := s.buildDeviceFromTemplate(tmpl, "sensor-1", lorawan.EUI64{1}, 45, 7, 0)
+
+			payload, err := d.Info.Status.Payload.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			if !bytes.Equal(payload, tt.wantPayload) {
+				t.Errorf("payload = %x, want %x", payload, tt.wantPayload)
+			}
+			if got := d.Info.Configuration.PayloadConfig; (got != nil) != tt.wantConfig {
+				t.Fatalf("payload config = %v, want set %v", got, tt.wantConfig)
+			}
+			if tt.wantConfig {
+				d.Info.Configuration.PayloadConfig["mode"] = 2
+				if tmpl.PayloadConfig["mode"] != 1 {
+					t.Error("the device shares the payload config of the template")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+// Package testutil drives devices through their uplink/downlink cycle without a gateway
+// bridge or a network server, so that tests can script the network side end-to-end.
+package testutil
+
+import (
+	"encoding/base64"
+	"errors"
+	"time"
+
+	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/features"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
+	f "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder"
+	mfw "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/forwarder/models"
+	res "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/buffer"
+	pkt "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/communication/packets"
+	loc "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/location"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+// Timings of the devices created by a Network, short enough to run many cycles per test
+const (
+	RXDelay      = 10 * time.Millisecond
+	RXDuration   = 100 * time.Millisecond
+	AckTimeout   = 10 * time.Millisecond
+	CycleTimeout = 5 * time.Second
+
+	RX2Frequency = 869525000 // EU868 default
+)
+
+// Network is an in-memory radio network: the real forwarder with a single gateway,
+// whose uplinks are collected instead of being sent to a network server
+type Network struct {
+	Forwarder *f.Forwarder
+	Gateway   lorawan.EUI64
+
+	uplinks   *buffer.BufferUplink
+	resources res.Resources
+	location  loc.Location
+}
+
+// NewNetwork returns a network with one gateway. Seed the simulator's random source
+// (util.SetSeed) as well to make the channel of every uplink reproducible.
+func NewNetwork() *Network {
+	n := &Network{
+		Forwarder: f.Setup(),
+		Gateway:   lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, 1},
+		uplinks:   buffer.NewBufferUplink(64),
+		location:  loc.Location{Latitude: 45.0, Longitude: 7.0},
+	}
+
+	n.Forwarder.AddGateway(mfw.InfoGateway{
+		MACAddress: n.Gateway,
+		Buffer:     n.uplinks,
+		Location:   n.location,
+	})
+
+	return n
+}
+
+// NewABPDevice returns a class A EU868 device, activated by personalization with the
+// given session keys and in range of the gateway. The device is ready for Cycle; it
+// never runs its own loop.
+func (n *Network) NewABPDevice(devEUI lorawan.EUI64, devAddr lorawan.DevAddr, nwkSKey, appSKey [16]byte) *dev.Device {
+	return n.newABPDevice(devEUI, devAddr, nwkSKey, appSKey, false)
+}
+
+// NewClassBDevice returns a device like NewABPDevice that also supports the class B,
+// with 2^(7-periodicity) ping slots per beacon period. It stays in class A until
+// switched.
+func (n *Network) NewClassBDevice(devEUI lorawan.EUI64, devAddr lorawan.DevAddr, nwkSKey, appSKey [16]byte,
+	periodicity uint8) *dev.Device {
+
+	d := n.newABPDevice(devEUI, devAddr, nwkSKey, appSKey, true)
+	d.Info.Status.InfoClassB.Periodicity = periodicity
+
+	return d
+}
+
+func (n *Network) newABPDevice(devEUI lorawan.EUI64, devAddr lorawan.DevAddr, nwkSKey, appSKey [16]byte,
+	classB bool) *dev.Device {
+	fport := uint8(1)
+
+	d := &dev.Device{
+		Info: models.InformationDevice{
+			Name:     devEUI.String(),
+			DevEUI:   devEUI,
+			DevAddr:  devAddr,
+			NwkSKey:  nwkSKey,
+			AppSKey:  appSKey,
+			Location: n.location,
+			Status: models.Status{
+				Active:  true,
+				MType:   lorawan.UnconfirmedDataUp,
+				Payload: &lorawan.DataPayload{Bytes: []byte{0x01}},
+			},
+			Configuration: models.Configuration{
+				Region:          rp.GetRegionalParameters(rp.Code_Eu868),
+				AckTimeout:      AckTimeout,
+				Range:           10000,
+				SupportedClassB: classB,
+			},
+			RX: []features.Window{
+				{Delay: RXDelay, DurationOpen: RXDuration},
+				{Delay: RXDelay, DurationOpen: RXDuration},
+			},
+		},
+	}
+	d.Info.Status.DataUplink.FPort = &fport
+
+	d.Setup(&n.resources, n.Forwarder)
+	d.Info.RX[1].SetListeningFrequency(RX2Frequency)
+
+	n.Forwarder.AddDevice(mfw.InfoDevice{
+		DevEUI:   devEUI,
+		DevAddr:  devAddr,
+		Location: n.location,
+		Range:    d.Info.Configuration.Range,
+	})
+
+	// Execute only runs MAC commands and procedures while the device is running
+	d.State = util.Running
+
+	return d
+}
+
+// Cycle runs one Execute cycle of d and returns the uplinks received by the gateway.
+// If downlink is not nil, it is sent through the forwarder as soon as RX1 opens.
+func (n *Network) Cycle(d *dev.Device, downlink *lorawan.PHYPayload) ([]pkt.RXPK, error) {
+	var raw []byte
+	if downlink != nil {
+		var err error
+		if raw, err = downlink.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.Execute()
+		close(done)
+	}()
+
+	timeout := time.NewTimer(CycleTimeout)
+	defer timeout.Stop()
+
+	if downlink != nil {
+		freq, err := waitRX1(d, done, timeout.C)
+		if err != nil {
+			return nil, err
+		}
+		n.Forwarder.Downlink(downlink, freq, n.Gateway, nil, raw)
+	}
+
+	select {
+	case <-done:
+	case <-timeout.C:
+		return nil, errors.New("execute cycle timed out")
+	}
+
+	var uplinks []pkt.RXPK
+	for n.uplinks.Len() > 0 {
+		rxpk, ok := n.uplinks.Pop()
+		if !ok {
+			break
+		}
+		uplinks = append(uplinks, rxpk)
+	}
+
+	return uplinks, nil
+}
+
+// Start turns d on, so that it runs its own loop as in the simulator
+func (n *Network) Start(d *dev.Device) {
+	d.TurnON()
+}
+
+// Stop turns d off and waits for its loop to end
+func (n *Network) Stop(d *dev.Device) {
+	n.resources.ExitGroup.Add(1)
+	d.TurnOFF()
+	n.resources.ExitGroup.Wait()
+}
+
+// NextUplink waits up to timeout for the gateway to receive an uplink
+func (n *Network) NextUplink(timeout time.Duration) (pkt.RXPK, error) {
+	received := make(chan pkt.RXPK, 1)
+	go func() {
+		if rxpk, ok := n.uplinks.Pop(); ok {
+			received <- rxpk
+		}
+	}()
+
+	select {
+	case rxpk := <-received:
+		return rxpk, nil
+	case <-time.After(timeout):
+		n.uplinks.Signal() // release the pending Pop
+		return pkt.RXPK{}, errors.New("no uplink received")
+	}
+}
+
+// waitRX1 polls until the first receive window of d is open and returns its frequency
+func waitRX1(d *dev.Device, done <-chan struct{}, timeout <-chan time.Time) (uint32, error) {
+	for {
+		d.Info.ReceivedDownlink.Mutex.Lock()
+		open := d.Info.ReceivedDownlink.IsOpen
+		d.Info.ReceivedDownlink.Mutex.Unlock()
+
+		if open {
+			return d.Info.RX[0].GetListeningFrequency(), nil
+		}
+
+		select {
+		case <-done:
+			return 0, errors.New("execute cycle ended before RX1 opened")
+		case <-timeout:
+			return 0, errors.New("RX1 did not open")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+// DataDown builds an unconfirmed downlink for d carrying commands in FOpts, with the
+// frame counter the device expects next and a MIC computed with its network session key
+func DataDown(d *dev.Device, commands ...lorawan.MACCommand) (*lorawan.PHYPayload, error) {
+	fopts := make([]lorawan.Payload, len(commands))
+	for i := range commands {
+		fopts[i] = &commands[i]
+	}
+
+	phy := &lorawan.PHYPayload{
+		MHDR: lorawan.MHDR{
+			MType: lorawan.UnconfirmedDataDown,
+			Major: lorawan.LoRaWANR1,
+		},
+		MACPayload: &lorawan.MACPayload{
+			FHDR: lorawan.FHDR{
+				DevAddr: d.Info.DevAddr,
+				FCnt:    d.Info.Status.FCntDown,
+				FOpts:   fopts,
+			},
+		},
+	}
+
+	if err := phy.SetDownlinkDataMIC(lorawan.LoRaWAN1_0, 0, d.Info.NwkSKey); err != nil {
+		return nil, err
+	}
+
+	return phy, nil
+}
+
+// Decode returns the PHYPayload of an uplink collected by Cycle, with the MAC commands
+// of FOpts decoded
+func Decode(rxpk pkt.RXPK) (*lorawan.PHYPayload, error) {
+	raw, err := base64.StdEncoding.DecodeString(rxpk.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var phy lorawan.PHYPayload
+	if err := phy.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+
+	if err := phy.DecodeFOptsToMACCommands(); err != nil {
+		return nil, err
+	}
+
+	return &phy, nil
+}
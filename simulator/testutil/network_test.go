@@ -0,0 +1,91 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/util"
+	"github.com/brocaar/lorawan"
+)
+
+func TestLinkADRReqEndToEnd(t *testing.T) {
+	util.SetSeed(1)
+
+	n := NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, lorawan.DevAddr{1, 2, 3, 4},
+		[16]byte{1}, [16]byte{2})
+
+	// EU868 only acknowledges the data rate of a LinkADRReq on a channel beyond the
+	// three default ones, so the network adds one first
+	downlink, err := DataDown(d, lorawan.MACCommand{
+		CID: lorawan.NewChannelReq,
+		Payload: &lorawan.NewChannelReqPayload{
+			ChIndex: 3,
+			Freq:    867100000,
+			MaxDR:   5,
+		},
+	})
+	if err != nil {
+		t.Fatalf("DataDown() error = %v", err)
+	}
+	if _, err := n.Cycle(d, downlink); err != nil {
+		t.Fatalf("Cycle() error = %v", err)
+	}
+
+	var chMask lorawan.ChMask
+	for i := 0; i < 4; i++ {
+		chMask[i] = true
+	}
+
+	downlink, err = DataDown(d, lorawan.MACCommand{
+		CID: lorawan.LinkADRReq,
+		Payload: &lorawan.LinkADRReqPayload{
+			DataRate:   3,
+			TXPower:    2,
+			ChMask:     chMask,
+			Redundancy: lorawan.Redundancy{NbRep: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("DataDown() error = %v", err)
+	}
+
+	uplinks, err := n.Cycle(d, downlink)
+	if err != nil {
+		t.Fatalf("Cycle() error = %v", err)
+	}
+	if len(uplinks) != 1 {
+		t.Fatalf("cycle sent %d uplinks, want 1", len(uplinks))
+	}
+	if d.Info.Status.DataRate != 3 || d.Info.Status.TXPower != 2 {
+		t.Fatalf("DataRate/TXPower = %d/%d, want 3/2", d.Info.Status.DataRate, d.Info.Status.TXPower)
+	}
+
+	// The answer goes out with the next uplink, at the new data rate
+	uplinks, err = n.Cycle(d, nil)
+	if err != nil {
+		t.Fatalf("Cycle() error = %v", err)
+	}
+	if len(uplinks) != 1 {
+		t.Fatalf("next cycle sent %d uplinks, want 1", len(uplinks))
+	}
+	if uplinks[0].DatR != "SF9BW125" {
+		t.Errorf("DatR = %q, want SF9BW125", uplinks[0].DatR)
+	}
+
+	phy, err := Decode(uplinks[0])
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	fopts := phy.MACPayload.(*lorawan.MACPayload).FHDR.FOpts
+	if len(fopts) != 1 {
+		t.Fatalf("uplink has %d MAC commands, want 1", len(fopts))
+	}
+	cmd, ok := fopts[0].(*lorawan.MACCommand)
+	if !ok || cmd.CID != lorawan.LinkADRAns {
+		t.Fatalf("FOpts[0] = %v, want a LinkADRAns", fopts[0])
+	}
+	ans := cmd.Payload.(*lorawan.LinkADRAnsPayload)
+	if !ans.ChannelMaskACK || !ans.DataRateACK || !ans.PowerACK {
+		t.Errorf("LinkADRAns = %+v, want every bit acknowledged", *ans)
+	}
+}
@@ -0,0 +1,74 @@
+package testutil
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
+	"github.com/brocaar/lorawan"
+)
+
+func TestReplayPlaysRecordedUplinks(t *testing.T) {
+	n := NewNetwork()
+	d := n.NewABPDevice(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 9}, lorawan.DevAddr{1, 2, 3, 5},
+		[16]byte{1}, [16]byte{2})
+	d.Info.Configuration.SendInterval = time.Hour // only the replay sends
+
+	if err := d.Replay(models.Replay{Uplinks: []models.ReplayUplink{{FPort: 1, PayloadHex: "01"}}}); err == nil {
+		t.Fatal("Replay() of a stopped device succeeded, want an error")
+	}
+
+	n.Start(d)
+	defer n.Stop(d)
+
+	replay := models.Replay{
+		Uplinks: []models.ReplayUplink{
+			{DelayMs: 10, FPort: 10, PayloadHex: "0a0b"},
+			{DelayMs: 10, FPort: 20, PayloadHex: "0c"},
+		},
+		Loop: true,
+	}
+	if err := d.Replay(replay); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+
+	want := []struct {
+		fPort   uint8
+		payload []byte
+	}{
+		{10, []byte{0x0a, 0x0b}},
+		{20, []byte{0x0c}},
+	}
+
+	// Twice through the loop
+	for i := 0; i < 4; i++ {
+		rxpk, err := n.NextUplink(CycleTimeout)
+		if err != nil {
+			t.Fatalf("uplink %d: %v", i, err)
+		}
+		phy, err := Decode(rxpk)
+		if err != nil {
+			t.Fatalf("uplink %d: Decode() error = %v", i, err)
+		}
+		if err := phy.DecryptFRMPayload(d.Info.AppSKey); err != nil {
+			t.Fatalf("uplink %d: DecryptFRMPayload() error = %v", i, err)
+		}
+
+		macPL := phy.MACPayload.(*lorawan.MACPayload)
+		if macPL.FPort == nil || *macPL.FPort != want[i%2].fPort {
+			t.Errorf("uplink %d: fPort = %v, want %d", i, macPL.FPort, want[i%2].fPort)
+		}
+		if got := macPL.FRMPayload[0].(*lorawan.DataPayload).Bytes; !bytes.Equal(got, want[i%2].payload) {
+			t.Errorf("uplink %d: payload = %x, want %x", i, got, want[i%2].payload)
+		}
+	}
+
+	d.StopReplay()
+	if d.Replaying() {
+		t.Error("Replaying() = true after StopReplay()")
+	}
+	if *d.Info.Status.DataUplink.FPort != 1 {
+		t.Errorf("device fPort = %d after the replay, want 1", *d.Info.Status.DataUplink.FPort)
+	}
+}
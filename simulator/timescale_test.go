@@ -0,0 +1,44 @@
+package simulator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestSetTimeScale(t *testing.T) {
+	tests := []struct {
+		name    string
+		scale   float64
+		wantErr bool
+	}{
+		{"faster", 10, false},
+		{"slower", 0.5, false},
+		{"minimum", MinTimeScale, false},
+		{"maximum", MaxTimeScale, false},
+		{"too slow", MinTimeScale / 2, true},
+		{"too fast", MaxTimeScale * 2, true},
+		{"zero", 0, true},
+		{"NaN", math.NaN(), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSimulator(t)
+			s.TimeScale = 1
+			s.Devices[1] = newTestDevice("sensor", lorawan.EUI64{1})
+
+			err := s.SetTimeScale(tt.scale)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetTimeScale(%v) error = %v, wantErr %v", tt.scale, err, tt.wantErr)
+			}
+			want := tt.scale
+			if tt.wantErr {
+				want = 1
+			}
+			if s.TimeScale != want {
+				t.Errorf("TimeScale = %v, want %v", s.TimeScale, want)
+			}
+		})
+	}
+}
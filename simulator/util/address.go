@@ -0,0 +1,22 @@
+package util
+
+import (
+	"errors"
+	"net"
+	"strconv"
+)
+
+// ValidateUDPAddress checks that address is a host:port pair with a non-empty host and a valid port
+func ValidateUDPAddress(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		return errors.New("host is required")
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return errors.New("port must be between 1 and 65535")
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package util
+
+import "testing"
+
+func TestValidateUDPAddress(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:1700", "bridge.local:1700", "[::1]:1700"} {
+		if err := ValidateUDPAddress(address); err != nil {
+			t.Errorf("ValidateUDPAddress(%q) = %v, want nil", address, err)
+		}
+	}
+	for _, address := range []string{"127.0.0.1", ":1700", "127.0.0.1:0", "127.0.0.1:70000", "127.0.0.1:port"} {
+		if err := ValidateUDPAddress(address); err == nil {
+			t.Errorf("ValidateUDPAddress(%q) = nil, want an error", address)
+		}
+	}
+}
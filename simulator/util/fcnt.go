@@ -0,0 +1,37 @@
+package util
+
+// Frame counter widths accepted by the device configuration. Zero keeps the
+// historical behavior of wrapping at MAXFCNTGAP.
+const (
+	FCntBitsDefault = 0
+	FCntBits16      = 16
+	FCntBits32      = 32
+)
+
+// IsValidFCntBits reports whether bits is a supported frame counter width
+func IsValidFCntBits(bits int) bool {
+	switch bits {
+	case FCntBitsDefault, FCntBits16, FCntBits32:
+		return true
+	}
+	return false
+}
+
+// MaxFCnt returns the largest counter value before rollover for the given width
+func MaxFCnt(bits int) uint32 {
+	switch bits {
+	case FCntBits16:
+		return 0xFFFF
+	case FCntBits32:
+		return 0xFFFFFFFF
+	}
+	return MAXFCNTGAP - 1
+}
+
+// NextFCnt increments a frame counter, rolling over to 0 past MaxFCnt(bits)
+func NextFCnt(fcnt uint32, bits int) uint32 {
+	if fcnt >= MaxFCnt(bits) {
+		return 0
+	}
+	return fcnt + 1
+}
@@ -0,0 +1,39 @@
+package util
+
+import "testing"
+
+func TestNextFCnt(t *testing.T) {
+	tests := []struct {
+		name string
+		fcnt uint32
+		bits int
+		want uint32
+	}{
+		{"default increments", 10, FCntBitsDefault, 11},
+		{"default wraps at gap", MAXFCNTGAP - 1, FCntBitsDefault, 0},
+		{"16 bit increments past gap", MAXFCNTGAP - 1, FCntBits16, MAXFCNTGAP},
+		{"16 bit wraps", 0xFFFF, FCntBits16, 0},
+		{"32 bit increments past 16 bit", 0xFFFF, FCntBits32, 0x10000},
+		{"32 bit wraps", 0xFFFFFFFF, FCntBits32, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextFCnt(tt.fcnt, tt.bits); got != tt.want {
+				t.Errorf("NextFCnt(%d, %d) = %d, want %d", tt.fcnt, tt.bits, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidFCntBits(t *testing.T) {
+	for _, bits := range []int{0, 16, 32} {
+		if !IsValidFCntBits(bits) {
+			t.Errorf("IsValidFCntBits(%d) = false, want true", bits)
+		}
+	}
+	for _, bits := range []int{8, 24, -1} {
+		if IsValidFCntBits(bits) {
+			t.Errorf("IsValidFCntBits(%d) = true, want false", bits)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"errors"
+	"time"
+)
+
+// LoRaWAN default delays between the end of a JoinRequest and the opening of
+// the RX1 and RX2 windows
+const (
+	JoinAcceptDelay1Default = 5 * time.Second
+	JoinAcceptDelay2Default = 6 * time.Second
+)
+
+// JoinAcceptDelays converts join-accept delays in milliseconds, 0 meaning the
+// LoRaWAN default
+func JoinAcceptDelays(delay1, delay2 int) (time.Duration, time.Duration) {
+	d1, d2 := JoinAcceptDelay1Default, JoinAcceptDelay2Default
+	if delay1 > 0 {
+		d1 = time.Duration(delay1) * time.Millisecond
+	}
+	if delay2 > 0 {
+		d2 = time.Duration(delay2) * time.Millisecond
+	}
+	return d1, d2
+}
+
+// ValidateJoinAcceptDelays checks join-accept delays in milliseconds: they must
+// not be negative and the resulting RX2 delay must follow the RX1 one
+func ValidateJoinAcceptDelays(delay1, delay2 int) error {
+	if delay1 < 0 || delay2 < 0 {
+		return errors.New("join-accept delays must be positive")
+	}
+	if d1, d2 := JoinAcceptDelays(delay1, delay2); d2 <= d1 {
+		return errors.New("join-accept delay 2 must be greater than join-accept delay 1")
+	}
+	return nil
+}
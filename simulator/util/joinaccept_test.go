@@ -0,0 +1,41 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJoinAcceptDelays(t *testing.T) {
+	d1, d2 := JoinAcceptDelays(0, 0)
+	if d1 != JoinAcceptDelay1Default || d2 != JoinAcceptDelay2Default {
+		t.Errorf("JoinAcceptDelays(0, 0) = %v, %v, want the LoRaWAN defaults", d1, d2)
+	}
+	d1, d2 = JoinAcceptDelays(1500, 2500)
+	if d1 != 1500*time.Millisecond || d2 != 2500*time.Millisecond {
+		t.Errorf("JoinAcceptDelays(1500, 2500) = %v, %v", d1, d2)
+	}
+}
+
+func TestValidateJoinAcceptDelays(t *testing.T) {
+	tests := []struct {
+		name           string
+		delay1, delay2 int
+		wantErr        bool
+	}{
+		{"defaults", 0, 0, false},
+		{"custom", 1000, 2000, false},
+		{"custom delay 1 before default delay 2", 4000, 0, false},
+		{"negative", -1, 2000, true},
+		{"equal", 2000, 2000, true},
+		{"delay 2 before delay 1", 3000, 2000, true},
+		{"custom delay 1 after default delay 2", 7000, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJoinAcceptDelays(tt.delay1, tt.delay2)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateJoinAcceptDelays(%d, %d) = %v, wantErr %v", tt.delay1, tt.delay2, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,16 +1,37 @@
 package util
 
 import (
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sync/atomic"
 
 	"github.com/R3DPanda1/LWN-Sim-Plus/models"
 )
 
-// GetPath returns the path of the config directory path, and creates it if it does not exist
+// inMemory disables every read and write of the configuration files
+var inMemory atomic.Bool
+
+// SetInMemory enables or disables the in-memory mode, in which the simulator starts
+// empty and nothing is read from or written to the config directory
+func SetInMemory(enabled bool) {
+	inMemory.Store(enabled)
+}
+
+// IsInMemory reports whether the in-memory mode is enabled
+func IsInMemory() bool {
+	return inMemory.Load()
+}
+
+// GetPath returns the path of the config directory path, and creates it if it does not exist.
+// In in-memory mode the path is empty and nothing is created.
 func GetPath() (string, error) {
+	if IsInMemory() {
+		return "", nil
+	}
 	path := GetConfigDirname()
 	err := CreateConfigDir(path)
 	if err != nil {
@@ -57,26 +78,119 @@ func CreateConfigFiles() {
 	}
 }
 
-// RecoverConfigFile reads the data from the file in the path and stores it in the provided interface
+// CompressedSuffix is appended to the path of the config files saved gzipped
+const CompressedSuffix = ".gz"
+
+// IsCompressionEnabled returns the field compressData from the config file
+func IsCompressionEnabled() bool {
+	info, err := models.GetConfigFile("config.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return info.CompressData
+}
+
+// RecoverConfigFile reads the data from the file in the path and stores it in the provided interface.
+// A gzipped copy of the file (path + CompressedSuffix) is read instead when it exists.
 func RecoverConfigFile(path string, v interface{}) error {
+	if IsInMemory() {
+		return nil
+	}
 	// In case of first execution, create the config files
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	if !fileExists(path) && !fileExists(path+CompressedSuffix) {
 		CreateConfigFiles()
 	}
-	fileBytes, err := os.ReadFile(path)
+	fileBytes, err := readCompressed(path + CompressedSuffix)
+	if os.IsNotExist(err) {
+		fileBytes, err = os.ReadFile(path)
+	}
 	if err != nil {
 		return err
 	}
 	return json.Unmarshal(fileBytes, &v)
 }
 
-// WriteConfigFile writes the data to the file in the path
+// WriteConfigFile writes the data to the file in the path, gzipped to path + CompressedSuffix
+// if compression is enabled. The copy in the other format is removed so that it is not loaded instead.
 func WriteConfigFile(path string, data []byte) error {
+	if IsInMemory() {
+		return nil
+	}
+	if IsCompressionEnabled() {
+		if err := writeCompressed(path+CompressedSuffix, data); err != nil {
+			return err
+		}
+		return removeIfExists(path)
+	}
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		_, err = os.Create(path)
 		if err != nil {
 			log.Fatal(fmt.Sprintf("Error creating file: %v", err))
 		}
 	}
-	return os.WriteFile(path, data, os.ModePerm)
+	if err := os.WriteFile(path, data, os.ModePerm); err != nil {
+		return err
+	}
+	return removeIfExists(path + CompressedSuffix)
+}
+
+// readCompressed returns the decompressed content of a gzipped file
+func readCompressed(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// writeCompressed gzips the data into the file, through a temporary file so that a
+// failed write does not corrupt the previous content
+func writeCompressed(path string, data []byte) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(file)
+	if _, err := writer.Write(data); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ConfigFileExists reports whether the file in the path was saved, plain or gzipped.
+// Unlike RecoverConfigFile, it never creates the config files.
+func ConfigFileExists(path string) bool {
+	return fileExists(path) || fileExists(path+CompressedSuffix)
+}
+
+// fileExists reports whether there is a file at the path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return !os.IsNotExist(err)
+}
+
+// removeIfExists deletes the file, ignoring it if it does not exist
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json"+CompressedSuffix)
+	data := []byte(`{"1":{"id":1,"info":{"name":"dev-1"}}}`)
+
+	if err := writeCompressed(path, data); err != nil {
+		t.Fatalf("writeCompressed: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+
+	got, err := readCompressed(path)
+	if err != nil {
+		t.Fatalf("readCompressed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("got %s, want %s", got, data)
+	}
+}
+
+func TestReadCompressedRejectsPlainJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json"+CompressedSuffix)
+	if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCompressed(path); err == nil {
+		t.Error("expected an error reading a file that is not gzipped")
+	}
+}
+
+func TestInMemorySkipsFiles(t *testing.T) {
+	SetInMemory(true)
+	defer SetInMemory(false)
+
+	path := filepath.Join(t.TempDir(), "devices.json")
+	if err := WriteConfigFile(path, []byte("{}")); err != nil {
+		t.Fatalf("WriteConfigFile: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file written in in-memory mode: %v", err)
+	}
+
+	v := map[int]string{1: "kept"}
+	if err := RecoverConfigFile(path, &v); err != nil {
+		t.Fatalf("RecoverConfigFile: %v", err)
+	}
+	if len(v) != 1 || v[1] != "kept" {
+		t.Errorf("value changed in in-memory mode: %v", v)
+	}
+	if dir, err := GetPath(); err != nil || dir != "" {
+		t.Errorf("GetPath = %q, %v, want empty path", dir, err)
+	}
+}
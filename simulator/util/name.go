@@ -0,0 +1,33 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxNameLength is the maximum number of characters allowed in a component name
+const MaxNameLength = 64
+
+// nameSymbols lists the non-alphanumeric characters accepted in a component name
+const nameSymbols = " -_.:()#/"
+
+// ValidateName checks that a gateway, device or template name is non-empty after trimming,
+// at most MaxNameLength characters long and only uses letters, digits and nameSymbols
+func ValidateName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("Error: Name is required")
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return fmt.Errorf("Error: Name exceeds %d characters", MaxNameLength)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(nameSymbols, r) {
+			return fmt.Errorf("Error: Name contains invalid character %q", r)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateNameAccepted(t *testing.T) {
+	for _, name := range []string{"Milesight AM319", "Enginko MCF-LW13IO", "dev_001", "gw #2 (roof)", strings.Repeat("a", MaxNameLength)} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) = %v, want nil", name, err)
+		}
+	}
+}
+
+func TestValidateNameEmpty(t *testing.T) {
+	if err := ValidateName(""); err == nil {
+		t.Fatal("expected error for empty name")
+	}
+}
+
+func TestValidateNameWhitespaceOnly(t *testing.T) {
+	if err := ValidateName(" \t\n "); err == nil {
+		t.Fatal("expected error for whitespace-only name")
+	}
+}
+
+func TestValidateNameTooLong(t *testing.T) {
+	if err := ValidateName(strings.Repeat("a", MaxNameLength+1)); err == nil {
+		t.Fatal("expected error for over-length name")
+	}
+}
+
+func TestValidateNameInvalidCharacter(t *testing.T) {
+	if err := ValidateName("dev<script>"); err == nil {
+		t.Fatal("expected error for invalid character")
+	}
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Shared pseudo-random source used by every simulated component so that a fixed
+// seed reproduces DevNonces, channel hops and generated coordinates
+var (
+	randMu sync.Mutex
+	rng    = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// SetSeed re-initializes the shared random source with the given seed
+func SetSeed(seed int64) {
+	randMu.Lock()
+	defer randMu.Unlock()
+	rng = rand.New(rand.NewSource(seed))
+}
+
+// RandInt returns a non-negative pseudo-random int from the shared source
+func RandInt() int {
+	randMu.Lock()
+	defer randMu.Unlock()
+	return rng.Int()
+}
+
+// RandIntn returns a pseudo-random int in [0,n) from the shared source
+func RandIntn(n int) int {
+	randMu.Lock()
+	defer randMu.Unlock()
+	return rng.Intn(n)
+}
+
+// RandFloat64 returns a pseudo-random float64 in [0.0,1.0) from the shared source
+func RandFloat64() float64 {
+	randMu.Lock()
+	defer randMu.Unlock()
+	return rng.Float64()
+}
+
+// RandNormFloat64 returns a normally distributed float64 (mean 0, stddev 1) from the shared source
+func RandNormFloat64() float64 {
+	randMu.Lock()
+	defer randMu.Unlock()
+	return rng.NormFloat64()
+}
@@ -0,0 +1,41 @@
+package util
+
+import "testing"
+
+func TestSetSeedReproducesSequences(t *testing.T) {
+	tests := []struct {
+		name string
+		draw func() float64
+	}{
+		{"RandInt", func() float64 { return float64(RandInt()) }},
+		{"RandIntn", func() float64 { return float64(RandIntn(1000)) }},
+		{"RandFloat64", RandFloat64},
+		{"RandNormFloat64", RandNormFloat64},
+	}
+	sequence := func(seed int64, draw func() float64) []float64 {
+		SetSeed(seed)
+		values := make([]float64, 8)
+		for i := range values {
+			values[i] = draw()
+		}
+		return values
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := sequence(42, tt.draw)
+			second := sequence(42, tt.draw)
+			other := sequence(43, tt.draw)
+
+			same := true
+			for i := range first {
+				if first[i] != second[i] {
+					t.Fatalf("draw %d = %v then %v with the same seed", i, first[i], second[i])
+				}
+				same = same && first[i] == other[i]
+			}
+			if same {
+				t.Error("seeds 42 and 43 gave the same sequence")
+			}
+		})
+	}
+}
@@ -28,6 +28,8 @@ const (
 	EventChangeLocation = "change-location"
 	// EventGetParameters is the event name used for requesting regional parameters in the simulation environment.
 	EventGetParameters = "get-regional-parameters"
+	// EventGetDevices is emitted by the client to receive the current device list, as returned by GET /api/devices.
+	EventGetDevices = "get-devices"
 	// EventCodecAdded represents the event emitted when a new codec is added to the library.
 	EventCodecAdded = "codec-added"
 	// EventCodecDeleted represents the event emitted when a codec is deleted from the library.
@@ -46,4 +48,28 @@ const (
 	EventUnwatchDev = "unwatch-dev"
 	// EventDevLogHistory is emitted by the server with buffered log history for a watched device.
 	EventDevLogHistory = "dev-log-history"
+	// EventStreamFilter is emitted by the client to restrict the stream to selected event types.
+	EventStreamFilter = "stream-filter"
+	// EventDownlinkAck is emitted when a confirmed downlink is received and when its ACK is sent.
+	EventDownlinkAck = "downlink-ack"
+	// EventDownlink is emitted by the server with the payload of each downlink carrying data, decoded by the codec when the device uses one.
+	EventDownlink = "downlink"
+	// EventStartMovement is emitted by the client to move a device along a path of waypoints.
+	EventStartMovement = "start-movement"
+	// EventStopMovement is emitted by the client to stop a moving device where it is.
+	EventStopMovement = "stop-movement"
+	// EventDevLocation is emitted by the server each time a moving device changes position.
+	EventDevLocation = "dev-location"
+	// EventRetransmission is emitted by the server each time a confirmed uplink without ACK is resent.
+	EventRetransmission = "retransmission"
+	// EventStateChanged is emitted by the server each time the simulator, a device or a gateway is turned on or off.
+	EventStateChanged = "state-changed"
+	// EventDataRateChanged is emitted by the server each time the data rate sweep of a device changes its data rate.
+	EventDataRateChanged = "datarate-changed"
+	// EventStreamExecutorMetrics is emitted by the client with true to receive the codec executor metrics every few seconds, or false to stop, and by the server with each snapshot.
+	EventStreamExecutorMetrics = "stream-executor-metrics"
+	// EventUplinkThrottled is emitted by the server when an uplink is dropped because the device queue is full or it came too soon.
+	EventUplinkThrottled = "uplink-throttled"
+	// EventUplink is emitted by the server with each frame a device sends.
+	EventUplink = "uplink"
 )
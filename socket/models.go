@@ -33,9 +33,108 @@ type NewLocation struct {
 	Altitude  int32   `json:"altitude"`  // Altitude is the height above sea level.
 }
 
+// Movement describes a path a device moves along at a constant speed.
+type Movement struct {
+	Id        int           `json:"id"`        // Id is the identifier of the device.
+	Waypoints []NewLocation `json:"waypoints"` // Waypoints are the points visited in order (their Id is ignored).
+	Speed     float64       `json:"speed"`     // Speed is expressed in meters per second.
+	Loop      bool          `json:"loop"`      // Loop restarts from the first waypoint instead of stopping at the last one.
+}
+
+// DevLocation reports the current position of a moving device.
+type DevLocation struct {
+	Id        int           `json:"id"`        // Id is the identifier of the device.
+	DevEUI    lorawan.EUI64 `json:"devEUI"`    // DevEUI is the unique identifier of the device.
+	Latitude  float64       `json:"latitude"`  // Latitude is the geographical latitude.
+	Longitude float64       `json:"longitude"` // Longitude is the geographical longitude.
+	Altitude  int32         `json:"altitude"`  // Altitude is the height above sea level.
+}
+
 // MacCommand represents a MAC command to be sent to a device in the network.
 type MacCommand struct {
 	Id          int    `json:"id"`          // Id is the unique identifier of the MAC command.
 	CID         string `json:"cid"`         // CID is the command identifier.
 	Periodicity uint8  `json:"periodicity"` // Periodicity is the interval at which the command is sent.
 }
+
+// StreamRequest selects which event types are forwarded over the socket stream.
+type StreamRequest struct {
+	Types []string `json:"types"` // Types lists the event names to forward; empty forwards every event.
+}
+
+// Status values reported in DownlinkAck.
+const (
+	AckPending = "ack pending"
+	AckSent    = "ack sent"
+)
+
+// DownlinkAck reports the acknowledgement status of a confirmed downlink.
+type DownlinkAck struct {
+	Id     int    `json:"id"`     // Id is the identifier of the device.
+	Name   string `json:"name"`   // Name is the name of the device.
+	FCnt   uint32 `json:"fcnt"`   // FCnt is the downlink frame counter.
+	Status string `json:"status"` // Status is either AckPending or AckSent.
+}
+
+// Downlink reports the application payload of a received downlink.
+type Downlink struct {
+	Id      int         `json:"id"`                // Id is the identifier of the device.
+	Name    string      `json:"name"`              // Name is the name of the device.
+	FCnt    uint32      `json:"fcnt"`              // FCnt is the downlink frame counter.
+	FPort   uint8       `json:"fPort"`             // FPort is the port of the downlink.
+	Payload string      `json:"payload"`           // Payload is the FRMPayload in hex.
+	Decoded interface{} `json:"decoded,omitempty"` // Decoded is the object returned by the codec's OnDownlink, if any.
+	Error   string      `json:"error,omitempty"`   // Error is set when the codec failed to decode the payload.
+}
+
+// Retransmission reports a confirmed uplink resent because no ACK was received.
+type Retransmission struct {
+	Id          int    `json:"id"`          // Id is the identifier of the device.
+	Name        string `json:"name"`        // Name is the name of the device.
+	FCnt        uint32 `json:"fcnt"`        // FCnt is the frame counter of the resent uplink.
+	Attempt     int    `json:"attempt"`     // Attempt is the retry number, starting at 1.
+	MaxAttempts int    `json:"maxAttempts"` // MaxAttempts is the configured number of retries.
+}
+
+// DataRateChange reports a step of the data rate sweep of a device.
+type DataRateChange struct {
+	Id       int    `json:"id"`       // Id is the identifier of the device.
+	Name     string `json:"name"`     // Name is the name of the device.
+	Previous uint8  `json:"previous"` // Previous is the data rate used until now.
+	DataRate uint8  `json:"dataRate"` // DataRate is the data rate of the next uplinks.
+}
+
+// ExecutorMetrics reports the codec executor statistics, with rates over the last push interval.
+type ExecutorMetrics struct {
+	TotalExecutions     uint64  `json:"totalExecutions"`     // TotalExecutions is the number of codec executions since startup.
+	TotalErrors         uint64  `json:"totalErrors"`         // TotalErrors is the number of failed executions, timeouts included.
+	TotalTimeouts       uint64  `json:"totalTimeouts"`       // TotalTimeouts is the number of executions aborted by the timeout.
+	TotalResourceLimits uint64  `json:"totalResourceLimits"` // TotalResourceLimits is the number of executions aborted by the memory or call stack limit.
+	ExecutionsPerSecond float64 `json:"executionsPerSecond"` // ExecutionsPerSecond is the execution rate since the previous snapshot.
+	ErrorsPerSecond     float64 `json:"errorsPerSecond"`     // ErrorsPerSecond is the error rate since the previous snapshot.
+}
+
+// StateChange reports the simulator, a device or a gateway turned on or off.
+type StateChange struct {
+	Source string `json:"source"` // Source is "simulator", "device" or "gateway".
+	Id     int    `json:"id"`     // Id is the identifier of the device or gateway (0 for the simulator).
+	Name   string `json:"name"`   // Name is the name of the device or gateway.
+	State  string `json:"state"`  // State is the new state, "running" or "stopped".
+}
+
+// Uplink reports a frame sent by a device.
+type Uplink struct {
+	Id         int     `json:"id"`         // Id is the identifier of the device.
+	Name       string  `json:"name"`       // Name is the name of the device.
+	Frequency  float64 `json:"frequency"`  // Frequency is the uplink frequency in MHz.
+	DataRate   string  `json:"dataRate"`   // DataRate is the LoRa data rate, e.g. "SF7BW125".
+	PHYPayload string  `json:"phyPayload"` // PHYPayload is the encrypted frame in base64.
+}
+
+// UplinkThrottled reports an uplink that was not queued because of the uplink limits.
+type UplinkThrottled struct {
+	Id     int    `json:"id"`     // Id is the identifier of the device.
+	Name   string `json:"name"`   // Name is the name of the device.
+	Reason string `json:"reason"` // Reason explains which limit was exceeded.
+	Queued int    `json:"queued"` // Queued is the number of uplinks waiting to be sent.
+}
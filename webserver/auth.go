@@ -0,0 +1,40 @@
+package webserver
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/codes"
+	"github.com/gin-gonic/gin"
+)
+
+// authExemptPaths are reachable without a token, so that probes keep working
+var authExemptPaths = map[string]bool{
+	"/api/health": true,
+}
+
+// tokenAuth returns a middleware that rejects the requests not carrying the configured token,
+// either as an "Authorization: Bearer <token>" header or, for the socket handshake, as a "token" query parameter.
+func tokenAuth(token string) gin.HandlerFunc {
+	expected := []byte(token)
+	return func(c *gin.Context) {
+		if authExemptPaths[c.FullPath()] {
+			c.Next()
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(requestToken(c)), expected) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "code": codes.CodeErrorUnauthorized})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestToken extracts the token sent by the client, if any
+func requestToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return c.Query("token")
+}
@@ -0,0 +1,101 @@
+package webserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// executorMetricsInterval is the period between two executor metrics pushed to the subscribers
+const executorMetricsInterval = 2 * time.Second
+
+// executorMetricsStream pushes the codec executor metrics to the subscribed sockets.
+// The ticker only runs while at least one socket is subscribed.
+type executorMetricsStream struct {
+	mu          sync.Mutex
+	subscribers map[string]socketio.Conn // socket ID -> connection
+	stop        chan struct{}
+
+	interval time.Duration
+	metrics  func() socket.ExecutorMetrics // Current counters, without rates
+}
+
+var executorMetrics = newExecutorMetricsStream(executorMetricsInterval, codecMetrics)
+
+func newExecutorMetricsStream(interval time.Duration, metrics func() socket.ExecutorMetrics) *executorMetricsStream {
+	return &executorMetricsStream{
+		subscribers: make(map[string]socketio.Conn),
+		interval:    interval,
+		metrics:     metrics,
+	}
+}
+
+// codecMetrics returns the counters of the codec executor
+func codecMetrics() socket.ExecutorMetrics {
+	current := simulatorController.GetCodecMetrics()
+	return socket.ExecutorMetrics{
+		TotalExecutions:     current.TotalExecutions,
+		TotalErrors:         current.TotalErrors,
+		TotalTimeouts:       current.TotalTimeouts,
+		TotalResourceLimits: current.TotalResourceLimits,
+	}
+}
+
+// subscribe adds the socket to the subscribers, starting the ticker for the first one
+func (m *executorMetricsStream) subscribe(s socketio.Conn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers[s.ID()] = s
+	if m.stop == nil {
+		m.stop = make(chan struct{})
+		go m.run(m.stop)
+	}
+}
+
+// unsubscribe removes the socket from the subscribers, stopping the ticker after the last one
+func (m *executorMetricsStream) unsubscribe(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.subscribers, id)
+	if len(m.subscribers) == 0 && m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+}
+
+func (m *executorMetricsStream) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	previous := m.metrics()
+	last := time.Now()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			snapshot := m.metrics()
+			elapsed := now.Sub(last).Seconds()
+			snapshot.ExecutionsPerSecond = rate(previous.TotalExecutions, snapshot.TotalExecutions, elapsed)
+			snapshot.ErrorsPerSecond = rate(previous.TotalErrors, snapshot.TotalErrors, elapsed)
+			previous, last = snapshot, now
+
+			m.mu.Lock()
+			for _, s := range m.subscribers {
+				s.Emit(socket.EventStreamExecutorMetrics, snapshot)
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// rate returns the per-second increase of a counter, 0 when it was reset in between
+func rate(previous, current uint64, seconds float64) float64 {
+	if current < previous || seconds <= 0 {
+		return 0
+	}
+	return float64(current-previous) / seconds
+}
@@ -0,0 +1,126 @@
+package webserver
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// metricsConn is a socket that records the executor metrics emitted to it
+type metricsConn struct {
+	socketio.Conn
+	id      string
+	metrics chan socket.ExecutorMetrics
+}
+
+func newMetricsConn(id string) *metricsConn {
+	return &metricsConn{id: id, metrics: make(chan socket.ExecutorMetrics, 16)}
+}
+
+func (c *metricsConn) ID() string { return c.id }
+
+func (c *metricsConn) Emit(eventName string, v ...interface{}) {
+	if eventName != socket.EventStreamExecutorMetrics {
+		return
+	}
+	select {
+	case c.metrics <- v[0].(socket.ExecutorMetrics):
+	default:
+	}
+}
+
+// received reports whether a snapshot is emitted to c within timeout
+func (c *metricsConn) received(timeout time.Duration) (socket.ExecutorMetrics, bool) {
+	select {
+	case snapshot := <-c.metrics:
+		return snapshot, true
+	case <-time.After(timeout):
+		return socket.ExecutorMetrics{}, false
+	}
+}
+
+// drain discards the snapshots already emitted to c
+func (c *metricsConn) drain() {
+	for {
+		select {
+		case <-c.metrics:
+		default:
+			return
+		}
+	}
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name              string
+		previous, current uint64
+		seconds           float64
+		want              float64
+	}{
+		{"increase", 10, 30, 2, 10},
+		{"no change", 5, 5, 1, 0},
+		{"counter reset", 30, 10, 2, 0},
+		{"no time elapsed", 0, 10, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rate(tt.previous, tt.current, tt.seconds); got != tt.want {
+				t.Errorf("rate(%d, %d, %v) = %v, want %v", tt.previous, tt.current, tt.seconds, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutorMetricsStream(t *testing.T) {
+	var executions atomic.Uint64
+	m := newExecutorMetricsStream(20*time.Millisecond, func() socket.ExecutorMetrics {
+		total := executions.Add(10)
+		return socket.ExecutorMetrics{TotalExecutions: total, TotalErrors: total / 10}
+	})
+	first, second := newMetricsConn("first"), newMetricsConn("second")
+
+	// Each step runs on the subscriptions left by the previous ones
+	steps := []struct {
+		name        string
+		subscribe   []*metricsConn
+		unsubscribe []*metricsConn
+		want        map[*metricsConn]bool // whether the socket receives snapshots
+	}{
+		{"first subscriber", []*metricsConn{first}, nil, map[*metricsConn]bool{first: true, second: false}},
+		{"second subscriber", []*metricsConn{second}, nil, map[*metricsConn]bool{first: true, second: true}},
+		{"first unsubscribed", nil, []*metricsConn{first}, map[*metricsConn]bool{first: false, second: true}},
+		{"last unsubscribed", nil, []*metricsConn{second}, map[*metricsConn]bool{first: false, second: false}},
+		{"subscribed again", []*metricsConn{first}, nil, map[*metricsConn]bool{first: true, second: false}},
+	}
+	for _, step := range steps {
+		for _, c := range step.subscribe {
+			m.subscribe(c)
+		}
+		for _, c := range step.unsubscribe {
+			m.unsubscribe(c.ID())
+		}
+		first.drain()
+		second.drain()
+
+		for c, want := range step.want {
+			snapshot, got := c.received(200 * time.Millisecond)
+			if got != want {
+				t.Fatalf("%s: socket %s received snapshots = %v, want %v", step.name, c.ID(), got, want)
+			}
+			if got && (snapshot.TotalExecutions == 0 || snapshot.ExecutionsPerSecond <= 0 || snapshot.ErrorsPerSecond <= 0) {
+				t.Errorf("%s: snapshot = %+v, want executions and their rates", step.name, snapshot)
+			}
+		}
+
+		m.mu.Lock()
+		running, wantRunning := m.stop != nil, len(m.subscribers) > 0
+		m.mu.Unlock()
+		if running != wantRunning {
+			t.Errorf("%s: ticker running = %v, want %v", step.name, running, wantRunning)
+		}
+	}
+	m.unsubscribe(first.ID())
+}
@@ -149,7 +149,7 @@ function CleanCodecForm(){
     // Set skeleton code for new codec
     var skeletonCode = `// OnUplink function: Called when device sends an uplink
 // Returns byte array or {fPort: number, bytes: array}
-// Available helpers: getState, setState, getSendInterval, setSendInterval, hexToBytes, base64ToBytes, log
+// Available helpers: getState, setState, setUplinkField, getSendInterval, setSendInterval, hexToBytes, base64ToBytes, random, randomInt, gaussian, log
 function OnUplink() {
     var bytes = [];
 
@@ -286,7 +286,15 @@ function SaveCodec(isUpdate){
 
     $.post(url + apiEndpoint, jsonData, "json")
     .done((data)=>{
-        Show_SweetToast(isEdit ? "Codec updated successfully" : "Codec saved successfully", "");
+        var title = isEdit ? "Codec updated successfully" : "Codec saved successfully";
+        if (data.warnings && data.warnings.length > 0) {
+            var warnings = data.warnings.map(function(w) {
+                return "Line " + w.line + ": " + w.message;
+            });
+            swal(title, "Check these possible issues:\n\n" + warnings.join("\n"), "warning");
+        } else {
+            Show_SweetToast(title, "");
+        }
         CleanCodecForm();
         LoadCodecList();
         PopulatePayloadGenerationDropdown();
@@ -53,11 +53,47 @@ var GatewayDataTable = null;
 var AvailableCodecs = [];
 var WatchedDeviceId = null;
 
+//api token (only needed when the server is configured with apiToken)
+var ApiToken = localStorage.getItem("apiToken") || "";
+var AskingApiToken = false;
+
+$.ajaxSetup({
+    beforeSend: function(xhr){
+        if (ApiToken != "")
+            xhr.setRequestHeader("Authorization", "Bearer " + ApiToken);
+    }
+});
+
+$(document).ajaxError(function(event, xhr){
+    if (xhr.status == 401)
+        AskApiToken();
+});
+
+function AskApiToken(){
+
+    if (AskingApiToken)
+        return;
+    AskingApiToken = true;
+
+    var token = prompt("This simulator requires an API token");
+    if (token == null)
+        return;
+
+    localStorage.setItem("apiToken", token);
+    location.reload();
+}
+
 //socket
 var socket = io({
                 path:'/socket.io/',
+                query: ApiToken != "" ? {token: ApiToken} : {},
                 reconnectionDelay:5000});
 
+socket.on('connect_error', () => {
+    // the handshake is rejected with 401 when the token is missing or wrong
+    $.get(url + "/api/status");
+});
+
 var url = window.origin;
 
 $(document).ready(function(){
@@ -174,6 +210,22 @@ $(document).ready(function(){
 
     });
 
+    socket.on('dev-location',(data)=>{
+
+        var dev = Devices.get(data.devEUI);
+        if (dev == undefined)
+            return;
+
+        dev.info.location.latitude = data.latitude;
+        dev.info.location.longitude = data.longitude;
+        dev.info.location.altitude = data.altitude;
+
+        var element = MarkersHome.get(data.devEUI);
+        if (element != undefined)
+            element.Marker.setLatLng(L.latLng(data.latitude, data.longitude));
+
+    });
+
     socket.on('response-command',(data)=>{
         Show_iziToast(data,"");
     });
@@ -511,6 +563,8 @@ $(document).ready(function(){
     $("#region").on('change',function(){
         $(this).removeClass("is-valid is-invalid"); 
 
+        ShowAS923SubBand(Number($(this).val()));
+
         if(Number($(this).val()) == -1)
             return;
 
@@ -1529,6 +1583,15 @@ function IsValidIP(value){
         
 }
 
+function IsValidBridge(value){
+
+    var parts = value.split(":");
+    if (parts.length != 2 || parts[0] == "")
+        return false;
+
+    return IsValidNumber(Number(parts[1]),0,65536) && parts[1] != "";
+}
+
 function IsValidURL(value){
 
     //var expression = /[-a-zA-Z0-9@:%._\+~#=]{1,256}\.[a-zA-Z0-9()]{1,6}\b([-a-zA-Z0-9()@:%_\+.~#?&//=]*)?/gi;
@@ -2113,6 +2176,11 @@ function LoadGateway(gw) {
 
     //virtual
     $("[name=input-KeepAlive]").val(gw.info.keepAlive);
+    $("[name=input-bridge-gw]").val(gw.info.bridge);
+    $("[name=input-stat-interval-gw]").val(gw.info.statInterval);
+    $("[name=input-latency-gw]").val(gw.info.latency);
+    $("[name=input-jitter-gw]").val(gw.info.latencyJitter);
+    $("[name=input-clock-offset-gw]").val(gw.info.clockOffset);
 
     if (gw.info.integrationEnabled) {
         $("#checkbox-gw-integration-enabled").prop("checked", true);
@@ -2232,6 +2300,11 @@ function Click_SaveGateway(){
     var KeepAlive = $("[name=input-KeepAlive]");
     var IPGateway = $("[name=input-IP-gw]");
     var PortGateway = $("[name=input-port-gw]");
+    var BridgeGateway = $("[name=input-bridge-gw]");
+    var StatIntervalGateway = $("[name=input-stat-interval-gw]");
+    var LatencyGateway = $("[name=input-latency-gw]");
+    var JitterGateway = $("[name=input-jitter-gw]");
+    var ClockOffsetGateway = $("[name=input-clock-offset-gw]");
 
     if ($("#virtual-gw").hasClass("active")){//virtual
 
@@ -2249,6 +2322,23 @@ function Click_SaveGateway(){
         IPGateway.val("");
         PortGateway.val("");
 
+        var bridge = BridgeGateway.val().trim();
+        var validBridge = bridge == "" || IsValidBridge(bridge);
+        ValidationInput(BridgeGateway, validBridge);
+        valid = validBridge ? valid : false;
+
+        var validStatInterval = StatIntervalGateway.val() == "" || Number(StatIntervalGateway.val()) >= 0;
+        ValidationInput(StatIntervalGateway, validStatInterval);
+        valid = validStatInterval ? valid : false;
+
+        var validLatency = LatencyGateway.val() == "" || Number(LatencyGateway.val()) >= 0;
+        ValidationInput(LatencyGateway, validLatency);
+        var validJitter = JitterGateway.val() == "" || Number(JitterGateway.val()) >= 0;
+        ValidationInput(JitterGateway, validJitter);
+        var validOffset = ClockOffsetGateway.val() == "" || Number.isInteger(Number(ClockOffsetGateway.val()));
+        ValidationInput(ClockOffsetGateway, validOffset);
+        valid = validLatency && validJitter && validOffset ? valid : false;
+
 
     }else if ($("#real-gw").hasClass("active")){//real
 
@@ -2263,6 +2353,11 @@ function Click_SaveGateway(){
         valid = validPort ? valid : false;
 
         KeepAlive.val("");
+        BridgeGateway.val("");
+        StatIntervalGateway.val("");
+        LatencyGateway.val("");
+        JitterGateway.val("");
+        ClockOffsetGateway.val("");
     }
 
     //map
@@ -2314,6 +2409,11 @@ function Click_SaveGateway(){
             "typeGateway":TypeGateway,
             "ip":IPGateway.val(),
             "port": PortGateway.val(),
+            "bridge": BridgeGateway.val().trim(),
+            "statInterval": Number(StatIntervalGateway.val()) || 0,
+            "latency": Number(LatencyGateway.val()) || 0,
+            "latencyJitter": Number(JitterGateway.val()) || 0,
+            "clockOffset": Number(ClockOffsetGateway.val()) || 0,
             "location":location,
             "integrationEnabled": $("#checkbox-gw-integration-enabled").prop("checked"),
             "integrationId": Number($("#select-gw-integration").val()) || 0
@@ -2363,6 +2463,19 @@ function Click_SaveGateway(){
 
                     break;   
                     
+                case 8:// invalid bridge address
+                    BridgeGateway.addClass("is-invalid");
+                    break;
+
+                case 10:// negative latency
+                    LatencyGateway.addClass("is-invalid");
+                    JitterGateway.addClass("is-invalid");
+                    break;
+
+                case 11:// negative stat interval
+                    StatIntervalGateway.addClass("is-invalid");
+                    break;
+
                 case 4:
                     Show_ErrorSweetToast("Error",data.status)
                         
@@ -2416,6 +2529,19 @@ function Click_SaveGateway(){
                     Show_ErrorSweetToast("Error",data.status); 
                     return;
 
+                case 8:// invalid bridge address
+                    BridgeGateway.addClass("is-invalid");
+                    break;
+
+                case 10:// negative latency
+                    LatencyGateway.addClass("is-invalid");
+                    JitterGateway.addClass("is-invalid");
+                    break;
+
+                case 11:// negative stat interval
+                    StatIntervalGateway.addClass("is-invalid");
+                    break;
+
             }
 
             Show_ErrorSweetToast("Error",data.status);
@@ -2438,6 +2564,11 @@ function CleanActivation(){
 
 }
 
+// the sub-band selection only applies to AS923
+function ShowAS923SubBand(region){
+    $("#div-as923-sub-band").toggle(region == 7);
+}
+
 function CleanInputDevice(){
 
     $("#add-dev input").not("[type=submit]").val("");
@@ -2445,6 +2576,8 @@ function CleanInputDevice(){
     $("#add-dev [type=checkbox]").prop("checked",false);
 
     $("#region").val(-1);
+    $("#as923-sub-band").val(1);
+    ShowAS923SubBand(-1);
 
     $("select").removeClass("is-valid is-invalid");
     $("#table-body").empty();
@@ -2560,6 +2693,8 @@ function LoadDevice(dev){
     $("[name=input-name-dev]").val(dev.info.name);
     $("[name=input-devEUI]").val(dev.info.devEUI);
     $("#region").val(dev.info.configuration.region);
+    $("#as923-sub-band").val(dev.info.configuration.as923SubBand > 0 ? dev.info.configuration.as923SubBand : 1);
+    ShowAS923SubBand(dev.info.configuration.region);
     
     SetParameters(dev.info.configuration.region, true,dev);
 
@@ -3098,6 +3233,7 @@ function Click_SaveDevice(){
             },
             "configuration":{
                 "region":Number(region.val()),
+                "as923SubBand":Number($("#as923-sub-band").val()),
                 "ackTimeout":Number(ackTimeout.val()),
                 "rx1DROffset":Number(DROffsetRX1.val()),
                 "supportedADR":supportedADR,
@@ -3181,6 +3317,12 @@ function Click_SaveDevice(){
                     $("[name=input-devEUI]").siblings(".invalid-feedback").text(data.status);
 
                     return;
+
+                case 7:// device limit reached
+
+                    Show_ErrorSweetToast("Unable to save the device", data.status);
+
+                    return;
                    
             }
                  
@@ -7,6 +7,9 @@
 
 var Integrations = new Map();
 
+// placeholder shown instead of a stored API key (matches integration.RedactedAPIKey)
+var RedactedApiKey = "********";
+
 // Display label for an integration type identifier.
 function IntegrationTypeLabel(type) {
     if (type === "thingsboard") return "ThingsBoard";
@@ -91,7 +94,9 @@ function LoadIntegration(integration){
     $("[name=input-integration-name]").val(integration.name);
     $("#select-integration-type").val(integration.type);
     $("[name=input-integration-url]").val(integration.url);
-    $("[name=input-integration-apikey]").val(integration.apiKey);
+    // the server never sends the key back: saving the placeholder keeps the stored one
+    $("[name=input-integration-apikey]").val(RedactedApiKey);
+    $("[name=input-integration-apikey]").attr("type", "password");
     $("[name=input-integration-tenantid]").val(integration.tenantId);
     $("[name=input-integration-appid]").val(integration.applicationId);
     $("#checkbox-integration-enabled").prop("checked", integration.enabled);
@@ -571,11 +576,31 @@ function GenerateRandomKey() {
 // API key visibility toggle for integration form
 $("[name=btn-watch-apikey]").on('click', function(){
     var input = $("[name=input-integration-apikey]");
-    if(input.attr("type") === "password"){
-        input.attr("type", "text");
-    } else {
+    if(input.attr("type") !== "password"){
         input.attr("type", "password");
+        return;
+    }
+
+    var integrationId = $("#div-buttons-integration").data("id");
+    if(input.val() !== RedactedApiKey || integrationId === undefined){
+        input.attr("type", "text");
+        return;
     }
+
+    // the stored key is only fetched when explicitly asked for
+    $.ajax({
+        url: url + "/api/integration/" + integrationId + "/reveal-key",
+        type: "GET",
+        headers: {
+            "Access-Control-Allow-Origin": "*"
+        }
+    }).done((data) => {
+        input.val(data.apiKey);
+        input.attr("type", "text");
+    }).fail((data) => {
+        var errorMsg = data.responseJSON ? data.responseJSON.error : data.statusText;
+        Show_ErrorSweetToast("Unable to reveal the API key", errorMsg);
+    });
 });
 
 // Initialize integration list on page load
@@ -79,6 +79,15 @@ window.InitializeMonacoEditor = function() {
              */
             declare function setState(key: string, value: any): void;
 
+            /**
+             * Set a field of the config passed to the next OnUplink calls.
+             * Typically called from OnDownlink to apply a command. Persisted in the codec state.
+             * Example: setUplinkField("mode", 2) then OnUplink(config) sees config.mode === 2
+             * @param name The name of the field.
+             * @param value The value of the field.
+             */
+            declare function setUplinkField(name: string, value: any): void;
+
             /**
             * Get the current device send interval in seconds.
             * @returns {number} The send interval in seconds.
@@ -108,6 +117,33 @@ window.InitializeMonacoEditor = function() {
              */
             declare function base64ToBytes(b64String: string): number[];
 
+            /**
+             * Return a random float between min (inclusive) and max (exclusive).
+             * Example: random(19, 20) returns e.g. 19.42
+             * @param min The lower bound.
+             * @param max The upper bound.
+             * @returns {number} The random value.
+             */
+            declare function random(min: number, max: number): number;
+
+            /**
+             * Return a random integer between min and max (both inclusive).
+             * Example: randomInt(0, 5) returns one of 0, 1, 2, 3, 4, 5
+             * @param min The lower bound.
+             * @param max The upper bound.
+             * @returns {number} The random integer.
+             */
+            declare function randomInt(min: number, max: number): number;
+
+            /**
+             * Return a normally distributed random value.
+             * Example: gaussian(21, 0.5) returns values centered on 21
+             * @param mean The mean of the distribution.
+             * @param stddev The standard deviation of the distribution.
+             * @returns {number} The random value.
+             */
+            declare function gaussian(mean: number, stddev: number): number;
+
             /**
              * Log a debug message to the simulator console.
              * Useful for troubleshooting codec logic.
@@ -186,6 +222,38 @@ window.InitializeMonacoEditor = function() {
                         documentation: 'Convert a base64 string to byte array. Example: base64ToBytes("SGVsbG8=") returns [72, 101, 108, 108, 111]',
                         detail: '(b64String: string) => number[]'
                     },
+                    {
+                        label: 'setUplinkField',
+                        kind: monaco.languages.CompletionItemKind.Function,
+                        insertText: 'setUplinkField(${1:name}, ${2:value})',
+                        insertTextRules: monaco.languages.CompletionItemInsertTextRule.InsertAsSnippet,
+                        documentation: 'Set a field of the config passed to the next OnUplink calls. Typically called from OnDownlink to apply a command.',
+                        detail: '(name: string, value: any) => void'
+                    },
+                    {
+                        label: 'random',
+                        kind: monaco.languages.CompletionItemKind.Function,
+                        insertText: 'random(${1:min}, ${2:max})',
+                        insertTextRules: monaco.languages.CompletionItemInsertTextRule.InsertAsSnippet,
+                        documentation: 'Return a random float between min (inclusive) and max (exclusive). Example: random(19, 20) returns e.g. 19.42',
+                        detail: '(min: number, max: number) => number'
+                    },
+                    {
+                        label: 'randomInt',
+                        kind: monaco.languages.CompletionItemKind.Function,
+                        insertText: 'randomInt(${1:min}, ${2:max})',
+                        insertTextRules: monaco.languages.CompletionItemInsertTextRule.InsertAsSnippet,
+                        documentation: 'Return a random integer between min and max (both inclusive). Example: randomInt(0, 5)',
+                        detail: '(min: number, max: number) => number'
+                    },
+                    {
+                        label: 'gaussian',
+                        kind: monaco.languages.CompletionItemKind.Function,
+                        insertText: 'gaussian(${1:mean}, ${2:stddev})',
+                        insertTextRules: monaco.languages.CompletionItemInsertTextRule.InsertAsSnippet,
+                        documentation: 'Return a normally distributed random value. Example: gaussian(21, 0.5) returns values centered on 21',
+                        detail: '(mean: number, stddev: number) => number'
+                    },
                     {
                         label: 'log',
                         kind: monaco.languages.CompletionItemKind.Function,
@@ -82,6 +82,7 @@ function CleanTemplateForm() {
     $("[name=input-template-rx2duration]").val("3000");
     $("[name=input-template-fport]").val("1");
     $("#select-template-codec").val("");
+    $("#select-template-profile").val("");
     $("#checkbox-template-integration-enabled").prop("checked", false);
     $("#template-integration-settings").addClass("hide");
     $("#select-template-integration").val("");
@@ -117,6 +118,7 @@ function SetTemplateFormEnabled(enabled) {
     $("[name=input-template-rx2duration]").prop("disabled", !enabled);
     $("[name=input-template-fport]").prop("disabled", !enabled);
     $("#select-template-codec").prop("disabled", !enabled);
+    $("#select-template-profile").prop("disabled", !enabled);
     $("#checkbox-template-integration-enabled").prop("disabled", !enabled);
     $("#select-template-integration").prop("disabled", !enabled);
     $("#select-template-device-profile").prop("disabled", !enabled);
@@ -144,6 +146,7 @@ function LoadTemplate(template) {
     $("[name=input-template-rx2duration]").val(template.rx2Duration || 3000);
     $("[name=input-template-fport]").val(template.fport);
     $("#select-template-codec").val(template.codecId || "");
+    $("#select-template-profile").val(template.profileId || "");
 
     if (template.integrationEnabled) {
         $("#checkbox-template-integration-enabled").prop("checked", true);
@@ -210,6 +213,7 @@ function SaveTemplate() {
         fport: parseInt($("[name=input-template-fport]").val()),
         useCodec: $("#select-template-codec").val() !== "",
         codecId: parseInt($("#select-template-codec").val()) || 0,
+        profileId: $("#select-template-profile").val() || "",
         integrationEnabled: $("#checkbox-template-integration-enabled").prop("checked"),
         integrationId: parseInt($("#select-template-integration").val()) || 0,
         deviceProfileId: $("#select-template-device-profile").val() || "",
@@ -493,6 +497,7 @@ function SubmitBulkCreate() {
     var baseLat = parseFloat($("#input-bulk-lat").val());
     var baseLng = parseFloat($("#input-bulk-lng").val());
     var spreadMeters = parseFloat($("#input-bulk-spread").val());
+    var spreadShape = $("#select-bulk-shape").val();
 
     // Validate
     var validTemplate = !!templateId;
@@ -518,7 +523,8 @@ function SubmitBulkCreate() {
         baseLat: baseLat,
         baseLng: baseLng,
         baseAlt: 0,
-        spreadMeters: spreadMeters
+        spreadMeters: spreadMeters,
+        spreadShape: spreadShape
     };
 
     // Show loading
@@ -533,7 +539,11 @@ function SubmitBulkCreate() {
         if (result.error) {
             Show_ErrorSweetToast("Error", result.error);
         } else {
-            Show_SweetToast("Success", result.created + " devices created");
+            var msg = result.created + " devices created";
+            if (result.skipped > 0) {
+                msg += ", " + result.skipped + " skipped (device limit reached)";
+            }
+            Show_SweetToast("Success", msg);
             // Reload all data and switch to devices list
             Init();
             // Switch to devices list tab
@@ -565,6 +575,23 @@ function PopulateTemplateCodecDropdown() {
     });
 }
 
+function PopulateTemplateProfileDropdown() {
+    $.ajax({
+        url: url + "/api/profiles",
+        type: "GET"
+    }).done(function(data) {
+        var select = $("#select-template-profile");
+        select.empty();
+        select.append('<option value="">None</option>');
+
+        if (data.profiles && data.profiles.length > 0) {
+            data.profiles.forEach(function(profile) {
+                select.append('<option value="' + profile.id + '" title="' + profile.description + '">' + profile.name + '</option>');
+            });
+        }
+    });
+}
+
 // ==================== Event Handlers ====================
 
 $(document).ready(function() {
@@ -572,12 +599,14 @@ $(document).ready(function() {
     $("#templates-tab").on('click', function() {
         LoadTemplateList();
         PopulateTemplateCodecDropdown();
+        PopulateTemplateProfileDropdown();
         $(".section-header h1").text("List Templates");
     });
 
     $("#add-template-tab").on('click', function() {
         CleanTemplateForm();
         PopulateTemplateCodecDropdown();
+        PopulateTemplateProfileDropdown();
         $(".section-header h1").text("Add New Template");
     });
 
@@ -587,6 +616,7 @@ $(document).ready(function() {
         var template = Templates.get(templateId);
         if (template) {
             PopulateTemplateCodecDropdown();
+            PopulateTemplateProfileDropdown();
             setTimeout(function() {
                 LoadTemplate(template);
             }, 100);
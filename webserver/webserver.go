@@ -1,26 +1,39 @@
 package webserver
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"runtime"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/R3DPanda1/LWN-Sim-Plus/codes"
 	cnt "github.com/R3DPanda1/LWN-Sim-Plus/controllers"
 	"github.com/R3DPanda1/LWN-Sim-Plus/models"
+	"github.com/R3DPanda1/LWN-Sim-Plus/output/mqtt"
+	"github.com/R3DPanda1/LWN-Sim-Plus/shared"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/codec"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/integration"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/profiles"
 	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/template"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/webhook"
 	dev "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device"
+	devModels "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/models"
 	rp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters"
 	mrp "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/device/regional_parameters/models_rp"
 	gw "github.com/R3DPanda1/LWN-Sim-Plus/simulator/components/gateway"
+	"github.com/R3DPanda1/LWN-Sim-Plus/simulator/metrics"
+	loc "github.com/R3DPanda1/LWN-Sim-Plus/simulator/resources/location"
 	"github.com/R3DPanda1/LWN-Sim-Plus/socket"
 	_ "github.com/R3DPanda1/LWN-Sim-Plus/webserver/statik"
 	"github.com/brocaar/lorawan"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	socketio "github.com/googollee/go-socket.io"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rakyll/statik/fs"
 )
 
@@ -38,6 +51,15 @@ var (
 	configuration       *models.ServerConfig    // configuration is a pointer to models.ServerConfig struct which holds the server's configuration settings.
 )
 
+// allowedOrigins returns the configured origins without trailing slash, as browsers send them
+func allowedOrigins(origins []string) []string {
+	trimmed := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		trimmed = append(trimmed, strings.TrimSuffix(origin, "/"))
+	}
+	return trimmed
+}
+
 // NewWebServer creates a new web server instance with the given configuration and simulator controller.
 func NewWebServer(config *models.ServerConfig, controller cnt.SimulatorController) *WebServer {
 	// Storing the configuration and controller instances in the global variables.
@@ -56,9 +78,14 @@ func NewWebServer(config *models.ServerConfig, controller cnt.SimulatorControlle
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	configCors := cors.DefaultConfig()
-	configCors.AllowAllOrigins = true
+	if len(config.AllowedOrigins) > 0 {
+		configCors.AllowOrigins = allowedOrigins(config.AllowedOrigins)
+	} else {
+		log.Println("[WS] [WARNING]: CORS allows every origin, set allowedOrigins in the configuration to restrict it")
+		configCors.AllowAllOrigins = true
+	}
 	configCors.AllowHeaders = []string{"Origin", "Access-Control-Allow-Origin",
-		"Access-Control-Allow-Headers", "Content-type"}
+		"Access-Control-Allow-Headers", "Content-type", "Authorization"}
 	configCors.AllowMethods = []string{"GET", "POST", "DELETE", "OPTIONS"}
 	configCors.AllowCredentials = true
 	router.Use(cors.New(configCors))
@@ -80,49 +107,117 @@ func NewWebServer(config *models.ServerConfig, controller cnt.SimulatorControlle
 	staticGroup.StaticFS("/", staticFS)
 	// Set up the API routes.
 	apiRoutes := router.Group("/api")
+	// Require the API token on every route but the health check, if one is configured.
+	if configuration.APIToken != "" {
+		apiRoutes.Use(tokenAuth(configuration.APIToken))
+	}
 	{
 		apiRoutes.GET("/start", startSimulator)        // Start the simulator
 		apiRoutes.GET("/stop", stopSimulator)          // Stop the simulator
 		apiRoutes.GET("/status", simulatorStatus)      // Get the simulator status (running or stopped)
+		apiRoutes.GET("/health", healthCheck)          // Get readiness and component counts for probes
+		apiRoutes.GET("/version", getVersion)          // Get the version, build metadata and supported regions
+		apiRoutes.GET("/metrics/summary", getMetricsSummary) // Get the uplink, downlink, join and gateway packet counters as JSON
+		apiRoutes.POST("/simulator/reset", resetSimulator) // Wipe all components and restore defaults (simulator must be stopped)
+		apiRoutes.POST("/simulator/time-scale", setTimeScale) // Speed up (>1) or slow down (<1) every device, rescheduling the running ones
+		apiRoutes.POST("/simulator/network-server-delay", setNetworkServerDelay) // Delay every downlink ({delayMs}) to model the network server latency
+		apiRoutes.GET("/simulator/webhooks", getWebhooks)     // List the URLs notified of joins, device errors and gateway disconnections
+		apiRoutes.POST("/simulator/webhooks", setWebhooks)    // Replace the webhooks
+		apiRoutes.GET("/simulator/mqtt", getMQTT)             // Get the MQTT output configuration (password redacted)
+		apiRoutes.POST("/simulator/mqtt", setMQTT)            // Replace the MQTT output configuration and reconnect to the broker
 		apiRoutes.GET("/bridge", getRemoteAddress)     // Get the remote address of the bridge
+		apiRoutes.GET("/forwarder/topology", getForwarderTopology) // Get the in-range gateways of every device
+		apiRoutes.GET("/coverage", getCoverage)        // Get the gateways covering a point (?lat=&lng=&range=)
+		apiRoutes.GET("/regions", getRegions)          // Get the code and name of the supported regions
+		apiRoutes.GET("/region/:code", getRegion)      // Get the regional parameters of a region
+		apiRoutes.GET("/airtime", getAirtime)          // Get the time on air of a payload (?region=&dr=&size=)
 		apiRoutes.GET("/gateways", getGateways)        // Get the list of gateways
 		apiRoutes.GET("/devices", getDevices)          // Get the list of devices
-		apiRoutes.POST("/add-device", addDevice)       // Add a new device
+		apiRoutes.GET("/devices/search", searchDevices) // Search devices by region, class, codec, state and name
+		apiRoutes.GET("/devices.csv", exportDevicesCSV) // Export the devices as CSV
+		apiRoutes.POST("/devices/import-csv", importDevicesCSV) // Create devices from an uploaded CSV (multipart field "file")
+		apiRoutes.GET("/device/:id/downlink-acks", getDownlinkAcks) // Get the confirmed downlink ACK ledger of a device
+		apiRoutes.GET("/device/:id/counters", getDeviceCounters)    // Get the uplinks sent and downlinks received by a device
+		apiRoutes.GET("/device/:id/codec-errors", getDeviceCodecErrors) // Get the recent codec execution errors of a device
+		apiRoutes.GET("/device/:id/codec-state", getDeviceCodecState)         // Get the variables the codec of a device keeps between executions
+		apiRoutes.POST("/device/:id/codec-state/reset", resetDeviceCodecState) // Drop the codec state of a device, restarting its counters
+		apiRoutes.POST("/device/:id/rekey", rekeyDevice)            // Regenerate session keys (ABP) or force a rejoin (OTAA)
+		apiRoutes.POST("/device/:id/rx-windows", setRXWindows)      // Override RX1/RX2 delays, durations and RX2 data rate/frequency
+		apiRoutes.GET("/device/:id/retransmission", getRetransmission)  // Get the confirmed-uplink retries and ACK timeout of a device
+		apiRoutes.POST("/device/:id/retransmission", setRetransmission) // Change the confirmed-uplink retries and ACK timeout, even while running
+		apiRoutes.POST("/device/:id/fcnt", setFrameCounters)             // Set the current frame counters of a stopped device
+		apiRoutes.GET("/device/:id/join-status", getJoinStatus)          // Get whether an OTAA device joined, its join attempts and last join time
+		apiRoutes.GET("/device/:id/channels", getChannels)               // Get the channels of a device with their uplink flags and frequencies
+		apiRoutes.POST("/device/:id/channels", setChannels)              // Enable or disable uplink channels of a running device
+		apiRoutes.POST("/device/:id/replay", replayDevice)               // Play recorded uplinks ({delayMs, fPort, payloadHex}) through a running device, optionally in a loop
+		apiRoutes.POST("/device/:id/replay/stop", stopReplayDevice)      // Stop the replay of a device, which resumes its periodic uplinks
+		apiRoutes.POST("/device/:id/inject-mac", injectMACCommand)       // Execute a downlink MAC command ({cid, payloadHex}) on a running device, without a network server
+		apiRoutes.POST("/device/:id/mac-batch", queueMACCommands)        // Queue uplink MAC commands ([{cid, payloadHex}]) at once, failing the whole batch past 15 bytes of FOpts
+		apiRoutes.POST("/device/:id/downlink-codec-test", testDownlinkCodec) // Run a downlink ({payloadHex, fPort}) through the device codec against a copy of its state
+		apiRoutes.POST("/device/:id/start", startDevice)            // Turn a device on (no-op if already running)
+		apiRoutes.POST("/device/:id/stop", stopDevice)              // Turn a device off (no-op if already stopped)
+		apiRoutes.POST("/add-device", addDevice)       // Add a new device ("skipProvisioning": true to leave its integrations for later)
+		apiRoutes.POST("/device/:id/provision", provisionDevice) // Provision a device added with skipProvisioning to its integrations
 		apiRoutes.POST("/up-device", updateDevice)     // Update a device
+		apiRoutes.POST("/device/:id/validate-update", validateDeviceUpdate) // Check an update of a device and list its changes, without applying it
+		apiRoutes.GET("/check-address", checkAddress)  // Check that a DevEUI is free before adding a device (?deveui=&id=)
+		apiRoutes.GET("/check-name", checkName)        // Check that a name is free before adding a device (?name=&id=)
 		apiRoutes.POST("/del-device", deleteDevice)    // Delete a device
 		apiRoutes.POST("/del-all-devices", deleteAllDevices) // Delete all devices in bulk
+		apiRoutes.POST("/devices/set-active", setDevicesActive) // Activate or deactivate devices in bulk ({ids, active}), turning them on or off if running
 		apiRoutes.POST("/del-gateway", deleteGateway)  // Delete a gateway
 		apiRoutes.POST("/add-gateway", addGateway)     // Add a new gateway
 		apiRoutes.POST("/up-gateway", updateGateway)   // Update a gateway
+		apiRoutes.POST("/gateway/:id/reconnect", reconnectGateway) // Force a running gateway to reconnect to the bridge
+		apiRoutes.POST("/gateway/:id/start", startGateway)         // Turn a gateway on (no-op if already running)
+		apiRoutes.POST("/gateway/:id/stop", stopGateway)           // Turn a gateway off (no-op if already stopped)
 		apiRoutes.POST("/bridge/save", saveInfoBridge) // Save the remote address of the bridge
 		apiRoutes.GET("/codecs", getCodecs)                  // Get all available codecs
+		apiRoutes.GET("/codecs/full", getCodecsFull)         // Get codecs with their scripts (?offset=&limit=, paginated)
 		apiRoutes.GET("/codec/:id", getCodec)                // Get a specific codec by ID
 		apiRoutes.GET("/codec/:id/usage", getCodecUsage)     // Check which devices use this codec
+		apiRoutes.GET("/codecs/usage", getCodecsUsage)            // Get the number of devices and templates using each codec
+		apiRoutes.POST("/codecs/usage/reset", resetCodecsUsage)   // Drop the cached usage counts and count them again
+		apiRoutes.GET("/codec/orphan-states", getOrphanCodecStates) // List the DevEUIs having a codec state but no device
+		apiRoutes.POST("/codec/prune-states", pruneCodecStates)     // Drop the codec states having no device
+		apiRoutes.GET("/codec/:id/export", exportCodec)      // Download a codec as {name, script}
+		apiRoutes.POST("/codecs/import", importCodecs)       // Add a batch of {name, script} codecs, skipping duplicate names
+		apiRoutes.POST("/codec/validate", validateCodec)     // Compile a script and report its errors without adding it
+		apiRoutes.GET("/codec/boilerplate", getCodecBoilerplate) // Get a starter script (?type=generic|milesight)
 		apiRoutes.POST("/add-codec", addCodec)               // Add a custom codec
 		apiRoutes.POST("/update-codec", updateCodec)         // Update an existing codec
 		apiRoutes.POST("/delete-codec", deleteCodec)         // Delete a codec by ID
+		apiRoutes.GET("/profiles", getSensorProfiles)        // List the sensor profiles generating codec input
 
 		// Integration management endpoints
 		apiRoutes.GET("/integrations", getIntegrations)                    // Get all integrations
-		apiRoutes.GET("/integration/:id", getIntegration)                  // Get a specific integration
+		apiRoutes.GET("/integration/:id", getIntegration)                  // Get a specific integration (API key redacted)
+		apiRoutes.GET("/integration/:id/reveal-key", revealIntegrationKey) // Get the API key of an integration
+		apiRoutes.GET("/integration/:id/usage", getIntegrationUsage)       // Check which devices and templates use this integration
 		apiRoutes.POST("/add-integration", addIntegration)                 // Add a new integration
 		apiRoutes.POST("/update-integration", updateIntegration)           // Update an integration
 		apiRoutes.POST("/delete-integration", deleteIntegration)           // Delete an integration
 		apiRoutes.POST("/integration/:id/test", testIntegrationConnection) // Test connection to an integration
+		apiRoutes.POST("/integration/:id/provision-all", provisionIntegrationDevices) // Provision the devices of the integration missing in ChirpStack
 		apiRoutes.GET("/integration/:id/device-profiles", getDeviceProfiles) // Get device profiles from an integration (CS or TB)
 		apiRoutes.GET("/integration/:id/customers", getTbCustomers)          // Get customers for a ThingsBoard integration
 
 		// Template management endpoints
 		apiRoutes.GET("/templates", getTemplates)                                  // Get all templates
 		apiRoutes.GET("/template/:id", getTemplate)                                // Get a specific template
+		apiRoutes.GET("/template/:id/dependencies", getTemplateDependencies)       // Get the codec and integrations a template relies on
 		apiRoutes.POST("/add-template", addTemplate)                               // Add a new template
 		apiRoutes.POST("/update-template", updateTemplate)                         // Update a template
 		apiRoutes.POST("/delete-template", deleteTemplate)                         // Delete a template
-		apiRoutes.POST("/create-devices-from-template", createDevicesFromTemplate) // Bulk create devices from template
+		apiRoutes.POST("/create-devices-from-template", createDevicesFromTemplate) // Bulk create devices from template (?dryRun=true previews them)
+	}
+	// Set up the WebSocket routes, protected by the same token as the API.
+	socketRoutes := router.Group("/socket.io")
+	if configuration.APIToken != "" {
+		socketRoutes.Use(tokenAuth(configuration.APIToken))
 	}
-	// Set up the WebSocket routes.
-	router.GET("/socket.io/*any", gin.WrapH(serverSocket))
-	router.POST("/socket.io/*any", gin.WrapH(serverSocket))
+	socketRoutes.GET("/*any", gin.WrapH(serverSocket))
+	socketRoutes.POST("/*any", gin.WrapH(serverSocket))
 	// Redirect the root path to the dashboard.
 	router.GET("/", func(context *gin.Context) { context.Redirect(http.StatusMovedPermanently, "/dashboard") })
 	return &ws
@@ -138,7 +233,8 @@ func newServerSocket() *socketio.Server {
 		return nil
 	})
 	serverSocket.OnDisconnect("/", func(s socketio.Conn, reason string) {
-		// Remove the socket from the list of connected sockets
+		// Remove the socket from the list of connected sockets and from the subscriptions
+		executorMetrics.unsubscribe(s.ID())
 		serverSocket.Remove(s.ID())
 		_ = s.Close()
 	})
@@ -163,15 +259,27 @@ func newServerSocket() *socketio.Server {
 	serverSocket.OnEvent("/", socket.EventChangePayload, func(s socketio.Conn, data socket.NewPayload) (string, bool) {
 		return simulatorController.ChangePayload(data)
 	})
-	serverSocket.OnEvent("/", socket.EventSendUplink, func(s socketio.Conn, data socket.NewPayload) {
-		simulatorController.SendUplink(data)
+	serverSocket.OnEvent("/", socket.EventSendUplink, func(s socketio.Conn, data socket.NewPayload) bool {
+		return simulatorController.SendUplink(data) == nil
 	})
 	serverSocket.OnEvent("/", socket.EventGetParameters, func(s socketio.Conn, code int) mrp.Informations {
 		return rp.GetInfo(code)
 	})
+	serverSocket.OnEvent("/", socket.EventGetDevices, func(s socketio.Conn) []*dev.Device {
+		return simulatorController.GetDevices()
+	})
 	serverSocket.OnEvent("/", socket.EventChangeLocation, func(s socketio.Conn, info socket.NewLocation) bool {
 		return simulatorController.ChangeLocation(info)
 	})
+	serverSocket.OnEvent("/", socket.EventStartMovement, func(s socketio.Conn, m socket.Movement) (bool, string) {
+		if err := simulatorController.StartMovement(m); err != nil {
+			return false, err.Error()
+		}
+		return true, ""
+	})
+	serverSocket.OnEvent("/", socket.EventStopMovement, func(s socketio.Conn, id int) bool {
+		return simulatorController.StopMovement(id)
+	})
 	serverSocket.OnEvent("/", socket.EventWatchDev, func(s socketio.Conn, id int) {
 		history := simulatorController.WatchDevice(id)
 		if history != nil {
@@ -181,14 +289,30 @@ func newServerSocket() *socketio.Server {
 	serverSocket.OnEvent("/", socket.EventUnwatchDev, func(s socketio.Conn) {
 		simulatorController.UnwatchDevice()
 	})
+	serverSocket.OnEvent("/", socket.EventStreamFilter, func(s socketio.Conn, req socket.StreamRequest) {
+		simulatorController.SetStreamFilter(req)
+	})
+	serverSocket.OnEvent("/", socket.EventStreamExecutorMetrics, func(s socketio.Conn, enabled bool) {
+		if enabled {
+			executorMetrics.subscribe(s)
+		} else {
+			executorMetrics.unsubscribe(s.ID())
+		}
+	})
 	return serverSocket
 }
 
 // Run starts the web server and listens on the given address and port.
 func (ws *WebServer) Run() {
 	fullAddress := ws.Address + ":" + strconv.Itoa(ws.Port)
-	log.Printf("[WS]: Listen [%s]", fullAddress)
-	err := ws.Router.Run(fullAddress)
+	var err error
+	if configuration.TLSEnabled() {
+		log.Printf("[WS]: Listen [%s] (HTTPS)", fullAddress)
+		err = ws.Router.RunTLS(fullAddress, configuration.TLSCertFile, configuration.TLSKeyFile)
+	} else {
+		log.Printf("[WS]: Listen [%s]", fullAddress)
+		err = ws.Router.Run(fullAddress)
+	}
 	// If an error occurs, log it and terminate the program.
 	if err != nil {
 		log.Fatal(fmt.Errorf("[WS] [ERROR]: %w", err))
@@ -211,16 +335,118 @@ func simulatorStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, simulatorController.Status())
 }
 
+// healthCheck returns the simulator readiness and component counts
+func healthCheck(c *gin.Context) {
+	health, ready := simulatorController.Health()
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "simulator not initialized", "code": codes.CodeErrorInternal})
+		return
+	}
+	c.JSON(http.StatusOK, health)
+}
+
+// getMetricsSummary returns the current values of the Prometheus counters as JSON
+func getMetricsSummary(c *gin.Context) {
+	summary, err := metrics.GatherSummary(prometheus.DefaultGatherer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": codes.CodeErrorInternal})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// resetSimulator wipes devices, gateways, integrations and user templates
+func resetSimulator(c *gin.Context) {
+	summary, err := simulatorController.Reset()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorSimulator})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": summary, "code": codes.CodeOK})
+}
+
+// setTimeScale changes the factor dividing the send interval and ACK timeout of every device
+func setTimeScale(c *gin.Context) {
+	var req struct {
+		TimeScale float64 `json:"timeScale"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if err := simulatorController.SetTimeScale(req.TimeScale); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorSimulator})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"timeScale": req.TimeScale, "code": codes.CodeOK})
+}
+
+// setNetworkServerDelay changes the milliseconds added to every downlink before it reaches the devices
+func setNetworkServerDelay(c *gin.Context) {
+	var req struct {
+		DelayMs *int `json:"delayMs"`
+	}
+	if err := c.BindJSON(&req); err != nil || req.DelayMs == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request, delayMs is required", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if err := simulatorController.SetNetworkServerDelay(*req.DelayMs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorSimulator})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"delayMs": *req.DelayMs, "code": codes.CodeOK})
+}
+
+// getWebhooks returns the configured webhooks and the event types they can subscribe to
+func getWebhooks(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"webhooks": simulatorController.GetWebhooks(), "eventTypes": webhook.EventTypes, "code": codes.CodeOK})
+}
+
+// setWebhooks replaces the configured webhooks
+func setWebhooks(c *gin.Context) {
+	var req struct {
+		Webhooks []webhook.Config `json:"webhooks"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if err := simulatorController.SetWebhooks(req.Webhooks); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorSimulator})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": simulatorController.GetWebhooks(), "code": codes.CodeOK})
+}
+
+// getMQTT returns the MQTT output configuration, the password redacted
+func getMQTT(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"mqtt": simulatorController.GetMQTT(), "code": codes.CodeOK})
+}
+
+// setMQTT replaces the MQTT output configuration
+func setMQTT(c *gin.Context) {
+	var config mqtt.Config
+	if err := c.BindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if err := simulatorController.SetMQTT(config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorSimulator})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mqtt": simulatorController.GetMQTT(), "code": codes.CodeOK})
+}
+
 // saveInfoBridge saves the remote address of the bridge
 func saveInfoBridge(c *gin.Context) {
 	var ns models.AddressIP
 	err := c.BindJSON(&ns)
 	// If an error occurs, return a bad request status.
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request"})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": simulatorController.SaveBridgeAddress(ns)})
+	c.JSON(http.StatusOK, gin.H{"status": simulatorController.SaveBridgeAddress(ns), "code": codes.CodeOK})
 }
 
 // getRemoteAddress returns the remote address of the bridge
@@ -228,6 +454,103 @@ func getRemoteAddress(c *gin.Context) {
 	c.JSON(http.StatusOK, simulatorController.GetBridgeAddress())
 }
 
+// getForwarderTopology returns, per DevEUI, the MAC addresses of the gateways in range
+func getForwarderTopology(c *gin.Context) {
+	c.JSON(http.StatusOK, simulatorController.GetForwarderTopology())
+}
+
+// getCoverage returns the gateways whose coverage includes the point, for a device with the given range in meters
+func getCoverage(c *gin.Context) {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid latitude", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	lng, err := strconv.ParseFloat(c.Query("lng"), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid longitude", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	rangeMeters, err := strconv.ParseFloat(c.Query("range"), 64)
+	if err != nil || rangeMeters <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	point := loc.Location{Latitude: lat, Longitude: lng}
+	c.JSON(http.StatusOK, simulatorController.GetCoverage(point, rangeMeters))
+}
+
+// getVersion returns the simulator version, its build metadata and the supported regions
+func getVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, models.VersionInfo{
+		Version:   shared.Version,
+		GoVersion: runtime.Version(),
+		Commit:    shared.Commit,
+		BuildDate: shared.BuildDate,
+		Regions:   rp.Regions(),
+	})
+}
+
+// getRegions returns the list of supported regions
+func getRegions(c *gin.Context) {
+	c.JSON(http.StatusOK, rp.Regions())
+}
+
+// getRegion returns the regional parameters of a region
+func getRegion(c *gin.Context) {
+	code, err := strconv.Atoi(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid region code", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if !rp.IsSupported(code) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found", "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, rp.GetInfo(code))
+}
+
+// getAirtime returns the time on air of an uplink carrying size bytes of application
+// payload, at a data rate of a region
+func getAirtime(c *gin.Context) {
+	code, err := strconv.Atoi(c.Query("region"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid region code", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if !rp.IsSupported(code) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found", "code": codes.CodeErrorNotFound})
+		return
+	}
+	dr, err := strconv.ParseUint(c.Query("dr"), 10, 8)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data rate", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	size, err := strconv.Atoi(c.Query("size"))
+	if err != nil || size < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload size", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+
+	region := rp.GetRegionalParameters(code)
+	region.Setup()
+
+	toa, err := rp.TimeOnAir(region, uint8(dr), size+rp.FrameOverhead)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"region":         code,
+		"dataRate":       dr,
+		"size":           size,
+		"phyPayloadSize": size + rp.FrameOverhead,
+		"timeOnAir":      float64(toa) / float64(time.Millisecond),
+		"code":           codes.CodeOK,
+	})
+}
+
 // getGateways returns the list of gateways
 func getGateways(c *gin.Context) {
 	gws := simulatorController.GetGateways()
@@ -239,7 +562,7 @@ func addGateway(c *gin.Context) {
 	var g gw.Gateway
 	err := c.BindJSON(&g)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request"})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 	code, id, err := simulatorController.AddGateway(&g)
@@ -247,12 +570,51 @@ func addGateway(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": errString, "code": code, "id": id})
 }
 
+// reconnectGateway drops the UDP connection of a running gateway so it reconnects immediately
+func reconnectGateway(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid gateway ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if err := simulatorController.ReconnectGateway(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorGateway})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "Gateway reconnecting", "id": id, "code": codes.CodeOK})
+}
+
+// startGateway turns a gateway on and returns its resulting state
+func startGateway(c *gin.Context) {
+	setGatewayState(c, simulatorController.StartGateway)
+}
+
+// stopGateway turns a gateway off and returns its resulting state
+func stopGateway(c *gin.Context) {
+	setGatewayState(c, simulatorController.StopGateway)
+}
+
+// setGatewayState applies a start or stop action to the gateway of the request
+func setGatewayState(c *gin.Context, action func(int) (bool, error)) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid gateway ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	running, err := action(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "id": id, "running": running, "code": codes.CodeErrorGateway})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "running": running, "code": codes.CodeOK})
+}
+
 // updateGateway updates a gateway
 func updateGateway(c *gin.Context) {
 	var g gw.Gateway
 	err := c.BindJSON(&g)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request"})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 	code, err := simulatorController.UpdateGateway(&g)
@@ -267,10 +629,14 @@ func deleteGateway(c *gin.Context) {
 	}{}
 	err := c.BindJSON(&Identifier)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request"})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if !simulatorController.DeleteGateway(Identifier.Id) {
+		c.JSON(http.StatusOK, gin.H{"status": false, "code": codes.CodeErrorGatewayActive})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": simulatorController.DeleteGateway(Identifier.Id)})
+	c.JSON(http.StatusOK, gin.H{"status": true, "code": codes.CodeOK})
 }
 
 // getDevices returns the list of devices
@@ -278,25 +644,458 @@ func getDevices(c *gin.Context) {
 	c.JSON(http.StatusOK, simulatorController.GetDevices())
 }
 
+// searchDevices returns the devices matching the query parameters
+func searchDevices(c *gin.Context) {
+	filter := models.DeviceFilter{
+		Class: c.Query("class"),
+		Name:  c.Query("name"),
+	}
+	if v := c.Query("region"); v != "" {
+		region, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid region", "code": codes.CodeErrorInvalidRequest})
+			return
+		}
+		filter.Region = &region
+	}
+	if v := c.Query("codecId"); v != "" {
+		codecID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid codec ID", "code": codes.CodeErrorInvalidRequest})
+			return
+		}
+		filter.CodecID = &codecID
+	}
+	if v := c.Query("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid active flag", "code": codes.CodeErrorInvalidRequest})
+			return
+		}
+		filter.Active = &active
+	}
+	switch strings.ToUpper(filter.Class) {
+	case "", "A", "B", "C":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	c.JSON(http.StatusOK, simulatorController.SearchDevices(filter))
+}
+
+// exportDevicesCSV streams the devices as a CSV attachment
+func exportDevicesCSV(c *gin.Context) {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename=devices.csv")
+	c.Status(http.StatusOK)
+	if err := simulatorController.WriteDevicesCSV(c.Writer); err != nil {
+		log.Printf("[WS] [ERROR]: CSV export failed: %v", err)
+	}
+}
+
+// importDevicesCSV creates devices from an uploaded CSV file
+func importDevicesCSV(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is required", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	defer file.Close()
+
+	summary, err := simulatorController.ImportDevicesCSV(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// getDownlinkAcks returns the confirmed downlink ACK ledger of a device
+func getDownlinkAcks(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	acks, err := simulatorController.GetDownlinkAcks(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "acks": acks, "code": codes.CodeOK})
+}
+
+// getDeviceCounters returns the uplink/downlink counters and frame counters of a device
+func getDeviceCounters(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	counters, err := simulatorController.GetDeviceCounters(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "counters": counters, "code": codes.CodeOK})
+}
+
+// getDeviceCodecErrors returns the recent codec execution errors of a device
+func getDeviceCodecErrors(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	codecErrors, err := simulatorController.GetDeviceCodecErrors(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "errors": codecErrors, "code": codes.CodeOK})
+}
+
+// getDeviceCodecState returns the variables the codec of a device keeps between executions
+func getDeviceCodecState(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	state, err := simulatorController.GetDeviceCodecState(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "state": state, "code": codes.CodeOK})
+}
+
+// resetDeviceCodecState drops the codec state of a device, restarting its counters
+func resetDeviceCodecState(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if err := simulatorController.ResetDeviceCodecState(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "Codec state reset", "id": id, "code": codes.CodeOK})
+}
+
+// setRXWindows applies RX window overrides to a device and returns the resulting windows
+func setRXWindows(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	var update devModels.RXWindowsUpdate
+	if err := c.BindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	windows, err := simulatorController.SetRXWindows(id, update)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "rxs": windows, "code": codes.CodeOK})
+}
+
+// getRetransmission returns the confirmed-uplink retry settings of a device
+func getRetransmission(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	settings, err := simulatorController.GetRetransmission(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "retransmission": settings, "code": codes.CodeOK})
+}
+
+// setRetransmission changes the confirmed-uplink retry settings of a device
+func setRetransmission(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	var update devModels.RetransmissionUpdate
+	if err := c.BindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	settings, err := simulatorController.SetRetransmission(id, update)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "retransmission": settings, "code": codes.CodeOK})
+}
+
+// setFrameCounters sets the current uplink and downlink frame counters of a stopped device
+func setFrameCounters(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	var update devModels.FrameCountersUpdate
+	if err := c.BindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	counters, err := simulatorController.SetFrameCounters(id, update)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "fcntUp": counters.FCntUp, "fcntDown": counters.FCntDown, "code": codes.CodeOK})
+}
+
+// getJoinStatus returns whether a device joined, its join attempts and its last join time
+func getJoinStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	status, err := simulatorController.GetJoinStatus(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "joinStatus": status, "code": codes.CodeOK})
+}
+
+// getChannels returns the channel plan of a device
+func getChannels(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	list, err := simulatorController.GetChannels(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	limit, err := simulatorController.GetPayloadLimit(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "channels": list, "dataRate": limit.DataRate, "maxPayload": limit.MaxPayload, "code": codes.CodeOK})
+}
+
+// setChannels enables or disables uplink channels of a running device
+func setChannels(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	var update devModels.ChannelsUpdate
+	if err := c.BindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	list, err := simulatorController.SetChannels(id, update)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "channels": list, "code": codes.CodeOK})
+}
+
+// replayDevice starts playing recorded uplinks through a running device
+func replayDevice(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	var replay devModels.Replay
+	if err := c.BindJSON(&replay); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if err := simulatorController.ReplayDevice(id, replay); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "Replay started", "id": id, "uplinks": len(replay.Uplinks), "loop": replay.Loop, "code": codes.CodeOK})
+}
+
+// stopReplayDevice stops the replay of a device
+func stopReplayDevice(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if err := simulatorController.StopReplayDevice(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "Replay stopped", "id": id, "code": codes.CodeOK})
+}
+
+// injectMACCommand executes a downlink MAC command on a running device and returns
+// the answers it queued
+func injectMACCommand(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	var injection devModels.MACInjection
+	if err := c.BindJSON(&injection); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	answers, err := simulatorController.InjectMACCommand(id, injection)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "cid": injection.CID, "answers": answers, "code": codes.CodeOK})
+}
+
+// queueMACCommands queues a batch of uplink MAC commands ([{cid, payloadHex}]) on a running
+// device, all of them or none, and returns the MAC commands waiting for the next uplink
+func queueMACCommands(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	var batch []devModels.UplinkMACCommand
+	if err := c.BindJSON(&batch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	queue, err := simulatorController.QueueMACCommands(id, batch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "queue": queue, "code": codes.CodeOK})
+}
+
+// testDownlinkCodec runs a downlink ({payloadHex, fPort}) through the codec of a device and
+// returns the state changes it would make, without applying them
+func testDownlinkCodec(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	var test devModels.DownlinkCodecTest
+	if err := c.BindJSON(&test); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	result, err := simulatorController.TestDownlinkCodec(id, test)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "result": result, "code": codes.CodeOK})
+}
+
+// rekeyDevice regenerates the session keys of a stopped device
+func rekeyDevice(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if err := simulatorController.RekeyDevice(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "Device rekeyed", "id": id, "code": codes.CodeOK})
+}
+
+// startDevice turns a device on and returns its resulting state
+func startDevice(c *gin.Context) {
+	setDeviceState(c, simulatorController.StartDevice)
+}
+
+// stopDevice turns a device off and returns its resulting state
+func stopDevice(c *gin.Context) {
+	setDeviceState(c, simulatorController.StopDevice)
+}
+
+// setDeviceState applies a start or stop action to the device of the request
+func setDeviceState(c *gin.Context, action func(int) (bool, error)) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	running, err := action(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "id": id, "running": running, "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "running": running, "code": codes.CodeOK})
+}
+
 // addDevice adds a new device
 func addDevice(c *gin.Context) {
 	var device dev.Device
-	err := c.BindJSON(&device)
+	var options struct {
+		SkipProvisioning bool `json:"skipProvisioning"` // Add the device without provisioning it to its integrations
+	}
+	body, err := c.GetRawData()
+	if err == nil {
+		err = json.Unmarshal(body, &device)
+	}
+	if err == nil {
+		err = json.Unmarshal(body, &options)
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request"})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
-	code, id, err := simulatorController.AddDevice(&device)
+	code, id, err := simulatorController.AddDevice(&device, options.SkipProvisioning)
 	errString := fmt.Sprintf("%v", err)
 	c.JSON(http.StatusOK, gin.H{"status": errString, "code": code, "id": id})
 }
 
+// provisionDevice provisions a device added with skipProvisioning to its integrations
+func provisionDevice(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if err := simulatorController.ProvisionDeviceIntegrations(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorIntegration})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "Device provisioned", "id": id, "code": codes.CodeOK})
+}
+
 // updateDevice updates a device
 func updateDevice(c *gin.Context) {
 	var device dev.Device
 	err := c.BindJSON(&device)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request"})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 	code, err := simulatorController.UpdateDevice(&device)
@@ -304,6 +1103,72 @@ func updateDevice(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": errString, "code": code})
 }
 
+// validateDeviceUpdate reports whether an update of a device would be applied, and
+// the fields it would change
+func validateDeviceUpdate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	var device dev.Device
+	if err := c.BindJSON(&device); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	check, err := simulatorController.ValidateDeviceUpdate(id, &device)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, check)
+}
+
+// checkDeviceID returns the optional ?id= of the device being edited, -1 for a new device
+func checkDeviceID(c *gin.Context) (int, bool) {
+	v := c.Query("id")
+	if v == "" {
+		return -1, true
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "code": codes.CodeErrorInvalidRequest})
+		return 0, false
+	}
+	return id, true
+}
+
+// checkAddress reports whether a DevEUI can be given to a new (or the ?id= edited) device
+func checkAddress(c *gin.Context) {
+	var devEUI lorawan.EUI64
+	if err := devEUI.UnmarshalText([]byte(c.Query("deveui"))); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid DevEUI, 16 hex characters expected", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	id, ok := checkDeviceID(c)
+	if !ok {
+		return
+	}
+	if code, err := simulatorController.CheckDevEUI(devEUI, id); err != nil {
+		c.JSON(http.StatusOK, gin.H{"available": false, "error": err.Error(), "code": code})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"available": true, "code": codes.CodeOK})
+}
+
+// checkName reports whether a name can be given to a new (or the ?id= edited) device
+func checkName(c *gin.Context) {
+	id, ok := checkDeviceID(c)
+	if !ok {
+		return
+	}
+	if code, err := simulatorController.CheckDeviceName(c.Query("name"), id); err != nil {
+		c.JSON(http.StatusOK, gin.H{"available": false, "error": err.Error(), "code": code})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"available": true, "code": codes.CodeOK})
+}
+
 // deleteDevice deletes a device
 func deleteDevice(c *gin.Context) {
 	Identifier := struct {
@@ -311,41 +1176,169 @@ func deleteDevice(c *gin.Context) {
 	}{}
 	err := c.BindJSON(&Identifier)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request"})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	if !simulatorController.DeleteDevice(Identifier.Id) {
+		c.JSON(http.StatusOK, gin.H{"status": false, "code": codes.CodeErrorDeviceActive})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": simulatorController.DeleteDevice(Identifier.Id)})
+	c.JSON(http.StatusOK, gin.H{"status": true, "code": codes.CodeOK})
 }
 
 // deleteAllDevices deletes all devices in bulk
 func deleteAllDevices(c *gin.Context) {
 	count, err := simulatorController.DeleteAllDevices()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorDevice})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": count, "code": codes.CodeOK})
+}
+
+// setDevicesActive activates or deactivates a list of devices ({ids, active}) at once
+func setDevicesActive(c *gin.Context) {
+	var req struct {
+		IDs    []int `json:"ids"`
+		Active *bool `json:"active"`
+	}
+	if err := c.BindJSON(&req); err != nil || len(req.IDs) == 0 || req.Active == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request, ids and active are required", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"deleted": count})
+	results := simulatorController.SetDevicesActive(req.IDs, *req.Active)
+	c.JSON(http.StatusOK, gin.H{"results": results, "code": codes.CodeOK})
 }
 
 // getCodecs returns all available codecs
 func getCodecs(c *gin.Context) {
 	codecs := simulatorController.GetCodecs()
-	c.JSON(http.StatusOK, gin.H{"codecs": codecs})
+	c.JSON(http.StatusOK, gin.H{"codecs": codecs, "code": codes.CodeOK})
+}
+
+// Page size of /codecs/full
+const (
+	defaultCodecsPageSize = 50
+	maxCodecsPageSize     = 200
+)
+
+// getCodecsFull returns a page of codecs including their scripts
+func getCodecsFull(c *gin.Context) {
+	offset, limit := 0, defaultCodecsPageSize
+	var err error
+	if v := c.Query("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset", "code": codes.CodeErrorInvalidRequest})
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxCodecsPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxCodecsPageSize), "code": codes.CodeErrorInvalidRequest})
+			return
+		}
+	}
+	codecs, total := simulatorController.GetCodecsFull(offset, limit)
+	c.JSON(http.StatusOK, gin.H{"codecs": codecs, "total": total, "offset": offset, "limit": limit, "code": codes.CodeOK})
 }
 
 // getCodec returns a specific codec by ID
 func getCodec(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid codec ID", "error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid codec ID", "error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 	codec, err := simulatorController.GetCodec(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"status": "Codec not found", "error": err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"status": "Codec not found", "error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"codec": codec, "code": codes.CodeOK})
+}
+
+// exportCodec returns a codec's name and script as a downloadable JSON file
+func exportCodec(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid codec ID", "error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	cd, err := simulatorController.GetCodec(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "Codec not found", "error": err.Error(), "code": codes.CodeErrorNotFound})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"codec": codec})
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"codec-%d.json\"", id))
+	c.JSON(http.StatusOK, cd.Export())
+}
+
+// importCodecs adds the codecs of an exported batch and reports the outcome of each one
+func importCodecs(c *gin.Context) {
+	var items []codec.CodecExport
+	if err := c.BindJSON(&items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid JSON", "error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+
+	results, err := simulatorController.ImportCodecs(items)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Failed to import codecs", "error": err.Error(), "code": codes.CodeErrorCodec})
+		return
+	}
+
+	// Emit WebSocket event for each added codec
+	for _, r := range results {
+		if r.Status == "added" {
+			simulatorController.EmitCodecEvent(socket.EventCodecAdded, codec.CodecMetadata{ID: r.ID, Name: r.Name})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results, "code": codes.CodeOK})
+}
+
+// validateCodec compiles a codec script in a throwaway VM and reports the errors found
+func validateCodec(c *gin.Context) {
+	var codecData struct {
+		Script string `json:"script"`
+	}
+
+	if err := c.BindJSON(&codecData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid JSON", "error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+
+	errs := codec.CheckScript(codecData.Script)
+	if errs == nil {
+		errs = []codec.ScriptError{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": len(errs) == 0, "errors": errs, "warnings": lintCodec(codecData.Script), "code": codes.CodeOK})
+}
+
+// getCodecBoilerplate returns a starter codec script of the requested type
+func getCodecBoilerplate(c *gin.Context) {
+	kind := c.DefaultQuery("type", codec.BoilerplateGeneric)
+	script, err := codec.Boilerplate(kind)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "types": codec.BoilerplateTypes, "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"type": kind, "script": script, "code": codes.CodeOK})
+}
+
+// getSensorProfiles lists the built-in sensor profiles
+func getSensorProfiles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles.List(), "code": codes.CodeOK})
+}
+
+// lintCodec returns the non-fatal warnings about a script, never nil
+func lintCodec(script string) []codec.LintWarning {
+	warnings := codec.Lint(script)
+	if warnings == nil {
+		warnings = []codec.LintWarning{}
+	}
+	return warnings
 }
 
 // addCodec adds a custom codec
@@ -356,7 +1349,7 @@ func addCodec(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&codecData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid JSON", "error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid JSON", "error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
@@ -365,14 +1358,14 @@ func addCodec(c *gin.Context) {
 
 	// Add to manager
 	if err := simulatorController.AddCodec(newCodec); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Failed to add codec", "error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Failed to add codec", "error": err.Error(), "code": codes.CodeErrorCodec})
 		return
 	}
 
 	// Emit WebSocket event
 	simulatorController.EmitCodecEvent(socket.EventCodecAdded, newCodec.Metadata())
 
-	c.JSON(http.StatusOK, gin.H{"status": "Codec added successfully", "id": newCodec.ID})
+	c.JSON(http.StatusOK, gin.H{"status": "Codec added successfully", "id": newCodec.ID, "warnings": lintCodec(newCodec.Script), "code": codes.CodeOK})
 }
 
 // updateCodec updates an existing codec
@@ -384,19 +1377,19 @@ func updateCodec(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&codecData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid JSON", "error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid JSON", "error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	// Validate required fields
 	if codecData.ID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request", "error": "ID is required"})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid request", "error": "ID is required", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	// Update codec
 	if err := simulatorController.UpdateCodec(codecData.ID, codecData.Name, codecData.Script); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Failed to update codec", "error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Failed to update codec", "error": err.Error(), "code": codes.CodeErrorCodec})
 		return
 	}
 
@@ -404,14 +1397,14 @@ func updateCodec(c *gin.Context) {
 	updatedCodec, err := simulatorController.GetCodec(codecData.ID)
 	if err != nil {
 		// Still return success but without metadata
-		c.JSON(http.StatusOK, gin.H{"status": "Codec updated successfully", "id": codecData.ID})
+		c.JSON(http.StatusOK, gin.H{"status": "Codec updated successfully", "id": codecData.ID, "warnings": lintCodec(codecData.Script), "code": codes.CodeOK})
 		return
 	}
 
 	// Emit WebSocket event
 	simulatorController.EmitCodecEvent(socket.EventCodecUpdated, updatedCodec.Metadata())
 
-	c.JSON(http.StatusOK, gin.H{"status": "Codec updated successfully", "id": codecData.ID})
+	c.JSON(http.StatusOK, gin.H{"status": "Codec updated successfully", "id": codecData.ID, "warnings": lintCodec(codecData.Script), "code": codes.CodeOK})
 }
 
 // deleteCodec deletes a codec by ID
@@ -421,30 +1414,51 @@ func deleteCodec(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&reqData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid JSON", "error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid JSON", "error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	if err := simulatorController.DeleteCodec(reqData.ID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Failed to delete codec", "error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Failed to delete codec", "error": err.Error(), "code": codes.CodeErrorCodec})
 		return
 	}
 
 	// Emit WebSocket event
 	simulatorController.EmitCodecEvent(socket.EventCodecDeleted, gin.H{"id": reqData.ID})
 
-	c.JSON(http.StatusOK, gin.H{"status": "Codec deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"status": "Codec deleted successfully", "code": codes.CodeOK})
 }
 
 // getCodecUsage returns which devices are using a specific codec
 func getCodecUsage(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid codec ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid codec ID", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 	devices := simulatorController.GetDevicesUsingCodec(id)
-	c.JSON(http.StatusOK, gin.H{"codecId": id, "devices": devices, "count": len(devices)})
+	c.JSON(http.StatusOK, gin.H{"codecId": id, "devices": devices, "count": len(devices), "code": codes.CodeOK})
+}
+
+// getCodecsUsage returns the number of devices and templates using each codec
+func getCodecsUsage(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"usage": simulatorController.GetCodecUsage(), "code": codes.CodeOK})
+}
+
+// resetCodecsUsage counts the codec usage again and returns it
+func resetCodecsUsage(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"usage": simulatorController.ResetCodecUsage(), "code": codes.CodeOK})
+}
+
+// getOrphanCodecStates returns the DevEUIs having a codec state but no device
+func getOrphanCodecStates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"devEUIs": simulatorController.GetOrphanCodecStates(), "code": codes.CodeOK})
+}
+
+// pruneCodecStates drops the codec states having no device and returns their DevEUIs
+func pruneCodecStates(c *gin.Context) {
+	pruned := simulatorController.PruneCodecStates()
+	c.JSON(http.StatusOK, gin.H{"pruned": pruned, "count": len(pruned), "code": codes.CodeOK})
 }
 
 // ==================== Integration Handlers ====================
@@ -452,22 +1466,48 @@ func getCodecUsage(c *gin.Context) {
 // getIntegrations returns all integrations
 func getIntegrations(c *gin.Context) {
 	integrations := simulatorController.GetIntegrations()
-	c.JSON(http.StatusOK, gin.H{"integrations": integrations})
+	c.JSON(http.StatusOK, gin.H{"integrations": integrations, "code": codes.CodeOK})
+}
+
+// getIntegrationUsage returns the devices and templates that use an integration
+func getIntegrationUsage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid integration ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	usage := simulatorController.GetIntegrationUsage(id)
+	c.JSON(http.StatusOK, gin.H{"integrationId": id, "devices": usage, "count": len(usage), "code": codes.CodeOK})
 }
 
 // getIntegration returns a specific integration by ID
 func getIntegration(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid integration ID", "error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid integration ID", "error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 	integ, err := simulatorController.GetIntegration(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"status": "Integration not found", "error": err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"status": "Integration not found", "error": err.Error(), "code": codes.CodeErrorNotFound})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"integration": integ})
+	c.JSON(http.StatusOK, gin.H{"integration": integ, "code": codes.CodeOK})
+}
+
+// revealIntegrationKey returns the API key of an integration, which the other endpoints never expose
+func revealIntegrationKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Invalid integration ID", "error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	apiKey, err := simulatorController.RevealIntegrationKey(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "Integration not found", "error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"apiKey": apiKey, "code": codes.CodeOK})
 }
 
 // addIntegration adds a new integration
@@ -482,7 +1522,7 @@ func addIntegration(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
@@ -493,12 +1533,12 @@ func addIntegration(c *gin.Context) {
 
 	id, err := simulatorController.AddIntegration(data.Name, intType, data.URL, data.APIKey, data.TenantID, data.ApplicationID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorIntegration})
 		return
 	}
 
 	simulatorController.EmitIntegrationEvent(socket.EventIntegrationAdded, gin.H{"id": id, "name": data.Name})
-	c.JSON(http.StatusOK, gin.H{"id": id})
+	c.JSON(http.StatusOK, gin.H{"id": id, "code": codes.CodeOK})
 }
 
 // updateIntegration updates an existing integration
@@ -514,22 +1554,22 @@ func updateIntegration(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	if data.ID == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is required", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	if err := simulatorController.UpdateIntegration(*data.ID, data.Name, data.URL, data.APIKey, data.TenantID, data.ApplicationID, data.Enabled); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorIntegration})
 		return
 	}
 
 	simulatorController.EmitIntegrationEvent(socket.EventIntegrationUpdated, gin.H{"id": *data.ID, "name": data.Name})
-	c.JSON(http.StatusOK, gin.H{"success": true})
+	c.JSON(http.StatusOK, gin.H{"success": true, "code": codes.CodeOK})
 }
 
 // deleteIntegration removes an integration
@@ -539,67 +1579,84 @@ func deleteIntegration(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	if err := simulatorController.DeleteIntegration(data.ID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorIntegration})
 		return
 	}
 
 	simulatorController.EmitIntegrationEvent(socket.EventIntegrationDeleted, gin.H{"id": data.ID})
-	c.JSON(http.StatusOK, gin.H{"success": true})
+	c.JSON(http.StatusOK, gin.H{"success": true, "code": codes.CodeOK})
 }
 
 // testIntegrationConnection tests connection to an integration
 func testIntegrationConnection(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid integration ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid integration ID", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	if err := simulatorController.TestIntegrationConnection(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorIntegration})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "code": codes.CodeOK})
+}
+
+// provisionIntegrationDevices provisions the devices of a ChirpStack integration that are missing there
+func provisionIntegrationDevices(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid integration ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+
+	summary, err := simulatorController.ProvisionIntegrationDevices(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorIntegration})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true})
+	c.JSON(http.StatusOK, gin.H{"summary": summary, "code": codes.CodeOK})
 }
 
 // getDeviceProfiles returns device profiles for an integration
 func getDeviceProfiles(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid integration ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid integration ID", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	profiles, err := simulatorController.GetDeviceProfiles(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorIntegration})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"deviceProfiles": profiles})
+	c.JSON(http.StatusOK, gin.H{"deviceProfiles": profiles, "code": codes.CodeOK})
 }
 
 // getTbCustomers returns the list of customers for a ThingsBoard integration
 func getTbCustomers(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid integration ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid integration ID", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	customers, err := simulatorController.GetThingsBoardCustomers(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorIntegration})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"customers": customers})
+	c.JSON(http.StatusOK, gin.H{"customers": customers, "code": codes.CodeOK})
 }
 
 // ==================== Template Handlers ====================
@@ -607,22 +1664,37 @@ func getTbCustomers(c *gin.Context) {
 // getTemplates returns all templates
 func getTemplates(c *gin.Context) {
 	templates := simulatorController.GetTemplates()
-	c.JSON(http.StatusOK, gin.H{"templates": templates})
+	c.JSON(http.StatusOK, gin.H{"templates": templates, "code": codes.CodeOK})
 }
 
 // getTemplate returns a specific template by ID
 func getTemplate(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 	tmpl, err := simulatorController.GetTemplate(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"template": tmpl})
+	c.JSON(http.StatusOK, gin.H{"template": tmpl, "code": codes.CodeOK})
+}
+
+// getTemplateDependencies returns the codec and integration IDs a template relies on
+func getTemplateDependencies(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID", "code": codes.CodeErrorInvalidRequest})
+		return
+	}
+	deps, err := simulatorController.GetTemplateDependencies(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": codes.CodeErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, deps)
 }
 
 // addTemplate adds a new template
@@ -630,17 +1702,17 @@ func addTemplate(c *gin.Context) {
 	var tmpl template.DeviceTemplate
 
 	if err := c.BindJSON(&tmpl); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	id, err := simulatorController.AddTemplate(&tmpl)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorTemplate})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"id": id})
+	c.JSON(http.StatusOK, gin.H{"id": id, "code": codes.CodeOK})
 }
 
 // updateTemplate updates an existing template
@@ -648,21 +1720,21 @@ func updateTemplate(c *gin.Context) {
 	var tmpl template.DeviceTemplate
 
 	if err := c.BindJSON(&tmpl); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	if tmpl.ID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is required", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	if err := simulatorController.UpdateTemplate(&tmpl); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorTemplate})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true})
+	c.JSON(http.StatusOK, gin.H{"success": true, "code": codes.CodeOK})
 }
 
 // deleteTemplate removes a template
@@ -672,16 +1744,16 @@ func deleteTemplate(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	if err := simulatorController.DeleteTemplate(data.ID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorTemplate})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true})
+	c.JSON(http.StatusOK, gin.H{"success": true, "code": codes.CodeOK})
 }
 
 // BulkDeviceRequest represents the request for bulk device creation
@@ -693,6 +1765,7 @@ type BulkDeviceRequest struct {
 	BaseLng      float64 `json:"baseLng"`
 	BaseAlt      int32   `json:"baseAlt"`
 	SpreadMeters float64 `json:"spreadMeters"`
+	SpreadShape  string  `json:"spreadShape"` // square (default), circle or grid
 }
 
 // createDevicesFromTemplate creates multiple devices from a template
@@ -700,32 +1773,42 @@ func createDevicesFromTemplate(c *gin.Context) {
 	var req BulkDeviceRequest
 
 	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 
 	// Validate request
 	if req.TemplateID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "templateId is required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "templateId is required", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 	if req.Count < 1 || req.Count > 10000 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "count must be between 1 and 10000"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "count must be between 1 and 10000", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 	if req.NamePrefix == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "namePrefix is required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "namePrefix is required", "code": codes.CodeErrorInvalidRequest})
 		return
 	}
 	if req.SpreadMeters <= 0 {
 		req.SpreadMeters = 100 // Default 100m spread
 	}
 
-	createdIDs, err := simulatorController.CreateDevicesFromTemplate(req.TemplateID, req.Count, req.NamePrefix, req.BaseLat, req.BaseLng, req.BaseAlt, req.SpreadMeters)
+	if dryRun, _ := strconv.ParseBool(c.Query("dryRun")); dryRun {
+		devices, skipped, err := simulatorController.PreviewDevicesFromTemplate(req.TemplateID, req.Count, req.NamePrefix, req.BaseLat, req.BaseLng, req.BaseAlt, req.SpreadMeters, req.SpreadShape)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorInvalidRequest})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"dryRun": true, "count": len(devices), "skipped": skipped, "devices": devices, "code": codes.CodeOK})
+		return
+	}
+
+	createdIDs, skipped, err := simulatorController.CreateDevicesFromTemplate(req.TemplateID, req.Count, req.NamePrefix, req.BaseLat, req.BaseLng, req.BaseAlt, req.SpreadMeters, req.SpreadShape)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": codes.CodeErrorTemplate})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"created": len(createdIDs), "deviceIds": createdIDs})
+	c.JSON(http.StatusOK, gin.H{"created": len(createdIDs), "skipped": skipped, "deviceIds": createdIDs, "code": codes.CodeOK})
 }